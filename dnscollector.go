@@ -199,7 +199,8 @@ func main() {
 			case <-sigHUP:
 				logger.Warning("main - SIGHUP received")
 
-				// read config
+				// read config, keep the running pipelines to compute the changes
+				previousPipelines := config.Pipelines
 				err := pkgconfig.ReloadConfig(configPath, config)
 				if err != nil {
 					logger.Error("main - reload config error:  %v", err)
@@ -213,7 +214,9 @@ func main() {
 					pkginit.ReloadMultiplexer(mapLoggers, mapCollectors, config, logger)
 				}
 				if pkginit.IsPipelinesEnabled(config) {
-					pkginit.ReloadPipelines(mapLoggers, mapCollectors, config, logger)
+					if err := pkginit.ReloadPipelines(previousPipelines, mapLoggers, mapCollectors, config, logger, metrics); err != nil {
+						logger.Error("main - reload pipelines error: %v", err)
+					}
 				}
 
 			case <-sigTerm:
@@ -10,11 +10,16 @@ const (
 	DNSRcodeServFail = "SERVFAIL"
 	DNSRcodeTimeout  = "TIMEOUT"
 
+	SessionClosingFin     = "fin"
+	SessionClosingRst     = "rst"
+	SessionClosingTimeout = "timeout"
+
 	DNSTapOperationQuery = "QUERY"
 	DNSTapOperationReply = "REPLY"
 
 	DNSTapClientResponse = "CLIENT_RESPONSE"
 	DNSTapClientQuery    = "CLIENT_QUERY"
+	DNSTapClientSession  = "CLIENT_SESSION"
 
 	DNSTapIdentityTest = "test_id"
 
@@ -79,6 +79,7 @@ var (
 		60:    "CDNSKEY",    // Child DNSKEY
 		61:    "OPENPGPKEY", // OpenPGP key
 		62:    "CSYNC",      // Child-to-parent synchronization
+		63:    "ZONEMD",     // Message digest for DNS zone
 		64:    "SVCB",       // Service binding
 		65:    "HTTPS",      // HTTPS binding
 		99:    "SPF",        // Sender policy framework (deprecated, use TXT)
@@ -603,6 +604,38 @@ func ParseRdata(rdatatype string, rdata []byte, payload []byte, rdataOffset int)
 		ret, err = ParseSOA(rdataOffset, payload)
 	case "HTTPS", "SVCB":
 		ret, err = ParseSVCB(rdata)
+	case "DNSKEY", "CDNSKEY":
+		ret, err = ParseDNSKEY(rdata)
+	case "DS", "CDS":
+		ret, err = ParseDS(rdata)
+	case "RRSIG":
+		ret, err = ParseRRSIG(rdata)
+	case "NSEC":
+		ret, err = ParseNSEC(rdata)
+	case "NSEC3":
+		ret, err = ParseNSEC3(rdata)
+	case "NSEC3PARAM":
+		ret, err = ParseNSEC3PARAM(rdata)
+	case "CAA":
+		ret, err = ParseCAA(rdata)
+	case "NAPTR":
+		ret, err = ParseNAPTR(rdata)
+	case "TLSA":
+		ret, err = ParseTLSA(rdata)
+	case "SSHFP":
+		ret, err = ParseSSHFP(rdata)
+	case "CERT":
+		ret, err = ParseCERT(rdata)
+	case "DNAME":
+		ret, err = ParseDNAME(rdataOffset, payload)
+	case "LOC":
+		ret, err = ParseLOC(rdata)
+	case "URI":
+		ret, err = ParseURI(rdata)
+	case "OPENPGPKEY":
+		ret, err = ParseOPENPGPKEY(rdata)
+	case "ZONEMD":
+		ret, err = ParseZONEMD(rdata)
 	default:
 		ret = "-"
 		err = nil
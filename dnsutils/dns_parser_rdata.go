@@ -0,0 +1,549 @@
+package dnsutils
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrDecodeDNSAnswerRdataInvalid = errors.New("malformed pkt, invalid rdata answer")
+
+// presentation format of the DNSSEC timestamps
+const rrsigTimeLayout = "20060102150405"
+
+/*
+DNSKEY, CDNSKEY
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|              Flags            |    Protocol   |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|   Algorithm   |                               /
++--+--+--+--+--+--+--+--+                       /
+/                   Public Key                  /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseDNSKEY(rdata []byte) (string, error) {
+	if len(rdata) < 4 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	flags := binary.BigEndian.Uint16(rdata[0:2])
+	protocol := rdata[2]
+	algorithm := rdata[3]
+	publicKey := base64.StdEncoding.EncodeToString(rdata[4:])
+	return fmt.Sprintf("%d %d %d %s", flags, protocol, algorithm, publicKey), nil
+}
+
+/*
+DS, CDS
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|           Key Tag             |  Algorithm    |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|  Digest Type  |                               /
++--+--+--+--+--+--+--+--+                       /
+/                    Digest                     /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseDS(rdata []byte) (string, error) {
+	if len(rdata) < 5 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	keyTag := binary.BigEndian.Uint16(rdata[0:2])
+	algorithm := rdata[2]
+	digestType := rdata[3]
+	digest := strings.ToUpper(hex.EncodeToString(rdata[4:]))
+	return fmt.Sprintf("%d %d %d %s", keyTag, algorithm, digestType, digest), nil
+}
+
+/*
+RRSIG
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                 Type Covered                  |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|   Algorithm   |    Labels     |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                 Original TTL                  |
+|                                               |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|             Signature Expiration              |
+|                                               |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|              Signature Inception              |
+|                                               |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                    Key Tag                    |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                 Signer's Name                 /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                   Signature                   /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseRRSIG(rdata []byte) (string, error) {
+	// fixed fields and at least the root label
+	if len(rdata) < 19 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	typeCovered := rdataTypeToString(binary.BigEndian.Uint16(rdata[0:2]))
+	algorithm := rdata[2]
+	labels := rdata[3]
+	originalTTL := binary.BigEndian.Uint32(rdata[4:8])
+	expiration := time.Unix(int64(binary.BigEndian.Uint32(rdata[8:12])), 0).UTC().Format(rrsigTimeLayout)
+	inception := time.Unix(int64(binary.BigEndian.Uint32(rdata[12:16])), 0).UTC().Format(rrsigTimeLayout)
+	keyTag := binary.BigEndian.Uint16(rdata[16:18])
+
+	signer, offset, err := ParseLabels(18, rdata)
+	if err != nil {
+		return "", err
+	}
+	if signer == "" {
+		signer = "."
+	}
+	signature := base64.StdEncoding.EncodeToString(rdata[offset:])
+	return fmt.Sprintf("%s %d %d %d %s %s %d %s %s", typeCovered, algorithm, labels, originalTTL,
+		expiration, inception, keyTag, signer, signature), nil
+}
+
+/*
+NSEC
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/              Next Domain Name                 /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/             Type Bit Maps                     /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseNSEC(rdata []byte) (string, error) {
+	if len(rdata) < 1 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	nextName, offset, err := ParseLabels(0, rdata)
+	if err != nil {
+		return "", err
+	}
+	if nextName == "" {
+		nextName = "."
+	}
+	types, err := ParseTypeBitMaps(rdata[offset:])
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(append([]string{nextName}, types...), " "), nil
+}
+
+/*
+NSEC3
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|   Hash Alg.   |     Flags     |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                  Iterations                   |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|  Salt Length  |             Salt              /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|  Hash Length  |    Next Hashed Owner Name     /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                 Type Bit Maps                 /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseNSEC3(rdata []byte) (string, error) {
+	params, offset, err := parseNSEC3Params(rdata)
+	if err != nil {
+		return "", err
+	}
+	if len(rdata) < offset+1 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	hashLength := int(rdata[offset])
+	offset++
+	if len(rdata) < offset+hashLength {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	nextHash := base32.HexEncoding.WithPadding(base32.NoPadding).EncodeToString(rdata[offset : offset+hashLength])
+	offset += hashLength
+
+	types, err := ParseTypeBitMaps(rdata[offset:])
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(append([]string{params, nextHash}, types...), " "), nil
+}
+
+/*
+NSEC3PARAM
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|   Hash Alg.   |     Flags     |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                  Iterations                   |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|  Salt Length  |             Salt              /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseNSEC3PARAM(rdata []byte) (string, error) {
+	params, _, err := parseNSEC3Params(rdata)
+	return params, err
+}
+
+// parseNSEC3Params decodes the fields shared by NSEC3 and NSEC3PARAM
+func parseNSEC3Params(rdata []byte) (string, int, error) {
+	if len(rdata) < 5 {
+		return "", 0, ErrDecodeDNSAnswerRdataTooShort
+	}
+	hashAlgorithm := rdata[0]
+	flags := rdata[1]
+	iterations := binary.BigEndian.Uint16(rdata[2:4])
+	saltLength := int(rdata[4])
+	if len(rdata) < 5+saltLength {
+		return "", 0, ErrDecodeDNSAnswerRdataTooShort
+	}
+	salt := "-"
+	if saltLength > 0 {
+		salt = strings.ToUpper(hex.EncodeToString(rdata[5 : 5+saltLength]))
+	}
+	return fmt.Sprintf("%d %d %d %s", hashAlgorithm, flags, iterations, salt), 5 + saltLength, nil
+}
+
+// ParseTypeBitMaps decodes the list of types of NSEC and NSEC3 records,
+// each window is encoded with its number, the length and the bitmap
+func ParseTypeBitMaps(data []byte) ([]string, error) {
+	var types []string
+	offset := 0
+	for offset < len(data) {
+		if len(data) < offset+2 {
+			return nil, ErrDecodeDNSAnswerRdataTooShort
+		}
+		window := int(data[offset])
+		length := int(data[offset+1])
+		offset += 2
+		if length == 0 || length > 32 {
+			return nil, ErrDecodeDNSAnswerRdataInvalid
+		}
+		if len(data) < offset+length {
+			return nil, ErrDecodeDNSAnswerRdataTooShort
+		}
+		for i, b := range data[offset : offset+length] {
+			for bit := 0; bit < 8; bit++ {
+				if b&(0x80>>bit) != 0 {
+					types = append(types, rdataTypeToString(uint16(window*256+i*8+bit)))
+				}
+			}
+		}
+		offset += length
+	}
+	return types, nil
+}
+
+/*
+CAA
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|     Flags     |  Tag Length   |               /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                      Tag                      /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                     Value                     /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseCAA(rdata []byte) (string, error) {
+	if len(rdata) < 2 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	flags := rdata[0]
+	tagLength := int(rdata[1])
+	if tagLength == 0 {
+		return "", ErrDecodeDNSAnswerRdataInvalid
+	}
+	if len(rdata) < 2+tagLength {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	tag := string(rdata[2 : 2+tagLength])
+	value := quoteRdataString(rdata[2+tagLength:])
+	return fmt.Sprintf("%d %s %s", flags, tag, value), nil
+}
+
+/*
+NAPTR
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                     ORDER                     |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                   PREFERENCE                  |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                     FLAGS                     /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                   SERVICES                    /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                    REGEXP                     /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                  REPLACEMENT                  /
+/                                               /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseNAPTR(rdata []byte) (string, error) {
+	if len(rdata) < 4 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	order := binary.BigEndian.Uint16(rdata[0:2])
+	preference := binary.BigEndian.Uint16(rdata[2:4])
+	offset := 4
+
+	// flags, services and regexp are character strings
+	var fields []string
+	for i := 0; i < 3; i++ {
+		if len(rdata) < offset+1 {
+			return "", ErrDecodeDNSAnswerRdataTooShort
+		}
+		length := int(rdata[offset])
+		offset++
+		if len(rdata) < offset+length {
+			return "", ErrDecodeDNSAnswerRdataTooShort
+		}
+		fields = append(fields, quoteRdataString(rdata[offset:offset+length]))
+		offset += length
+	}
+
+	if len(rdata) < offset+1 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	replacement, _, err := ParseLabels(offset, rdata)
+	if err != nil {
+		return "", err
+	}
+	if replacement == "" {
+		replacement = "."
+	}
+	return fmt.Sprintf("%d %d %s %s", order, preference, strings.Join(fields, " "), replacement), nil
+}
+
+/*
+TLSA
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|  Cert. Usage  |   Selector    | Matching Type |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/      Certificate Association Data             /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseTLSA(rdata []byte) (string, error) {
+	if len(rdata) < 4 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	usage := rdata[0]
+	selector := rdata[1]
+	matchingType := rdata[2]
+	data := hex.EncodeToString(rdata[3:])
+	return fmt.Sprintf("%d %d %d %s", usage, selector, matchingType, data), nil
+}
+
+/*
+SSHFP
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|   algorithm   |    fp type    |               /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                  fingerprint                  /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseSSHFP(rdata []byte) (string, error) {
+	if len(rdata) < 3 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	algorithm := rdata[0]
+	fpType := rdata[1]
+	fingerprint := strings.ToUpper(hex.EncodeToString(rdata[2:]))
+	return fmt.Sprintf("%d %d %s", algorithm, fpType, fingerprint), nil
+}
+
+/*
+CERT
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|             type              |    key tag    |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|               |   algorithm   |               /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/          certificate or CRL                   /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseCERT(rdata []byte) (string, error) {
+	if len(rdata) < 6 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	certType := binary.BigEndian.Uint16(rdata[0:2])
+	keyTag := binary.BigEndian.Uint16(rdata[2:4])
+	algorithm := rdata[4]
+	certificate := base64.StdEncoding.EncodeToString(rdata[5:])
+	return fmt.Sprintf("%d %d %d %s", certType, keyTag, algorithm, certificate), nil
+}
+
+/*
+DNAME
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                    TARGET                     /
+/                                               /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseDNAME(rdataOffset int, payload []byte) (string, error) {
+	target, _, err := ParseLabels(rdataOffset, payload)
+	if err != nil {
+		return "", err
+	}
+	return target, err
+}
+
+/*
+LOC
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|        VERSION        |         SIZE          |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|       HORIZ PRE       |       VERT PRE        |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                   LATITUDE                    |
+|                                               |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                   LONGITUDE                   |
+|                                               |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                   ALTITUDE                    |
+|                                               |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseLOC(rdata []byte) (string, error) {
+	if len(rdata) < 16 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	// only the version 0 is defined
+	if rdata[0] != 0 {
+		return "", ErrDecodeDNSAnswerRdataInvalid
+	}
+	size, horizPre, vertPre := rdata[1], rdata[2], rdata[3]
+	latitude := binary.BigEndian.Uint32(rdata[4:8])
+	longitude := binary.BigEndian.Uint32(rdata[8:12])
+	altitude := binary.BigEndian.Uint32(rdata[12:16])
+
+	const (
+		equator    = 1 << 31 // equator and prime meridian, in thousandths of arc second
+		arcMinute  = 60 * 1000
+		arcDegree  = 60 * arcMinute
+		altBaseCm  = 100000 * 100
+		centimeter = 100
+	)
+	formatCoordinate := func(value uint32, positive, negative string) string {
+		hemisphere := positive
+		if value >= equator {
+			value -= equator
+		} else {
+			hemisphere = negative
+			value = equator - value
+		}
+		degrees := value / arcDegree
+		value %= arcDegree
+		minutes := value / arcMinute
+		value %= arcMinute
+		return fmt.Sprintf("%02d %02d %0.3f %s", degrees, minutes, float64(value)/1000, hemisphere)
+	}
+
+	alt := float64(int64(altitude)-altBaseCm) / centimeter
+	altStr := fmt.Sprintf("%.0fm", alt)
+	if altitude%centimeter != 0 {
+		altStr = fmt.Sprintf("%.2fm", alt)
+	}
+
+	return fmt.Sprintf("%s %s %s %sm %sm %sm",
+		formatCoordinate(latitude, "N", "S"), formatCoordinate(longitude, "E", "W"), altStr,
+		locPrecisionToString(size), locPrecisionToString(horizPre), locPrecisionToString(vertPre)), nil
+}
+
+// locPrecisionToString converts the size and precisions of LOC records, encoded as
+// a mantissa and a power of ten in centimeters, to meters
+func locPrecisionToString(value uint8) string {
+	mantissa := int(value&0xf0) >> 4
+	exponent := int(value & 0x0f)
+	if exponent < 2 {
+		if exponent == 1 {
+			mantissa *= 10
+		}
+		return fmt.Sprintf("0.%02d", mantissa)
+	}
+	return strconv.Itoa(mantissa) + strings.Repeat("0", exponent-2)
+}
+
+/*
+URI
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                   PRIORITY                    |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                    WEIGHT                     |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                    TARGET                     /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseURI(rdata []byte) (string, error) {
+	if len(rdata) < 5 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	priority := binary.BigEndian.Uint16(rdata[0:2])
+	weight := binary.BigEndian.Uint16(rdata[2:4])
+	return fmt.Sprintf("%d %d %s", priority, weight, quoteRdataString(rdata[4:])), nil
+}
+
+/*
+OPENPGPKEY
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                 OpenPGP Key                   /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseOPENPGPKEY(rdata []byte) (string, error) {
+	if len(rdata) < 1 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	return base64.StdEncoding.EncodeToString(rdata), nil
+}
+
+/*
+ZONEMD
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|                     Serial                    |
+|                                               |
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+|    Scheme     |Hash Algorithm |               /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+/                    Digest                     /
++--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+*/
+func ParseZONEMD(rdata []byte) (string, error) {
+	// the digest is at least 12 bytes long
+	if len(rdata) < 18 {
+		return "", ErrDecodeDNSAnswerRdataTooShort
+	}
+	serial := binary.BigEndian.Uint32(rdata[0:4])
+	scheme := rdata[4]
+	hashAlgorithm := rdata[5]
+	digest := hex.EncodeToString(rdata[6:])
+	return fmt.Sprintf("%d %d %d %s", serial, scheme, hashAlgorithm, digest), nil
+}
+
+// rdataTypeToString returns the mnemonic of the type, or the generic TYPEn notation
+func rdataTypeToString(rrtype uint16) string {
+	if value, ok := Rdatatypes[int(rrtype)]; ok {
+		return value
+	}
+	return "TYPE" + strconv.Itoa(int(rrtype))
+}
+
+// quoteRdataString returns the character string between quotes,
+// the quotes, backslashes and non printable characters are escaped
+func quoteRdataString(s []byte) string {
+	var str strings.Builder
+	str.Grow(4*len(s) + 2)
+	str.WriteByte('"')
+	for _, e := range s {
+		switch {
+		case e == '"' || e == '\\':
+			str.WriteByte('\\')
+			str.WriteByte(e)
+		case ' ' <= e && e <= '~':
+			str.WriteByte(e)
+		default:
+			str.WriteString(escapeByte(e))
+		}
+	}
+	str.WriteByte('"')
+	return str.String()
+}
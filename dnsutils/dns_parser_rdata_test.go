@@ -659,3 +659,120 @@ func TestDecodeRdataSVCB_params(t *testing.T) {
 		}
 	}
 }
+
+func TestDecodeRdata_MoreTypes(t *testing.T) {
+	fqdn := TestQName
+
+	vectors := []struct {
+		rrtype string
+		rdata  string
+		want   string
+	}{
+		{"DNSKEY", "257 3 8 AwEAAagAIKlVZrpC6Ia7gEzahOR+9W29euxhJhVVLOyQbSEW0O8gcCjF", ""},
+		{"CDNSKEY", "256 3 13 oJMRESz5E4gYzS/q6XDrvU1qMPYIjCWzJaOau8XNEZeqCYKD5ar0IRd8KqXXFJkqmVfRvMGPmM1x8fGAa2XhSA==", ""},
+		{"DS", "20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D", ""},
+		{"CDS", "12345 13 2 3A8A5F7B9E0B2C1D4E6F8091A2B3C4D5E6F708192A3B4C5D6E7F8091A2B3C4D5", ""},
+		{"RRSIG", "A 8 2 3600 20240131000000 20240101000000 12345 example.com. c2lnbmF0dXJl", "A 8 2 3600 20240131000000 20240101000000 12345 example.com c2lnbmF0dXJl"},
+		{"RRSIG", "TYPE65280 13 0 300 20240131000000 20240101000000 1 . c2lnbmF0dXJl", ""},
+		{"NSEC", "host.example.com. A MX RRSIG NSEC TYPE1234", "host.example.com A MX RRSIG NSEC TYPE1234"},
+		{"NSEC", ". NS SOA", ""},
+		{"NSEC3", "1 1 12 AABBCCDD 2T7B4G4VSA5SMI47K61MV5BV1A22BOJR A RRSIG", ""},
+		{"NSEC3", "1 0 0 - 2T7B4G4VSA5SMI47K61MV5BV1A22BOJR", ""},
+		{"NSEC3PARAM", "1 0 10 AABBCCDD", ""},
+		{"CAA", "0 issue \"letsencrypt.org\"", ""},
+		{"CAA", "128 iodef \"mailto:security@example.com\"", ""},
+		{"NAPTR", "100 10 \"S\" \"SIP+D2U\" \"\" _sip._udp.example.com.", "100 10 \"S\" \"SIP+D2U\" \"\" _sip._udp.example.com"},
+		{"NAPTR", "100 50 \"a\" \"z3950+N2L+N2C\" \"!^.*$!\\\\1!\" .", ""},
+		{"TLSA", "3 1 1 0d6fce3368d1c2b9b5b1b7e0e0b8f0f0c3a6b0f3c9f3b5e8c1d8a1a6f0f3c9f3", ""},
+		{"SSHFP", "4 2 123456789ABCDEF67890123456789ABCDEF67890123456789ABCDEF123456789", ""},
+		{"CERT", "1 12345 8 MIIBkjCBAAAA", ""},
+		{"DNAME", "target.example.com.", "target.example.com"},
+		{"LOC", "52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m", "52 22 23.000 N 04 53 32.000 E -2m 0.00m 10000m 10m"},
+		{"LOC", "42 21 54.000 S 71 06 18.000 W -24m 30m 10000m 10m", ""},
+		{"URI", "10 1 \"ftp://ftp1.example.com/public\"", ""},
+		{"OPENPGPKEY", "mQINBFit2jsBEADrbl5vjVxYeAE0g0IDYCBpHirv1Sjlqxx5gjtPhb2YhvyDMXjq", ""},
+		{"ZONEMD", "2018031500 1 1 0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", ""},
+	}
+
+	for _, vector := range vectors {
+		want := vector.want
+		if want == "" {
+			want = vector.rdata
+		}
+
+		dm := new(dns.Msg)
+		dm.SetQuestion(fqdn, dns.StringToType[vector.rrtype])
+		rr1, err := dns.NewRR(fmt.Sprintf("%s %s %s", fqdn, vector.rrtype, vector.rdata))
+		if err != nil {
+			t.Fatalf("invalid rr %s %s: %s", vector.rrtype, vector.rdata, err)
+		}
+		dm.Answer = append(dm.Answer, rr1)
+		payload, _ := dm.Pack()
+		_, _, _, offsetRR, _ := DecodeQuestion(1, payload)
+		answer, _, err := DecodeAnswer(len(dm.Answer), offsetRR, payload)
+		if err != nil {
+			t.Errorf("unexpected error for rdata %s: %v", vector.rrtype, err)
+			continue
+		}
+		if answer[0].Rdatatype != vector.rrtype {
+			t.Errorf("invalid rdatatype, want %s, got: %s", vector.rrtype, answer[0].Rdatatype)
+		}
+		if answer[0].Rdata != want {
+			t.Errorf("invalid decode for rdata %s, want %s, got: %s", vector.rrtype, want, answer[0].Rdata)
+		}
+	}
+}
+
+func TestDecodeRdata_MoreTypes_Errors(t *testing.T) {
+	testcases := []struct {
+		name   string
+		parser func([]byte) (string, error)
+		rdata  []byte
+		err    error
+	}{
+		{"DNSKEY_short", ParseDNSKEY, []byte{0x01, 0x01, 0x03}, ErrDecodeDNSAnswerRdataTooShort},
+		{"DS_short", ParseDS, []byte{0x4f, 0x66, 0x08, 0x02}, ErrDecodeDNSAnswerRdataTooShort},
+		{"RRSIG_short", ParseRRSIG, make([]byte, 18), ErrDecodeDNSAnswerRdataTooShort},
+		{"RRSIG_invalid_signer", ParseRRSIG, append(make([]byte, 18), 0x05, 'a'), ErrDecodeDNSLabelTooShort},
+		{"NSEC_empty", ParseNSEC, []byte{}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NSEC_bitmap_short", ParseNSEC, []byte{0x00, 0x00, 0x02, 0x40}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NSEC_bitmap_truncated_window", ParseNSEC, []byte{0x00, 0x00}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NSEC_bitmap_empty_window", ParseNSEC, []byte{0x00, 0x00, 0x00}, ErrDecodeDNSAnswerRdataInvalid},
+		{"NSEC_bitmap_too_long", ParseNSEC, append([]byte{0x00, 0x00, 0x21}, make([]byte, 33)...), ErrDecodeDNSAnswerRdataInvalid},
+		{"NSEC3_short", ParseNSEC3, []byte{0x01, 0x00, 0x00, 0x0a}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NSEC3_salt_short", ParseNSEC3, []byte{0x01, 0x00, 0x00, 0x0a, 0x04, 0xaa}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NSEC3_no_hash", ParseNSEC3, []byte{0x01, 0x00, 0x00, 0x0a, 0x00}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NSEC3_hash_short", ParseNSEC3, []byte{0x01, 0x00, 0x00, 0x0a, 0x00, 0x14, 0xaa}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NSEC3PARAM_salt_short", ParseNSEC3PARAM, []byte{0x01, 0x00, 0x00, 0x0a, 0x02, 0xaa}, ErrDecodeDNSAnswerRdataTooShort},
+		{"CAA_short", ParseCAA, []byte{0x00}, ErrDecodeDNSAnswerRdataTooShort},
+		{"CAA_empty_tag", ParseCAA, []byte{0x00, 0x00}, ErrDecodeDNSAnswerRdataInvalid},
+		{"CAA_tag_short", ParseCAA, []byte{0x00, 0x05, 'i', 's'}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NAPTR_short", ParseNAPTR, []byte{0x00, 0x64, 0x00}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NAPTR_flags_short", ParseNAPTR, []byte{0x00, 0x64, 0x00, 0x0a, 0x02, 'S'}, ErrDecodeDNSAnswerRdataTooShort},
+		{"NAPTR_no_replacement", ParseNAPTR, []byte{0x00, 0x64, 0x00, 0x0a, 0x00, 0x00, 0x00}, ErrDecodeDNSAnswerRdataTooShort},
+		{"TLSA_short", ParseTLSA, []byte{0x03, 0x01, 0x01}, ErrDecodeDNSAnswerRdataTooShort},
+		{"SSHFP_short", ParseSSHFP, []byte{0x04, 0x02}, ErrDecodeDNSAnswerRdataTooShort},
+		{"CERT_short", ParseCERT, []byte{0x00, 0x01, 0x30, 0x39, 0x08}, ErrDecodeDNSAnswerRdataTooShort},
+		{"LOC_short", ParseLOC, make([]byte, 15), ErrDecodeDNSAnswerRdataTooShort},
+		{"LOC_version", ParseLOC, append([]byte{0x01}, make([]byte, 15)...), ErrDecodeDNSAnswerRdataInvalid},
+		{"URI_short", ParseURI, []byte{0x00, 0x0a, 0x00, 0x01}, ErrDecodeDNSAnswerRdataTooShort},
+		{"OPENPGPKEY_empty", ParseOPENPGPKEY, []byte{}, ErrDecodeDNSAnswerRdataTooShort},
+		{"ZONEMD_short", ParseZONEMD, make([]byte, 17), ErrDecodeDNSAnswerRdataTooShort},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.parser(tc.rdata)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("bad error returned, want %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestDecodeRdataDNAME_Short(t *testing.T) {
+	_, err := ParseDNAME(0, []byte{0x05, 'a'})
+	if !errors.Is(err, ErrDecodeDNSLabelTooShort) {
+		t.Errorf("bad error returned: %v", err)
+	}
+}
@@ -4,11 +4,18 @@ import (
 	"regexp"
 )
 
+// The MessagePack codec of the DNS message is generated with msgp, using the json field names
+//go:generate msgp -file dnsmessage.go -o dnsmessage_gen.go -io=false -tests=false
+
+//msgp:tag json
+//msgp:ignore RelabelingRule TransformRelabeling
+
 var (
 	DNSQuery      = "QUERY"
 	DNSQueryQuiet = "Q"
 	DNSReply      = "REPLY"
 	DNSReplyQuiet = "R"
+	DNSSession    = "SESSION"
 )
 
 type DNSAnswer struct {
@@ -80,6 +87,16 @@ type DNSExtended struct {
 	Do            int         `json:"dnssec-ok"`
 	Z             int         `json:"-"`
 	Options       []DNSOption `json:"options"`
+	// typed values of the decoded options
+	ClientCookie     string `json:"client-cookie,omitempty"`
+	ServerCookie     string `json:"server-cookie,omitempty"`
+	NSID             string `json:"nsid,omitempty"`
+	Padding          int    `json:"padding,omitempty"`
+	KeepaliveTimeout int    `json:"keepalive-timeout,omitempty"`
+	Expire           int    `json:"expire,omitempty"`
+	Chain            string `json:"chain,omitempty"`
+	ReportChannel    string `json:"report-channel,omitempty"`
+	ZoneVersion      string `json:"zoneversion,omitempty"`
 }
 
 type DNSTap struct {
@@ -122,11 +139,30 @@ type CollectorPowerDNS struct {
 	EdnsVersion           string            `json:"edns-version"`
 }
 
+type CollectorSession struct {
+	SNI           string   `json:"sni"`
+	ALPN          []string `json:"alpn"`
+	BytesSent     int      `json:"bytes-sent"`
+	BytesReceived int      `json:"bytes-received"`
+	Duration      float64  `json:"duration"`
+	Closing       string   `json:"closing"`
+}
+
 type LoggerOpenTelemetry struct {
 	TraceID string `json:"trace-id"`
 }
 
 type TransformDNSGeo struct {
+	City                   string                  `json:"city"`
+	Continent              string                  `json:"continent"`
+	CountryIsoCode         string                  `json:"country-isocode"`
+	AutonomousSystemNumber string                  `json:"as-number"`
+	AutonomousSystemOrg    string                  `json:"as-owner"`
+	Answers                []TransformDNSGeoAnswer `json:"answers,omitempty"`
+}
+
+type TransformDNSGeoAnswer struct {
+	IP                     string `json:"ip"`
 	City                   string `json:"city"`
 	Continent              string `json:"continent"`
 	CountryIsoCode         string `json:"country-isocode"`
@@ -143,6 +179,8 @@ type TransformSuspicious struct {
 	UnallowedChars        bool    `json:"unallowed-chars"`
 	UncommonQtypes        bool    `json:"uncommon-qtypes"`
 	ExcessiveNumberLabels bool    `json:"excessive-number-labels"`
+	PrivateAnswer         bool    `json:"private-answer,omitempty"`
+	LargeRdata            bool    `json:"large-rdata,omitempty"`
 	Domain                string  `json:"domain,omitempty"`
 }
 
@@ -161,6 +199,15 @@ type TransformReducer struct {
 	CumulativeLength int `json:"cumulative-length"`
 }
 
+type TransformCorrelation struct {
+	Status            string  `json:"status"`
+	QueryTimestamp    string  `json:"query-timestamp-rfc3339ns"`
+	ResponseTimestamp string  `json:"response-timestamp-rfc3339ns"`
+	Latency           float64 `json:"latency"`
+	QueryLength       int     `json:"query-length"`
+	ResponseLength    int     `json:"response-length"`
+}
+
 type TransformFiltering struct {
 	SampleRate int `json:"sample-rate"`
 }
@@ -187,6 +234,13 @@ type TransformML struct {
 	UncommonQtypes        int     `json:"uncommon-qtypes"`
 }
 
+type TransformDetection struct {
+	Score          float64  `json:"score"`
+	TunnelingScore float64  `json:"tunneling-score"`
+	DGAScore       float64  `json:"dga-score"`
+	Reasons        []string `json:"reasons"`
+}
+
 type TransformATags struct {
 	Tags []string `json:"tags"`
 }
@@ -212,13 +266,16 @@ type DNSMessage struct {
 	EDNS            DNSExtended            `json:"edns"`
 	DNSTap          DNSTap                 `json:"dnstap"`
 	PowerDNS        *CollectorPowerDNS     `json:"powerdns,omitempty"`
+	Session         *CollectorSession      `json:"session,omitempty"`
 	OpenTelemetry   *LoggerOpenTelemetry   `json:"opentelemetry,omitempty"`
 	Geo             *TransformDNSGeo       `json:"geoip,omitempty"`
 	Suspicious      *TransformSuspicious   `json:"suspicious,omitempty"`
 	PublicSuffix    *TransformPublicSuffix `json:"publicsuffix,omitempty"`
 	Extracted       *TransformExtracted    `json:"extracted,omitempty"`
 	Reducer         *TransformReducer      `json:"reducer,omitempty"`
+	Correlation     *TransformCorrelation  `json:"correlation,omitempty"`
 	MachineLearning *TransformML           `json:"ml,omitempty"`
+	Detection       *TransformDetection    `json:"detection,omitempty"`
 	Filtering       *TransformFiltering    `json:"filtering,omitempty"`
 	ATags           *TransformATags        `json:"atags,omitempty"`
 	Rest            *TransformRest         `json:"rest,omitempty"`
@@ -274,7 +331,9 @@ func (dm *DNSMessage) InitTransforms() {
 	dm.Rest = &TransformRest{}
 	dm.Filtering = &TransformFiltering{}
 	dm.MachineLearning = &TransformML{}
+	dm.Detection = &TransformDetection{}
 	dm.Reducer = &TransformReducer{}
+	dm.Correlation = &TransformCorrelation{}
 	dm.Extracted = &TransformExtracted{}
 	dm.PublicSuffix = &TransformPublicSuffix{}
 	dm.Suspicious = &TransformSuspicious{}
@@ -282,5 +341,6 @@ func (dm *DNSMessage) InitTransforms() {
 	dm.Relabeling = &TransformRelabeling{}
 	// init collectors & loggers
 	dm.PowerDNS = &CollectorPowerDNS{}
+	dm.Session = &CollectorSession{}
 	dm.OpenTelemetry = &LoggerOpenTelemetry{}
 }
@@ -0,0 +1,166 @@
+package dnsutils
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+const (
+	cborMajorUint   = 0
+	cborMajorNegInt = 1
+	cborMajorBytes  = 2
+	cborMajorText   = 3
+	cborMajorArray  = 4
+	cborMajorMap    = 5
+
+	cborFalse   = 0xf4
+	cborTrue    = 0xf5
+	cborNull    = 0xf6
+	cborFloat32 = 0xfa
+	cborFloat64 = 0xfb
+)
+
+var (
+	ErrCBORUnsupportedType = errors.New("cbor: unsupported msgpack type")
+
+	msgpackBufferPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+)
+
+// ToCBOR encodes the dns message in CBOR (RFC 8949). MessagePack and CBOR share the
+// same data model, so the output of the generated MessagePack codec is translated
+// item per item instead of walking the message with reflection.
+func (dm *DNSMessage) ToCBOR() ([]byte, error) {
+	buf := msgpackBufferPool.Get().(*[]byte)
+	defer msgpackBufferPool.Put(buf)
+
+	mp, err := dm.MarshalMsg((*buf)[:0])
+	if err != nil {
+		return nil, err
+	}
+	*buf = mp
+
+	out, _, err := msgpackToCBOR(make([]byte, 0, len(mp)), mp)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// msgpackToCBOR translates the next msgpack object of src and appends it to dst
+func msgpackToCBOR(dst, src []byte) ([]byte, []byte, error) {
+	var err error
+	switch msgp.NextType(src) {
+	case msgp.StrType:
+		var s []byte
+		if s, src, err = msgp.ReadStringZC(src); err != nil {
+			return dst, src, err
+		}
+		dst = append(appendCBORHead(dst, cborMajorText, uint64(len(s))), s...)
+
+	case msgp.BinType:
+		var b []byte
+		if b, src, err = msgp.ReadBytesZC(src); err != nil {
+			return dst, src, err
+		}
+		dst = append(appendCBORHead(dst, cborMajorBytes, uint64(len(b))), b...)
+
+	case msgp.MapType:
+		var n uint32
+		if n, src, err = msgp.ReadMapHeaderBytes(src); err != nil {
+			return dst, src, err
+		}
+		dst = appendCBORHead(dst, cborMajorMap, uint64(n))
+		for i := uint32(0); i < n*2; i++ {
+			if dst, src, err = msgpackToCBOR(dst, src); err != nil {
+				return dst, src, err
+			}
+		}
+
+	case msgp.ArrayType:
+		var n uint32
+		if n, src, err = msgp.ReadArrayHeaderBytes(src); err != nil {
+			return dst, src, err
+		}
+		dst = appendCBORHead(dst, cborMajorArray, uint64(n))
+		for i := uint32(0); i < n; i++ {
+			if dst, src, err = msgpackToCBOR(dst, src); err != nil {
+				return dst, src, err
+			}
+		}
+
+	case msgp.IntType:
+		var v int64
+		if v, src, err = msgp.ReadInt64Bytes(src); err != nil {
+			return dst, src, err
+		}
+		if v >= 0 {
+			dst = appendCBORHead(dst, cborMajorUint, uint64(v))
+		} else {
+			dst = appendCBORHead(dst, cborMajorNegInt, uint64(-1-v))
+		}
+
+	case msgp.UintType:
+		var v uint64
+		if v, src, err = msgp.ReadUint64Bytes(src); err != nil {
+			return dst, src, err
+		}
+		dst = appendCBORHead(dst, cborMajorUint, v)
+
+	case msgp.Float64Type:
+		var f float64
+		if f, src, err = msgp.ReadFloat64Bytes(src); err != nil {
+			return dst, src, err
+		}
+		dst = binary.BigEndian.AppendUint64(append(dst, cborFloat64), math.Float64bits(f))
+
+	case msgp.Float32Type:
+		var f float32
+		if f, src, err = msgp.ReadFloat32Bytes(src); err != nil {
+			return dst, src, err
+		}
+		dst = binary.BigEndian.AppendUint32(append(dst, cborFloat32), math.Float32bits(f))
+
+	case msgp.BoolType:
+		var v bool
+		if v, src, err = msgp.ReadBoolBytes(src); err != nil {
+			return dst, src, err
+		}
+		if v {
+			dst = append(dst, cborTrue)
+		} else {
+			dst = append(dst, cborFalse)
+		}
+
+	case msgp.NilType:
+		if src, err = msgp.ReadNilBytes(src); err != nil {
+			return dst, src, err
+		}
+		dst = append(dst, cborNull)
+
+	default:
+		return dst, src, ErrCBORUnsupportedType
+	}
+	return dst, src, nil
+}
+
+// appendCBORHead appends the initial byte of a data item with its argument
+// encoded on the shortest form
+func appendCBORHead(dst []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(dst, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, major|27), n)
+	}
+}
@@ -0,0 +1,77 @@
+package dnsutils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestDnsMessage_MsgpackToCBOR(t *testing.T) {
+	testcases := []struct {
+		name     string
+		msgpack  func(b []byte) []byte
+		expected string
+	}{
+		{"uint", func(b []byte) []byte { return msgp.AppendInt(b, 500) }, "1901f4"},
+		{"negative_int", func(b []byte) []byte { return msgp.AppendInt(b, -10) }, "29"},
+		{"bool", func(b []byte) []byte { return msgp.AppendBool(b, true) }, "f5"},
+		{"nil", func(b []byte) []byte { return msgp.AppendNil(b) }, "f6"},
+		{"float64", func(b []byte) []byte { return msgp.AppendFloat64(b, 1.5) }, "fb3ff8000000000000"},
+		{"string", func(b []byte) []byte { return msgp.AppendString(b, "IETF") }, "6449455446"},
+		{"bytes", func(b []byte) []byte { return msgp.AppendBytes(b, []byte{1, 2}) }, "420102"},
+		{"array", func(b []byte) []byte {
+			b = msgp.AppendArrayHeader(b, 2)
+			b = msgp.AppendInt(b, 1)
+			return msgp.AppendInt(b, 2)
+		}, "820102"},
+		{"map", func(b []byte) []byte {
+			b = msgp.AppendMapHeader(b, 1)
+			b = msgp.AppendString(b, "a")
+			return msgp.AppendInt(b, 1)
+		}, "a1616101"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, rest, err := msgpackToCBOR(nil, tc.msgpack(nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rest) != 0 {
+				t.Errorf("%d unexpected remaining bytes", len(rest))
+			}
+			if hex.EncodeToString(out) != tc.expected {
+				t.Errorf("expected %s, got %x", tc.expected, out)
+			}
+		})
+	}
+}
+
+func TestDnsMessage_ToCBOR(t *testing.T) {
+	dm := GetFakeDNSMessage()
+
+	data, err := dm.ToCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// map of the 4 mandatory groups: network, dns, edns and dnstap
+	if data[0] != 0xa4 {
+		t.Errorf("unexpected map header: %x", data[0])
+	}
+	if !bytes.Contains(data, append([]byte{0x6d}, "dns.collector"...)) {
+		t.Errorf("qname not found in cbor message")
+	}
+}
+
+func TestDnsMessage_ToCBOR_Unsupported(t *testing.T) {
+	ext, err := msgp.AppendExtension(nil, &msgp.RawExtension{Type: 10, Data: []byte{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = msgpackToCBOR(nil, ext); err != ErrCBORUnsupportedType {
+		t.Errorf("unsupported type error expected, got %v", err)
+	}
+}
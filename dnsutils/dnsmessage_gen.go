@@ -0,0 +1,3629 @@
+package dnsutils
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// MarshalMsg implements msgp.Marshaler
+func (z *CollectorPowerDNS) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 16
+	// string "tags"
+	o = append(o, 0xde, 0x0, 0x10, 0xa4, 0x74, 0x61, 0x67, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Tags)))
+	for za0001 := range z.Tags {
+		o = msgp.AppendString(o, z.Tags[za0001])
+	}
+	// string "original-request-subnet"
+	o = append(o, 0xb7, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c, 0x2d, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2d, 0x73, 0x75, 0x62, 0x6e, 0x65, 0x74)
+	o = msgp.AppendString(o, z.OriginalRequestSubnet)
+	// string "applied-policy"
+	o = append(o, 0xae, 0x61, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x2d, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79)
+	o = msgp.AppendString(o, z.AppliedPolicy)
+	// string "applied-policy-hit"
+	o = append(o, 0xb2, 0x61, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x2d, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x68, 0x69, 0x74)
+	o = msgp.AppendString(o, z.AppliedPolicyHit)
+	// string "applied-policy-kind"
+	o = append(o, 0xb3, 0x61, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x2d, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x6b, 0x69, 0x6e, 0x64)
+	o = msgp.AppendString(o, z.AppliedPolicyKind)
+	// string "applied-policy-trigger"
+	o = append(o, 0xb6, 0x61, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x2d, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x74, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72)
+	o = msgp.AppendString(o, z.AppliedPolicyTrigger)
+	// string "applied-policy-type"
+	o = append(o, 0xb3, 0x61, 0x70, 0x70, 0x6c, 0x69, 0x65, 0x64, 0x2d, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x74, 0x79, 0x70, 0x65)
+	o = msgp.AppendString(o, z.AppliedPolicyType)
+	// string "metadata"
+	o = append(o, 0xa8, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61)
+	o = msgp.AppendMapHeader(o, uint32(len(z.Metadata)))
+	for za0002, za0003 := range z.Metadata {
+		o = msgp.AppendString(o, za0002)
+		o = msgp.AppendString(o, za0003)
+	}
+	// string "http-version"
+	o = append(o, 0xac, 0x68, 0x74, 0x74, 0x70, 0x2d, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendString(o, z.HTTPVersion)
+	// string "message-id"
+	o = append(o, 0xaa, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2d, 0x69, 0x64)
+	o = msgp.AppendString(o, z.MessageID)
+	// string "initial-requestor-id"
+	o = append(o, 0xb4, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x2d, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x2d, 0x69, 0x64)
+	o = msgp.AppendString(o, z.InitialRequestorID)
+	// string "requestor-id"
+	o = append(o, 0xac, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x2d, 0x69, 0x64)
+	o = msgp.AppendString(o, z.RequestorID)
+	// string "device-name"
+	o = append(o, 0xab, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x2d, 0x6e, 0x61, 0x6d, 0x65)
+	o = msgp.AppendString(o, z.DeviceName)
+	// string "device-id"
+	o = append(o, 0xa9, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x2d, 0x69, 0x64)
+	o = msgp.AppendString(o, z.DeviceID)
+	// string "opentelemetry-data"
+	o = append(o, 0xb2, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2d, 0x64, 0x61, 0x74, 0x61)
+	o = msgp.AppendString(o, z.OpenTelemetryData)
+	// string "edns-version"
+	o = append(o, 0xac, 0x65, 0x64, 0x6e, 0x73, 0x2d, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendString(o, z.EdnsVersion)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *CollectorPowerDNS) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "tags":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Tags")
+				return
+			}
+			if cap(z.Tags) >= int(zb0002) {
+				z.Tags = (z.Tags)[:zb0002]
+			} else {
+				z.Tags = make([]string, zb0002)
+			}
+			for za0001 := range z.Tags {
+				z.Tags[za0001], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Tags", za0001)
+					return
+				}
+			}
+		case "original-request-subnet":
+			z.OriginalRequestSubnet, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "OriginalRequestSubnet")
+				return
+			}
+		case "applied-policy":
+			z.AppliedPolicy, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AppliedPolicy")
+				return
+			}
+		case "applied-policy-hit":
+			z.AppliedPolicyHit, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AppliedPolicyHit")
+				return
+			}
+		case "applied-policy-kind":
+			z.AppliedPolicyKind, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AppliedPolicyKind")
+				return
+			}
+		case "applied-policy-trigger":
+			z.AppliedPolicyTrigger, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AppliedPolicyTrigger")
+				return
+			}
+		case "applied-policy-type":
+			z.AppliedPolicyType, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AppliedPolicyType")
+				return
+			}
+		case "metadata":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Metadata")
+				return
+			}
+			if z.Metadata == nil {
+				z.Metadata = make(map[string]string, zb0003)
+			} else if len(z.Metadata) > 0 {
+				for key := range z.Metadata {
+					delete(z.Metadata, key)
+				}
+			}
+			for zb0003 > 0 {
+				var za0002 string
+				var za0003 string
+				zb0003--
+				za0002, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Metadata")
+					return
+				}
+				za0003, bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Metadata", za0002)
+					return
+				}
+				z.Metadata[za0002] = za0003
+			}
+		case "http-version":
+			z.HTTPVersion, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "HTTPVersion")
+				return
+			}
+		case "message-id":
+			z.MessageID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MessageID")
+				return
+			}
+		case "initial-requestor-id":
+			z.InitialRequestorID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "InitialRequestorID")
+				return
+			}
+		case "requestor-id":
+			z.RequestorID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RequestorID")
+				return
+			}
+		case "device-name":
+			z.DeviceName, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DeviceName")
+				return
+			}
+		case "device-id":
+			z.DeviceID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DeviceID")
+				return
+			}
+		case "opentelemetry-data":
+			z.OpenTelemetryData, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "OpenTelemetryData")
+				return
+			}
+		case "edns-version":
+			z.EdnsVersion, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "EdnsVersion")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *CollectorPowerDNS) Msgsize() (s int) {
+	s = 3 + 5 + msgp.ArrayHeaderSize
+	for za0001 := range z.Tags {
+		s += msgp.StringPrefixSize + len(z.Tags[za0001])
+	}
+	s += 24 + msgp.StringPrefixSize + len(z.OriginalRequestSubnet) + 15 + msgp.StringPrefixSize + len(z.AppliedPolicy) + 19 + msgp.StringPrefixSize + len(z.AppliedPolicyHit) + 20 + msgp.StringPrefixSize + len(z.AppliedPolicyKind) + 23 + msgp.StringPrefixSize + len(z.AppliedPolicyTrigger) + 20 + msgp.StringPrefixSize + len(z.AppliedPolicyType) + 9 + msgp.MapHeaderSize
+	if z.Metadata != nil {
+		for za0002, za0003 := range z.Metadata {
+			_ = za0003
+			s += msgp.StringPrefixSize + len(za0002) + msgp.StringPrefixSize + len(za0003)
+		}
+	}
+	s += 13 + msgp.StringPrefixSize + len(z.HTTPVersion) + 11 + msgp.StringPrefixSize + len(z.MessageID) + 21 + msgp.StringPrefixSize + len(z.InitialRequestorID) + 13 + msgp.StringPrefixSize + len(z.RequestorID) + 12 + msgp.StringPrefixSize + len(z.DeviceName) + 10 + msgp.StringPrefixSize + len(z.DeviceID) + 19 + msgp.StringPrefixSize + len(z.OpenTelemetryData) + 13 + msgp.StringPrefixSize + len(z.EdnsVersion)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *CollectorSession) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 6
+	// string "sni"
+	o = append(o, 0x86, 0xa3, 0x73, 0x6e, 0x69)
+	o = msgp.AppendString(o, z.SNI)
+	// string "alpn"
+	o = append(o, 0xa4, 0x61, 0x6c, 0x70, 0x6e)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.ALPN)))
+	for za0001 := range z.ALPN {
+		o = msgp.AppendString(o, z.ALPN[za0001])
+	}
+	// string "bytes-sent"
+	o = append(o, 0xaa, 0x62, 0x79, 0x74, 0x65, 0x73, 0x2d, 0x73, 0x65, 0x6e, 0x74)
+	o = msgp.AppendInt(o, z.BytesSent)
+	// string "bytes-received"
+	o = append(o, 0xae, 0x62, 0x79, 0x74, 0x65, 0x73, 0x2d, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64)
+	o = msgp.AppendInt(o, z.BytesReceived)
+	// string "duration"
+	o = append(o, 0xa8, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendFloat64(o, z.Duration)
+	// string "closing"
+	o = append(o, 0xa7, 0x63, 0x6c, 0x6f, 0x73, 0x69, 0x6e, 0x67)
+	o = msgp.AppendString(o, z.Closing)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *CollectorSession) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "sni":
+			z.SNI, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SNI")
+				return
+			}
+		case "alpn":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ALPN")
+				return
+			}
+			if cap(z.ALPN) >= int(zb0002) {
+				z.ALPN = (z.ALPN)[:zb0002]
+			} else {
+				z.ALPN = make([]string, zb0002)
+			}
+			for za0001 := range z.ALPN {
+				z.ALPN[za0001], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "ALPN", za0001)
+					return
+				}
+			}
+		case "bytes-sent":
+			z.BytesSent, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "BytesSent")
+				return
+			}
+		case "bytes-received":
+			z.BytesReceived, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "BytesReceived")
+				return
+			}
+		case "duration":
+			z.Duration, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Duration")
+				return
+			}
+		case "closing":
+			z.Closing, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Closing")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *CollectorSession) Msgsize() (s int) {
+	s = 1 + 4 + msgp.StringPrefixSize + len(z.SNI) + 5 + msgp.ArrayHeaderSize
+	for za0001 := range z.ALPN {
+		s += msgp.StringPrefixSize + len(z.ALPN[za0001])
+	}
+	s += 11 + msgp.IntSize + 15 + msgp.IntSize + 9 + msgp.Float64Size + 8 + msgp.StringPrefixSize + len(z.Closing)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DNS) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 14
+	// string "length"
+	o = append(o, 0x8e, 0xa6, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	o = msgp.AppendInt(o, z.Length)
+	// string "id"
+	o = append(o, 0xa2, 0x69, 0x64)
+	o = msgp.AppendInt(o, z.ID)
+	// string "opcode"
+	o = append(o, 0xa6, 0x6f, 0x70, 0x63, 0x6f, 0x64, 0x65)
+	o = msgp.AppendInt(o, z.Opcode)
+	// string "rcode"
+	o = append(o, 0xa5, 0x72, 0x63, 0x6f, 0x64, 0x65)
+	o = msgp.AppendString(o, z.Rcode)
+	// string "qname"
+	o = append(o, 0xa5, 0x71, 0x6e, 0x61, 0x6d, 0x65)
+	o = msgp.AppendString(o, z.Qname)
+	// string "qclass"
+	o = append(o, 0xa6, 0x71, 0x63, 0x6c, 0x61, 0x73, 0x73)
+	o = msgp.AppendString(o, z.Qclass)
+	// string "qdcount"
+	o = append(o, 0xa7, 0x71, 0x64, 0x63, 0x6f, 0x75, 0x6e, 0x74)
+	o = msgp.AppendInt(o, z.QdCount)
+	// string "ancount"
+	o = append(o, 0xa7, 0x61, 0x6e, 0x63, 0x6f, 0x75, 0x6e, 0x74)
+	o = msgp.AppendInt(o, z.AnCount)
+	// string "nscount"
+	o = append(o, 0xa7, 0x6e, 0x73, 0x63, 0x6f, 0x75, 0x6e, 0x74)
+	o = msgp.AppendInt(o, z.NsCount)
+	// string "arcount"
+	o = append(o, 0xa7, 0x61, 0x72, 0x63, 0x6f, 0x75, 0x6e, 0x74)
+	o = msgp.AppendInt(o, z.ArCount)
+	// string "qtype"
+	o = append(o, 0xa5, 0x71, 0x74, 0x79, 0x70, 0x65)
+	o = msgp.AppendString(o, z.Qtype)
+	// string "flags"
+	o = append(o, 0xa5, 0x66, 0x6c, 0x61, 0x67, 0x73)
+	o, err = z.Flags.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Flags")
+		return
+	}
+	// string "resource-records"
+	o = append(o, 0xb0, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x2d, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73)
+	o, err = z.DNSRRs.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "DNSRRs")
+		return
+	}
+	// string "malformed-packet"
+	o = append(o, 0xb0, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x2d, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74)
+	o = msgp.AppendBool(o, z.MalformedPacket)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNS) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "length":
+			z.Length, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Length")
+				return
+			}
+		case "id":
+			z.ID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ID")
+				return
+			}
+		case "opcode":
+			z.Opcode, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Opcode")
+				return
+			}
+		case "rcode":
+			z.Rcode, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Rcode")
+				return
+			}
+		case "qname":
+			z.Qname, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Qname")
+				return
+			}
+		case "qclass":
+			z.Qclass, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Qclass")
+				return
+			}
+		case "qdcount":
+			z.QdCount, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QdCount")
+				return
+			}
+		case "ancount":
+			z.AnCount, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AnCount")
+				return
+			}
+		case "nscount":
+			z.NsCount, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "NsCount")
+				return
+			}
+		case "arcount":
+			z.ArCount, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ArCount")
+				return
+			}
+		case "qtype":
+			z.Qtype, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Qtype")
+				return
+			}
+		case "flags":
+			bts, err = z.Flags.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Flags")
+				return
+			}
+		case "resource-records":
+			bts, err = z.DNSRRs.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DNSRRs")
+				return
+			}
+		case "malformed-packet":
+			z.MalformedPacket, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MalformedPacket")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DNS) Msgsize() (s int) {
+	s = 1 + 7 + msgp.IntSize + 3 + msgp.IntSize + 7 + msgp.IntSize + 6 + msgp.StringPrefixSize + len(z.Rcode) + 6 + msgp.StringPrefixSize + len(z.Qname) + 7 + msgp.StringPrefixSize + len(z.Qclass) + 8 + msgp.IntSize + 8 + msgp.IntSize + 8 + msgp.IntSize + 8 + msgp.IntSize + 6 + msgp.StringPrefixSize + len(z.Qtype) + 6 + z.Flags.Msgsize() + 17 + z.DNSRRs.Msgsize() + 17 + msgp.BoolSize
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DNSAnswer) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 5
+	// string "name"
+	o = append(o, 0x85, 0xa4, 0x6e, 0x61, 0x6d, 0x65)
+	o = msgp.AppendString(o, z.Name)
+	// string "rdatatype"
+	o = append(o, 0xa9, 0x72, 0x64, 0x61, 0x74, 0x61, 0x74, 0x79, 0x70, 0x65)
+	o = msgp.AppendString(o, z.Rdatatype)
+	// string "class"
+	o = append(o, 0xa5, 0x63, 0x6c, 0x61, 0x73, 0x73)
+	o = msgp.AppendString(o, z.Class)
+	// string "ttl"
+	o = append(o, 0xa3, 0x74, 0x74, 0x6c)
+	o = msgp.AppendInt(o, z.TTL)
+	// string "rdata"
+	o = append(o, 0xa5, 0x72, 0x64, 0x61, 0x74, 0x61)
+	o = msgp.AppendString(o, z.Rdata)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNSAnswer) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "name":
+			z.Name, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Name")
+				return
+			}
+		case "rdatatype":
+			z.Rdatatype, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Rdatatype")
+				return
+			}
+		case "class":
+			z.Class, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Class")
+				return
+			}
+		case "ttl":
+			z.TTL, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TTL")
+				return
+			}
+		case "rdata":
+			z.Rdata, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Rdata")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DNSAnswer) Msgsize() (s int) {
+	s = 1 + 5 + msgp.StringPrefixSize + len(z.Name) + 10 + msgp.StringPrefixSize + len(z.Rdatatype) + 6 + msgp.StringPrefixSize + len(z.Class) + 4 + msgp.IntSize + 6 + msgp.StringPrefixSize + len(z.Rdata)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DNSExtended) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// check for omitted fields
+	zb0001Len := uint32(14)
+	var zb0001Mask uint16 /* 14 bits */
+	_ = zb0001Mask
+	if z.ClientCookie == "" {
+		zb0001Len--
+		zb0001Mask |= 0x20
+	}
+	if z.ServerCookie == "" {
+		zb0001Len--
+		zb0001Mask |= 0x40
+	}
+	if z.NSID == "" {
+		zb0001Len--
+		zb0001Mask |= 0x80
+	}
+	if z.Padding == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x100
+	}
+	if z.KeepaliveTimeout == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x200
+	}
+	if z.Expire == 0 {
+		zb0001Len--
+		zb0001Mask |= 0x400
+	}
+	if z.Chain == "" {
+		zb0001Len--
+		zb0001Mask |= 0x800
+	}
+	if z.ReportChannel == "" {
+		zb0001Len--
+		zb0001Mask |= 0x1000
+	}
+	if z.ZoneVersion == "" {
+		zb0001Len--
+		zb0001Mask |= 0x2000
+	}
+	// variable map header, size zb0001Len
+	o = append(o, 0x80|uint8(zb0001Len))
+
+	// skip if no fields are to be emitted
+	if zb0001Len != 0 {
+		// string "udp-size"
+		o = append(o, 0xa8, 0x75, 0x64, 0x70, 0x2d, 0x73, 0x69, 0x7a, 0x65)
+		o = msgp.AppendInt(o, z.UDPSize)
+		// string "rcode"
+		o = append(o, 0xa5, 0x72, 0x63, 0x6f, 0x64, 0x65)
+		o = msgp.AppendInt(o, z.ExtendedRcode)
+		// string "version"
+		o = append(o, 0xa7, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+		o = msgp.AppendInt(o, z.Version)
+		// string "dnssec-ok"
+		o = append(o, 0xa9, 0x64, 0x6e, 0x73, 0x73, 0x65, 0x63, 0x2d, 0x6f, 0x6b)
+		o = msgp.AppendInt(o, z.Do)
+		// string "options"
+		o = append(o, 0xa7, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73)
+		o = msgp.AppendArrayHeader(o, uint32(len(z.Options)))
+		for za0001 := range z.Options {
+			// map header, size 3
+			// string "code"
+			o = append(o, 0x83, 0xa4, 0x63, 0x6f, 0x64, 0x65)
+			o = msgp.AppendInt(o, z.Options[za0001].Code)
+			// string "name"
+			o = append(o, 0xa4, 0x6e, 0x61, 0x6d, 0x65)
+			o = msgp.AppendString(o, z.Options[za0001].Name)
+			// string "data"
+			o = append(o, 0xa4, 0x64, 0x61, 0x74, 0x61)
+			o = msgp.AppendString(o, z.Options[za0001].Data)
+		}
+		if (zb0001Mask & 0x20) == 0 { // if not omitted
+			// string "client-cookie"
+			o = append(o, 0xad, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2d, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65)
+			o = msgp.AppendString(o, z.ClientCookie)
+		}
+		if (zb0001Mask & 0x40) == 0 { // if not omitted
+			// string "server-cookie"
+			o = append(o, 0xad, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2d, 0x63, 0x6f, 0x6f, 0x6b, 0x69, 0x65)
+			o = msgp.AppendString(o, z.ServerCookie)
+		}
+		if (zb0001Mask & 0x80) == 0 { // if not omitted
+			// string "nsid"
+			o = append(o, 0xa4, 0x6e, 0x73, 0x69, 0x64)
+			o = msgp.AppendString(o, z.NSID)
+		}
+		if (zb0001Mask & 0x100) == 0 { // if not omitted
+			// string "padding"
+			o = append(o, 0xa7, 0x70, 0x61, 0x64, 0x64, 0x69, 0x6e, 0x67)
+			o = msgp.AppendInt(o, z.Padding)
+		}
+		if (zb0001Mask & 0x200) == 0 { // if not omitted
+			// string "keepalive-timeout"
+			o = append(o, 0xb1, 0x6b, 0x65, 0x65, 0x70, 0x61, 0x6c, 0x69, 0x76, 0x65, 0x2d, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74)
+			o = msgp.AppendInt(o, z.KeepaliveTimeout)
+		}
+		if (zb0001Mask & 0x400) == 0 { // if not omitted
+			// string "expire"
+			o = append(o, 0xa6, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65)
+			o = msgp.AppendInt(o, z.Expire)
+		}
+		if (zb0001Mask & 0x800) == 0 { // if not omitted
+			// string "chain"
+			o = append(o, 0xa5, 0x63, 0x68, 0x61, 0x69, 0x6e)
+			o = msgp.AppendString(o, z.Chain)
+		}
+		if (zb0001Mask & 0x1000) == 0 { // if not omitted
+			// string "report-channel"
+			o = append(o, 0xae, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2d, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c)
+			o = msgp.AppendString(o, z.ReportChannel)
+		}
+		if (zb0001Mask & 0x2000) == 0 { // if not omitted
+			// string "zoneversion"
+			o = append(o, 0xab, 0x7a, 0x6f, 0x6e, 0x65, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+			o = msgp.AppendString(o, z.ZoneVersion)
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNSExtended) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "udp-size":
+			z.UDPSize, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UDPSize")
+				return
+			}
+		case "rcode":
+			z.ExtendedRcode, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ExtendedRcode")
+				return
+			}
+		case "version":
+			z.Version, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "dnssec-ok":
+			z.Do, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Do")
+				return
+			}
+		case "options":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Options")
+				return
+			}
+			if cap(z.Options) >= int(zb0002) {
+				z.Options = (z.Options)[:zb0002]
+			} else {
+				z.Options = make([]DNSOption, zb0002)
+			}
+			for za0001 := range z.Options {
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Options", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Options", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "code":
+						z.Options[za0001].Code, bts, err = msgp.ReadIntBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Options", za0001, "Code")
+							return
+						}
+					case "name":
+						z.Options[za0001].Name, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Options", za0001, "Name")
+							return
+						}
+					case "data":
+						z.Options[za0001].Data, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Options", za0001, "Data")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Options", za0001)
+							return
+						}
+					}
+				}
+			}
+		case "client-cookie":
+			z.ClientCookie, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ClientCookie")
+				return
+			}
+		case "server-cookie":
+			z.ServerCookie, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ServerCookie")
+				return
+			}
+		case "nsid":
+			z.NSID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "NSID")
+				return
+			}
+		case "padding":
+			z.Padding, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Padding")
+				return
+			}
+		case "keepalive-timeout":
+			z.KeepaliveTimeout, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "KeepaliveTimeout")
+				return
+			}
+		case "expire":
+			z.Expire, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Expire")
+				return
+			}
+		case "chain":
+			z.Chain, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Chain")
+				return
+			}
+		case "report-channel":
+			z.ReportChannel, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ReportChannel")
+				return
+			}
+		case "zoneversion":
+			z.ZoneVersion, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ZoneVersion")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DNSExtended) Msgsize() (s int) {
+	s = 1 + 9 + msgp.IntSize + 6 + msgp.IntSize + 8 + msgp.IntSize + 10 + msgp.IntSize + 8 + msgp.ArrayHeaderSize
+	for za0001 := range z.Options {
+		s += 1 + 5 + msgp.IntSize + 5 + msgp.StringPrefixSize + len(z.Options[za0001].Name) + 5 + msgp.StringPrefixSize + len(z.Options[za0001].Data)
+	}
+	s += 14 + msgp.StringPrefixSize + len(z.ClientCookie) + 14 + msgp.StringPrefixSize + len(z.ServerCookie) + 5 + msgp.StringPrefixSize + len(z.NSID) + 8 + msgp.IntSize + 18 + msgp.IntSize + 7 + msgp.IntSize + 6 + msgp.StringPrefixSize + len(z.Chain) + 15 + msgp.StringPrefixSize + len(z.ReportChannel) + 12 + msgp.StringPrefixSize + len(z.ZoneVersion)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DNSFlags) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 7
+	// string "qr"
+	o = append(o, 0x87, 0xa2, 0x71, 0x72)
+	o = msgp.AppendBool(o, z.QR)
+	// string "tc"
+	o = append(o, 0xa2, 0x74, 0x63)
+	o = msgp.AppendBool(o, z.TC)
+	// string "aa"
+	o = append(o, 0xa2, 0x61, 0x61)
+	o = msgp.AppendBool(o, z.AA)
+	// string "ra"
+	o = append(o, 0xa2, 0x72, 0x61)
+	o = msgp.AppendBool(o, z.RA)
+	// string "ad"
+	o = append(o, 0xa2, 0x61, 0x64)
+	o = msgp.AppendBool(o, z.AD)
+	// string "rd"
+	o = append(o, 0xa2, 0x72, 0x64)
+	o = msgp.AppendBool(o, z.RD)
+	// string "cd"
+	o = append(o, 0xa2, 0x63, 0x64)
+	o = msgp.AppendBool(o, z.CD)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNSFlags) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "qr":
+			z.QR, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QR")
+				return
+			}
+		case "tc":
+			z.TC, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TC")
+				return
+			}
+		case "aa":
+			z.AA, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AA")
+				return
+			}
+		case "ra":
+			z.RA, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RA")
+				return
+			}
+		case "ad":
+			z.AD, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AD")
+				return
+			}
+		case "rd":
+			z.RD, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RD")
+				return
+			}
+		case "cd":
+			z.CD, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "CD")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DNSFlags) Msgsize() (s int) {
+	s = 1 + 3 + msgp.BoolSize + 3 + msgp.BoolSize + 3 + msgp.BoolSize + 3 + msgp.BoolSize + 3 + msgp.BoolSize + 3 + msgp.BoolSize + 3 + msgp.BoolSize
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DNSMessage) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// check for omitted fields
+	zb0001Len := uint32(18)
+	var zb0001Mask uint32 /* 18 bits */
+	_ = zb0001Mask
+	if z.PowerDNS == nil {
+		zb0001Len--
+		zb0001Mask |= 0x10
+	}
+	if z.Session == nil {
+		zb0001Len--
+		zb0001Mask |= 0x20
+	}
+	if z.OpenTelemetry == nil {
+		zb0001Len--
+		zb0001Mask |= 0x40
+	}
+	if z.Geo == nil {
+		zb0001Len--
+		zb0001Mask |= 0x80
+	}
+	if z.Suspicious == nil {
+		zb0001Len--
+		zb0001Mask |= 0x100
+	}
+	if z.PublicSuffix == nil {
+		zb0001Len--
+		zb0001Mask |= 0x200
+	}
+	if z.Extracted == nil {
+		zb0001Len--
+		zb0001Mask |= 0x400
+	}
+	if z.Reducer == nil {
+		zb0001Len--
+		zb0001Mask |= 0x800
+	}
+	if z.Correlation == nil {
+		zb0001Len--
+		zb0001Mask |= 0x1000
+	}
+	if z.MachineLearning == nil {
+		zb0001Len--
+		zb0001Mask |= 0x2000
+	}
+	if z.Detection == nil {
+		zb0001Len--
+		zb0001Mask |= 0x4000
+	}
+	if z.Filtering == nil {
+		zb0001Len--
+		zb0001Mask |= 0x8000
+	}
+	if z.ATags == nil {
+		zb0001Len--
+		zb0001Mask |= 0x10000
+	}
+	if z.Rest == nil {
+		zb0001Len--
+		zb0001Mask |= 0x20000
+	}
+	// variable map header, size zb0001Len
+	o = msgp.AppendMapHeader(o, zb0001Len)
+
+	// skip if no fields are to be emitted
+	if zb0001Len != 0 {
+		// string "network"
+		o = append(o, 0xa7, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b)
+		o, err = z.NetworkInfo.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "NetworkInfo")
+			return
+		}
+		// string "dns"
+		o = append(o, 0xa3, 0x64, 0x6e, 0x73)
+		o, err = z.DNS.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "DNS")
+			return
+		}
+		// string "edns"
+		o = append(o, 0xa4, 0x65, 0x64, 0x6e, 0x73)
+		o, err = z.EDNS.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "EDNS")
+			return
+		}
+		// string "dnstap"
+		o = append(o, 0xa6, 0x64, 0x6e, 0x73, 0x74, 0x61, 0x70)
+		o, err = z.DNSTap.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "DNSTap")
+			return
+		}
+		if (zb0001Mask & 0x10) == 0 { // if not omitted
+			// string "powerdns"
+			o = append(o, 0xa8, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x64, 0x6e, 0x73)
+			if z.PowerDNS == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				o, err = z.PowerDNS.MarshalMsg(o)
+				if err != nil {
+					err = msgp.WrapError(err, "PowerDNS")
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x20) == 0 { // if not omitted
+			// string "session"
+			o = append(o, 0xa7, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e)
+			if z.Session == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				o, err = z.Session.MarshalMsg(o)
+				if err != nil {
+					err = msgp.WrapError(err, "Session")
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x40) == 0 { // if not omitted
+			// string "opentelemetry"
+			o = append(o, 0xad, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79)
+			if z.OpenTelemetry == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				// map header, size 1
+				// string "trace-id"
+				o = append(o, 0x81, 0xa8, 0x74, 0x72, 0x61, 0x63, 0x65, 0x2d, 0x69, 0x64)
+				o = msgp.AppendString(o, z.OpenTelemetry.TraceID)
+			}
+		}
+		if (zb0001Mask & 0x80) == 0 { // if not omitted
+			// string "geoip"
+			o = append(o, 0xa5, 0x67, 0x65, 0x6f, 0x69, 0x70)
+			if z.Geo == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				o, err = z.Geo.MarshalMsg(o)
+				if err != nil {
+					err = msgp.WrapError(err, "Geo")
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x100) == 0 { // if not omitted
+			// string "suspicious"
+			o = append(o, 0xaa, 0x73, 0x75, 0x73, 0x70, 0x69, 0x63, 0x69, 0x6f, 0x75, 0x73)
+			if z.Suspicious == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				o, err = z.Suspicious.MarshalMsg(o)
+				if err != nil {
+					err = msgp.WrapError(err, "Suspicious")
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x200) == 0 { // if not omitted
+			// string "publicsuffix"
+			o = append(o, 0xac, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x73, 0x75, 0x66, 0x66, 0x69, 0x78)
+			if z.PublicSuffix == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				// map header, size 3
+				// string "tld"
+				o = append(o, 0x83, 0xa3, 0x74, 0x6c, 0x64)
+				o = msgp.AppendString(o, z.PublicSuffix.QnamePublicSuffix)
+				// string "etld+1"
+				o = append(o, 0xa6, 0x65, 0x74, 0x6c, 0x64, 0x2b, 0x31)
+				o = msgp.AppendString(o, z.PublicSuffix.QnameEffectiveTLDPlusOne)
+				// string "managed-icann"
+				o = append(o, 0xad, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x64, 0x2d, 0x69, 0x63, 0x61, 0x6e, 0x6e)
+				o = msgp.AppendBool(o, z.PublicSuffix.ManagedByICANN)
+			}
+		}
+		if (zb0001Mask & 0x400) == 0 { // if not omitted
+			// string "extracted"
+			o = append(o, 0xa9, 0x65, 0x78, 0x74, 0x72, 0x61, 0x63, 0x74, 0x65, 0x64)
+			if z.Extracted == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				// map header, size 1
+				// string "dns_payload"
+				o = append(o, 0x81, 0xab, 0x64, 0x6e, 0x73, 0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64)
+				o = msgp.AppendBytes(o, z.Extracted.Base64Payload)
+			}
+		}
+		if (zb0001Mask & 0x800) == 0 { // if not omitted
+			// string "reducer"
+			o = append(o, 0xa7, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x72)
+			if z.Reducer == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				// map header, size 2
+				// string "occurrences"
+				o = append(o, 0x82, 0xab, 0x6f, 0x63, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73)
+				o = msgp.AppendInt(o, z.Reducer.Occurrences)
+				// string "cumulative-length"
+				o = append(o, 0xb1, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x76, 0x65, 0x2d, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+				o = msgp.AppendInt(o, z.Reducer.CumulativeLength)
+			}
+		}
+		if (zb0001Mask & 0x1000) == 0 { // if not omitted
+			// string "correlation"
+			o = append(o, 0xab, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+			if z.Correlation == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				o, err = z.Correlation.MarshalMsg(o)
+				if err != nil {
+					err = msgp.WrapError(err, "Correlation")
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x2000) == 0 { // if not omitted
+			// string "ml"
+			o = append(o, 0xa2, 0x6d, 0x6c)
+			if z.MachineLearning == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				o, err = z.MachineLearning.MarshalMsg(o)
+				if err != nil {
+					err = msgp.WrapError(err, "MachineLearning")
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x4000) == 0 { // if not omitted
+			// string "detection"
+			o = append(o, 0xa9, 0x64, 0x65, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e)
+			if z.Detection == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				o, err = z.Detection.MarshalMsg(o)
+				if err != nil {
+					err = msgp.WrapError(err, "Detection")
+					return
+				}
+			}
+		}
+		if (zb0001Mask & 0x8000) == 0 { // if not omitted
+			// string "filtering"
+			o = append(o, 0xa9, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x69, 0x6e, 0x67)
+			if z.Filtering == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				// map header, size 1
+				// string "sample-rate"
+				o = append(o, 0x81, 0xab, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2d, 0x72, 0x61, 0x74, 0x65)
+				o = msgp.AppendInt(o, z.Filtering.SampleRate)
+			}
+		}
+		if (zb0001Mask & 0x10000) == 0 { // if not omitted
+			// string "atags"
+			o = append(o, 0xa5, 0x61, 0x74, 0x61, 0x67, 0x73)
+			if z.ATags == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				// map header, size 1
+				// string "tags"
+				o = append(o, 0x81, 0xa4, 0x74, 0x61, 0x67, 0x73)
+				o = msgp.AppendArrayHeader(o, uint32(len(z.ATags.Tags)))
+				for za0001 := range z.ATags.Tags {
+					o = msgp.AppendString(o, z.ATags.Tags[za0001])
+				}
+			}
+		}
+		if (zb0001Mask & 0x20000) == 0 { // if not omitted
+			// string "rest"
+			o = append(o, 0xa4, 0x72, 0x65, 0x73, 0x74)
+			if z.Rest == nil {
+				o = msgp.AppendNil(o)
+			} else {
+				// map header, size 2
+				// string "failed"
+				o = append(o, 0x82, 0xa6, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64)
+				o = msgp.AppendBool(o, z.Rest.Failed)
+				// string "response"
+				o = append(o, 0xa8, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65)
+				o = msgp.AppendString(o, z.Rest.Response)
+			}
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNSMessage) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "network":
+			bts, err = z.NetworkInfo.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "NetworkInfo")
+				return
+			}
+		case "dns":
+			bts, err = z.DNS.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DNS")
+				return
+			}
+		case "edns":
+			bts, err = z.EDNS.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "EDNS")
+				return
+			}
+		case "dnstap":
+			bts, err = z.DNSTap.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DNSTap")
+				return
+			}
+		case "powerdns":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.PowerDNS = nil
+			} else {
+				if z.PowerDNS == nil {
+					z.PowerDNS = new(CollectorPowerDNS)
+				}
+				bts, err = z.PowerDNS.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "PowerDNS")
+					return
+				}
+			}
+		case "session":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Session = nil
+			} else {
+				if z.Session == nil {
+					z.Session = new(CollectorSession)
+				}
+				bts, err = z.Session.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Session")
+					return
+				}
+			}
+		case "opentelemetry":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.OpenTelemetry = nil
+			} else {
+				if z.OpenTelemetry == nil {
+					z.OpenTelemetry = new(LoggerOpenTelemetry)
+				}
+				var zb0002 uint32
+				zb0002, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "OpenTelemetry")
+					return
+				}
+				for zb0002 > 0 {
+					zb0002--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "OpenTelemetry")
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "trace-id":
+						z.OpenTelemetry.TraceID, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "OpenTelemetry", "TraceID")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "OpenTelemetry")
+							return
+						}
+					}
+				}
+			}
+		case "geoip":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Geo = nil
+			} else {
+				if z.Geo == nil {
+					z.Geo = new(TransformDNSGeo)
+				}
+				bts, err = z.Geo.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Geo")
+					return
+				}
+			}
+		case "suspicious":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Suspicious = nil
+			} else {
+				if z.Suspicious == nil {
+					z.Suspicious = new(TransformSuspicious)
+				}
+				bts, err = z.Suspicious.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Suspicious")
+					return
+				}
+			}
+		case "publicsuffix":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.PublicSuffix = nil
+			} else {
+				if z.PublicSuffix == nil {
+					z.PublicSuffix = new(TransformPublicSuffix)
+				}
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "PublicSuffix")
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "PublicSuffix")
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "tld":
+						z.PublicSuffix.QnamePublicSuffix, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "PublicSuffix", "QnamePublicSuffix")
+							return
+						}
+					case "etld+1":
+						z.PublicSuffix.QnameEffectiveTLDPlusOne, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "PublicSuffix", "QnameEffectiveTLDPlusOne")
+							return
+						}
+					case "managed-icann":
+						z.PublicSuffix.ManagedByICANN, bts, err = msgp.ReadBoolBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "PublicSuffix", "ManagedByICANN")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "PublicSuffix")
+							return
+						}
+					}
+				}
+			}
+		case "extracted":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Extracted = nil
+			} else {
+				if z.Extracted == nil {
+					z.Extracted = new(TransformExtracted)
+				}
+				var zb0004 uint32
+				zb0004, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Extracted")
+					return
+				}
+				for zb0004 > 0 {
+					zb0004--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Extracted")
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "dns_payload":
+						z.Extracted.Base64Payload, bts, err = msgp.ReadBytesBytes(bts, z.Extracted.Base64Payload)
+						if err != nil {
+							err = msgp.WrapError(err, "Extracted", "Base64Payload")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Extracted")
+							return
+						}
+					}
+				}
+			}
+		case "reducer":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Reducer = nil
+			} else {
+				if z.Reducer == nil {
+					z.Reducer = new(TransformReducer)
+				}
+				var zb0005 uint32
+				zb0005, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Reducer")
+					return
+				}
+				for zb0005 > 0 {
+					zb0005--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Reducer")
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "occurrences":
+						z.Reducer.Occurrences, bts, err = msgp.ReadIntBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Reducer", "Occurrences")
+							return
+						}
+					case "cumulative-length":
+						z.Reducer.CumulativeLength, bts, err = msgp.ReadIntBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Reducer", "CumulativeLength")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Reducer")
+							return
+						}
+					}
+				}
+			}
+		case "correlation":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Correlation = nil
+			} else {
+				if z.Correlation == nil {
+					z.Correlation = new(TransformCorrelation)
+				}
+				bts, err = z.Correlation.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Correlation")
+					return
+				}
+			}
+		case "ml":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.MachineLearning = nil
+			} else {
+				if z.MachineLearning == nil {
+					z.MachineLearning = new(TransformML)
+				}
+				bts, err = z.MachineLearning.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "MachineLearning")
+					return
+				}
+			}
+		case "detection":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Detection = nil
+			} else {
+				if z.Detection == nil {
+					z.Detection = new(TransformDetection)
+				}
+				bts, err = z.Detection.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Detection")
+					return
+				}
+			}
+		case "filtering":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Filtering = nil
+			} else {
+				if z.Filtering == nil {
+					z.Filtering = new(TransformFiltering)
+				}
+				var zb0006 uint32
+				zb0006, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Filtering")
+					return
+				}
+				for zb0006 > 0 {
+					zb0006--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Filtering")
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "sample-rate":
+						z.Filtering.SampleRate, bts, err = msgp.ReadIntBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Filtering", "SampleRate")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Filtering")
+							return
+						}
+					}
+				}
+			}
+		case "atags":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.ATags = nil
+			} else {
+				if z.ATags == nil {
+					z.ATags = new(TransformATags)
+				}
+				var zb0007 uint32
+				zb0007, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "ATags")
+					return
+				}
+				for zb0007 > 0 {
+					zb0007--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "ATags")
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "tags":
+						var zb0008 uint32
+						zb0008, bts, err = msgp.ReadArrayHeaderBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "ATags", "Tags")
+							return
+						}
+						if cap(z.ATags.Tags) >= int(zb0008) {
+							z.ATags.Tags = (z.ATags.Tags)[:zb0008]
+						} else {
+							z.ATags.Tags = make([]string, zb0008)
+						}
+						for za0001 := range z.ATags.Tags {
+							z.ATags.Tags[za0001], bts, err = msgp.ReadStringBytes(bts)
+							if err != nil {
+								err = msgp.WrapError(err, "ATags", "Tags", za0001)
+								return
+							}
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "ATags")
+							return
+						}
+					}
+				}
+			}
+		case "rest":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Rest = nil
+			} else {
+				if z.Rest == nil {
+					z.Rest = new(TransformRest)
+				}
+				var zb0009 uint32
+				zb0009, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Rest")
+					return
+				}
+				for zb0009 > 0 {
+					zb0009--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Rest")
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "failed":
+						z.Rest.Failed, bts, err = msgp.ReadBoolBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Rest", "Failed")
+							return
+						}
+					case "response":
+						z.Rest.Response, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Rest", "Response")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Rest")
+							return
+						}
+					}
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DNSMessage) Msgsize() (s int) {
+	s = 3 + 8 + z.NetworkInfo.Msgsize() + 4 + z.DNS.Msgsize() + 5 + z.EDNS.Msgsize() + 7 + z.DNSTap.Msgsize() + 9
+	if z.PowerDNS == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.PowerDNS.Msgsize()
+	}
+	s += 8
+	if z.Session == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Session.Msgsize()
+	}
+	s += 14
+	if z.OpenTelemetry == nil {
+		s += msgp.NilSize
+	} else {
+		s += 1 + 9 + msgp.StringPrefixSize + len(z.OpenTelemetry.TraceID)
+	}
+	s += 6
+	if z.Geo == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Geo.Msgsize()
+	}
+	s += 11
+	if z.Suspicious == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Suspicious.Msgsize()
+	}
+	s += 13
+	if z.PublicSuffix == nil {
+		s += msgp.NilSize
+	} else {
+		s += 1 + 4 + msgp.StringPrefixSize + len(z.PublicSuffix.QnamePublicSuffix) + 7 + msgp.StringPrefixSize + len(z.PublicSuffix.QnameEffectiveTLDPlusOne) + 14 + msgp.BoolSize
+	}
+	s += 10
+	if z.Extracted == nil {
+		s += msgp.NilSize
+	} else {
+		s += 1 + 12 + msgp.BytesPrefixSize + len(z.Extracted.Base64Payload)
+	}
+	s += 8
+	if z.Reducer == nil {
+		s += msgp.NilSize
+	} else {
+		s += 1 + 12 + msgp.IntSize + 18 + msgp.IntSize
+	}
+	s += 12
+	if z.Correlation == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Correlation.Msgsize()
+	}
+	s += 3
+	if z.MachineLearning == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.MachineLearning.Msgsize()
+	}
+	s += 10
+	if z.Detection == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Detection.Msgsize()
+	}
+	s += 10
+	if z.Filtering == nil {
+		s += msgp.NilSize
+	} else {
+		s += 1 + 12 + msgp.IntSize
+	}
+	s += 6
+	if z.ATags == nil {
+		s += msgp.NilSize
+	} else {
+		s += 1 + 5 + msgp.ArrayHeaderSize
+		for za0001 := range z.ATags.Tags {
+			s += msgp.StringPrefixSize + len(z.ATags.Tags[za0001])
+		}
+	}
+	s += 5
+	if z.Rest == nil {
+		s += msgp.NilSize
+	} else {
+		s += 1 + 7 + msgp.BoolSize + 9 + msgp.StringPrefixSize + len(z.Rest.Response)
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DNSNetInfo) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 8
+	// string "family"
+	o = append(o, 0x88, 0xa6, 0x66, 0x61, 0x6d, 0x69, 0x6c, 0x79)
+	o = msgp.AppendString(o, z.Family)
+	// string "protocol"
+	o = append(o, 0xa8, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c)
+	o = msgp.AppendString(o, z.Protocol)
+	// string "query-ip"
+	o = append(o, 0xa8, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2d, 0x69, 0x70)
+	o = msgp.AppendString(o, z.QueryIP)
+	// string "query-port"
+	o = append(o, 0xaa, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2d, 0x70, 0x6f, 0x72, 0x74)
+	o = msgp.AppendString(o, z.QueryPort)
+	// string "response-ip"
+	o = append(o, 0xab, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2d, 0x69, 0x70)
+	o = msgp.AppendString(o, z.ResponseIP)
+	// string "response-port"
+	o = append(o, 0xad, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2d, 0x70, 0x6f, 0x72, 0x74)
+	o = msgp.AppendString(o, z.ResponsePort)
+	// string "ip-defragmented"
+	o = append(o, 0xaf, 0x69, 0x70, 0x2d, 0x64, 0x65, 0x66, 0x72, 0x61, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x65, 0x64)
+	o = msgp.AppendBool(o, z.IPDefragmented)
+	// string "tcp-reassembled"
+	o = append(o, 0xaf, 0x74, 0x63, 0x70, 0x2d, 0x72, 0x65, 0x61, 0x73, 0x73, 0x65, 0x6d, 0x62, 0x6c, 0x65, 0x64)
+	o = msgp.AppendBool(o, z.TCPReassembled)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNSNetInfo) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "family":
+			z.Family, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Family")
+				return
+			}
+		case "protocol":
+			z.Protocol, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Protocol")
+				return
+			}
+		case "query-ip":
+			z.QueryIP, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QueryIP")
+				return
+			}
+		case "query-port":
+			z.QueryPort, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QueryPort")
+				return
+			}
+		case "response-ip":
+			z.ResponseIP, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ResponseIP")
+				return
+			}
+		case "response-port":
+			z.ResponsePort, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ResponsePort")
+				return
+			}
+		case "ip-defragmented":
+			z.IPDefragmented, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "IPDefragmented")
+				return
+			}
+		case "tcp-reassembled":
+			z.TCPReassembled, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TCPReassembled")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DNSNetInfo) Msgsize() (s int) {
+	s = 1 + 7 + msgp.StringPrefixSize + len(z.Family) + 9 + msgp.StringPrefixSize + len(z.Protocol) + 9 + msgp.StringPrefixSize + len(z.QueryIP) + 11 + msgp.StringPrefixSize + len(z.QueryPort) + 12 + msgp.StringPrefixSize + len(z.ResponseIP) + 14 + msgp.StringPrefixSize + len(z.ResponsePort) + 16 + msgp.BoolSize + 16 + msgp.BoolSize
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z DNSOption) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "code"
+	o = append(o, 0x83, 0xa4, 0x63, 0x6f, 0x64, 0x65)
+	o = msgp.AppendInt(o, z.Code)
+	// string "name"
+	o = append(o, 0xa4, 0x6e, 0x61, 0x6d, 0x65)
+	o = msgp.AppendString(o, z.Name)
+	// string "data"
+	o = append(o, 0xa4, 0x64, 0x61, 0x74, 0x61)
+	o = msgp.AppendString(o, z.Data)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNSOption) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "code":
+			z.Code, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Code")
+				return
+			}
+		case "name":
+			z.Name, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Name")
+				return
+			}
+		case "data":
+			z.Data, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Data")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z DNSOption) Msgsize() (s int) {
+	s = 1 + 5 + msgp.IntSize + 5 + msgp.StringPrefixSize + len(z.Name) + 5 + msgp.StringPrefixSize + len(z.Data)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DNSRRs) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "an"
+	o = append(o, 0x83, 0xa2, 0x61, 0x6e)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Answers)))
+	for za0001 := range z.Answers {
+		o, err = z.Answers[za0001].MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Answers", za0001)
+			return
+		}
+	}
+	// string "ns"
+	o = append(o, 0xa2, 0x6e, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Nameservers)))
+	for za0002 := range z.Nameservers {
+		o, err = z.Nameservers[za0002].MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Nameservers", za0002)
+			return
+		}
+	}
+	// string "ar"
+	o = append(o, 0xa2, 0x61, 0x72)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Records)))
+	for za0003 := range z.Records {
+		o, err = z.Records[za0003].MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Records", za0003)
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNSRRs) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "an":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Answers")
+				return
+			}
+			if cap(z.Answers) >= int(zb0002) {
+				z.Answers = (z.Answers)[:zb0002]
+			} else {
+				z.Answers = make([]DNSAnswer, zb0002)
+			}
+			for za0001 := range z.Answers {
+				bts, err = z.Answers[za0001].UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Answers", za0001)
+					return
+				}
+			}
+		case "ns":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Nameservers")
+				return
+			}
+			if cap(z.Nameservers) >= int(zb0003) {
+				z.Nameservers = (z.Nameservers)[:zb0003]
+			} else {
+				z.Nameservers = make([]DNSAnswer, zb0003)
+			}
+			for za0002 := range z.Nameservers {
+				bts, err = z.Nameservers[za0002].UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Nameservers", za0002)
+					return
+				}
+			}
+		case "ar":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Records")
+				return
+			}
+			if cap(z.Records) >= int(zb0004) {
+				z.Records = (z.Records)[:zb0004]
+			} else {
+				z.Records = make([]DNSAnswer, zb0004)
+			}
+			for za0003 := range z.Records {
+				bts, err = z.Records[za0003].UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Records", za0003)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DNSRRs) Msgsize() (s int) {
+	s = 1 + 3 + msgp.ArrayHeaderSize
+	for za0001 := range z.Answers {
+		s += z.Answers[za0001].Msgsize()
+	}
+	s += 3 + msgp.ArrayHeaderSize
+	for za0002 := range z.Nameservers {
+		s += z.Nameservers[za0002].Msgsize()
+	}
+	s += 3 + msgp.ArrayHeaderSize
+	for za0003 := range z.Records {
+		s += z.Records[za0003].Msgsize()
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *DNSTap) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 14
+	// string "operation"
+	o = append(o, 0x8e, 0xa9, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendString(o, z.Operation)
+	// string "identity"
+	o = append(o, 0xa8, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79)
+	o = msgp.AppendString(o, z.Identity)
+	// string "version"
+	o = append(o, 0xa7, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendString(o, z.Version)
+	// string "timestamp-rfc3339ns"
+	o = append(o, 0xb3, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2d, 0x72, 0x66, 0x63, 0x33, 0x33, 0x33, 0x39, 0x6e, 0x73)
+	o = msgp.AppendString(o, z.TimestampRFC3339)
+	// string "latency"
+	o = append(o, 0xa7, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79)
+	o = msgp.AppendFloat64(o, z.Latency)
+	// string "extra"
+	o = append(o, 0xa5, 0x65, 0x78, 0x74, 0x72, 0x61)
+	o = msgp.AppendString(o, z.Extra)
+	// string "policy-rule"
+	o = append(o, 0xab, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x72, 0x75, 0x6c, 0x65)
+	o = msgp.AppendString(o, z.PolicyRule)
+	// string "policy-type"
+	o = append(o, 0xab, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x74, 0x79, 0x70, 0x65)
+	o = msgp.AppendString(o, z.PolicyType)
+	// string "policy-match"
+	o = append(o, 0xac, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x6d, 0x61, 0x74, 0x63, 0x68)
+	o = msgp.AppendString(o, z.PolicyMatch)
+	// string "policy-action"
+	o = append(o, 0xad, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e)
+	o = msgp.AppendString(o, z.PolicyAction)
+	// string "policy-value"
+	o = append(o, 0xac, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2d, 0x76, 0x61, 0x6c, 0x75, 0x65)
+	o = msgp.AppendString(o, z.PolicyValue)
+	// string "peer-name"
+	o = append(o, 0xa9, 0x70, 0x65, 0x65, 0x72, 0x2d, 0x6e, 0x61, 0x6d, 0x65)
+	o = msgp.AppendString(o, z.PeerName)
+	// string "query-zone"
+	o = append(o, 0xaa, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2d, 0x7a, 0x6f, 0x6e, 0x65)
+	o = msgp.AppendString(o, z.QueryZone)
+	// string "http-protocol"
+	o = append(o, 0xad, 0x68, 0x74, 0x74, 0x70, 0x2d, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c)
+	o = msgp.AppendString(o, z.HttpProtocol)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *DNSTap) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "operation":
+			z.Operation, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Operation")
+				return
+			}
+		case "identity":
+			z.Identity, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Identity")
+				return
+			}
+		case "version":
+			z.Version, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Version")
+				return
+			}
+		case "timestamp-rfc3339ns":
+			z.TimestampRFC3339, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TimestampRFC3339")
+				return
+			}
+		case "latency":
+			z.Latency, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Latency")
+				return
+			}
+		case "extra":
+			z.Extra, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Extra")
+				return
+			}
+		case "policy-rule":
+			z.PolicyRule, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "PolicyRule")
+				return
+			}
+		case "policy-type":
+			z.PolicyType, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "PolicyType")
+				return
+			}
+		case "policy-match":
+			z.PolicyMatch, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "PolicyMatch")
+				return
+			}
+		case "policy-action":
+			z.PolicyAction, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "PolicyAction")
+				return
+			}
+		case "policy-value":
+			z.PolicyValue, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "PolicyValue")
+				return
+			}
+		case "peer-name":
+			z.PeerName, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "PeerName")
+				return
+			}
+		case "query-zone":
+			z.QueryZone, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QueryZone")
+				return
+			}
+		case "http-protocol":
+			z.HttpProtocol, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "HttpProtocol")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *DNSTap) Msgsize() (s int) {
+	s = 1 + 10 + msgp.StringPrefixSize + len(z.Operation) + 9 + msgp.StringPrefixSize + len(z.Identity) + 8 + msgp.StringPrefixSize + len(z.Version) + 20 + msgp.StringPrefixSize + len(z.TimestampRFC3339) + 8 + msgp.Float64Size + 6 + msgp.StringPrefixSize + len(z.Extra) + 12 + msgp.StringPrefixSize + len(z.PolicyRule) + 12 + msgp.StringPrefixSize + len(z.PolicyType) + 13 + msgp.StringPrefixSize + len(z.PolicyMatch) + 14 + msgp.StringPrefixSize + len(z.PolicyAction) + 13 + msgp.StringPrefixSize + len(z.PolicyValue) + 10 + msgp.StringPrefixSize + len(z.PeerName) + 11 + msgp.StringPrefixSize + len(z.QueryZone) + 14 + msgp.StringPrefixSize + len(z.HttpProtocol)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z LoggerOpenTelemetry) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "trace-id"
+	o = append(o, 0x81, 0xa8, 0x74, 0x72, 0x61, 0x63, 0x65, 0x2d, 0x69, 0x64)
+	o = msgp.AppendString(o, z.TraceID)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *LoggerOpenTelemetry) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "trace-id":
+			z.TraceID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TraceID")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z LoggerOpenTelemetry) Msgsize() (s int) {
+	s = 1 + 9 + msgp.StringPrefixSize + len(z.TraceID)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *TransformATags) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "tags"
+	o = append(o, 0x81, 0xa4, 0x74, 0x61, 0x67, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Tags)))
+	for za0001 := range z.Tags {
+		o = msgp.AppendString(o, z.Tags[za0001])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformATags) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "tags":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Tags")
+				return
+			}
+			if cap(z.Tags) >= int(zb0002) {
+				z.Tags = (z.Tags)[:zb0002]
+			} else {
+				z.Tags = make([]string, zb0002)
+			}
+			for za0001 := range z.Tags {
+				z.Tags[za0001], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Tags", za0001)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *TransformATags) Msgsize() (s int) {
+	s = 1 + 5 + msgp.ArrayHeaderSize
+	for za0001 := range z.Tags {
+		s += msgp.StringPrefixSize + len(z.Tags[za0001])
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *TransformCorrelation) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 6
+	// string "status"
+	o = append(o, 0x86, 0xa6, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73)
+	o = msgp.AppendString(o, z.Status)
+	// string "query-timestamp-rfc3339ns"
+	o = append(o, 0xb9, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2d, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2d, 0x72, 0x66, 0x63, 0x33, 0x33, 0x33, 0x39, 0x6e, 0x73)
+	o = msgp.AppendString(o, z.QueryTimestamp)
+	// string "response-timestamp-rfc3339ns"
+	o = append(o, 0xbc, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2d, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2d, 0x72, 0x66, 0x63, 0x33, 0x33, 0x33, 0x39, 0x6e, 0x73)
+	o = msgp.AppendString(o, z.ResponseTimestamp)
+	// string "latency"
+	o = append(o, 0xa7, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79)
+	o = msgp.AppendFloat64(o, z.Latency)
+	// string "query-length"
+	o = append(o, 0xac, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2d, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	o = msgp.AppendInt(o, z.QueryLength)
+	// string "response-length"
+	o = append(o, 0xaf, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2d, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	o = msgp.AppendInt(o, z.ResponseLength)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformCorrelation) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "status":
+			z.Status, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Status")
+				return
+			}
+		case "query-timestamp-rfc3339ns":
+			z.QueryTimestamp, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QueryTimestamp")
+				return
+			}
+		case "response-timestamp-rfc3339ns":
+			z.ResponseTimestamp, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ResponseTimestamp")
+				return
+			}
+		case "latency":
+			z.Latency, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Latency")
+				return
+			}
+		case "query-length":
+			z.QueryLength, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QueryLength")
+				return
+			}
+		case "response-length":
+			z.ResponseLength, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ResponseLength")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *TransformCorrelation) Msgsize() (s int) {
+	s = 1 + 7 + msgp.StringPrefixSize + len(z.Status) + 26 + msgp.StringPrefixSize + len(z.QueryTimestamp) + 29 + msgp.StringPrefixSize + len(z.ResponseTimestamp) + 8 + msgp.Float64Size + 13 + msgp.IntSize + 16 + msgp.IntSize
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *TransformDNSGeo) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// check for omitted fields
+	zb0001Len := uint32(6)
+	var zb0001Mask uint8 /* 6 bits */
+	_ = zb0001Mask
+	if z.Answers == nil {
+		zb0001Len--
+		zb0001Mask |= 0x20
+	}
+	// variable map header, size zb0001Len
+	o = append(o, 0x80|uint8(zb0001Len))
+
+	// skip if no fields are to be emitted
+	if zb0001Len != 0 {
+		// string "city"
+		o = append(o, 0xa4, 0x63, 0x69, 0x74, 0x79)
+		o = msgp.AppendString(o, z.City)
+		// string "continent"
+		o = append(o, 0xa9, 0x63, 0x6f, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x6e, 0x74)
+		o = msgp.AppendString(o, z.Continent)
+		// string "country-isocode"
+		o = append(o, 0xaf, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x2d, 0x69, 0x73, 0x6f, 0x63, 0x6f, 0x64, 0x65)
+		o = msgp.AppendString(o, z.CountryIsoCode)
+		// string "as-number"
+		o = append(o, 0xa9, 0x61, 0x73, 0x2d, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72)
+		o = msgp.AppendString(o, z.AutonomousSystemNumber)
+		// string "as-owner"
+		o = append(o, 0xa8, 0x61, 0x73, 0x2d, 0x6f, 0x77, 0x6e, 0x65, 0x72)
+		o = msgp.AppendString(o, z.AutonomousSystemOrg)
+		if (zb0001Mask & 0x20) == 0 { // if not omitted
+			// string "answers"
+			o = append(o, 0xa7, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x73)
+			o = msgp.AppendArrayHeader(o, uint32(len(z.Answers)))
+			for za0001 := range z.Answers {
+				o, err = z.Answers[za0001].MarshalMsg(o)
+				if err != nil {
+					err = msgp.WrapError(err, "Answers", za0001)
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformDNSGeo) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "city":
+			z.City, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "City")
+				return
+			}
+		case "continent":
+			z.Continent, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Continent")
+				return
+			}
+		case "country-isocode":
+			z.CountryIsoCode, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "CountryIsoCode")
+				return
+			}
+		case "as-number":
+			z.AutonomousSystemNumber, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AutonomousSystemNumber")
+				return
+			}
+		case "as-owner":
+			z.AutonomousSystemOrg, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AutonomousSystemOrg")
+				return
+			}
+		case "answers":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Answers")
+				return
+			}
+			if cap(z.Answers) >= int(zb0002) {
+				z.Answers = (z.Answers)[:zb0002]
+			} else {
+				z.Answers = make([]TransformDNSGeoAnswer, zb0002)
+			}
+			for za0001 := range z.Answers {
+				bts, err = z.Answers[za0001].UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Answers", za0001)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *TransformDNSGeo) Msgsize() (s int) {
+	s = 1 + 5 + msgp.StringPrefixSize + len(z.City) + 10 + msgp.StringPrefixSize + len(z.Continent) + 16 + msgp.StringPrefixSize + len(z.CountryIsoCode) + 10 + msgp.StringPrefixSize + len(z.AutonomousSystemNumber) + 9 + msgp.StringPrefixSize + len(z.AutonomousSystemOrg) + 8 + msgp.ArrayHeaderSize
+	for za0001 := range z.Answers {
+		s += z.Answers[za0001].Msgsize()
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *TransformDNSGeoAnswer) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 6
+	// string "ip"
+	o = append(o, 0x86, 0xa2, 0x69, 0x70)
+	o = msgp.AppendString(o, z.IP)
+	// string "city"
+	o = append(o, 0xa4, 0x63, 0x69, 0x74, 0x79)
+	o = msgp.AppendString(o, z.City)
+	// string "continent"
+	o = append(o, 0xa9, 0x63, 0x6f, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x6e, 0x74)
+	o = msgp.AppendString(o, z.Continent)
+	// string "country-isocode"
+	o = append(o, 0xaf, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x2d, 0x69, 0x73, 0x6f, 0x63, 0x6f, 0x64, 0x65)
+	o = msgp.AppendString(o, z.CountryIsoCode)
+	// string "as-number"
+	o = append(o, 0xa9, 0x61, 0x73, 0x2d, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72)
+	o = msgp.AppendString(o, z.AutonomousSystemNumber)
+	// string "as-owner"
+	o = append(o, 0xa8, 0x61, 0x73, 0x2d, 0x6f, 0x77, 0x6e, 0x65, 0x72)
+	o = msgp.AppendString(o, z.AutonomousSystemOrg)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformDNSGeoAnswer) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "ip":
+			z.IP, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "IP")
+				return
+			}
+		case "city":
+			z.City, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "City")
+				return
+			}
+		case "continent":
+			z.Continent, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Continent")
+				return
+			}
+		case "country-isocode":
+			z.CountryIsoCode, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "CountryIsoCode")
+				return
+			}
+		case "as-number":
+			z.AutonomousSystemNumber, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AutonomousSystemNumber")
+				return
+			}
+		case "as-owner":
+			z.AutonomousSystemOrg, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "AutonomousSystemOrg")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *TransformDNSGeoAnswer) Msgsize() (s int) {
+	s = 1 + 3 + msgp.StringPrefixSize + len(z.IP) + 5 + msgp.StringPrefixSize + len(z.City) + 10 + msgp.StringPrefixSize + len(z.Continent) + 16 + msgp.StringPrefixSize + len(z.CountryIsoCode) + 10 + msgp.StringPrefixSize + len(z.AutonomousSystemNumber) + 9 + msgp.StringPrefixSize + len(z.AutonomousSystemOrg)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *TransformDetection) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "score"
+	o = append(o, 0x84, 0xa5, 0x73, 0x63, 0x6f, 0x72, 0x65)
+	o = msgp.AppendFloat64(o, z.Score)
+	// string "tunneling-score"
+	o = append(o, 0xaf, 0x74, 0x75, 0x6e, 0x6e, 0x65, 0x6c, 0x69, 0x6e, 0x67, 0x2d, 0x73, 0x63, 0x6f, 0x72, 0x65)
+	o = msgp.AppendFloat64(o, z.TunnelingScore)
+	// string "dga-score"
+	o = append(o, 0xa9, 0x64, 0x67, 0x61, 0x2d, 0x73, 0x63, 0x6f, 0x72, 0x65)
+	o = msgp.AppendFloat64(o, z.DGAScore)
+	// string "reasons"
+	o = append(o, 0xa7, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Reasons)))
+	for za0001 := range z.Reasons {
+		o = msgp.AppendString(o, z.Reasons[za0001])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformDetection) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "score":
+			z.Score, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Score")
+				return
+			}
+		case "tunneling-score":
+			z.TunnelingScore, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TunnelingScore")
+				return
+			}
+		case "dga-score":
+			z.DGAScore, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "DGAScore")
+				return
+			}
+		case "reasons":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Reasons")
+				return
+			}
+			if cap(z.Reasons) >= int(zb0002) {
+				z.Reasons = (z.Reasons)[:zb0002]
+			} else {
+				z.Reasons = make([]string, zb0002)
+			}
+			for za0001 := range z.Reasons {
+				z.Reasons[za0001], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Reasons", za0001)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *TransformDetection) Msgsize() (s int) {
+	s = 1 + 6 + msgp.Float64Size + 16 + msgp.Float64Size + 10 + msgp.Float64Size + 8 + msgp.ArrayHeaderSize
+	for za0001 := range z.Reasons {
+		s += msgp.StringPrefixSize + len(z.Reasons[za0001])
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *TransformExtracted) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "dns_payload"
+	o = append(o, 0x81, 0xab, 0x64, 0x6e, 0x73, 0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64)
+	o = msgp.AppendBytes(o, z.Base64Payload)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformExtracted) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dns_payload":
+			z.Base64Payload, bts, err = msgp.ReadBytesBytes(bts, z.Base64Payload)
+			if err != nil {
+				err = msgp.WrapError(err, "Base64Payload")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *TransformExtracted) Msgsize() (s int) {
+	s = 1 + 12 + msgp.BytesPrefixSize + len(z.Base64Payload)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z TransformFiltering) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "sample-rate"
+	o = append(o, 0x81, 0xab, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2d, 0x72, 0x61, 0x74, 0x65)
+	o = msgp.AppendInt(o, z.SampleRate)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformFiltering) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "sample-rate":
+			z.SampleRate, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SampleRate")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z TransformFiltering) Msgsize() (s int) {
+	s = 1 + 12 + msgp.IntSize
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *TransformML) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 19
+	// string "entropy"
+	o = append(o, 0xde, 0x0, 0x13, 0xa7, 0x65, 0x6e, 0x74, 0x72, 0x6f, 0x70, 0x79)
+	o = msgp.AppendFloat64(o, z.Entropy)
+	// string "length"
+	o = append(o, 0xa6, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	o = msgp.AppendInt(o, z.Length)
+	// string "labels"
+	o = append(o, 0xa6, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73)
+	o = msgp.AppendInt(o, z.Labels)
+	// string "digits"
+	o = append(o, 0xa6, 0x64, 0x69, 0x67, 0x69, 0x74, 0x73)
+	o = msgp.AppendInt(o, z.Digits)
+	// string "lowers"
+	o = append(o, 0xa6, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x73)
+	o = msgp.AppendInt(o, z.Lowers)
+	// string "uppers"
+	o = append(o, 0xa6, 0x75, 0x70, 0x70, 0x65, 0x72, 0x73)
+	o = msgp.AppendInt(o, z.Uppers)
+	// string "specials"
+	o = append(o, 0xa8, 0x73, 0x70, 0x65, 0x63, 0x69, 0x61, 0x6c, 0x73)
+	o = msgp.AppendInt(o, z.Specials)
+	// string "others"
+	o = append(o, 0xa6, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x73)
+	o = msgp.AppendInt(o, z.Others)
+	// string "ratio-digits"
+	o = append(o, 0xac, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x2d, 0x64, 0x69, 0x67, 0x69, 0x74, 0x73)
+	o = msgp.AppendFloat64(o, z.RatioDigits)
+	// string "ratio-letters"
+	o = append(o, 0xad, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x2d, 0x6c, 0x65, 0x74, 0x74, 0x65, 0x72, 0x73)
+	o = msgp.AppendFloat64(o, z.RatioLetters)
+	// string "ratio-specials"
+	o = append(o, 0xae, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x2d, 0x73, 0x70, 0x65, 0x63, 0x69, 0x61, 0x6c, 0x73)
+	o = msgp.AppendFloat64(o, z.RatioSpecials)
+	// string "ratio-others"
+	o = append(o, 0xac, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x2d, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x73)
+	o = msgp.AppendFloat64(o, z.RatioOthers)
+	// string "consecutive-chars"
+	o = append(o, 0xb1, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x2d, 0x63, 0x68, 0x61, 0x72, 0x73)
+	o = msgp.AppendInt(o, z.ConsecutiveChars)
+	// string "consecutive-vowels"
+	o = append(o, 0xb2, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x2d, 0x76, 0x6f, 0x77, 0x65, 0x6c, 0x73)
+	o = msgp.AppendInt(o, z.ConsecutiveVowels)
+	// string "consecutive-digits"
+	o = append(o, 0xb2, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x2d, 0x64, 0x69, 0x67, 0x69, 0x74, 0x73)
+	o = msgp.AppendInt(o, z.ConsecutiveDigits)
+	// string "consecutive-consonants"
+	o = append(o, 0xb6, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x2d, 0x63, 0x6f, 0x6e, 0x73, 0x6f, 0x6e, 0x61, 0x6e, 0x74, 0x73)
+	o = msgp.AppendInt(o, z.ConsecutiveConsonants)
+	// string "size"
+	o = append(o, 0xa4, 0x73, 0x69, 0x7a, 0x65)
+	o = msgp.AppendInt(o, z.Size)
+	// string "occurrences"
+	o = append(o, 0xab, 0x6f, 0x63, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73)
+	o = msgp.AppendInt(o, z.Occurrences)
+	// string "uncommon-qtypes"
+	o = append(o, 0xaf, 0x75, 0x6e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2d, 0x71, 0x74, 0x79, 0x70, 0x65, 0x73)
+	o = msgp.AppendInt(o, z.UncommonQtypes)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformML) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "entropy":
+			z.Entropy, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Entropy")
+				return
+			}
+		case "length":
+			z.Length, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Length")
+				return
+			}
+		case "labels":
+			z.Labels, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Labels")
+				return
+			}
+		case "digits":
+			z.Digits, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Digits")
+				return
+			}
+		case "lowers":
+			z.Lowers, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Lowers")
+				return
+			}
+		case "uppers":
+			z.Uppers, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Uppers")
+				return
+			}
+		case "specials":
+			z.Specials, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Specials")
+				return
+			}
+		case "others":
+			z.Others, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Others")
+				return
+			}
+		case "ratio-digits":
+			z.RatioDigits, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RatioDigits")
+				return
+			}
+		case "ratio-letters":
+			z.RatioLetters, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RatioLetters")
+				return
+			}
+		case "ratio-specials":
+			z.RatioSpecials, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RatioSpecials")
+				return
+			}
+		case "ratio-others":
+			z.RatioOthers, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RatioOthers")
+				return
+			}
+		case "consecutive-chars":
+			z.ConsecutiveChars, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ConsecutiveChars")
+				return
+			}
+		case "consecutive-vowels":
+			z.ConsecutiveVowels, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ConsecutiveVowels")
+				return
+			}
+		case "consecutive-digits":
+			z.ConsecutiveDigits, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ConsecutiveDigits")
+				return
+			}
+		case "consecutive-consonants":
+			z.ConsecutiveConsonants, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ConsecutiveConsonants")
+				return
+			}
+		case "size":
+			z.Size, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+		case "occurrences":
+			z.Occurrences, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Occurrences")
+				return
+			}
+		case "uncommon-qtypes":
+			z.UncommonQtypes, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UncommonQtypes")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *TransformML) Msgsize() (s int) {
+	s = 3 + 8 + msgp.Float64Size + 7 + msgp.IntSize + 7 + msgp.IntSize + 7 + msgp.IntSize + 7 + msgp.IntSize + 7 + msgp.IntSize + 9 + msgp.IntSize + 7 + msgp.IntSize + 13 + msgp.Float64Size + 14 + msgp.Float64Size + 15 + msgp.Float64Size + 13 + msgp.Float64Size + 18 + msgp.IntSize + 19 + msgp.IntSize + 19 + msgp.IntSize + 23 + msgp.IntSize + 5 + msgp.IntSize + 12 + msgp.IntSize + 16 + msgp.IntSize
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z TransformPublicSuffix) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "tld"
+	o = append(o, 0x83, 0xa3, 0x74, 0x6c, 0x64)
+	o = msgp.AppendString(o, z.QnamePublicSuffix)
+	// string "etld+1"
+	o = append(o, 0xa6, 0x65, 0x74, 0x6c, 0x64, 0x2b, 0x31)
+	o = msgp.AppendString(o, z.QnameEffectiveTLDPlusOne)
+	// string "managed-icann"
+	o = append(o, 0xad, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x64, 0x2d, 0x69, 0x63, 0x61, 0x6e, 0x6e)
+	o = msgp.AppendBool(o, z.ManagedByICANN)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformPublicSuffix) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "tld":
+			z.QnamePublicSuffix, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QnamePublicSuffix")
+				return
+			}
+		case "etld+1":
+			z.QnameEffectiveTLDPlusOne, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "QnameEffectiveTLDPlusOne")
+				return
+			}
+		case "managed-icann":
+			z.ManagedByICANN, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ManagedByICANN")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z TransformPublicSuffix) Msgsize() (s int) {
+	s = 1 + 4 + msgp.StringPrefixSize + len(z.QnamePublicSuffix) + 7 + msgp.StringPrefixSize + len(z.QnameEffectiveTLDPlusOne) + 14 + msgp.BoolSize
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z TransformReducer) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "occurrences"
+	o = append(o, 0x82, 0xab, 0x6f, 0x63, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73)
+	o = msgp.AppendInt(o, z.Occurrences)
+	// string "cumulative-length"
+	o = append(o, 0xb1, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x76, 0x65, 0x2d, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	o = msgp.AppendInt(o, z.CumulativeLength)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformReducer) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "occurrences":
+			z.Occurrences, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Occurrences")
+				return
+			}
+		case "cumulative-length":
+			z.CumulativeLength, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "CumulativeLength")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z TransformReducer) Msgsize() (s int) {
+	s = 1 + 12 + msgp.IntSize + 18 + msgp.IntSize
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z TransformRest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "failed"
+	o = append(o, 0x82, 0xa6, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64)
+	o = msgp.AppendBool(o, z.Failed)
+	// string "response"
+	o = append(o, 0xa8, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65)
+	o = msgp.AppendString(o, z.Response)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformRest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "failed":
+			z.Failed, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Failed")
+				return
+			}
+		case "response":
+			z.Response, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Response")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z TransformRest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.BoolSize + 9 + msgp.StringPrefixSize + len(z.Response)
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *TransformSuspicious) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// check for omitted fields
+	zb0001Len := uint32(11)
+	var zb0001Mask uint16 /* 11 bits */
+	_ = zb0001Mask
+	if z.PrivateAnswer == false {
+		zb0001Len--
+		zb0001Mask |= 0x100
+	}
+	if z.LargeRdata == false {
+		zb0001Len--
+		zb0001Mask |= 0x200
+	}
+	if z.Domain == "" {
+		zb0001Len--
+		zb0001Mask |= 0x400
+	}
+	// variable map header, size zb0001Len
+	o = append(o, 0x80|uint8(zb0001Len))
+
+	// skip if no fields are to be emitted
+	if zb0001Len != 0 {
+		// string "score"
+		o = append(o, 0xa5, 0x73, 0x63, 0x6f, 0x72, 0x65)
+		o = msgp.AppendFloat64(o, z.Score)
+		// string "malformed-pkt"
+		o = append(o, 0xad, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x2d, 0x70, 0x6b, 0x74)
+		o = msgp.AppendBool(o, z.MalformedPacket)
+		// string "large-pkt"
+		o = append(o, 0xa9, 0x6c, 0x61, 0x72, 0x67, 0x65, 0x2d, 0x70, 0x6b, 0x74)
+		o = msgp.AppendBool(o, z.LargePacket)
+		// string "long-domain"
+		o = append(o, 0xab, 0x6c, 0x6f, 0x6e, 0x67, 0x2d, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e)
+		o = msgp.AppendBool(o, z.LongDomain)
+		// string "slow-domain"
+		o = append(o, 0xab, 0x73, 0x6c, 0x6f, 0x77, 0x2d, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e)
+		o = msgp.AppendBool(o, z.SlowDomain)
+		// string "unallowed-chars"
+		o = append(o, 0xaf, 0x75, 0x6e, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x2d, 0x63, 0x68, 0x61, 0x72, 0x73)
+		o = msgp.AppendBool(o, z.UnallowedChars)
+		// string "uncommon-qtypes"
+		o = append(o, 0xaf, 0x75, 0x6e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2d, 0x71, 0x74, 0x79, 0x70, 0x65, 0x73)
+		o = msgp.AppendBool(o, z.UncommonQtypes)
+		// string "excessive-number-labels"
+		o = append(o, 0xb7, 0x65, 0x78, 0x63, 0x65, 0x73, 0x73, 0x69, 0x76, 0x65, 0x2d, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x2d, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73)
+		o = msgp.AppendBool(o, z.ExcessiveNumberLabels)
+		if (zb0001Mask & 0x100) == 0 { // if not omitted
+			// string "private-answer"
+			o = append(o, 0xae, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x2d, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72)
+			o = msgp.AppendBool(o, z.PrivateAnswer)
+		}
+		if (zb0001Mask & 0x200) == 0 { // if not omitted
+			// string "large-rdata"
+			o = append(o, 0xab, 0x6c, 0x61, 0x72, 0x67, 0x65, 0x2d, 0x72, 0x64, 0x61, 0x74, 0x61)
+			o = msgp.AppendBool(o, z.LargeRdata)
+		}
+		if (zb0001Mask & 0x400) == 0 { // if not omitted
+			// string "domain"
+			o = append(o, 0xa6, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e)
+			o = msgp.AppendString(o, z.Domain)
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TransformSuspicious) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "score":
+			z.Score, bts, err = msgp.ReadFloat64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Score")
+				return
+			}
+		case "malformed-pkt":
+			z.MalformedPacket, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MalformedPacket")
+				return
+			}
+		case "large-pkt":
+			z.LargePacket, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "LargePacket")
+				return
+			}
+		case "long-domain":
+			z.LongDomain, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "LongDomain")
+				return
+			}
+		case "slow-domain":
+			z.SlowDomain, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SlowDomain")
+				return
+			}
+		case "unallowed-chars":
+			z.UnallowedChars, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UnallowedChars")
+				return
+			}
+		case "uncommon-qtypes":
+			z.UncommonQtypes, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UncommonQtypes")
+				return
+			}
+		case "excessive-number-labels":
+			z.ExcessiveNumberLabels, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ExcessiveNumberLabels")
+				return
+			}
+		case "private-answer":
+			z.PrivateAnswer, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "PrivateAnswer")
+				return
+			}
+		case "large-rdata":
+			z.LargeRdata, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "LargeRdata")
+				return
+			}
+		case "domain":
+			z.Domain, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Domain")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *TransformSuspicious) Msgsize() (s int) {
+	s = 1 + 6 + msgp.Float64Size + 14 + msgp.BoolSize + 10 + msgp.BoolSize + 12 + msgp.BoolSize + 12 + msgp.BoolSize + 16 + msgp.BoolSize + 16 + msgp.BoolSize + 24 + msgp.BoolSize + 15 + msgp.BoolSize + 12 + msgp.BoolSize + 7 + msgp.StringPrefixSize + len(z.Domain)
+	return
+}
@@ -2,9 +2,12 @@ package dnsutils
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func (dm *DNSMessage) ToJSON() string {
@@ -132,6 +135,29 @@ func (dm *DNSMessage) Flatten() (map[string]interface{}, error) {
 	dnsFields["edns.options.datas"] = joinOrDash(optDatas)
 	dnsFields["edns.options.names"] = joinOrDash(optNames)
 
+	// Add the typed EDNS options, only when present
+	for key, value := range map[string]string{
+		"edns.client-cookie":  dm.EDNS.ClientCookie,
+		"edns.server-cookie":  dm.EDNS.ServerCookie,
+		"edns.nsid":           dm.EDNS.NSID,
+		"edns.chain":          dm.EDNS.Chain,
+		"edns.report-channel": dm.EDNS.ReportChannel,
+		"edns.zoneversion":    dm.EDNS.ZoneVersion,
+	} {
+		if len(value) > 0 {
+			dnsFields[key] = value
+		}
+	}
+	for key, value := range map[string]int{
+		"edns.padding":           dm.EDNS.Padding,
+		"edns.keepalive-timeout": dm.EDNS.KeepaliveTimeout,
+		"edns.expire":            dm.EDNS.Expire,
+	} {
+		if value > 0 {
+			dnsFields[key] = value
+		}
+	}
+
 	// Add TransformDNSGeo fields
 	if dm.Geo != nil {
 		dnsFields["geoip.city"] = dm.Geo.City
@@ -139,6 +165,15 @@ func (dm *DNSMessage) Flatten() (map[string]interface{}, error) {
 		dnsFields["geoip.country-isocode"] = dm.Geo.CountryIsoCode
 		dnsFields["geoip.as-number"] = dm.Geo.AutonomousSystemNumber
 		dnsFields["geoip.as-owner"] = dm.Geo.AutonomousSystemOrg
+		for i, answer := range dm.Geo.Answers {
+			prefix := "geoip.answers." + strconv.Itoa(i)
+			dnsFields[prefix+".ip"] = answer.IP
+			dnsFields[prefix+".city"] = answer.City
+			dnsFields[prefix+".continent"] = answer.Continent
+			dnsFields[prefix+".country-isocode"] = answer.CountryIsoCode
+			dnsFields[prefix+".as-number"] = answer.AutonomousSystemNumber
+			dnsFields[prefix+".as-owner"] = answer.AutonomousSystemOrg
+		}
 	}
 
 	// Add TransformSuspicious fields
@@ -151,6 +186,8 @@ func (dm *DNSMessage) Flatten() (map[string]interface{}, error) {
 		dnsFields["suspicious.unallowed-chars"] = dm.Suspicious.UnallowedChars
 		dnsFields["suspicious.uncommon-qtypes"] = dm.Suspicious.UncommonQtypes
 		dnsFields["suspicious.excessive-number-labels"] = dm.Suspicious.ExcessiveNumberLabels
+		dnsFields["suspicious.private-answer"] = dm.Suspicious.PrivateAnswer
+		dnsFields["suspicious.large-rdata"] = dm.Suspicious.LargeRdata
 		dnsFields["suspicious.domain"] = dm.Suspicious.Domain
 	}
 
@@ -172,6 +209,16 @@ func (dm *DNSMessage) Flatten() (map[string]interface{}, error) {
 		dnsFields["reducer.cumulative-length"] = dm.Reducer.CumulativeLength
 	}
 
+	// Add TransformCorrelation fields
+	if dm.Correlation != nil {
+		dnsFields["correlation.status"] = dm.Correlation.Status
+		dnsFields["correlation.query-timestamp-rfc3339ns"] = dm.Correlation.QueryTimestamp
+		dnsFields["correlation.response-timestamp-rfc3339ns"] = dm.Correlation.ResponseTimestamp
+		dnsFields["correlation.latency"] = dm.Correlation.Latency
+		dnsFields["correlation.query-length"] = dm.Correlation.QueryLength
+		dnsFields["correlation.response-length"] = dm.Correlation.ResponseLength
+	}
+
 	// Add TransformFiltering fields
 	if dm.Filtering != nil {
 		dnsFields["filtering.sample-rate"] = dm.Filtering.SampleRate
@@ -200,6 +247,19 @@ func (dm *DNSMessage) Flatten() (map[string]interface{}, error) {
 		dnsFields["ml.uncommon-qtypes"] = dm.MachineLearning.UncommonQtypes
 	}
 
+	// Add TransformDetection fields
+	if dm.Detection != nil {
+		dnsFields["detection.score"] = dm.Detection.Score
+		dnsFields["detection.tunneling-score"] = dm.Detection.TunnelingScore
+		dnsFields["detection.dga-score"] = dm.Detection.DGAScore
+		if len(dm.Detection.Reasons) == 0 {
+			dnsFields["detection.reasons"] = "-"
+		}
+		for i, reason := range dm.Detection.Reasons {
+			dnsFields["detection.reasons."+strconv.Itoa(i)] = reason
+		}
+	}
+
 	// Add TransformATags fields
 	if dm.ATags != nil {
 		if len(dm.ATags.Tags) == 0 {
@@ -210,6 +270,21 @@ func (dm *DNSMessage) Flatten() (map[string]interface{}, error) {
 		}
 	}
 
+	// Add encrypted session fields
+	if dm.Session != nil {
+		dnsFields["session.sni"] = dm.Session.SNI
+		if len(dm.Session.ALPN) == 0 {
+			dnsFields["session.alpn"] = "-"
+		}
+		for i, proto := range dm.Session.ALPN {
+			dnsFields["session.alpn."+strconv.Itoa(i)] = proto
+		}
+		dnsFields["session.bytes-sent"] = dm.Session.BytesSent
+		dnsFields["session.bytes-received"] = dm.Session.BytesReceived
+		dnsFields["session.duration"] = dm.Session.Duration
+		dnsFields["session.closing"] = dm.Session.Closing
+	}
+
 	// Add PowerDNS collectors fields
 	if dm.PowerDNS != nil {
 		if len(dm.PowerDNS.Tags) == 0 {
@@ -247,3 +322,369 @@ func (dm *DNSMessage) Flatten() (map[string]interface{}, error) {
 
 	return dnsFields, nil
 }
+
+// FromJSON decodes a DNS message encoded with ToJSON
+func (dm *DNSMessage) FromJSON(data []byte) error {
+	dm.Init()
+	if err := json.Unmarshal(data, dm); err != nil {
+		return err
+	}
+	return dm.restoreDecodedFields()
+}
+
+// FromFlatJSON decodes a DNS message encoded with ToFlatJSON
+func (dm *DNSMessage) FromFlatJSON(data []byte) error {
+	var flat map[string]interface{}
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return err
+	}
+	return dm.Unflatten(flat)
+}
+
+// Unflatten is the inverse of Flatten, the missing keys keep their default values
+// and the collectors or transformers parts are restored only if their keys are present
+func (dm *DNSMessage) Unflatten(flat map[string]interface{}) error {
+	dm.Init()
+	r := &flatReader{fields: flat}
+
+	// network
+	r.str("network.family", &dm.NetworkInfo.Family)
+	r.str("network.protocol", &dm.NetworkInfo.Protocol)
+	r.str("network.query-ip", &dm.NetworkInfo.QueryIP)
+	r.str("network.query-port", &dm.NetworkInfo.QueryPort)
+	r.str("network.response-ip", &dm.NetworkInfo.ResponseIP)
+	r.str("network.response-port", &dm.NetworkInfo.ResponsePort)
+	r.boolean("network.ip-defragmented", &dm.NetworkInfo.IPDefragmented)
+	r.boolean("network.tcp-reassembled", &dm.NetworkInfo.TCPReassembled)
+
+	// dns
+	r.boolean("dns.flags.aa", &dm.DNS.Flags.AA)
+	r.boolean("dns.flags.ad", &dm.DNS.Flags.AD)
+	r.boolean("dns.flags.qr", &dm.DNS.Flags.QR)
+	r.boolean("dns.flags.ra", &dm.DNS.Flags.RA)
+	r.boolean("dns.flags.tc", &dm.DNS.Flags.TC)
+	r.boolean("dns.flags.rd", &dm.DNS.Flags.RD)
+	r.boolean("dns.flags.cd", &dm.DNS.Flags.CD)
+	r.integer("dns.length", &dm.DNS.Length)
+	r.boolean("dns.malformed-packet", &dm.DNS.MalformedPacket)
+	r.integer("dns.id", &dm.DNS.ID)
+	r.integer("dns.opcode", &dm.DNS.Opcode)
+	r.str("dns.qname", &dm.DNS.Qname)
+	r.str("dns.qtype", &dm.DNS.Qtype)
+	r.str("dns.qclass", &dm.DNS.Qclass)
+	r.str("dns.rcode", &dm.DNS.Rcode)
+	r.integer("dns.qdcount", &dm.DNS.QdCount)
+	r.integer("dns.ancount", &dm.DNS.AnCount)
+	r.integer("dns.arcount", &dm.DNS.ArCount)
+	r.integer("dns.nscount", &dm.DNS.NsCount)
+	dm.DNS.DNSRRs.Answers = r.resourceRecords("dns.resource-records.an")
+	dm.DNS.DNSRRs.Nameservers = r.resourceRecords("dns.resource-records.ns")
+	dm.DNS.DNSRRs.Records = r.resourceRecords("dns.resource-records.ar")
+
+	// dnstap
+	r.str("dnstap.identity", &dm.DNSTap.Identity)
+	r.float("dnstap.latency", &dm.DNSTap.Latency)
+	r.str("dnstap.operation", &dm.DNSTap.Operation)
+	r.str("dnstap.timestamp-rfc3339ns", &dm.DNSTap.TimestampRFC3339)
+	r.str("dnstap.version", &dm.DNSTap.Version)
+	r.str("dnstap.extra", &dm.DNSTap.Extra)
+	r.str("dnstap.policy-rule", &dm.DNSTap.PolicyRule)
+	r.str("dnstap.policy-type", &dm.DNSTap.PolicyType)
+	r.str("dnstap.policy-action", &dm.DNSTap.PolicyAction)
+	r.str("dnstap.policy-match", &dm.DNSTap.PolicyMatch)
+	r.str("dnstap.policy-value", &dm.DNSTap.PolicyValue)
+	r.str("dnstap.peer-name", &dm.DNSTap.PeerName)
+	r.str("dnstap.query-zone", &dm.DNSTap.QueryZone)
+
+	// edns
+	r.integer("edns.dnssec-ok", &dm.EDNS.Do)
+	r.integer("edns.rcode", &dm.EDNS.ExtendedRcode)
+	r.integer("edns.udp-size", &dm.EDNS.UDPSize)
+	r.integer("edns.version", &dm.EDNS.Version)
+	codes, datas, names := r.list("edns.options.codes"), r.list("edns.options.datas"), r.list("edns.options.names")
+	for i, code := range codes {
+		opt := DNSOption{Data: listItem(datas, i), Name: listItem(names, i)}
+		if opt.Code, r.err = strconv.Atoi(code); r.err != nil {
+			return r.err
+		}
+		dm.EDNS.Options = append(dm.EDNS.Options, opt)
+	}
+	r.str("edns.client-cookie", &dm.EDNS.ClientCookie)
+	r.str("edns.server-cookie", &dm.EDNS.ServerCookie)
+	r.str("edns.nsid", &dm.EDNS.NSID)
+	r.integer("edns.padding", &dm.EDNS.Padding)
+	r.integer("edns.keepalive-timeout", &dm.EDNS.KeepaliveTimeout)
+	r.integer("edns.expire", &dm.EDNS.Expire)
+	r.str("edns.chain", &dm.EDNS.Chain)
+	r.str("edns.report-channel", &dm.EDNS.ReportChannel)
+	r.str("edns.zoneversion", &dm.EDNS.ZoneVersion)
+
+	// collectors
+	if r.has("powerdns.") {
+		dm.PowerDNS = &CollectorPowerDNS{Tags: r.indexed("powerdns.tags"), Metadata: map[string]string{}}
+		r.str("powerdns.original-request-subnet", &dm.PowerDNS.OriginalRequestSubnet)
+		r.str("powerdns.applied-policy", &dm.PowerDNS.AppliedPolicy)
+		r.str("powerdns.applied-policy-hit", &dm.PowerDNS.AppliedPolicyHit)
+		r.str("powerdns.applied-policy-kind", &dm.PowerDNS.AppliedPolicyKind)
+		r.str("powerdns.applied-policy-trigger", &dm.PowerDNS.AppliedPolicyTrigger)
+		r.str("powerdns.applied-policy-type", &dm.PowerDNS.AppliedPolicyType)
+		for key := range flat {
+			if mk, found := strings.CutPrefix(key, "powerdns.metadata."); found {
+				var mv string
+				r.str(key, &mv)
+				dm.PowerDNS.Metadata[mk] = mv
+			}
+		}
+		r.str("powerdns.http-version", &dm.PowerDNS.HTTPVersion)
+		r.str("powerdns.message-id", &dm.PowerDNS.MessageID)
+		r.str("powerdns.requestor-id", &dm.PowerDNS.RequestorID)
+		r.str("powerdns.device-id", &dm.PowerDNS.DeviceID)
+		r.str("powerdns.device-name", &dm.PowerDNS.DeviceName)
+		r.str("powerdns.initial-requestor-id", &dm.PowerDNS.InitialRequestorID)
+		r.str("powerdns.edns-version", &dm.PowerDNS.EdnsVersion)
+		r.str("powerdns.opentelemetry-data", &dm.PowerDNS.OpenTelemetryData)
+	}
+	if r.has("session.") {
+		dm.Session = &CollectorSession{ALPN: r.indexed("session.alpn")}
+		r.str("session.sni", &dm.Session.SNI)
+		r.integer("session.bytes-sent", &dm.Session.BytesSent)
+		r.integer("session.bytes-received", &dm.Session.BytesReceived)
+		r.float("session.duration", &dm.Session.Duration)
+		r.str("session.closing", &dm.Session.Closing)
+	}
+
+	// transformers
+	if r.has("geoip.") {
+		dm.Geo = &TransformDNSGeo{}
+		r.str("geoip.city", &dm.Geo.City)
+		r.str("geoip.continent", &dm.Geo.Continent)
+		r.str("geoip.country-isocode", &dm.Geo.CountryIsoCode)
+		r.str("geoip.as-number", &dm.Geo.AutonomousSystemNumber)
+		r.str("geoip.as-owner", &dm.Geo.AutonomousSystemOrg)
+		for i := 0; ; i++ {
+			prefix := "geoip.answers." + strconv.Itoa(i)
+			if _, ok := flat[prefix+".ip"]; !ok {
+				break
+			}
+			answer := TransformDNSGeoAnswer{}
+			r.str(prefix+".ip", &answer.IP)
+			r.str(prefix+".city", &answer.City)
+			r.str(prefix+".continent", &answer.Continent)
+			r.str(prefix+".country-isocode", &answer.CountryIsoCode)
+			r.str(prefix+".as-number", &answer.AutonomousSystemNumber)
+			r.str(prefix+".as-owner", &answer.AutonomousSystemOrg)
+			dm.Geo.Answers = append(dm.Geo.Answers, answer)
+		}
+	}
+	if r.has("suspicious.") {
+		dm.Suspicious = &TransformSuspicious{}
+		r.float("suspicious.score", &dm.Suspicious.Score)
+		r.boolean("suspicious.malformed-pkt", &dm.Suspicious.MalformedPacket)
+		r.boolean("suspicious.large-pkt", &dm.Suspicious.LargePacket)
+		r.boolean("suspicious.long-domain", &dm.Suspicious.LongDomain)
+		r.boolean("suspicious.slow-domain", &dm.Suspicious.SlowDomain)
+		r.boolean("suspicious.unallowed-chars", &dm.Suspicious.UnallowedChars)
+		r.boolean("suspicious.uncommon-qtypes", &dm.Suspicious.UncommonQtypes)
+		r.boolean("suspicious.excessive-number-labels", &dm.Suspicious.ExcessiveNumberLabels)
+		r.boolean("suspicious.private-answer", &dm.Suspicious.PrivateAnswer)
+		r.boolean("suspicious.large-rdata", &dm.Suspicious.LargeRdata)
+		r.str("suspicious.domain", &dm.Suspicious.Domain)
+	}
+	if r.has("publicsuffix.") {
+		dm.PublicSuffix = &TransformPublicSuffix{}
+		r.str("publicsuffix.tld", &dm.PublicSuffix.QnamePublicSuffix)
+		r.str("publicsuffix.etld+1", &dm.PublicSuffix.QnameEffectiveTLDPlusOne)
+		r.boolean("publicsuffix.managed-icann", &dm.PublicSuffix.ManagedByICANN)
+	}
+	if r.has("extracted.") {
+		dm.Extracted = &TransformExtracted{}
+		var payload string
+		r.str("extracted.dns_payload", &payload)
+		if dm.Extracted.Base64Payload, r.err = base64.StdEncoding.DecodeString(payload); r.err != nil {
+			return r.err
+		}
+	}
+	if r.has("reducer.") {
+		dm.Reducer = &TransformReducer{}
+		r.integer("reducer.occurrences", &dm.Reducer.Occurrences)
+		r.integer("reducer.cumulative-length", &dm.Reducer.CumulativeLength)
+	}
+	if r.has("correlation.") {
+		dm.Correlation = &TransformCorrelation{}
+		r.str("correlation.status", &dm.Correlation.Status)
+		r.str("correlation.query-timestamp-rfc3339ns", &dm.Correlation.QueryTimestamp)
+		r.str("correlation.response-timestamp-rfc3339ns", &dm.Correlation.ResponseTimestamp)
+		r.float("correlation.latency", &dm.Correlation.Latency)
+		r.integer("correlation.query-length", &dm.Correlation.QueryLength)
+		r.integer("correlation.response-length", &dm.Correlation.ResponseLength)
+	}
+	if r.has("filtering.") {
+		dm.Filtering = &TransformFiltering{}
+		r.integer("filtering.sample-rate", &dm.Filtering.SampleRate)
+	}
+	if r.has("ml.") {
+		dm.MachineLearning = &TransformML{}
+		r.float("ml.entropy", &dm.MachineLearning.Entropy)
+		r.integer("ml.length", &dm.MachineLearning.Length)
+		r.integer("ml.labels", &dm.MachineLearning.Labels)
+		r.integer("ml.digits", &dm.MachineLearning.Digits)
+		r.integer("ml.lowers", &dm.MachineLearning.Lowers)
+		r.integer("ml.uppers", &dm.MachineLearning.Uppers)
+		r.integer("ml.specials", &dm.MachineLearning.Specials)
+		r.integer("ml.others", &dm.MachineLearning.Others)
+		r.float("ml.ratio-digits", &dm.MachineLearning.RatioDigits)
+		r.float("ml.ratio-letters", &dm.MachineLearning.RatioLetters)
+		r.float("ml.ratio-specials", &dm.MachineLearning.RatioSpecials)
+		r.float("ml.ratio-others", &dm.MachineLearning.RatioOthers)
+		r.integer("ml.consecutive-chars", &dm.MachineLearning.ConsecutiveChars)
+		r.integer("ml.consecutive-vowels", &dm.MachineLearning.ConsecutiveVowels)
+		r.integer("ml.consecutive-digits", &dm.MachineLearning.ConsecutiveDigits)
+		r.integer("ml.consecutive-consonants", &dm.MachineLearning.ConsecutiveConsonants)
+		r.integer("ml.size", &dm.MachineLearning.Size)
+		r.integer("ml.occurrences", &dm.MachineLearning.Occurrences)
+		r.integer("ml.uncommon-qtypes", &dm.MachineLearning.UncommonQtypes)
+	}
+	if r.has("detection.") {
+		dm.Detection = &TransformDetection{Reasons: r.indexed("detection.reasons")}
+		r.float("detection.score", &dm.Detection.Score)
+		r.float("detection.tunneling-score", &dm.Detection.TunnelingScore)
+		r.float("detection.dga-score", &dm.Detection.DGAScore)
+	}
+	if r.has("atags.") {
+		dm.ATags = &TransformATags{Tags: r.indexed("atags.tags")}
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+	return dm.restoreDecodedFields()
+}
+
+// restoreDecodedFields computes the fields not exported in json
+func (dm *DNSMessage) restoreDecodedFields() error {
+	if dm.DNSTap.TimestampRFC3339 != "-" && len(dm.DNSTap.TimestampRFC3339) > 0 {
+		ts, err := time.Parse(time.RFC3339Nano, dm.DNSTap.TimestampRFC3339)
+		if err != nil {
+			return err
+		}
+		dm.DNSTap.Timestamp = ts.UnixNano()
+		dm.DNSTap.TimeSec = int(ts.Unix())
+		dm.DNSTap.TimeNsec = ts.Nanosecond()
+	}
+
+	switch {
+	case strings.HasSuffix(dm.DNSTap.Operation, "_QUERY"):
+		dm.DNS.Type = DNSQuery
+	case strings.HasSuffix(dm.DNSTap.Operation, "_RESPONSE"):
+		dm.DNS.Type = DNSReply
+	case dm.DNS.Flags.QR:
+		dm.DNS.Type = DNSReply
+	default:
+		dm.DNS.Type = DNSQuery
+	}
+	return nil
+}
+
+// flatReader reads the typed values of a flat message, the first error is kept
+type flatReader struct {
+	fields map[string]interface{}
+	err    error
+}
+
+func (r *flatReader) typeError(key string, value interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("invalid value for key %s: %v", key, value)
+	}
+}
+
+func (r *flatReader) str(key string, target *string) {
+	if value, ok := r.fields[key]; ok {
+		if v, valid := value.(string); valid {
+			*target = v
+		} else {
+			r.typeError(key, value)
+		}
+	}
+}
+
+func (r *flatReader) boolean(key string, target *bool) {
+	if value, ok := r.fields[key]; ok {
+		if v, valid := value.(bool); valid {
+			*target = v
+		} else {
+			r.typeError(key, value)
+		}
+	}
+}
+
+func (r *flatReader) float(key string, target *float64) {
+	if value, ok := r.fields[key]; ok {
+		if v, valid := value.(float64); valid {
+			*target = v
+		} else {
+			r.typeError(key, value)
+		}
+	}
+}
+
+func (r *flatReader) integer(key string, target *int) {
+	var v float64
+	r.float(key, &v)
+	if _, ok := r.fields[key]; ok {
+		*target = int(v)
+	}
+}
+
+// has returns true if at least one key starts with the prefix
+func (r *flatReader) has(prefix string) bool {
+	for key := range r.fields {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexed reads the values stored with the keys prefix.0, prefix.1, ...
+func (r *flatReader) indexed(prefix string) []string {
+	values := []string{}
+	for i := 0; ; i++ {
+		key := prefix + "." + strconv.Itoa(i)
+		if _, ok := r.fields[key]; !ok {
+			return values
+		}
+		var value string
+		r.str(key, &value)
+		values = append(values, value)
+	}
+}
+
+// list splits the values joined with a pipe, "-" is an empty list
+func (r *flatReader) list(key string) []string {
+	var value string
+	r.str(key, &value)
+	if len(value) == 0 || value == "-" {
+		return nil
+	}
+	return strings.Split(value, "|")
+}
+
+func (r *flatReader) resourceRecords(prefix string) []DNSAnswer {
+	rrs := []DNSAnswer{}
+	names := r.list(prefix + ".names")
+	rdatatypes, rdatas := r.list(prefix+".rdatatypes"), r.list(prefix+".rdatas")
+	ttls, classes := r.list(prefix+".ttls"), r.list(prefix+".classes")
+	for i, name := range names {
+		rr := DNSAnswer{Name: name, Rdatatype: listItem(rdatatypes, i), Rdata: listItem(rdatas, i), Class: listItem(classes, i)}
+		rr.TTL, _ = strconv.Atoi(listItem(ttls, i))
+		rrs = append(rrs, rr)
+	}
+	return rrs
+}
+
+func listItem(items []string, i int) string {
+	if i < len(items) {
+		return items[i]
+	}
+	return ""
+}
@@ -144,6 +144,21 @@ func TestDnsMessage_Json_Collectors_Reference(t *testing.T) {
 						}
 					}`,
 		},
+		{
+			collector: "session",
+			dmRef: DNSMessage{Session: &CollectorSession{SNI: "dns.google", ALPN: []string{"h2"},
+				BytesSent: 517, BytesReceived: 4096, Duration: 1.5, Closing: "fin"}},
+			jsonRef: `{
+						"session": {
+							"sni": "dns.google",
+							"alpn": ["h2"],
+							"bytes-sent": 517,
+							"bytes-received": 4096,
+							"duration": 1.5,
+							"closing": "fin"
+						}
+					}`,
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.collector, func(t *testing.T) {
@@ -238,6 +253,21 @@ func TestDnsMessage_Json_Transforms_Reference(t *testing.T) {
 						}
 					}`,
 		},
+		{
+			transform: "correlation",
+			dmRef: DNSMessage{Correlation: &TransformCorrelation{Status: "answered", QueryTimestamp: "2024-01-01T00:00:00Z",
+				ResponseTimestamp: "2024-01-01T00:00:01Z", Latency: 1, QueryLength: 30, ResponseLength: 46}},
+			jsonRef: `{
+						"correlation": {
+							"status": "answered",
+							"query-timestamp-rfc3339ns": "2024-01-01T00:00:00Z",
+							"response-timestamp-rfc3339ns": "2024-01-01T00:00:01Z",
+							"latency": 1,
+							"query-length": 30,
+							"response-length": 46
+						}
+					}`,
+		},
 		{
 			transform: "normalize",
 			dmRef: DNSMessage{
@@ -545,6 +575,19 @@ func TestDnsMessage_JsonFlatten_Transforms_Reference(t *testing.T) {
 						"reducer.cumulative-length": 47
 					  }`,
 		},
+		{
+			transform: "correlation",
+			dm: DNSMessage{Correlation: &TransformCorrelation{Status: "answered", QueryTimestamp: "2024-01-01T00:00:00Z",
+				ResponseTimestamp: "2024-01-01T00:00:01Z", Latency: 1, QueryLength: 30, ResponseLength: 46}},
+			jsonRef: `{
+						"correlation.status": "answered",
+						"correlation.query-timestamp-rfc3339ns": "2024-01-01T00:00:00Z",
+						"correlation.response-timestamp-rfc3339ns": "2024-01-01T00:00:01Z",
+						"correlation.latency": 1,
+						"correlation.query-length": 30,
+						"correlation.response-length": 46
+					  }`,
+		},
 		{
 			transform: "publicsuffix",
 			dm: DNSMessage{
@@ -567,6 +610,8 @@ func TestDnsMessage_JsonFlatten_Transforms_Reference(t *testing.T) {
 					CountryIsoCode:         "FR",
 					AutonomousSystemNumber: "1234",
 					AutonomousSystemOrg:    "Internet",
+					Answers: []TransformDNSGeoAnswer{{IP: "8.8.8.8", City: "-", Continent: "NA",
+						CountryIsoCode: "US", AutonomousSystemNumber: "15169", AutonomousSystemOrg: "Google"}},
 				},
 			},
 			jsonRef: `{
@@ -574,7 +619,12 @@ func TestDnsMessage_JsonFlatten_Transforms_Reference(t *testing.T) {
 						"geoip.continent": "Europe",
 						"geoip.country-isocode": "FR",
 						"geoip.as-number": "1234",
-						"geoip.as-owner": "Internet"
+						"geoip.as-owner": "Internet",
+						"geoip.answers.0.ip": "8.8.8.8",
+						"geoip.answers.0.continent": "NA",
+						"geoip.answers.0.country-isocode": "US",
+						"geoip.answers.0.as-number": "15169",
+						"geoip.answers.0.as-owner": "Google"
 					}`,
 		},
 		{
@@ -661,6 +711,17 @@ func TestDnsMessage_JsonFlatten_Transforms_Reference(t *testing.T) {
 						"atags.tags.1": "test1"
 					  }`,
 		},
+		{
+			transform: "detection",
+			dm:        DNSMessage{Detection: &TransformDetection{Score: 0.7, TunnelingScore: 0.7, DGAScore: 0.3, Reasons: []string{"tunneling:client-rate=1000", "dga:entropy=3.9"}}},
+			jsonRef: `{
+						"detection.score": 0.7,
+						"detection.tunneling-score": 0.7,
+						"detection.dga-score": 0.3,
+						"detection.reasons.0": "tunneling:client-rate=1000",
+						"detection.reasons.1": "dga:entropy=3.9"
+					  }`,
+		},
 	}
 
 	for _, tc := range testcases {
@@ -733,6 +794,19 @@ func TestDnsMessage_JsonFlatten_Collectors_Reference(t *testing.T) {
 						"powerdns.opentelemetry-data": "5e006236c8a74f7eafc6af126e6d0689"
 					}`,
 		},
+		{
+			collector: "session",
+			dm: DNSMessage{Session: &CollectorSession{SNI: "dns.google", ALPN: []string{"h2"},
+				BytesSent: 517, BytesReceived: 4096, Duration: 1.5, Closing: "fin"}},
+			jsonRef: `{
+						"session.sni": "dns.google",
+						"session.alpn.0": "h2",
+						"session.bytes-sent": 517,
+						"session.bytes-received": 4096,
+						"session.duration": 1.5,
+						"session.closing": "fin"
+					}`,
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.collector, func(t *testing.T) {
@@ -768,6 +842,144 @@ func TestDnsMessage_JsonFlatten_Collectors_Reference(t *testing.T) {
 	}
 }
 
+func getDecodableDNSMessage() DNSMessage {
+	dm := GetFakeDNSMessage()
+	dm.DNSTap.Operation = "CLIENT_RESPONSE"
+	dm.DNSTap.TimestampRFC3339 = "2024-01-02T03:04:05.123456789Z"
+	dm.DNSTap.Latency = 0.5
+	dm.DNS.Flags.QR = true
+	dm.DNS.ID = 1234
+	dm.DNS.DNSRRs.Answers = append(dm.DNS.DNSRRs.Answers,
+		DNSAnswer{Name: "dnscollector.dev", Rdata: "1.2.3.4", Rdatatype: "A", TTL: 300, Class: "IN"},
+		DNSAnswer{Name: "dnscollector.dev", Rdata: "1.2.3.5", Rdatatype: "A", TTL: 60, Class: "IN"})
+	dm.EDNS.UDPSize = 1232
+	dm.EDNS.Options = append(dm.EDNS.Options, DNSOption{Code: 10, Data: "aaaabbbbcccc", Name: "COOKIE"})
+	dm.EDNS.ClientCookie = "aaaabbbbcccc"
+	dm.EDNS.Padding = 64
+	return dm
+}
+
+func checkDecodedDNSMessage(t *testing.T, dm DNSMessage, ref DNSMessage) {
+	if dm.DNS.Type != DNSReply {
+		t.Errorf("invalid dns type: %s", dm.DNS.Type)
+	}
+	if dm.DNSTap.TimeSec != 1704164645 || dm.DNSTap.TimeNsec != 123456789 {
+		t.Errorf("invalid timestamp: %d.%d", dm.DNSTap.TimeSec, dm.DNSTap.TimeNsec)
+	}
+	if !reflect.DeepEqual(dm.NetworkInfo, ref.NetworkInfo) {
+		t.Errorf("invalid network info: %+v", dm.NetworkInfo)
+	}
+	if !reflect.DeepEqual(dm.DNS.DNSRRs.Answers, ref.DNS.DNSRRs.Answers) {
+		t.Errorf("invalid answers: %+v", dm.DNS.DNSRRs.Answers)
+	}
+	if !reflect.DeepEqual(dm.EDNS, ref.EDNS) {
+		t.Errorf("invalid edns: %+v", dm.EDNS)
+	}
+	if dm.DNS.ID != ref.DNS.ID || dm.DNS.Qname != ref.DNS.Qname || dm.DNSTap.Latency != ref.DNSTap.Latency {
+		t.Errorf("invalid dns message: %+v", dm)
+	}
+}
+
+func TestDnsMessage_FromJSON(t *testing.T) {
+	ref := getDecodableDNSMessage()
+
+	var dm DNSMessage
+	if err := dm.FromJSON([]byte(ref.ToJSON())); err != nil {
+		t.Fatalf("could not decode json: %s", err)
+	}
+	checkDecodedDNSMessage(t, dm, ref)
+}
+
+func TestDnsMessage_FromFlatJSON(t *testing.T) {
+	ref := getDecodableDNSMessage()
+	flat, err := ref.ToFlatJSON()
+	if err != nil {
+		t.Fatalf("could not encode to flat json: %s", err)
+	}
+
+	var dm DNSMessage
+	if err := dm.FromFlatJSON([]byte(flat)); err != nil {
+		t.Fatalf("could not decode flat json: %s", err)
+	}
+	checkDecodedDNSMessage(t, dm, ref)
+}
+
+func TestDnsMessage_FromFlatJSON_Transforms(t *testing.T) {
+	ref := getDecodableDNSMessage()
+	ref.InitTransforms()
+	ref.PowerDNS = &CollectorPowerDNS{Tags: []string{"tag1", "tag2"}, Metadata: map[string]string{"stream_id": "collector"}, AppliedPolicy: "rpz"}
+	ref.Session = &CollectorSession{SNI: "dns.google", ALPN: []string{"dot"}, BytesSent: 10, BytesReceived: 20, Duration: 1.5, Closing: SessionClosingFin}
+	ref.Geo.CountryIsoCode = "FR"
+	ref.Geo.Answers = []TransformDNSGeoAnswer{{IP: "1.1.1.1", CountryIsoCode: "AU", AutonomousSystemNumber: "13335"}}
+	ref.Suspicious.Score = 1.5
+	ref.Suspicious.LongDomain = true
+	ref.Suspicious.PrivateAnswer = true
+	ref.PublicSuffix.QnamePublicSuffix = "dev"
+	ref.Extracted.Base64Payload = []byte{0x01, 0x02}
+	ref.Reducer.Occurrences = 3
+	ref.Correlation.Status = "ANSWERED"
+	ref.Filtering.SampleRate = 10
+	ref.MachineLearning.Entropy = 2.5
+	ref.MachineLearning.Labels = 3
+	ref.ATags.Tags = []string{"atag"}
+	ref.Detection.Score = 0.5
+	ref.Detection.DGAScore = 0.5
+	ref.Detection.Reasons = []string{"dga:entropy=4"}
+
+	flat, err := ref.ToFlatJSON()
+	if err != nil {
+		t.Fatalf("could not encode to flat json: %s", err)
+	}
+
+	var dm DNSMessage
+	if err := dm.FromFlatJSON([]byte(flat)); err != nil {
+		t.Fatalf("could not decode flat json: %s", err)
+	}
+
+	parts := []struct {
+		name     string
+		got, ref interface{}
+	}{
+		{"powerdns", dm.PowerDNS, ref.PowerDNS},
+		{"session", dm.Session, ref.Session},
+		{"geoip", dm.Geo, ref.Geo},
+		{"suspicious", dm.Suspicious, ref.Suspicious},
+		{"publicsuffix", dm.PublicSuffix, ref.PublicSuffix},
+		{"extracted", dm.Extracted, ref.Extracted},
+		{"reducer", dm.Reducer, ref.Reducer},
+		{"correlation", dm.Correlation, ref.Correlation},
+		{"filtering", dm.Filtering, ref.Filtering},
+		{"ml", dm.MachineLearning, ref.MachineLearning},
+		{"atags", dm.ATags, ref.ATags},
+		{"detection", dm.Detection, ref.Detection},
+	}
+	for _, part := range parts {
+		if !reflect.DeepEqual(part.got, part.ref) {
+			t.Errorf("invalid %s part: %+v, expected %+v", part.name, part.got, part.ref)
+		}
+	}
+}
+
+func TestDnsMessage_FromFlatJSON_Invalid(t *testing.T) {
+	testcases := []struct {
+		name string
+		data string
+	}{
+		{name: "not_json", data: "dnscollector"},
+		{name: "invalid_type", data: `{"dns.id": "1234"}`},
+		{name: "invalid_timestamp", data: `{"dnstap.timestamp-rfc3339ns": "yesterday"}`},
+		{name: "invalid_option_code", data: `{"edns.options.codes": "ten", "edns.options.datas": "-", "edns.options.names": "-"}`},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dm DNSMessage
+			if err := dm.FromFlatJSON([]byte(tc.data)); err == nil {
+				t.Errorf("error expected")
+			}
+		})
+	}
+}
+
 func BenchmarkDnsMessage_ToFlatJSON(b *testing.B) {
 	dm := DNSMessage{}
 	dm.Init()
@@ -781,3 +993,31 @@ func BenchmarkDnsMessage_ToFlatJSON(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkDnsMessage_ToMsgpack(b *testing.B) {
+	dm := DNSMessage{}
+	dm.Init()
+	dm.InitTransforms()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := dm.ToMsgpack()
+		if err != nil {
+			b.Fatalf("could not encode to msgpack: %v\n", err)
+		}
+	}
+}
+
+func BenchmarkDnsMessage_ToCBOR(b *testing.B) {
+	dm := DNSMessage{}
+	dm.Init()
+	dm.InitTransforms()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := dm.ToCBOR()
+		if err != nil {
+			b.Fatalf("could not encode to cbor: %v\n", err)
+		}
+	}
+}
@@ -39,6 +39,20 @@ func TestDNSMessage_Matching(t *testing.T) {
 			wantError: false,
 			wantMatch: false,
 		},
+		{
+			name:      "Test edns nsid matching",
+			dm:        &DNSMessage{EDNS: DNSExtended{NSID: "ns1.fra"}},
+			matching:  map[string]interface{}{"edns.nsid": "^ns1\\."},
+			wantError: false,
+			wantMatch: true,
+		},
+		{
+			name:      "Test no match with incorrect edns client cookie",
+			dm:        &DNSMessage{EDNS: DNSExtended{ClientCookie: "0102030405060708"}},
+			matching:  map[string]interface{}{"edns.client-cookie": "ffffffffffffffff"},
+			wantError: false,
+			wantMatch: false,
+		},
 		{
 			name:      "Test boolean matching",
 			dm:        &DNSMessage{DNS: DNS{Flags: DNSFlags{QR: true}}},
@@ -0,0 +1,7 @@
+package dnsutils
+
+// ToMsgpack encodes the dns message with the generated MessagePack codec,
+// the keys are the same as the json format
+func (dm *DNSMessage) ToMsgpack() ([]byte, error) {
+	return dm.MarshalMsg(make([]byte, 0, dm.Msgsize()))
+}
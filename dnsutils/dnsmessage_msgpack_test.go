@@ -0,0 +1,55 @@
+package dnsutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDnsMessage_ToMsgpack(t *testing.T) {
+	dm := GetFakeDNSMessage()
+	dm.InitTransforms()
+	dm.DNS.DNSRRs.Answers = append(dm.DNS.DNSRRs.Answers, DNSAnswer{Name: "dns.collector", Rdatatype: "A", Class: "IN", TTL: 300, Rdata: "1.2.3.4"})
+	dm.DNSTap.Latency = 0.5
+	dm.ATags.Tags = []string{"tag1", "tag2"}
+
+	data, err := dm.ToMsgpack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := DNSMessage{}
+	rest, err := decoded.UnmarshalMsg(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%d unexpected remaining bytes", len(rest))
+	}
+
+	if !reflect.DeepEqual(decoded.DNS.DNSRRs.Answers, dm.DNS.DNSRRs.Answers) {
+		t.Errorf("answers mismatch: %+v", decoded.DNS.DNSRRs.Answers)
+	}
+	if decoded.DNSTap.Identity != dm.DNSTap.Identity || decoded.DNSTap.Latency != dm.DNSTap.Latency {
+		t.Errorf("dnstap mismatch: %+v", decoded.DNSTap)
+	}
+	if decoded.ATags == nil || !reflect.DeepEqual(decoded.ATags.Tags, dm.ATags.Tags) {
+		t.Errorf("atags mismatch: %+v", decoded.ATags)
+	}
+}
+
+func TestDnsMessage_ToMsgpack_OmitEmpty(t *testing.T) {
+	dm := GetFakeDNSMessage()
+
+	data, err := dm.ToMsgpack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := DNSMessage{}
+	if _, err := decoded.UnmarshalMsg(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.PowerDNS != nil || decoded.Geo != nil {
+		t.Errorf("transforms not initialized must be omitted")
+	}
+}
@@ -0,0 +1,467 @@
+package dnsutils
+
+import (
+	"encoding/base64"
+	"sort"
+)
+
+// ParquetDNSMessage is the row written in parquet files, the schema is stable and does not depend
+// on the enabled transformers: the optional sections are null when the transformer is disabled.
+type ParquetDNSMessage struct {
+	Timestamp       int64                   `parquet:"timestamp,timestamp(nanosecond)"`
+	Network         ParquetNetwork          `parquet:"network"`
+	DNS             ParquetDNS              `parquet:"dns"`
+	EDNS            ParquetEDNS             `parquet:"edns"`
+	DNSTap          ParquetDNSTap           `parquet:"dnstap"`
+	PowerDNS        *ParquetPowerDNS        `parquet:"powerdns,optional"`
+	Session         *ParquetSession         `parquet:"session,optional"`
+	OpenTelemetry   *ParquetOpenTelemetry   `parquet:"opentelemetry,optional"`
+	Geo             *ParquetGeo             `parquet:"geoip,optional"`
+	Suspicious      *ParquetSuspicious      `parquet:"suspicious,optional"`
+	PublicSuffix    *ParquetPublicSuffix    `parquet:"publicsuffix,optional"`
+	Extracted       *ParquetExtracted       `parquet:"extracted,optional"`
+	Reducer         *ParquetReducer         `parquet:"reducer,optional"`
+	Correlation     *ParquetCorrelation     `parquet:"correlation,optional"`
+	MachineLearning *ParquetMachineLearning `parquet:"ml,optional"`
+	Detection       *ParquetDetection       `parquet:"detection,optional"`
+	Filtering       *ParquetFiltering       `parquet:"filtering,optional"`
+	ATags           *ParquetATags           `parquet:"atags,optional"`
+	Rest            *ParquetRest            `parquet:"rest,optional"`
+}
+
+type ParquetNetwork struct {
+	Family         string `parquet:"family"`
+	Protocol       string `parquet:"protocol"`
+	QueryIP        string `parquet:"query_ip"`
+	QueryPort      string `parquet:"query_port"`
+	ResponseIP     string `parquet:"response_ip"`
+	ResponsePort   string `parquet:"response_port"`
+	IPDefragmented bool   `parquet:"ip_defragmented"`
+	TCPReassembled bool   `parquet:"tcp_reassembled"`
+}
+
+type ParquetRR struct {
+	Name      string `parquet:"name"`
+	Rdatatype string `parquet:"rdatatype"`
+	Class     string `parquet:"class"`
+	TTL       int64  `parquet:"ttl"`
+	Rdata     string `parquet:"rdata"`
+}
+
+type ParquetDNS struct {
+	Length          int64       `parquet:"length"`
+	ID              int64       `parquet:"id"`
+	Opcode          int64       `parquet:"opcode"`
+	Rcode           string      `parquet:"rcode"`
+	Qname           string      `parquet:"qname"`
+	Qtype           string      `parquet:"qtype"`
+	Qclass          string      `parquet:"qclass"`
+	QdCount         int64       `parquet:"qdcount"`
+	AnCount         int64       `parquet:"ancount"`
+	NsCount         int64       `parquet:"nscount"`
+	ArCount         int64       `parquet:"arcount"`
+	FlagQR          bool        `parquet:"flag_qr"`
+	FlagTC          bool        `parquet:"flag_tc"`
+	FlagAA          bool        `parquet:"flag_aa"`
+	FlagRA          bool        `parquet:"flag_ra"`
+	FlagAD          bool        `parquet:"flag_ad"`
+	FlagRD          bool        `parquet:"flag_rd"`
+	FlagCD          bool        `parquet:"flag_cd"`
+	Answers         []ParquetRR `parquet:"an,list"`
+	Nameservers     []ParquetRR `parquet:"ns,list"`
+	Records         []ParquetRR `parquet:"ar,list"`
+	MalformedPacket bool        `parquet:"malformed_packet"`
+}
+
+type ParquetEDNSOption struct {
+	Code int64  `parquet:"code"`
+	Name string `parquet:"name"`
+	Data string `parquet:"data"`
+}
+
+type ParquetEDNS struct {
+	UDPSize       int64               `parquet:"udp_size"`
+	ExtendedRcode int64               `parquet:"rcode"`
+	Version       int64               `parquet:"version"`
+	Do            int64               `parquet:"dnssec_ok"`
+	Options       []ParquetEDNSOption `parquet:"options,list"`
+}
+
+type ParquetDNSTap struct {
+	Operation        string  `parquet:"operation"`
+	Identity         string  `parquet:"identity"`
+	Version          string  `parquet:"version"`
+	TimestampRFC3339 string  `parquet:"timestamp_rfc3339ns"`
+	Latency          float64 `parquet:"latency"`
+	Extra            string  `parquet:"extra"`
+	PolicyRule       string  `parquet:"policy_rule"`
+	PolicyType       string  `parquet:"policy_type"`
+	PolicyMatch      string  `parquet:"policy_match"`
+	PolicyAction     string  `parquet:"policy_action"`
+	PolicyValue      string  `parquet:"policy_value"`
+	PeerName         string  `parquet:"peer_name"`
+	QueryZone        string  `parquet:"query_zone"`
+	HTTPProtocol     string  `parquet:"http_protocol"`
+}
+
+type ParquetKeyValue struct {
+	Key   string `parquet:"key"`
+	Value string `parquet:"value"`
+}
+
+type ParquetPowerDNS struct {
+	Tags                  []string          `parquet:"tags,list"`
+	OriginalRequestSubnet string            `parquet:"original_request_subnet"`
+	AppliedPolicy         string            `parquet:"applied_policy"`
+	AppliedPolicyHit      string            `parquet:"applied_policy_hit"`
+	AppliedPolicyKind     string            `parquet:"applied_policy_kind"`
+	AppliedPolicyTrigger  string            `parquet:"applied_policy_trigger"`
+	AppliedPolicyType     string            `parquet:"applied_policy_type"`
+	Metadata              []ParquetKeyValue `parquet:"metadata,list"`
+	HTTPVersion           string            `parquet:"http_version"`
+	MessageID             string            `parquet:"message_id"`
+	InitialRequestorID    string            `parquet:"initial_requestor_id"`
+	RequestorID           string            `parquet:"requestor_id"`
+	DeviceName            string            `parquet:"device_name"`
+	DeviceID              string            `parquet:"device_id"`
+	OpenTelemetryData     string            `parquet:"opentelemetry_data"`
+	EdnsVersion           string            `parquet:"edns_version"`
+}
+
+type ParquetSession struct {
+	SNI           string   `parquet:"sni"`
+	ALPN          []string `parquet:"alpn,list"`
+	BytesSent     int64    `parquet:"bytes_sent"`
+	BytesReceived int64    `parquet:"bytes_received"`
+	Duration      float64  `parquet:"duration"`
+	Closing       string   `parquet:"closing"`
+}
+
+type ParquetOpenTelemetry struct {
+	TraceID string `parquet:"trace_id"`
+}
+
+type ParquetGeoAnswer struct {
+	IP                     string `parquet:"ip"`
+	City                   string `parquet:"city"`
+	Continent              string `parquet:"continent"`
+	CountryIsoCode         string `parquet:"country_isocode"`
+	AutonomousSystemNumber string `parquet:"as_number"`
+	AutonomousSystemOrg    string `parquet:"as_owner"`
+}
+
+type ParquetGeo struct {
+	City                   string             `parquet:"city"`
+	Continent              string             `parquet:"continent"`
+	CountryIsoCode         string             `parquet:"country_isocode"`
+	AutonomousSystemNumber string             `parquet:"as_number"`
+	AutonomousSystemOrg    string             `parquet:"as_owner"`
+	Answers                []ParquetGeoAnswer `parquet:"answers,list"`
+}
+
+type ParquetSuspicious struct {
+	Score                 float64 `parquet:"score"`
+	MalformedPacket       bool    `parquet:"malformed_pkt"`
+	LargePacket           bool    `parquet:"large_pkt"`
+	LongDomain            bool    `parquet:"long_domain"`
+	SlowDomain            bool    `parquet:"slow_domain"`
+	UnallowedChars        bool    `parquet:"unallowed_chars"`
+	UncommonQtypes        bool    `parquet:"uncommon_qtypes"`
+	ExcessiveNumberLabels bool    `parquet:"excessive_number_labels"`
+	PrivateAnswer         bool    `parquet:"private_answer"`
+	LargeRdata            bool    `parquet:"large_rdata"`
+	Domain                string  `parquet:"domain"`
+}
+
+type ParquetPublicSuffix struct {
+	QnamePublicSuffix        string `parquet:"tld"`
+	QnameEffectiveTLDPlusOne string `parquet:"etld_plus_one"`
+	ManagedByICANN           bool   `parquet:"managed_icann"`
+}
+
+type ParquetExtracted struct {
+	Base64Payload string `parquet:"dns_payload"`
+}
+
+type ParquetReducer struct {
+	Occurrences      int64 `parquet:"occurrences"`
+	CumulativeLength int64 `parquet:"cumulative_length"`
+}
+
+type ParquetCorrelation struct {
+	Status            string  `parquet:"status"`
+	QueryTimestamp    string  `parquet:"query_timestamp_rfc3339ns"`
+	ResponseTimestamp string  `parquet:"response_timestamp_rfc3339ns"`
+	Latency           float64 `parquet:"latency"`
+	QueryLength       int64   `parquet:"query_length"`
+	ResponseLength    int64   `parquet:"response_length"`
+}
+
+type ParquetMachineLearning struct {
+	Entropy               float64 `parquet:"entropy"`
+	Length                int64   `parquet:"length"`
+	Labels                int64   `parquet:"labels"`
+	Digits                int64   `parquet:"digits"`
+	Lowers                int64   `parquet:"lowers"`
+	Uppers                int64   `parquet:"uppers"`
+	Specials              int64   `parquet:"specials"`
+	Others                int64   `parquet:"others"`
+	RatioDigits           float64 `parquet:"ratio_digits"`
+	RatioLetters          float64 `parquet:"ratio_letters"`
+	RatioSpecials         float64 `parquet:"ratio_specials"`
+	RatioOthers           float64 `parquet:"ratio_others"`
+	ConsecutiveChars      int64   `parquet:"consecutive_chars"`
+	ConsecutiveVowels     int64   `parquet:"consecutive_vowels"`
+	ConsecutiveDigits     int64   `parquet:"consecutive_digits"`
+	ConsecutiveConsonants int64   `parquet:"consecutive_consonants"`
+	Size                  int64   `parquet:"size"`
+	Occurrences           int64   `parquet:"occurrences"`
+	UncommonQtypes        int64   `parquet:"uncommon_qtypes"`
+}
+
+type ParquetDetection struct {
+	Score          float64  `parquet:"score"`
+	TunnelingScore float64  `parquet:"tunneling_score"`
+	DGAScore       float64  `parquet:"dga_score"`
+	Reasons        []string `parquet:"reasons,list"`
+}
+
+type ParquetFiltering struct {
+	SampleRate int64 `parquet:"sample_rate"`
+}
+
+type ParquetATags struct {
+	Tags []string `parquet:"tags,list"`
+}
+
+type ParquetRest struct {
+	Failed   bool   `parquet:"failed"`
+	Response string `parquet:"response"`
+}
+
+func toParquetRRs(rrs []DNSAnswer) []ParquetRR {
+	ret := make([]ParquetRR, 0, len(rrs))
+	for _, rr := range rrs {
+		ret = append(ret, ParquetRR{Name: rr.Name, Rdatatype: rr.Rdatatype, Class: rr.Class, TTL: int64(rr.TTL), Rdata: rr.Rdata})
+	}
+	return ret
+}
+
+// ToParquet converts the dns message to a parquet row
+func (dm *DNSMessage) ToParquet() ParquetDNSMessage {
+	row := ParquetDNSMessage{
+		Timestamp: int64(dm.DNSTap.TimeSec)*1e9 + int64(dm.DNSTap.TimeNsec),
+		Network: ParquetNetwork{
+			Family:         dm.NetworkInfo.Family,
+			Protocol:       dm.NetworkInfo.Protocol,
+			QueryIP:        dm.NetworkInfo.QueryIP,
+			QueryPort:      dm.NetworkInfo.QueryPort,
+			ResponseIP:     dm.NetworkInfo.ResponseIP,
+			ResponsePort:   dm.NetworkInfo.ResponsePort,
+			IPDefragmented: dm.NetworkInfo.IPDefragmented,
+			TCPReassembled: dm.NetworkInfo.TCPReassembled,
+		},
+		DNS: ParquetDNS{
+			Length:          int64(dm.DNS.Length),
+			ID:              int64(dm.DNS.ID),
+			Opcode:          int64(dm.DNS.Opcode),
+			Rcode:           dm.DNS.Rcode,
+			Qname:           dm.DNS.Qname,
+			Qtype:           dm.DNS.Qtype,
+			Qclass:          dm.DNS.Qclass,
+			QdCount:         int64(dm.DNS.QdCount),
+			AnCount:         int64(dm.DNS.AnCount),
+			NsCount:         int64(dm.DNS.NsCount),
+			ArCount:         int64(dm.DNS.ArCount),
+			FlagQR:          dm.DNS.Flags.QR,
+			FlagTC:          dm.DNS.Flags.TC,
+			FlagAA:          dm.DNS.Flags.AA,
+			FlagRA:          dm.DNS.Flags.RA,
+			FlagAD:          dm.DNS.Flags.AD,
+			FlagRD:          dm.DNS.Flags.RD,
+			FlagCD:          dm.DNS.Flags.CD,
+			Answers:         toParquetRRs(dm.DNS.DNSRRs.Answers),
+			Nameservers:     toParquetRRs(dm.DNS.DNSRRs.Nameservers),
+			Records:         toParquetRRs(dm.DNS.DNSRRs.Records),
+			MalformedPacket: dm.DNS.MalformedPacket,
+		},
+		EDNS: ParquetEDNS{
+			UDPSize:       int64(dm.EDNS.UDPSize),
+			ExtendedRcode: int64(dm.EDNS.ExtendedRcode),
+			Version:       int64(dm.EDNS.Version),
+			Do:            int64(dm.EDNS.Do),
+			Options:       make([]ParquetEDNSOption, 0, len(dm.EDNS.Options)),
+		},
+		DNSTap: ParquetDNSTap{
+			Operation:        dm.DNSTap.Operation,
+			Identity:         dm.DNSTap.Identity,
+			Version:          dm.DNSTap.Version,
+			TimestampRFC3339: dm.DNSTap.TimestampRFC3339,
+			Latency:          dm.DNSTap.Latency,
+			Extra:            dm.DNSTap.Extra,
+			PolicyRule:       dm.DNSTap.PolicyRule,
+			PolicyType:       dm.DNSTap.PolicyType,
+			PolicyMatch:      dm.DNSTap.PolicyMatch,
+			PolicyAction:     dm.DNSTap.PolicyAction,
+			PolicyValue:      dm.DNSTap.PolicyValue,
+			PeerName:         dm.DNSTap.PeerName,
+			QueryZone:        dm.DNSTap.QueryZone,
+			HTTPProtocol:     dm.DNSTap.HttpProtocol,
+		},
+	}
+	for _, opt := range dm.EDNS.Options {
+		row.EDNS.Options = append(row.EDNS.Options, ParquetEDNSOption{Code: int64(opt.Code), Name: opt.Name, Data: opt.Data})
+	}
+
+	if dm.PowerDNS != nil {
+		row.PowerDNS = &ParquetPowerDNS{
+			Tags:                  dm.PowerDNS.Tags,
+			OriginalRequestSubnet: dm.PowerDNS.OriginalRequestSubnet,
+			AppliedPolicy:         dm.PowerDNS.AppliedPolicy,
+			AppliedPolicyHit:      dm.PowerDNS.AppliedPolicyHit,
+			AppliedPolicyKind:     dm.PowerDNS.AppliedPolicyKind,
+			AppliedPolicyTrigger:  dm.PowerDNS.AppliedPolicyTrigger,
+			AppliedPolicyType:     dm.PowerDNS.AppliedPolicyType,
+			HTTPVersion:           dm.PowerDNS.HTTPVersion,
+			MessageID:             dm.PowerDNS.MessageID,
+			InitialRequestorID:    dm.PowerDNS.InitialRequestorID,
+			RequestorID:           dm.PowerDNS.RequestorID,
+			DeviceName:            dm.PowerDNS.DeviceName,
+			DeviceID:              dm.PowerDNS.DeviceID,
+			OpenTelemetryData:     dm.PowerDNS.OpenTelemetryData,
+			EdnsVersion:           dm.PowerDNS.EdnsVersion,
+		}
+
+		// metadata, sorted by key
+		keys := make([]string, 0, len(dm.PowerDNS.Metadata))
+		for key := range dm.PowerDNS.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			row.PowerDNS.Metadata = append(row.PowerDNS.Metadata, ParquetKeyValue{Key: key, Value: dm.PowerDNS.Metadata[key]})
+		}
+	}
+
+	if dm.Session != nil {
+		row.Session = &ParquetSession{
+			SNI:           dm.Session.SNI,
+			ALPN:          dm.Session.ALPN,
+			BytesSent:     int64(dm.Session.BytesSent),
+			BytesReceived: int64(dm.Session.BytesReceived),
+			Duration:      dm.Session.Duration,
+			Closing:       dm.Session.Closing,
+		}
+	}
+
+	if dm.OpenTelemetry != nil {
+		row.OpenTelemetry = &ParquetOpenTelemetry{TraceID: dm.OpenTelemetry.TraceID}
+	}
+
+	if dm.Geo != nil {
+		row.Geo = &ParquetGeo{
+			City:                   dm.Geo.City,
+			Continent:              dm.Geo.Continent,
+			CountryIsoCode:         dm.Geo.CountryIsoCode,
+			AutonomousSystemNumber: dm.Geo.AutonomousSystemNumber,
+			AutonomousSystemOrg:    dm.Geo.AutonomousSystemOrg,
+		}
+		for _, answer := range dm.Geo.Answers {
+			row.Geo.Answers = append(row.Geo.Answers, ParquetGeoAnswer(answer))
+		}
+	}
+
+	if dm.Suspicious != nil {
+		row.Suspicious = &ParquetSuspicious{
+			Score:                 dm.Suspicious.Score,
+			MalformedPacket:       dm.Suspicious.MalformedPacket,
+			LargePacket:           dm.Suspicious.LargePacket,
+			LongDomain:            dm.Suspicious.LongDomain,
+			SlowDomain:            dm.Suspicious.SlowDomain,
+			UnallowedChars:        dm.Suspicious.UnallowedChars,
+			UncommonQtypes:        dm.Suspicious.UncommonQtypes,
+			ExcessiveNumberLabels: dm.Suspicious.ExcessiveNumberLabels,
+			PrivateAnswer:         dm.Suspicious.PrivateAnswer,
+			LargeRdata:            dm.Suspicious.LargeRdata,
+			Domain:                dm.Suspicious.Domain,
+		}
+	}
+
+	if dm.PublicSuffix != nil {
+		row.PublicSuffix = &ParquetPublicSuffix{
+			QnamePublicSuffix:        dm.PublicSuffix.QnamePublicSuffix,
+			QnameEffectiveTLDPlusOne: dm.PublicSuffix.QnameEffectiveTLDPlusOne,
+			ManagedByICANN:           dm.PublicSuffix.ManagedByICANN,
+		}
+	}
+
+	if dm.Extracted != nil {
+		row.Extracted = &ParquetExtracted{Base64Payload: base64.StdEncoding.EncodeToString(dm.Extracted.Base64Payload)}
+	}
+
+	if dm.Reducer != nil {
+		row.Reducer = &ParquetReducer{
+			Occurrences:      int64(dm.Reducer.Occurrences),
+			CumulativeLength: int64(dm.Reducer.CumulativeLength),
+		}
+	}
+
+	if dm.Correlation != nil {
+		row.Correlation = &ParquetCorrelation{
+			Status:            dm.Correlation.Status,
+			QueryTimestamp:    dm.Correlation.QueryTimestamp,
+			ResponseTimestamp: dm.Correlation.ResponseTimestamp,
+			Latency:           dm.Correlation.Latency,
+			QueryLength:       int64(dm.Correlation.QueryLength),
+			ResponseLength:    int64(dm.Correlation.ResponseLength),
+		}
+	}
+
+	if dm.MachineLearning != nil {
+		ml := dm.MachineLearning
+		row.MachineLearning = &ParquetMachineLearning{
+			Entropy:               ml.Entropy,
+			Length:                int64(ml.Length),
+			Labels:                int64(ml.Labels),
+			Digits:                int64(ml.Digits),
+			Lowers:                int64(ml.Lowers),
+			Uppers:                int64(ml.Uppers),
+			Specials:              int64(ml.Specials),
+			Others:                int64(ml.Others),
+			RatioDigits:           ml.RatioDigits,
+			RatioLetters:          ml.RatioLetters,
+			RatioSpecials:         ml.RatioSpecials,
+			RatioOthers:           ml.RatioOthers,
+			ConsecutiveChars:      int64(ml.ConsecutiveChars),
+			ConsecutiveVowels:     int64(ml.ConsecutiveVowels),
+			ConsecutiveDigits:     int64(ml.ConsecutiveDigits),
+			ConsecutiveConsonants: int64(ml.ConsecutiveConsonants),
+			Size:                  int64(ml.Size),
+			Occurrences:           int64(ml.Occurrences),
+			UncommonQtypes:        int64(ml.UncommonQtypes),
+		}
+	}
+
+	if dm.Detection != nil {
+		row.Detection = &ParquetDetection{
+			Score:          dm.Detection.Score,
+			TunnelingScore: dm.Detection.TunnelingScore,
+			DGAScore:       dm.Detection.DGAScore,
+			Reasons:        dm.Detection.Reasons,
+		}
+	}
+
+	if dm.Filtering != nil {
+		row.Filtering = &ParquetFiltering{SampleRate: int64(dm.Filtering.SampleRate)}
+	}
+
+	if dm.ATags != nil {
+		row.ATags = &ParquetATags{Tags: dm.ATags.Tags}
+	}
+
+	if dm.Rest != nil {
+		row.Rest = &ParquetRest{Failed: dm.Rest.Failed, Response: dm.Rest.Response}
+	}
+
+	return row
+}
@@ -0,0 +1,48 @@
+package dnsutils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestDnsMessage_ToParquet(t *testing.T) {
+	dm := GetFakeDNSMessage()
+	dm.DNSTap.TimeSec = 10
+	dm.DNSTap.TimeNsec = 5
+	dm.DNS.DNSRRs.Answers = []DNSAnswer{{Name: "dns.collector", Rdatatype: "A", Class: "IN", TTL: 300, Rdata: "1.2.3.4"}}
+	dm.PowerDNS = &CollectorPowerDNS{Metadata: map[string]string{"b": "2", "a": "1"}}
+	dm.ATags = &TransformATags{Tags: []string{"tag1"}}
+
+	buf := new(bytes.Buffer)
+	writer := parquet.NewGenericWriter[ParquetDNSMessage](buf)
+	if _, err := writer.Write([]ParquetDNSMessage{dm.ToParquet()}); err != nil {
+		t.Fatalf("unable to write parquet row: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unable to close parquet writer: %v", err)
+	}
+
+	rows, err := parquet.Read[ParquetDNSMessage](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("unable to read parquet rows: %v", err)
+	}
+	row := rows[0]
+
+	if row.Timestamp != 10*1e9+5 {
+		t.Errorf("invalid timestamp: %d", row.Timestamp)
+	}
+	if row.DNS.Qname != dm.DNS.Qname || len(row.DNS.Answers) != 1 || row.DNS.Answers[0].Rdata != "1.2.3.4" {
+		t.Errorf("invalid dns part: %v", row.DNS)
+	}
+	if row.PowerDNS == nil || len(row.PowerDNS.Metadata) != 2 || row.PowerDNS.Metadata[0].Key != "a" {
+		t.Errorf("invalid powerdns part: %v", row.PowerDNS)
+	}
+	if row.ATags == nil || row.ATags.Tags[0] != "tag1" {
+		t.Errorf("invalid atags part: %v", row.ATags)
+	}
+	if row.Geo != nil || row.Suspicious != nil {
+		t.Errorf("transformers sections should be null")
+	}
+}
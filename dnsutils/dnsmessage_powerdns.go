@@ -0,0 +1,201 @@
+package dnsutils
+
+import (
+	"encoding/hex"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dmachard/go-netutils"
+	powerdns_protobuf "github.com/dmachard/go-powerdns-protobuf"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	DNSTapToProtobufPowerDNS = map[string]string{
+		"CLIENT_QUERY":       "DNSQueryType",
+		"CLIENT_RESPONSE":    "DNSResponseType",
+		"AUTH_QUERY":         "DNSQueryType",
+		"AUTH_RESPONSE":      "DNSResponseType",
+		"RESOLVER_QUERY":     "DNSOutgoingQueryType",
+		"RESOLVER_RESPONSE":  "DNSIncomingResponseType",
+		"FORWARDER_QUERY":    "DNSOutgoingQueryType",
+		"FORWARDER_RESPONSE": "DNSIncomingResponseType",
+	}
+)
+
+// lookupCode returns the numeric code of the value in the map
+func lookupCode(codes map[int]string, value string) (uint32, bool) {
+	for code, v := range codes {
+		if v == value {
+			return uint32(code), true
+		}
+	}
+	return 0, false
+}
+
+// ToPowerDNS encodes the dns message to PowerDNS protobuf, as emitted by the PowerDNS servers
+func (dm *DNSMessage) ToPowerDNS() ([]byte, error) {
+	pbdm := &powerdns_protobuf.PBDNSMessage{}
+
+	// type of event, according to the dnstap operation or the dns type
+	pbType, found := DNSTapToProtobufPowerDNS[dm.DNSTap.Operation]
+	if !found {
+		pbType = "DNSQueryType"
+		if dm.DNS.Type == DNSReply {
+			pbType = "DNSResponseType"
+		}
+	}
+	pbdm.Type = powerdns_protobuf.PBDNSMessage_Type(powerdns_protobuf.PBDNSMessage_Type_value[pbType]).Enum()
+	isResponse := pbdm.GetType() == powerdns_protobuf.PBDNSMessage_DNSResponseType || pbdm.GetType() == powerdns_protobuf.PBDNSMessage_DNSIncomingResponseType
+
+	pbdm.ServerIdentity = []byte(dm.DNSTap.Identity)
+
+	// network
+	if ipNet, valid := netutils.IPToInet[dm.NetworkInfo.Family]; valid {
+		pbdm.SocketFamily = powerdns_protobuf.PBDNSMessage_SocketFamily(powerdns_protobuf.PBDNSMessage_SocketFamily_value[ipNet]).Enum()
+	}
+	if sp, valid := powerdns_protobuf.PBDNSMessage_SocketProtocol_value[dm.NetworkInfo.Protocol]; valid {
+		pbdm.SocketProtocol = powerdns_protobuf.PBDNSMessage_SocketProtocol(sp).Enum()
+	}
+	pbdm.From = ipToBytes(dm.NetworkInfo.QueryIP)
+	pbdm.To = ipToBytes(dm.NetworkInfo.ResponseIP)
+	if port, err := strconv.ParseUint(dm.NetworkInfo.QueryPort, 10, 16); err == nil {
+		pbdm.FromPort = proto.Uint32(uint32(port))
+	}
+	if port, err := strconv.ParseUint(dm.NetworkInfo.ResponsePort, 10, 16); err == nil {
+		pbdm.ToPort = proto.Uint32(uint32(port))
+	}
+
+	// dns
+	pbdm.Id = proto.Uint32(uint32(dm.DNS.ID))
+	pbdm.InBytes = proto.Uint64(uint64(dm.DNS.Length))
+	pbdm.TimeSec = proto.Uint32(uint32(dm.DNSTap.TimeSec))
+	pbdm.TimeUsec = proto.Uint32(uint32(dm.DNSTap.TimeNsec / 1e3))
+
+	question := &powerdns_protobuf.PBDNSMessage_DNSQuestion{QName: proto.String(fqdn(dm.DNS.Qname))}
+	if qtype, found := lookupCode(Rdatatypes, dm.DNS.Qtype); found {
+		question.QType = proto.Uint32(qtype)
+	}
+	if qclass, found := lookupCode(Class, dm.DNS.Qclass); found {
+		question.QClass = proto.Uint32(qclass)
+	}
+	pbdm.Question = question
+
+	if isResponse {
+		response := &powerdns_protobuf.PBDNSMessage_DNSResponse{}
+		if rcode, found := lookupCode(Rcodes, dm.DNS.Rcode); found {
+			response.Rcode = proto.Uint32(rcode)
+		}
+
+		// time of the query, according to the latency
+		tsQuery := float64(dm.DNSTap.TimeSec) + float64(dm.DNSTap.TimeNsec)/1e9 - dm.DNSTap.Latency
+		response.QueryTimeSec = proto.Uint32(uint32(tsQuery))
+		response.QueryTimeUsec = proto.Uint32(uint32((tsQuery - float64(uint32(tsQuery))) * 1e6))
+
+		for _, answer := range dm.DNS.DNSRRs.Answers {
+			rr := &powerdns_protobuf.PBDNSMessage_DNSResponse_DNSRR{
+				Name: proto.String(fqdn(answer.Name)),
+				Ttl:  proto.Uint32(uint32(answer.TTL)),
+			}
+			if rrtype, found := lookupCode(Rdatatypes, answer.Rdatatype); found {
+				rr.Type = proto.Uint32(rrtype)
+			}
+			if class, found := lookupCode(Class, answer.Class); found {
+				rr.Class = proto.Uint32(class)
+			}
+
+			// raw address for A and AAAA, text representation for the others
+			switch answer.Rdatatype {
+			case "A", "AAAA":
+				rr.Rdata = ipToBytes(answer.Rdata)
+			case "CNAME", "DNAME", "NS", "PTR":
+				rr.Rdata = []byte(fqdn(answer.Rdata))
+			default:
+				rr.Rdata = []byte(answer.Rdata)
+			}
+			response.Rrs = append(response.Rrs, rr)
+		}
+		pbdm.Response = response
+	}
+
+	// specific powerdns fields
+	if dm.PowerDNS != nil {
+		pbdm.OriginalRequestorSubnet = ipToBytes(dm.PowerDNS.OriginalRequestSubnet)
+		if len(dm.PowerDNS.RequestorID) > 0 {
+			pbdm.RequestorId = proto.String(dm.PowerDNS.RequestorID)
+		}
+		if len(dm.PowerDNS.DeviceName) > 0 {
+			pbdm.DeviceName = proto.String(dm.PowerDNS.DeviceName)
+		}
+		pbdm.MessageId, _ = hex.DecodeString(dm.PowerDNS.MessageID)
+		pbdm.InitialRequestId, _ = hex.DecodeString(dm.PowerDNS.InitialRequestorID)
+		pbdm.DeviceId, _ = hex.DecodeString(dm.PowerDNS.DeviceID)
+		pbdm.OpenTelemetryData, _ = hex.DecodeString(dm.PowerDNS.OpenTelemetryData)
+		if version, err := strconv.ParseUint(dm.PowerDNS.EdnsVersion, 10, 32); err == nil {
+			pbdm.EdnsVersion = proto.Uint32(uint32(version))
+		}
+		if version, valid := powerdns_protobuf.PBDNSMessage_HTTPVersion_value[dm.PowerDNS.HTTPVersion]; valid {
+			pbdm.HttpVersion = powerdns_protobuf.PBDNSMessage_HTTPVersion(version).Enum()
+		}
+
+		// metadata, sorted by key
+		keys := make([]string, 0, len(dm.PowerDNS.Metadata))
+		for key := range dm.PowerDNS.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			pbdm.Meta = append(pbdm.Meta, &powerdns_protobuf.PBDNSMessage_Meta{
+				Key:   proto.String(key),
+				Value: &powerdns_protobuf.PBDNSMessage_MetaValue{StringVal: []string{dm.PowerDNS.Metadata[key]}},
+			})
+		}
+
+		// tags and policy are part of the response
+		if pbdm.Response == nil && (len(dm.PowerDNS.Tags) > 0 || len(dm.PowerDNS.AppliedPolicy) > 0) {
+			pbdm.Response = &powerdns_protobuf.PBDNSMessage_DNSResponse{}
+		}
+		if pbdm.Response != nil {
+			pbdm.Response.Tags = dm.PowerDNS.Tags
+			if len(dm.PowerDNS.AppliedPolicy) > 0 {
+				pbdm.Response.AppliedPolicy = proto.String(dm.PowerDNS.AppliedPolicy)
+			}
+			if len(dm.PowerDNS.AppliedPolicyHit) > 0 {
+				pbdm.Response.AppliedPolicyHit = proto.String(dm.PowerDNS.AppliedPolicyHit)
+			}
+			if len(dm.PowerDNS.AppliedPolicyTrigger) > 0 {
+				pbdm.Response.AppliedPolicyTrigger = proto.String(dm.PowerDNS.AppliedPolicyTrigger)
+			}
+			if kind, valid := powerdns_protobuf.PBDNSMessage_PolicyKind_value[dm.PowerDNS.AppliedPolicyKind]; valid {
+				pbdm.Response.AppliedPolicyKind = powerdns_protobuf.PBDNSMessage_PolicyKind(kind).Enum()
+			}
+			if policyType, valid := powerdns_protobuf.PBDNSMessage_PolicyType_value[dm.PowerDNS.AppliedPolicyType]; valid {
+				pbdm.Response.AppliedPolicyType = powerdns_protobuf.PBDNSMessage_PolicyType(policyType).Enum()
+			}
+		}
+	}
+
+	return proto.Marshal(pbdm)
+}
+
+// ipToBytes returns the 4 or 16 raw bytes of the ip address, nil if the address is invalid
+func ipToBytes(value string) []byte {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+}
+
+// fqdn adds the trailing dot to the domain name
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
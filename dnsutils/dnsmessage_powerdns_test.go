@@ -0,0 +1,128 @@
+package dnsutils
+
+import (
+	"net"
+	"testing"
+
+	powerdns_protobuf "github.com/dmachard/go-powerdns-protobuf"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDnsMessage_ToPowerDNS_Query(t *testing.T) {
+	dm := GetFakeDNSMessageWithPayload()
+	dm.DNS.Qclass = "IN"
+
+	data, err := dm.ToPowerDNS()
+	if err != nil {
+		t.Fatalf("could not encode to powerdns protobuf: %v", err)
+	}
+
+	pbdm := &powerdns_protobuf.PBDNSMessage{}
+	if err := proto.Unmarshal(data, pbdm); err != nil {
+		t.Fatalf("error to decode powerdns protobuf: %v", err)
+	}
+
+	if pbdm.GetType() != powerdns_protobuf.PBDNSMessage_DNSQueryType {
+		t.Errorf("invalid type: %s", pbdm.GetType())
+	}
+	if string(pbdm.GetServerIdentity()) != dm.DNSTap.Identity {
+		t.Errorf("invalid identity: %s", pbdm.GetServerIdentity())
+	}
+	if pbdm.GetSocketFamily() != powerdns_protobuf.PBDNSMessage_INET || pbdm.GetSocketProtocol() != powerdns_protobuf.PBDNSMessage_UDP {
+		t.Errorf("invalid socket family or protocol: %s %s", pbdm.GetSocketFamily(), pbdm.GetSocketProtocol())
+	}
+	if net.IP(pbdm.GetFrom()).String() != "1.2.3.4" || pbdm.GetFromPort() != 1234 {
+		t.Errorf("invalid from: %v:%d", net.IP(pbdm.GetFrom()), pbdm.GetFromPort())
+	}
+	if net.IP(pbdm.GetTo()).String() != "4.3.2.1" || pbdm.GetToPort() != 4321 {
+		t.Errorf("invalid to: %v:%d", net.IP(pbdm.GetTo()), pbdm.GetToPort())
+	}
+	if pbdm.GetQuestion().GetQName() != "dns.collector." || pbdm.GetQuestion().GetQType() != 1 || pbdm.GetQuestion().GetQClass() != 1 {
+		t.Errorf("invalid question: %v", pbdm.GetQuestion())
+	}
+	if pbdm.GetResponse() != nil {
+		t.Errorf("no response expected for a query")
+	}
+}
+
+func TestDnsMessage_ToPowerDNS_Response(t *testing.T) {
+	dm := GetFakeDNSMessage()
+	dm.DNSTap.Operation = "CLIENT_RESPONSE"
+	dm.DNS.Type = DNSReply
+	dm.DNS.Rcode = "NXDOMAIN"
+	dm.DNSTap.TimeSec = 10
+	dm.DNSTap.Latency = 0.5
+	dm.DNS.DNSRRs.Answers = []DNSAnswer{
+		{Name: "dns.collector", Rdatatype: "CNAME", Class: "IN", TTL: 300, Rdata: "www.collector"},
+		{Name: "www.collector", Rdatatype: "AAAA", Class: "IN", TTL: 300, Rdata: "2001:db8::1"},
+	}
+	dm.PowerDNS = &CollectorPowerDNS{
+		Tags:                  []string{"tag1", "tag2"},
+		AppliedPolicy:         "rpz.local",
+		AppliedPolicyKind:     "NXDOMAIN",
+		AppliedPolicyType:     "QNAME",
+		AppliedPolicyTrigger:  "dns.collector",
+		Metadata:              map[string]string{"b": "2", "a": "1"},
+		MessageID:             "0102",
+		RequestorID:           "alice",
+		OriginalRequestSubnet: "10.0.0.0",
+	}
+
+	data, err := dm.ToPowerDNS()
+	if err != nil {
+		t.Fatalf("could not encode to powerdns protobuf: %v", err)
+	}
+
+	pbdm := &powerdns_protobuf.PBDNSMessage{}
+	if err := proto.Unmarshal(data, pbdm); err != nil {
+		t.Fatalf("error to decode powerdns protobuf: %v", err)
+	}
+
+	response := pbdm.GetResponse()
+	if pbdm.GetType() != powerdns_protobuf.PBDNSMessage_DNSResponseType || response.GetRcode() != 3 {
+		t.Errorf("invalid type or rcode: %s %d", pbdm.GetType(), response.GetRcode())
+	}
+	if response.GetQueryTimeSec() != 9 || response.GetQueryTimeUsec() != 500000 {
+		t.Errorf("invalid query time: %d.%d", response.GetQueryTimeSec(), response.GetQueryTimeUsec())
+	}
+
+	rrs := response.GetRrs()
+	if len(rrs) != 2 {
+		t.Fatalf("invalid number of rrs: %d", len(rrs))
+	}
+	if rrs[0].GetName() != "dns.collector." || rrs[0].GetType() != 5 || string(rrs[0].GetRdata()) != "www.collector." {
+		t.Errorf("invalid cname rr: %v", rrs[0])
+	}
+	if rrs[1].GetType() != 28 || net.IP(rrs[1].GetRdata()).String() != "2001:db8::1" || rrs[1].GetTtl() != 300 {
+		t.Errorf("invalid aaaa rr: %v", rrs[1])
+	}
+
+	if len(response.GetTags()) != 2 || response.GetAppliedPolicy() != "rpz.local" || response.GetAppliedPolicyTrigger() != "dns.collector" {
+		t.Errorf("invalid policy: %v", response)
+	}
+	if response.GetAppliedPolicyKind() != powerdns_protobuf.PBDNSMessage_NXDOMAIN || response.GetAppliedPolicyType() != powerdns_protobuf.PBDNSMessage_QNAME {
+		t.Errorf("invalid policy kind or type: %s %s", response.GetAppliedPolicyKind(), response.GetAppliedPolicyType())
+	}
+
+	metas := pbdm.GetMeta()
+	if len(metas) != 2 || metas[0].GetKey() != "a" || metas[0].GetValue().GetStringVal()[0] != "1" {
+		t.Errorf("invalid metadata: %v", metas)
+	}
+	if string(pbdm.GetMessageId()) != "\x01\x02" || pbdm.GetRequestorId() != "alice" {
+		t.Errorf("invalid message id or requestor id: %v", pbdm)
+	}
+	if net.IP(pbdm.GetOriginalRequestorSubnet()).String() != "10.0.0.0" {
+		t.Errorf("invalid original requestor subnet: %v", pbdm.GetOriginalRequestorSubnet())
+	}
+}
+
+func BenchmarkDnsMessage_ToPowerDNS(b *testing.B) {
+	dm := GetFakeDNSMessage()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := dm.ToPowerDNS()
+		if err != nil {
+			b.Fatalf("could not encode to powerdns protobuf: %v\n", err)
+		}
+	}
+}
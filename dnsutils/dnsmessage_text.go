@@ -14,12 +14,15 @@ import (
 var (
 	OtelDirectives            = regexp.MustCompile(`^otel-*`)
 	PdnsDirectives            = regexp.MustCompile(`^powerdns-*`)
+	SessionDirectives         = regexp.MustCompile(`^session-*`)
 	GeoIPDirectives           = regexp.MustCompile(`^geoip-*`)
 	SuspiciousDirectives      = regexp.MustCompile(`^suspicious-*`)
 	PublicSuffixDirectives    = regexp.MustCompile(`^publicsuffix-*`)
 	ExtractedDirectives       = regexp.MustCompile(`^extracted-*`)
 	ReducerDirectives         = regexp.MustCompile(`^reducer-*`)
+	CorrelationDirectives     = regexp.MustCompile(`^correlation-*`)
 	MachineLearningDirectives = regexp.MustCompile(`^ml-*`)
+	DetectionDirectives       = regexp.MustCompile(`^detection-*`)
 	FilteringDirectives       = regexp.MustCompile(`^filtering-*`)
 	RawTextDirective          = regexp.MustCompile(`^ *\{.*\}`)
 	ATagsDirectives           = regexp.MustCompile(`^atags*`)
@@ -54,6 +57,12 @@ func (dm *DNSMessage) handleGeoIPDirectives(directive string, s *strings.Builder
 			s.WriteString(dm.Geo.AutonomousSystemNumber)
 		case "geoip-as-owner":
 			s.WriteString(dm.Geo.AutonomousSystemOrg)
+		case "geoip-answers-country":
+			s.WriteString(joinGeoAnswers(dm.Geo.Answers, func(a TransformDNSGeoAnswer) string { return a.CountryIsoCode }))
+		case "geoip-answers-as-number":
+			s.WriteString(joinGeoAnswers(dm.Geo.Answers, func(a TransformDNSGeoAnswer) string { return a.AutonomousSystemNumber }))
+		case "geoip-answers-as-owner":
+			s.WriteString(joinGeoAnswers(dm.Geo.Answers, func(a TransformDNSGeoAnswer) string { return a.AutonomousSystemOrg }))
 		default:
 			return errors.New(ErrorUnexpectedDirective + directive)
 		}
@@ -61,6 +70,18 @@ func (dm *DNSMessage) handleGeoIPDirectives(directive string, s *strings.Builder
 	return nil
 }
 
+// joinGeoAnswers returns the comma-separated field of each answer, or "-" without answers
+func joinGeoAnswers(answers []TransformDNSGeoAnswer, field func(TransformDNSGeoAnswer) string) string {
+	if len(answers) == 0 {
+		return "-"
+	}
+	values := make([]string, len(answers))
+	for i, answer := range answers {
+		values[i] = field(answer)
+	}
+	return strings.Join(values, ",")
+}
+
 func (dm *DNSMessage) handlePdnsDirectives(directive string, s *strings.Builder) error {
 	if dm.PowerDNS == nil {
 		s.WriteString("-")
@@ -341,6 +362,62 @@ func (dm *DNSMessage) handleReducerDirectives(directive string, s *strings.Build
 	return nil
 }
 
+func (dm *DNSMessage) handleSessionDirectives(directive string, s *strings.Builder) error {
+	if dm.Session == nil {
+		s.WriteString("-")
+	} else {
+		switch directive {
+		case "session-sni":
+			if len(dm.Session.SNI) == 0 {
+				s.WriteString("-")
+			} else {
+				s.WriteString(dm.Session.SNI)
+			}
+		case "session-alpn":
+			if len(dm.Session.ALPN) == 0 {
+				s.WriteString("-")
+			} else {
+				s.WriteString(strings.Join(dm.Session.ALPN, ","))
+			}
+		case "session-bytes-sent":
+			s.WriteString(strconv.Itoa(dm.Session.BytesSent))
+		case "session-bytes-received":
+			s.WriteString(strconv.Itoa(dm.Session.BytesReceived))
+		case "session-duration":
+			s.WriteString(fmt.Sprintf("%.9f", dm.Session.Duration))
+		case "session-closing":
+			s.WriteString(dm.Session.Closing)
+		default:
+			return errors.New(ErrorUnexpectedDirective + directive)
+		}
+	}
+	return nil
+}
+
+func (dm *DNSMessage) handleCorrelationDirectives(directive string, s *strings.Builder) error {
+	if dm.Correlation == nil {
+		s.WriteString("-")
+	} else {
+		switch directive {
+		case "correlation-status":
+			s.WriteString(dm.Correlation.Status)
+		case "correlation-query-timestamp":
+			s.WriteString(dm.Correlation.QueryTimestamp)
+		case "correlation-response-timestamp":
+			s.WriteString(dm.Correlation.ResponseTimestamp)
+		case "correlation-latency":
+			s.WriteString(fmt.Sprintf("%.9f", dm.Correlation.Latency))
+		case "correlation-query-length":
+			s.WriteString(strconv.Itoa(dm.Correlation.QueryLength))
+		case "correlation-response-length":
+			s.WriteString(strconv.Itoa(dm.Correlation.ResponseLength))
+		default:
+			return errors.New(ErrorUnexpectedDirective + directive)
+		}
+	}
+	return nil
+}
+
 func (dm *DNSMessage) handleMachineLearningDirectives(directive string, s *strings.Builder) error {
 	if dm.MachineLearning == nil {
 		s.WriteString("-")
@@ -391,6 +468,30 @@ func (dm *DNSMessage) handleMachineLearningDirectives(directive string, s *strin
 	return nil
 }
 
+func (dm *DNSMessage) handleDetectionDirectives(directive string, s *strings.Builder) error {
+	if dm.Detection == nil {
+		s.WriteString("-")
+	} else {
+		switch directive {
+		case "detection-score":
+			s.WriteString(strconv.FormatFloat(dm.Detection.Score, 'f', 2, 64))
+		case "detection-tunneling-score":
+			s.WriteString(strconv.FormatFloat(dm.Detection.TunnelingScore, 'f', 2, 64))
+		case "detection-dga-score":
+			s.WriteString(strconv.FormatFloat(dm.Detection.DGAScore, 'f', 2, 64))
+		case "detection-reasons":
+			if len(dm.Detection.Reasons) > 0 {
+				s.WriteString(strings.Join(dm.Detection.Reasons, ","))
+			} else {
+				s.WriteString("-")
+			}
+		default:
+			return errors.New(ErrorUnexpectedDirective + directive)
+		}
+	}
+	return nil
+}
+
 func (dm *DNSMessage) Bytes(format []string, fieldDelimiter string, fieldBoundary string) []byte {
 	line, err := dm.ToTextLine(format, fieldDelimiter, fieldBoundary)
 	if err != nil {
@@ -657,6 +758,48 @@ func (dm *DNSMessage) ToTextLine(format []string, fieldDelimiter string, fieldBo
 			} else {
 				s.WriteByte('-')
 			}
+		case directive == "edns-client-cookie":
+			if len(dm.EDNS.ClientCookie) == 0 {
+				s.WriteByte('-')
+			} else {
+				s.WriteString(dm.EDNS.ClientCookie)
+			}
+		case directive == "edns-server-cookie":
+			if len(dm.EDNS.ServerCookie) == 0 {
+				s.WriteByte('-')
+			} else {
+				s.WriteString(dm.EDNS.ServerCookie)
+			}
+		case directive == "edns-nsid":
+			if len(dm.EDNS.NSID) == 0 {
+				s.WriteByte('-')
+			} else {
+				QuoteStringAndWrite(&s, dm.EDNS.NSID, fieldDelimiter, fieldBoundary)
+			}
+		case directive == "edns-padding":
+			s.WriteString(strconv.Itoa(dm.EDNS.Padding))
+		case directive == "edns-keepalive":
+			s.WriteString(strconv.Itoa(dm.EDNS.KeepaliveTimeout))
+		case directive == "edns-expire":
+			s.WriteString(strconv.Itoa(dm.EDNS.Expire))
+		case directive == "edns-chain":
+			if len(dm.EDNS.Chain) == 0 {
+				s.WriteByte('-')
+			} else {
+				s.WriteString(dm.EDNS.Chain)
+			}
+		case directive == "edns-report-channel":
+			if len(dm.EDNS.ReportChannel) == 0 {
+				s.WriteByte('-')
+			} else {
+				s.WriteString(dm.EDNS.ReportChannel)
+			}
+		case directive == "edns-zoneversion":
+			if len(dm.EDNS.ZoneVersion) == 0 {
+				s.WriteByte('-')
+			} else {
+				QuoteStringAndWrite(&s, dm.EDNS.ZoneVersion, fieldDelimiter, fieldBoundary)
+			}
 
 		// more directives from loggers
 		case OtelDirectives.MatchString(directive):
@@ -671,6 +814,11 @@ func (dm *DNSMessage) ToTextLine(format []string, fieldDelimiter string, fieldBo
 			if err != nil {
 				return nil, err
 			}
+		case SessionDirectives.MatchString(directive):
+			err := dm.handleSessionDirectives(directive, &s)
+			if err != nil {
+				return nil, err
+			}
 
 		// more directives from transformers
 		case ReducerDirectives.MatchString(directive):
@@ -678,6 +826,11 @@ func (dm *DNSMessage) ToTextLine(format []string, fieldDelimiter string, fieldBo
 			if err != nil {
 				return nil, err
 			}
+		case CorrelationDirectives.MatchString(directive):
+			err := dm.handleCorrelationDirectives(directive, &s)
+			if err != nil {
+				return nil, err
+			}
 		case GeoIPDirectives.MatchString(directive):
 			err := dm.handleGeoIPDirectives(directive, &s)
 			if err != nil {
@@ -703,6 +856,11 @@ func (dm *DNSMessage) ToTextLine(format []string, fieldDelimiter string, fieldBo
 			if err != nil {
 				return nil, err
 			}
+		case DetectionDirectives.MatchString(directive):
+			err := dm.handleDetectionDirectives(directive, &s)
+			if err != nil {
+				return nil, err
+			}
 		case FilteringDirectives.MatchString(directive):
 			err := dm.handleFilteringDirectives(directive, &s)
 			if err != nil {
@@ -390,6 +390,11 @@ func TestDnsMessage_TextFormat_InvalidDirectives(t *testing.T) {
 			dm:     DNSMessage{PowerDNS: &CollectorPowerDNS{}},
 			format: "powerdns-invalid",
 		},
+		{
+			name:   "session",
+			dm:     DNSMessage{Session: &CollectorSession{}},
+			format: "session-invalid",
+		},
 		{
 			name:   "geoip",
 			dm:     DNSMessage{Geo: &TransformDNSGeo{}},
@@ -420,6 +425,11 @@ func TestDnsMessage_TextFormat_InvalidDirectives(t *testing.T) {
 			dm:     DNSMessage{MachineLearning: &TransformML{}},
 			format: "ml-invalid",
 		},
+		{
+			name:   "correlation",
+			dm:     DNSMessage{Correlation: &TransformCorrelation{}},
+			format: "correlation-invalid",
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -492,6 +502,21 @@ func TestDnsMessage_TextFormat_Directives_Geo(t *testing.T) {
 				CountryIsoCode: "FR", AutonomousSystemNumber: "AS1", AutonomousSystemOrg: "Google"}},
 			expected: "Europe FR Paris AS1 Google",
 		},
+		{
+			name:     "no_answers",
+			format:   "geoip-answers-country",
+			dm:       DNSMessage{Geo: &TransformDNSGeo{}},
+			expected: "-",
+		},
+		{
+			name:   "answers",
+			format: "geoip-answers-country geoip-answers-as-number geoip-answers-as-owner",
+			dm: DNSMessage{Geo: &TransformDNSGeo{Answers: []TransformDNSGeoAnswer{
+				{IP: "1.1.1.1", CountryIsoCode: "AU", AutonomousSystemNumber: "13335", AutonomousSystemOrg: "Cloudflare"},
+				{IP: "8.8.8.8", CountryIsoCode: "US", AutonomousSystemNumber: "15169", AutonomousSystemOrg: "Google"},
+			}}},
+			expected: "AU,US 13335,15169 Cloudflare,Google",
+		},
 	}
 
 	for _, tc := range testcases {
@@ -545,6 +570,56 @@ func TestDnsMessage_TextFormat_Directives_OpenTelemetry(t *testing.T) {
 	}
 }
 
+func TestDnsMessage_TextFormat_Directives_Session(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+
+	testcases := []struct {
+		name     string
+		format   string
+		dm       DNSMessage
+		expected string
+	}{
+		{
+			name:     "undefined",
+			format:   "session-sni",
+			dm:       DNSMessage{},
+			expected: "-",
+		},
+		{
+			name:     "empty_attributes",
+			format:   "session-sni session-alpn",
+			dm:       DNSMessage{Session: &CollectorSession{}},
+			expected: "- -",
+		},
+		{
+			name:   "default",
+			format: "session-sni session-alpn session-bytes-sent session-bytes-received session-duration session-closing",
+			dm: DNSMessage{Session: &CollectorSession{
+				SNI:           "dns.google",
+				ALPN:          []string{"h2", "http/1.1"},
+				BytesSent:     517,
+				BytesReceived: 4096,
+				Duration:      1.5,
+				Closing:       "fin",
+			}},
+			expected: "dns.google h2,http/1.1 517 4096 1.500000000 fin",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			line := tc.dm.String(
+				strings.Fields(tc.format),
+				config.Global.TextFormatDelimiter,
+				config.Global.TextFormatBoundary,
+			)
+			if line != tc.expected {
+				t.Errorf("Want: %s, got: %s", tc.expected, line)
+			}
+		})
+	}
+}
+
 func TestDnsMessage_TextFormat_Directives_Pdns(t *testing.T) {
 	config := pkgconfig.GetDefaultConfig()
 
@@ -764,6 +839,110 @@ func TestDnsMessage_TextFormat_Directives_Suspicious(t *testing.T) {
 	}
 }
 
+func TestDnsMessage_TextFormat_Directives_Detection(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+
+	testcases := []struct {
+		name     string
+		format   string
+		dm       DNSMessage
+		expected string
+	}{
+		{
+			name:     "undefined",
+			format:   "detection-score",
+			dm:       DNSMessage{},
+			expected: "-",
+		},
+		{
+			name:     "scores",
+			format:   "detection-score detection-tunneling-score detection-dga-score",
+			dm:       DNSMessage{Detection: &TransformDetection{Score: 0.7, TunnelingScore: 0.7, DGAScore: 0.3}},
+			expected: "0.70 0.70 0.30",
+		},
+		{
+			name:     "reasons",
+			format:   "detection-reasons",
+			dm:       DNSMessage{Detection: &TransformDetection{Reasons: []string{"dga:entropy=3.9", "dga:ratio-digits=0.4"}}},
+			expected: "dga:entropy=3.9,dga:ratio-digits=0.4",
+		},
+		{
+			name:     "no_reasons",
+			format:   "detection-reasons",
+			dm:       DNSMessage{Detection: &TransformDetection{}},
+			expected: "-",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			line := tc.dm.String(
+				strings.Fields(tc.format),
+				config.Global.TextFormatDelimiter,
+				config.Global.TextFormatBoundary,
+			)
+			if line != tc.expected {
+				t.Errorf("Want: %s, got: %s", tc.expected, line)
+			}
+		})
+	}
+}
+
+func TestDnsMessage_TextFormat_Directives_Edns(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+
+	testcases := []struct {
+		name     string
+		format   string
+		dm       DNSMessage
+		expected string
+	}{
+		{
+			name:     "undefined",
+			format:   "edns-client-cookie edns-server-cookie edns-nsid edns-chain edns-report-channel edns-zoneversion",
+			dm:       DNSMessage{},
+			expected: "- - - - - -",
+		},
+		{
+			name:     "cookies",
+			format:   "edns-client-cookie edns-server-cookie",
+			dm:       DNSMessage{EDNS: DNSExtended{ClientCookie: "0102030405060708", ServerCookie: "a1a2a3a4a5a6a7a8"}},
+			expected: "0102030405060708 a1a2a3a4a5a6a7a8",
+		},
+		{
+			name:     "nsid",
+			format:   "edns-nsid",
+			dm:       DNSMessage{EDNS: DNSExtended{NSID: "ns1 fra"}},
+			expected: "\"ns1 fra\"",
+		},
+		{
+			name:     "integers",
+			format:   "edns-padding edns-keepalive edns-expire",
+			dm:       DNSMessage{EDNS: DNSExtended{Padding: 64, KeepaliveTimeout: 30000, Expire: 3600}},
+			expected: "64 30000 3600",
+		},
+		{
+			name:     "zoneversion",
+			format:   "edns-zoneversion",
+			dm:       DNSMessage{EDNS: DNSExtended{ZoneVersion: "2 SOA-SERIAL 2018051123"}},
+			expected: "\"2 SOA-SERIAL 2018051123\"",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			line := tc.dm.String(
+				strings.Fields(tc.format),
+				config.Global.TextFormatDelimiter,
+				config.Global.TextFormatBoundary,
+			)
+			if line != tc.expected {
+				t.Errorf("Want: %s, got: %s", tc.expected, line)
+			}
+		})
+	}
+}
+
 func TestDnsMessage_TextFormat_Directives_Reducer(t *testing.T) {
 	config := pkgconfig.GetDefaultConfig()
 
@@ -801,6 +980,48 @@ func TestDnsMessage_TextFormat_Directives_Reducer(t *testing.T) {
 	}
 }
 
+func TestDnsMessage_TextFormat_Directives_Correlation(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+
+	testcases := []struct {
+		name     string
+		format   string
+		dm       DNSMessage
+		expected string
+	}{
+		{
+			name:     "undefined",
+			format:   "correlation-status",
+			dm:       DNSMessage{},
+			expected: "-",
+		},
+		{
+			name:   "default",
+			format: "correlation-status correlation-query-timestamp correlation-response-timestamp correlation-latency",
+			dm: DNSMessage{Correlation: &TransformCorrelation{
+				Status:            "answered",
+				QueryTimestamp:    "2024-01-01T00:00:00Z",
+				ResponseTimestamp: "2024-01-01T00:00:01Z",
+				Latency:           1,
+			}},
+			expected: "answered 2024-01-01T00:00:00Z 2024-01-01T00:00:01Z 1.000000000",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			line := tc.dm.String(
+				strings.Fields(tc.format),
+				config.Global.TextFormatDelimiter,
+				config.Global.TextFormatBoundary,
+			)
+			if line != tc.expected {
+				t.Errorf("Want: %s, got: %s", tc.expected, line)
+			}
+		})
+	}
+}
+
 func TestDnsMessage_TextFormat_Directives_Extracted(t *testing.T) {
 	config := pkgconfig.GetDefaultConfig()
 
@@ -2,9 +2,11 @@ package dnsutils
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 )
@@ -14,10 +16,15 @@ var ErrDecodeEdnsDataTooShort = errors.New("edns, not enough data to decode rdat
 var ErrDecodeEdnsOptionTooShort = errors.New("edns, not enough data to decode option answer")
 var ErrDecodeEdnsOptionCsubnetBadFamily = errors.New("edns, csubnet option bad family")
 var ErrDecodeEdnsTooManyOpts = errors.New("edns, packet contained too many OPT RRs")
+var ErrDecodeEdnsOptionBadLength = errors.New("edns, option with invalid length")
 
 var (
 	OptCodes = map[int]string{
-		3: "NSID", 8: "CSUBNET", 9: "EXPIRE", 10: "COOKIE", 11: "KEEPALIVE", 12: "PADDING", 15: "ERRORS",
+		3: "NSID", 7: "CHAIN", 8: "CSUBNET", 9: "EXPIRE", 10: "COOKIE", 11: "KEEPALIVE", 12: "PADDING", 15: "ERRORS",
+		18: "REPORT-CHANNEL", 19: "ZONEVERSION",
+	}
+	ZoneVersionTypes = map[int]string{
+		0: "SOA-SERIAL",
 	}
 	ErrorCodeToString = map[int]string{
 		0:  "Other",
@@ -136,10 +143,19 @@ func DecodeEDNS(arcount int, startOffset int, payload []byte) (DNSExtended, int,
 				}
 
 				optName := OptCodeToString(optCode)
-				optString, err := ParseOption(optName, payload[offsetNext+4:offsetNext+4+optLength])
-				if err != nil {
+				optData := payload[offsetNext+4 : offsetNext+4+optLength]
+				optString, err := ParseOption(optName, optData)
+				switch {
+				case err == nil:
+					edns.setTypedOption(optName, optString, optData)
+				case optName == "ERRORS" || optName == "CSUBNET":
 					return edns, offset, err
+				default:
+					// the other options are decoded for information only,
+					// an unexpected value doesn't invalidate the packet
+					optString = rawOptionValue(optData)
 				}
+
 				// create option
 				o := DNSOption{
 					Code: optCode,
@@ -176,6 +192,20 @@ func ParseOption(optName string, optData []byte) (string, error) {
 		ret, err = ParseErrors(optData)
 	case "CSUBNET":
 		ret, err = ParseCsubnet(optData)
+	case "NSID":
+		ret, err = ParseNSID(optData)
+	case "COOKIE":
+		ret, err = ParseCookie(optData)
+	case "PADDING":
+		ret, err = strconv.Itoa(len(optData)), nil
+	case "KEEPALIVE":
+		ret, err = ParseKeepalive(optData)
+	case "EXPIRE":
+		ret, err = ParseExpire(optData)
+	case "CHAIN", "REPORT-CHANNEL":
+		ret, err = ParseOptionDomain(optData)
+	case "ZONEVERSION":
+		ret, err = ParseZoneVersion(optData)
 	default:
 		ret = "-"
 		err = nil
@@ -253,3 +283,195 @@ func ParseCsubnet(d []byte) (string, error) {
 		return "-", ErrDecodeEdnsOptionCsubnetBadFamily
 	}
 }
+
+// setTypedOption exposes the value of the option as a dedicated field,
+// the option data is already validated by ParseOption
+func (edns *DNSExtended) setTypedOption(optName, optString string, optData []byte) {
+	switch optName {
+	case "NSID":
+		edns.NSID = hex.EncodeToString(optData)
+		if isPrintable(optData) {
+			edns.NSID = string(optData)
+		}
+	case "COOKIE":
+		if len(optData) <= 8 {
+			edns.ClientCookie = hex.EncodeToString(optData)
+		} else {
+			edns.ClientCookie = hex.EncodeToString(optData[:8])
+			edns.ServerCookie = hex.EncodeToString(optData[8:])
+		}
+	case "PADDING":
+		edns.Padding = len(optData)
+	case "KEEPALIVE":
+		if len(optData) == 2 {
+			edns.KeepaliveTimeout = int(binary.BigEndian.Uint16(optData)) * 100
+		}
+	case "EXPIRE":
+		if len(optData) == 4 {
+			edns.Expire = int(binary.BigEndian.Uint32(optData))
+		}
+	case "CHAIN":
+		edns.Chain = optString
+	case "REPORT-CHANNEL":
+		edns.ReportChannel = optString
+	case "ZONEVERSION":
+		if len(optData) > 0 {
+			edns.ZoneVersion = optString
+		}
+	}
+}
+
+// rawOptionValue is the value of an option which can't be decoded
+func rawOptionValue(d []byte) string {
+	if len(d) == 0 {
+		return "-"
+	}
+	return hex.EncodeToString(d)
+}
+
+func isPrintable(d []byte) bool {
+	if len(d) == 0 {
+		return false
+	}
+	for _, c := range d {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+https://datatracker.ietf.org/doc/html/rfc5001
+
+The NSID is an opaque identifier, empty in queries. The value is
+returned in hexadecimal followed by the text when it is printable.
+*/
+func ParseNSID(d []byte) (string, error) {
+	if len(d) == 0 {
+		return "-", nil
+	}
+	nsid := hex.EncodeToString(d)
+	if isPrintable(d) {
+		nsid = fmt.Sprintf("%s (%s)", nsid, d)
+	}
+	return nsid, nil
+}
+
+/*
+https://datatracker.ietf.org/doc/html/rfc7873
+
+Cookie EDNS0 option format
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+/                 Client Cookie (8 bytes)                       /
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+/            Server Cookie (8 to 32 bytes, optional)            /
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+*/
+func ParseCookie(d []byte) (string, error) {
+	if len(d) == 0 {
+		return "", ErrDecodeEdnsOptionTooShort
+	}
+	if len(d) > 40 {
+		return "", ErrDecodeEdnsOptionBadLength
+	}
+	// a client cookie shorter than 8 bytes is malformed but still reported
+	if len(d) <= 8 {
+		return hex.EncodeToString(d), nil
+	}
+	return hex.EncodeToString(d[:8]) + " " + hex.EncodeToString(d[8:]), nil
+}
+
+/*
+https://datatracker.ietf.org/doc/html/rfc7828
+
+edns-tcp-keepalive EDNS0 option format, the timeout is
+empty in queries and expressed in units of 100 milliseconds
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+|                           TIMEOUT                             |
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+*/
+func ParseKeepalive(d []byte) (string, error) {
+	switch len(d) {
+	case 0:
+		return "-", nil
+	case 2:
+		timeout := int(binary.BigEndian.Uint16(d)) * 100
+		return fmt.Sprintf("%dms", timeout), nil
+	default:
+		return "", ErrDecodeEdnsOptionBadLength
+	}
+}
+
+/*
+https://datatracker.ietf.org/doc/html/rfc7314
+
+EDNS EXPIRE option format, empty in queries
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+|                            EXPIRE                             |
+|                                                               |
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+*/
+func ParseExpire(d []byte) (string, error) {
+	switch len(d) {
+	case 0:
+		return "-", nil
+	case 4:
+		return strconv.FormatUint(uint64(binary.BigEndian.Uint32(d)), 10), nil
+	default:
+		return "", ErrDecodeEdnsOptionBadLength
+	}
+}
+
+/*
+https://datatracker.ietf.org/doc/html/rfc7901
+https://datatracker.ietf.org/doc/html/rfc9567
+
+The CHAIN (closest trust point) and Report-Channel (agent domain)
+options contain a single uncompressed domain name
+*/
+func ParseOptionDomain(d []byte) (string, error) {
+	if len(d) == 0 {
+		return "", ErrDecodeEdnsOptionTooShort
+	}
+	name, _, err := ParseLabels(0, d)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return ".", nil
+	}
+	return name, nil
+}
+
+/*
+https://datatracker.ietf.org/doc/html/rfc9660
+
+ZONEVERSION EDNS0 option format, empty in queries
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+|      LABELCOUNT               |            TYPE               |
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+|                           VERSION                             |
+/                                                               /
++---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+---+
+*/
+func ParseZoneVersion(d []byte) (string, error) {
+	if len(d) == 0 {
+		return "-", nil
+	}
+	if len(d) < 2 {
+		return "", ErrDecodeEdnsOptionTooShort
+	}
+	labelCount := int(d[0])
+	versionType := int(d[1])
+	version := d[2:]
+
+	if versionType == 0 {
+		if len(version) != 4 {
+			return "", ErrDecodeEdnsOptionBadLength
+		}
+		serial := binary.BigEndian.Uint32(version)
+		return fmt.Sprintf("%d %s %d", labelCount, ZoneVersionTypes[versionType], serial), nil
+	}
+	return fmt.Sprintf("%d %d %s", labelCount, versionType, hex.EncodeToString(version)), nil
+}
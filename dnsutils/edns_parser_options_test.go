@@ -0,0 +1,174 @@
+package dnsutils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestDecodeEdns_Options(t *testing.T) {
+	testcases := []struct {
+		name   string
+		option dns.EDNS0
+		want   DNSOption
+		check  func(edns DNSExtended) bool
+	}{
+		{
+			name:   "nsid",
+			option: &dns.EDNS0_NSID{Code: dns.EDNS0NSID, Nsid: "6e73312e6c6f63616c"},
+			want:   DNSOption{Code: 3, Name: "NSID", Data: "6e73312e6c6f63616c (ns1.local)"},
+			check:  func(edns DNSExtended) bool { return edns.NSID == "ns1.local" },
+		},
+		{
+			name:   "nsid_binary",
+			option: &dns.EDNS0_NSID{Code: dns.EDNS0NSID, Nsid: "00ff"},
+			want:   DNSOption{Code: 3, Name: "NSID", Data: "00ff"},
+			check:  func(edns DNSExtended) bool { return edns.NSID == "00ff" },
+		},
+		{
+			name:   "cookie_client",
+			option: &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"},
+			want:   DNSOption{Code: 10, Name: "COOKIE", Data: "0102030405060708"},
+			check: func(edns DNSExtended) bool {
+				return edns.ClientCookie == "0102030405060708" && edns.ServerCookie == ""
+			},
+		},
+		{
+			name:   "cookie_server",
+			option: &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708a1a2a3a4a5a6a7a8"},
+			want:   DNSOption{Code: 10, Name: "COOKIE", Data: "0102030405060708 a1a2a3a4a5a6a7a8"},
+			check: func(edns DNSExtended) bool {
+				return edns.ClientCookie == "0102030405060708" && edns.ServerCookie == "a1a2a3a4a5a6a7a8"
+			},
+		},
+		{
+			name:   "padding",
+			option: &dns.EDNS0_PADDING{Padding: make([]byte, 64)},
+			want:   DNSOption{Code: 12, Name: "PADDING", Data: "64"},
+			check:  func(edns DNSExtended) bool { return edns.Padding == 64 },
+		},
+		{
+			name:   "keepalive",
+			option: &dns.EDNS0_TCP_KEEPALIVE{Code: dns.EDNS0TCPKEEPALIVE, Timeout: 300},
+			want:   DNSOption{Code: 11, Name: "KEEPALIVE", Data: "30000ms"},
+			check:  func(edns DNSExtended) bool { return edns.KeepaliveTimeout == 30000 },
+		},
+		{
+			name:   "expire",
+			option: &dns.EDNS0_EXPIRE{Code: dns.EDNS0EXPIRE, Expire: 604800},
+			want:   DNSOption{Code: 9, Name: "EXPIRE", Data: "604800"},
+			check:  func(edns DNSExtended) bool { return edns.Expire == 604800 },
+		},
+		{
+			name:   "expire_query",
+			option: &dns.EDNS0_EXPIRE{Code: dns.EDNS0EXPIRE, Empty: true},
+			want:   DNSOption{Code: 9, Name: "EXPIRE", Data: "-"},
+			check:  func(edns DNSExtended) bool { return edns.Expire == 0 },
+		},
+		{
+			name:   "chain",
+			option: &dns.EDNS0_LOCAL{Code: 7, Data: []byte{0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 0x00}},
+			want:   DNSOption{Code: 7, Name: "CHAIN", Data: "example"},
+			check:  func(edns DNSExtended) bool { return edns.Chain == "example" },
+		},
+		{
+			name:   "chain_root",
+			option: &dns.EDNS0_LOCAL{Code: 7, Data: []byte{0x00}},
+			want:   DNSOption{Code: 7, Name: "CHAIN", Data: "."},
+			check:  func(edns DNSExtended) bool { return edns.Chain == "." },
+		},
+		{
+			name: "report_channel",
+			option: &dns.EDNS0_LOCAL{Code: 18, Data: []byte{0x05, 'a', 'g', 'e', 'n', 't',
+				0x07, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 0x00}},
+			want:  DNSOption{Code: 18, Name: "REPORT-CHANNEL", Data: "agent.example"},
+			check: func(edns DNSExtended) bool { return edns.ReportChannel == "agent.example" },
+		},
+		{
+			name:   "zoneversion_serial",
+			option: &dns.EDNS0_LOCAL{Code: 19, Data: []byte{0x02, 0x00, 0x78, 0x49, 0x04, 0x33}},
+			want:   DNSOption{Code: 19, Name: "ZONEVERSION", Data: "2 SOA-SERIAL 2018051123"},
+			check:  func(edns DNSExtended) bool { return edns.ZoneVersion == "2 SOA-SERIAL 2018051123" },
+		},
+		{
+			name:   "zoneversion_query",
+			option: &dns.EDNS0_LOCAL{Code: 19, Data: []byte{}},
+			want:   DNSOption{Code: 19, Name: "ZONEVERSION", Data: "-"},
+			check:  func(edns DNSExtended) bool { return edns.ZoneVersion == "" },
+		},
+		{
+			name:   "cookie_invalid",
+			option: &dns.EDNS0_LOCAL{Code: dns.EDNS0COOKIE, Data: []byte{}},
+			want:   DNSOption{Code: 10, Name: "COOKIE", Data: "-"},
+			check:  func(edns DNSExtended) bool { return edns.ClientCookie == "" },
+		},
+		{
+			name:   "keepalive_invalid",
+			option: &dns.EDNS0_LOCAL{Code: dns.EDNS0TCPKEEPALIVE, Data: []byte{0x01}},
+			want:   DNSOption{Code: 11, Name: "KEEPALIVE", Data: "01"},
+			check:  func(edns DNSExtended) bool { return edns.KeepaliveTimeout == 0 },
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dm := new(dns.Msg)
+			dm.SetQuestion("dnstapcollector.test.", dns.TypeA)
+
+			e := &dns.OPT{}
+			e.Hdr.Name = "."
+			e.Hdr.Rrtype = dns.TypeOPT
+			e.Option = append(e.Option, tc.option)
+			dm.Extra = append(dm.Extra, e)
+
+			payload, err := dm.Pack()
+			if err != nil {
+				t.Fatalf("unable to pack the message: %v", err)
+			}
+
+			_, _, _, offsetRR, _ := DecodeQuestion(1, payload)
+			edns, _, err := DecodeEDNS(len(dm.Extra), offsetRR, payload)
+			if err != nil {
+				t.Fatalf("edns error returned: %v", err)
+			}
+			if len(edns.Options) != 1 {
+				t.Fatalf("expected one edns option to be parsed, got %d", len(edns.Options))
+			}
+			if edns.Options[0] != tc.want {
+				t.Errorf("bad edns option, expected %v, got %v", tc.want, edns.Options[0])
+			}
+			if !tc.check(edns) {
+				t.Errorf("invalid typed option: %+v", edns)
+			}
+		})
+	}
+}
+
+func TestDecodeEdns_Options_Invalid(t *testing.T) {
+	testcases := []struct {
+		name    string
+		optName string
+		data    []byte
+		err     error
+	}{
+		{"cookie_empty", "COOKIE", []byte{}, ErrDecodeEdnsOptionTooShort},
+		{"cookie_too_long", "COOKIE", make([]byte, 41), ErrDecodeEdnsOptionBadLength},
+		{"keepalive", "KEEPALIVE", []byte{0x01}, ErrDecodeEdnsOptionBadLength},
+		{"expire", "EXPIRE", []byte{0x00, 0x00, 0x01}, ErrDecodeEdnsOptionBadLength},
+		{"chain_empty", "CHAIN", []byte{}, ErrDecodeEdnsOptionTooShort},
+		{"chain_label", "CHAIN", []byte{0x07, 'e', 'x'}, ErrDecodeDNSLabelTooShort},
+		{"report_channel_empty", "REPORT-CHANNEL", []byte{}, ErrDecodeEdnsOptionTooShort},
+		{"zoneversion_short", "ZONEVERSION", []byte{0x02}, ErrDecodeEdnsOptionTooShort},
+		{"zoneversion_serial", "ZONEVERSION", []byte{0x02, 0x00, 0x01}, ErrDecodeEdnsOptionBadLength},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseOption(tc.optName, tc.data)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("bad error returned, want %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package dnsutils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/flosch/pongo2"
+)
+
+// EncoderOptions contains the settings of the logger used by the encoders
+type EncoderOptions struct {
+	TextFormat      []string
+	FieldDelimiter  string
+	FieldBoundary   string
+	JinjaFormat     string
+	ExtendedSupport bool
+}
+
+// Encoder converts a dns message to the output format of a logger,
+// the encoded message is returned without any trailing delimiter
+type Encoder interface {
+	Encode(dm *DNSMessage) ([]byte, error)
+	// IsBinary returns true if the encoded messages are not printable text
+	IsBinary() bool
+}
+
+// EncoderFactory creates an encoder according to the options of the logger
+type EncoderFactory func(opts EncoderOptions) (Encoder, error)
+
+var (
+	encoders   = map[string]EncoderFactory{}
+	encodersMu sync.RWMutex
+)
+
+func init() {
+	RegisterEncoder(pkgconfig.ModeText, newTextEncoder)
+	RegisterEncoder(pkgconfig.ModeJinja, newJinjaEncoder)
+	RegisterEncoder(pkgconfig.ModeJSON, newJSONEncoder)
+	RegisterEncoder(pkgconfig.ModeFlatJSON, newFlatJSONEncoder)
+	RegisterEncoder(pkgconfig.ModeCSV, newCSVEncoder)
+	RegisterEncoder(pkgconfig.ModeDNSTap, newDNSTapEncoder)
+	RegisterEncoder(pkgconfig.ModeProtobuf, newProtobufEncoder)
+	RegisterEncoder(pkgconfig.ModeMsgpack, newMsgpackEncoder)
+	RegisterEncoder(pkgconfig.ModeCBOR, newCBOREncoder)
+}
+
+// RegisterEncoder makes an output format available as mode for all loggers
+func RegisterEncoder(mode string, factory EncoderFactory) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[mode] = factory
+	pkgconfig.RegisterMode(mode)
+}
+
+// NewEncoder returns the encoder registered for the mode
+func NewEncoder(mode string, opts EncoderOptions) (Encoder, error) {
+	encodersMu.RLock()
+	factory, found := encoders[mode]
+	encodersMu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unsupported mode: %s", mode)
+	}
+	return factory(opts)
+}
+
+// GetEncoderModes returns the sorted list of registered modes
+func GetEncoderModes() []string {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	modes := make([]string, 0, len(encoders))
+	for mode := range encoders {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// text
+type textEncoder struct {
+	opts EncoderOptions
+}
+
+func newTextEncoder(opts EncoderOptions) (Encoder, error) {
+	return &textEncoder{opts: opts}, nil
+}
+
+func (e *textEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	return dm.ToTextLine(e.opts.TextFormat, e.opts.FieldDelimiter, e.opts.FieldBoundary)
+}
+
+func (e *textEncoder) IsBinary() bool { return false }
+
+// jinja, the template is compiled once
+type jinjaEncoder struct {
+	template *pongo2.Template
+}
+
+func newJinjaEncoder(opts EncoderOptions) (Encoder, error) {
+	tmpl, err := pongo2.FromString(opts.JinjaFormat)
+	if err != nil {
+		return nil, err
+	}
+	return &jinjaEncoder{template: tmpl}, nil
+}
+
+func (e *jinjaEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	return e.template.ExecuteBytes(pongo2.Context{"dm": dm})
+}
+
+func (e *jinjaEncoder) IsBinary() bool { return false }
+
+// json
+type jsonEncoder struct{}
+
+func newJSONEncoder(opts EncoderOptions) (Encoder, error) {
+	return &jsonEncoder{}, nil
+}
+
+func (e *jsonEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	return json.Marshal(dm)
+}
+
+func (e *jsonEncoder) IsBinary() bool { return false }
+
+// flat json
+type flatJSONEncoder struct{}
+
+func newFlatJSONEncoder(opts EncoderOptions) (Encoder, error) {
+	return &flatJSONEncoder{}, nil
+}
+
+func (e *flatJSONEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	flat, err := dm.Flatten()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(flat)
+}
+
+func (e *flatJSONEncoder) IsBinary() bool { return false }
+
+// csv, one column per directive of the text format
+type csvEncoder struct {
+	format []string
+}
+
+func newCSVEncoder(opts EncoderOptions) (Encoder, error) {
+	return &csvEncoder{format: opts.TextFormat}, nil
+}
+
+func (e *csvEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	record := make([]string, 0, len(e.format))
+	for _, directive := range e.format {
+		field, err := dm.ToTextLine([]string{directive}, "", "")
+		if err != nil {
+			return nil, err
+		}
+		record = append(record, string(field))
+	}
+
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	if err := writer.Write(record); err != nil {
+		return nil, err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+func (e *csvEncoder) IsBinary() bool { return false }
+
+// dnstap protobuf
+type dnstapEncoder struct {
+	extended bool
+}
+
+func newDNSTapEncoder(opts EncoderOptions) (Encoder, error) {
+	return &dnstapEncoder{extended: opts.ExtendedSupport}, nil
+}
+
+func (e *dnstapEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	return dm.ToDNSTap(e.extended)
+}
+
+func (e *dnstapEncoder) IsBinary() bool { return true }
+
+// powerdns protobuf
+type protobufEncoder struct{}
+
+func newProtobufEncoder(opts EncoderOptions) (Encoder, error) {
+	return &protobufEncoder{}, nil
+}
+
+func (e *protobufEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	return dm.ToPowerDNS()
+}
+
+func (e *protobufEncoder) IsBinary() bool { return true }
+
+// msgpack, generated codec
+type msgpackEncoder struct{}
+
+func newMsgpackEncoder(opts EncoderOptions) (Encoder, error) {
+	return &msgpackEncoder{}, nil
+}
+
+func (e *msgpackEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	return dm.ToMsgpack()
+}
+
+func (e *msgpackEncoder) IsBinary() bool { return true }
+
+// cbor
+type cborEncoder struct{}
+
+func newCBOREncoder(opts EncoderOptions) (Encoder, error) {
+	return &cborEncoder{}, nil
+}
+
+func (e *cborEncoder) Encode(dm *DNSMessage) ([]byte, error) {
+	return dm.ToCBOR()
+}
+
+func (e *cborEncoder) IsBinary() bool { return true }
@@ -0,0 +1,122 @@
+package dnsutils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+)
+
+func TestEncoder_Registry(t *testing.T) {
+	for _, mode := range GetEncoderModes() {
+		if !pkgconfig.IsValidMode(mode) {
+			t.Errorf("mode %s registered but not valid", mode)
+		}
+		if _, err := NewEncoder(mode, EncoderOptions{TextFormat: []string{"qname"}}); err != nil {
+			t.Errorf("mode %s: %v", mode, err)
+		}
+	}
+
+	if _, err := NewEncoder("invalid", EncoderOptions{}); err == nil {
+		t.Errorf("error expected for unsupported mode")
+	}
+	if pkgconfig.IsValidMode("invalid") {
+		t.Errorf("invalid mode should not be valid")
+	}
+}
+
+func TestEncoder_Text(t *testing.T) {
+	dm := GetFakeDNSMessage()
+
+	enc, _ := NewEncoder(pkgconfig.ModeText, EncoderOptions{TextFormat: []string{"identity", "qname"}, FieldDelimiter: " "})
+	data, err := enc.Encode(&dm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc.IsBinary() || string(data) != "collector dns.collector" {
+		t.Errorf("unexpected text line: %s", data)
+	}
+}
+
+func TestEncoder_Jinja(t *testing.T) {
+	dm := GetFakeDNSMessage()
+
+	enc, err := NewEncoder(pkgconfig.ModeJinja, EncoderOptions{JinjaFormat: "{{ dm.DNS.Qname }}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := enc.Encode(&dm)
+	if string(data) != "dns.collector" {
+		t.Errorf("unexpected jinja output: %s", data)
+	}
+
+	if _, err := NewEncoder(pkgconfig.ModeJinja, EncoderOptions{JinjaFormat: "{{ dm"}); err == nil {
+		t.Errorf("error expected for invalid template")
+	}
+}
+
+func TestEncoder_CSV(t *testing.T) {
+	dm := GetFakeDNSMessage()
+	dm.DNS.Qname = "dns,collector"
+
+	enc, _ := NewEncoder(pkgconfig.ModeCSV, EncoderOptions{TextFormat: []string{"identity", "qname", "qtype"}})
+	data, err := enc.Encode(&dm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "collector,\"dns,collector\",A" {
+		t.Errorf("unexpected csv record: %s", data)
+	}
+}
+
+func TestEncoder_FlatJSON(t *testing.T) {
+	dm := GetFakeDNSMessage()
+
+	enc, _ := NewEncoder(pkgconfig.ModeFlatJSON, EncoderOptions{})
+	data, err := enc.Encode(&dm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flat := map[string]interface{}{}
+	if err := json.Unmarshal(data, &flat); err != nil {
+		t.Fatal(err)
+	}
+	if flat["dns.qname"] != "dns.collector" {
+		t.Errorf("unexpected qname: %v", flat["dns.qname"])
+	}
+}
+
+func TestEncoder_Msgpack(t *testing.T) {
+	dm := GetFakeDNSMessage()
+
+	enc, _ := NewEncoder(pkgconfig.ModeMsgpack, EncoderOptions{})
+	data, err := enc.Encode(&dm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enc.IsBinary() {
+		t.Errorf("msgpack should be binary")
+	}
+
+	decoded := DNSMessage{}
+	if _, err := decoded.UnmarshalMsg(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.DNS.Qname != "dns.collector" {
+		t.Errorf("unexpected qname: %v", decoded.DNS.Qname)
+	}
+}
+
+func TestEncoder_DNSTap(t *testing.T) {
+	dm := GetFakeDNSMessageWithPayload()
+
+	enc, _ := NewEncoder(pkgconfig.ModeDNSTap, EncoderOptions{})
+	data, err := enc.Encode(&dm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enc.IsBinary() || len(data) == 0 {
+		t.Errorf("dnstap encoding expected")
+	}
+}
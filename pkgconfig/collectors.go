@@ -40,15 +40,20 @@ type ConfigCollectors struct {
 		Compression       string `yaml:"compression" default:"none"`
 	} `yaml:"dnstap"`
 	DnstapProxifier struct {
-		Enable            bool   `yaml:"enable" default:"false"`
-		ListenIP          string `yaml:"listen-ip" default:"0.0.0.0"`
-		ListenPort        int    `yaml:"listen-port" default:"6000"`
-		SockPath          string `yaml:"sock-path" default:""`
-		TLSSupport        bool   `yaml:"tls-support" default:"false"`
-		TLSMinVersion     string `yaml:"tls-min-version" default:"1.2"`
-		CertFile          string `yaml:"cert-file" default:""`
-		KeyFile           string `yaml:"key-file" default:""`
-		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
+		Enable            bool     `yaml:"enable" default:"false"`
+		ListenIP          string   `yaml:"listen-ip" default:"0.0.0.0"`
+		ListenPort        int      `yaml:"listen-port" default:"6000"`
+		SockPath          string   `yaml:"sock-path" default:""`
+		TLSSupport        bool     `yaml:"tls-support" default:"false"`
+		TLSMinVersion     string   `yaml:"tls-min-version" default:"1.2"`
+		CertFile          string   `yaml:"cert-file" default:""`
+		KeyFile           string   `yaml:"key-file" default:""`
+		ChannelBufferSize int      `yaml:"chan-buffer-size" default:"0"`
+		KeepMessageTypes  []string `yaml:"keep-message-types,flow" default:"[]"`
+		DropMessageTypes  []string `yaml:"drop-message-types,flow" default:"[]"`
+		Identity          string   `yaml:"identity" default:""`
+		OverwriteIdentity bool     `yaml:"overwrite-identity" default:"false"`
+		SampleRate        int      `yaml:"sample-rate" default:"1"`
 	} `yaml:"dnstap-relay"`
 	AfpacketLiveCapture struct {
 		Enable            bool   `yaml:"enable" default:"false"`
@@ -58,6 +63,11 @@ type ConfigCollectors struct {
 		FragmentSupport   bool   `yaml:"enable-defrag-ip" default:"true"`
 		GreSupport        bool   `yaml:"enable-gre" default:"false"`
 		RawIPSupport      bool   `yaml:"enable-rawip" default:"false"`
+		Ports             []int  `yaml:"ports,flow" default:"[]"`
+		EncryptedSupport  bool   `yaml:"enable-encrypted" default:"false"`
+		DoTPorts          []int  `yaml:"dot-ports,flow" default:"[853]"`
+		DoHPorts          []int  `yaml:"doh-ports,flow" default:"[]"`
+		SessionTimeout    int    `yaml:"session-timeout" default:"60"`
 	} `yaml:"afpacket-sniffer"`
 	XdpLiveCapture struct {
 		Enable            bool   `yaml:"enable" default:"false"`
@@ -102,6 +112,44 @@ type ConfigCollectors struct {
 		NumThreads        int    `yaml:"num-threads" default:"1"`
 		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
 	} `yaml:"webhook"`
+	KafkaConsumer struct {
+		Enable            bool     `yaml:"enable" default:"false"`
+		RemoteAddress     string   `yaml:"remote-address" default:"127.0.0.1"`
+		RemotePort        int      `yaml:"remote-port" default:"9092"`
+		TLSSupport        bool     `yaml:"tls-support" default:"false"`
+		TLSInsecure       bool     `yaml:"tls-insecure" default:"false"`
+		TLSMinVersion     string   `yaml:"tls-min-version" default:"1.2"`
+		CAFile            string   `yaml:"ca-file" default:""`
+		CertFile          string   `yaml:"cert-file" default:""`
+		KeyFile           string   `yaml:"key-file" default:""`
+		SaslSupport       bool     `yaml:"sasl-support" default:"false"`
+		SaslUsername      string   `yaml:"sasl-username" default:""`
+		SaslPassword      string   `yaml:"sasl-password" default:""`
+		SaslMechanism     string   `yaml:"sasl-mechanism" default:"PLAIN"`
+		Mode              string   `yaml:"mode" default:"dnstap"`
+		ConnectTimeout    int      `yaml:"connect-timeout" default:"5"`
+		Topics            []string `yaml:"topics,flow" default:"[\"dnscollector\"]"`
+		GroupID           string   `yaml:"group-id" default:"dnscollector"`
+		StartOffset       string   `yaml:"start-offset" default:"latest"`
+		CommitInterval    int      `yaml:"commit-interval" default:"1000"`
+		ExtendedSupport   bool     `yaml:"extended-support" default:"false"`
+		DisableDNSParser  bool     `yaml:"disable-dnsparser" default:"false"`
+		ChannelBufferSize int      `yaml:"chan-buffer-size" default:"0"`
+	} `yaml:"kafkaconsumer"`
+	OtlpReceiver struct {
+		Enable            bool   `yaml:"enable" default:"false"`
+		ListenIP          string `yaml:"listen-ip" default:"0.0.0.0"`
+		GRPCPort          int    `yaml:"grpc-port" default:"4317"`
+		HTTPPort          int    `yaml:"http-port" default:"4318"`
+		TLSSupport        bool   `yaml:"tls-support" default:"false"`
+		TLSMinVersion     string `yaml:"tls-min-version" default:"1.2"`
+		CertFile          string `yaml:"cert-file" default:""`
+		KeyFile           string `yaml:"key-file" default:""`
+		PayloadAttribute  string `yaml:"payload-attribute" default:"dns.payload"`
+		MaxRequestSize    int    `yaml:"max-request-size" default:"4194304"`
+		DisableDNSParser  bool   `yaml:"disable-dnsparser" default:"false"`
+		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
+	} `yaml:"otlp"`
 }
 
 func (c *ConfigCollectors) SetDefault() {
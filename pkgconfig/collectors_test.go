@@ -21,4 +21,16 @@ func TestConfigCollectorsSetDefault(t *testing.T) {
 	if config.AfpacketLiveCapture.Enable != false {
 		t.Errorf("sniffer afpacket should be disabled")
 	}
+	if len(config.AfpacketLiveCapture.DoHPorts) != 0 {
+		t.Errorf("doh ports should be empty by default: %v", config.AfpacketLiveCapture.DoHPorts)
+	}
+	if config.KafkaConsumer.Enable != false {
+		t.Errorf("kafka consumer should be disabled")
+	}
+	if len(config.KafkaConsumer.Topics) != 1 || config.KafkaConsumer.Topics[0] != "dnscollector" {
+		t.Errorf("invalid default kafka consumer topics: %v", config.KafkaConsumer.Topics)
+	}
+	if config.OtlpReceiver.GRPCPort != 4317 || config.OtlpReceiver.HTTPPort != 4318 {
+		t.Errorf("invalid default otlp receiver ports")
+	}
 }
@@ -5,20 +5,29 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 )
 
+var (
+	validModes   = map[string]bool{}
+	validModesMu sync.RWMutex
+)
+
+// RegisterMode declares an output mode, called by the encoders registry of dnsutils
+func RegisterMode(mode string) {
+	validModesMu.Lock()
+	defer validModesMu.Unlock()
+	validModes[mode] = true
+}
+
+// IsValidMode returns true if an encoder is registered for this output mode
 func IsValidMode(mode string) bool {
-	switch mode {
-	case
-		ModeText,
-		ModeJSON,
-		ModeFlatJSON:
-		return true
-	}
-	return false
+	validModesMu.RLock()
+	defer validModesMu.RUnlock()
+	return validModes[mode]
 }
 
 type Config struct {
@@ -130,6 +130,39 @@ pipelines:
   - name: console
     stdout:
       mode: text
+`,
+			wantErr: false,
+		},
+		{
+			name: "Valid pipeline with conditional routes",
+			content: `
+pipelines:
+  - name: dnsdist-main
+    dnstap:
+      listen-ip: 0.0.0.0
+      listen-port: 6000
+    routing-policy:
+      routes:
+        - matching:
+            include:
+              dns.rcode: NXDOMAIN
+          forward: [ kafka-nx ]
+        - matching:
+            include:
+              suspicious.score:
+                greater-than: 2.0
+          forward: [ siem ]
+      default: [ console ]
+
+  - name: console
+    stdout:
+      mode: text
+  - name: kafka-nx
+    stdout:
+      mode: json
+  - name: siem
+    stdout:
+      mode: flat-json
 `,
 			wantErr: false,
 		},
@@ -24,15 +24,43 @@ const (
 	ModeFlatJSON = "flat-json"
 	ModePCAP     = "pcap"
 	ModeDNSTap   = "dnstap"
+	ModeParquet  = "parquet"
+	ModeCSV      = "csv"
+	ModeProtobuf = "protobuf"
+	ModeCBOR     = "cbor"
+	ModeMsgpack  = "msgpack"
+
+	OtlpProtocolGRPC = "grpc"
+	OtlpProtocolHTTP = "http"
+
+	PromMetricCounter   = "counter"
+	PromMetricHistogram = "histogram"
 
 	SASLMechanismPlain = "PLAIN"
 	SASLMechanismScram = "SCRAM-SHA-512"
 
+	KafkaOffsetEarliest = "earliest"
+	KafkaOffsetLatest   = "latest"
+
 	CompressGzip   = "gzip"
 	CompressSnappy = "snappy"
 	CompressLz4    = "lz4"
 	CompressZstd   = "ztd"
 	CompressNone   = "none"
+
+	LoadBalancingFailover       = "failover"
+	LoadBalancingRoundRobin     = "round-robin"
+	LoadBalancingConsistentHash = "consistent-hash"
+
+	HashKeyIdentity = "identity"
+	HashKeyClientIP = "client-ip"
+
+	SpoolFsyncAlways   = "always"
+	SpoolFsyncInterval = "interval"
+	SpoolFsyncNever    = "never"
+
+	ClickhouseJSONEachRow = "JSONEachRow"
+	ClickhouseRowBinary   = "RowBinary"
 )
 
 var (
@@ -40,6 +68,7 @@ var (
 	PrefixLogTransformer  = "transformer - "
 	DefaultBufferSize     = 512
 	DefaultBufferOne      = 1
+	DefaultMaxCardinality = 10000
 	DefaultMonitor        = true
 	WorkerMonitorDisabled = false
 
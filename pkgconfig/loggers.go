@@ -7,6 +7,40 @@ import (
 	"github.com/prometheus/prometheus/model/relabel"
 )
 
+// ConfigSpool is the disk-backed queue shared by the loggers with a remote
+// backend, messages are written to segment files while the remote is down.
+type ConfigSpool struct {
+	Enable        bool   `yaml:"enable" default:"false"`
+	Directory     string `yaml:"directory" default:"/var/spool/dnscollector"`
+	SegmentSize   int    `yaml:"segment-size" default:"10485760"`
+	MaxSize       int    `yaml:"max-size" default:"1073741824"`
+	FsyncPolicy   string `yaml:"fsync-policy" default:"interval"`
+	FsyncInterval int    `yaml:"fsync-interval" default:"1"`
+}
+
+// ConfigClickhouseColumn maps a flat-json field of the dns message to a column
+type ConfigClickhouseColumn struct {
+	Name  string `yaml:"name"`
+	Field string `yaml:"field"`
+	Type  string `yaml:"type"`
+}
+
+// ConfigPrometheusMetric describes a user-defined metric derived from the dns messages,
+// labels and value are json paths of the dns message
+type ConfigPrometheusMetric struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Help     string `yaml:"help"`
+	Matching struct {
+		Include map[string]interface{} `yaml:"include"`
+		Exclude map[string]interface{} `yaml:"exclude"`
+	} `yaml:"matching"`
+	Labels         []string  `yaml:"labels,flow"`
+	Value          string    `yaml:"value"`
+	Buckets        []float64 `yaml:"buckets,flow"`
+	MaxCardinality int       `yaml:"max-cardinality"`
+}
+
 type ConfigLoggers struct {
 	DevNull struct {
 		Enable            bool `yaml:"enable" default:"false"`
@@ -21,40 +55,42 @@ type ConfigLoggers struct {
 		OverwriteDNSPortPcap bool   `yaml:"overwrite-dns-port-pcap" default:"false"`
 	} `yaml:"stdout"`
 	Prometheus struct {
-		Enable                    bool     `yaml:"enable" default:"false"`
-		ListenIP                  string   `yaml:"listen-ip" default:"127.0.0.1"`
-		ListenPort                int      `yaml:"listen-port" default:"8081"`
-		TLSSupport                bool     `yaml:"tls-support" default:"false"`
-		TLSMutual                 bool     `yaml:"tls-mutual" default:"false"`
-		TLSMinVersion             string   `yaml:"tls-min-version" default:"1.2"`
-		CertFile                  string   `yaml:"cert-file" default:""`
-		KeyFile                   string   `yaml:"key-file" default:""`
-		PromPrefix                string   `yaml:"prometheus-prefix" default:"dnscollector"`
-		LabelsList                []string `yaml:"prometheus-labels" default:"[]"`
-		TopN                      int      `yaml:"top-n" default:"10"`
-		BasicAuthLogin            string   `yaml:"basic-auth-login" default:"admin"`
-		BasicAuthPwd              string   `yaml:"basic-auth-pwd" default:"changeme"`
-		BasicAuthEnabled          bool     `yaml:"basic-auth-enable" default:"true"`
-		ChannelBufferSize         int      `yaml:"chan-buffer-size" default:"0"`
-		RequestersMetricsEnabled  bool     `yaml:"requesters-metrics-enabled" default:"true"`
-		DomainsMetricsEnabled     bool     `yaml:"domains-metrics-enabled" default:"true"`
-		NoErrorMetricsEnabled     bool     `yaml:"noerror-metrics-enabled" default:"true"`
-		ServfailMetricsEnabled    bool     `yaml:"servfail-metrics-enabled" default:"true"`
-		NonExistentMetricsEnabled bool     `yaml:"nonexistent-metrics-enabled" default:"true"`
-		TimeoutMetricsEnabled     bool     `yaml:"timeout-metrics-enabled" default:"false"`
-		HistogramMetricsEnabled   bool     `yaml:"histogram-metrics-enabled" default:"false"`
-		RequestersCacheTTL        int      `yaml:"requesters-cache-ttl" default:"250000"`
-		RequestersCacheSize       int      `yaml:"requesters-cache-size" default:"3600"`
-		DomainsCacheTTL           int      `yaml:"domains-cache-ttl" default:"500000"`
-		DomainsCacheSize          int      `yaml:"domains-cache-size" default:"3600"`
-		NoErrorDomainsCacheTTL    int      `yaml:"noerror-domains-cache-ttl" default:"100000"`
-		NoErrorDomainsCacheSize   int      `yaml:"noerror-domains-cache-size" default:"3600"`
-		ServfailDomainsCacheTTL   int      `yaml:"servfail-domains-cache-ttl" default:"10000"`
-		ServfailDomainsCacheSize  int      `yaml:"servfail-domains-cache-size" default:"3600"`
-		NXDomainsCacheTTL         int      `yaml:"nonexistent-domains-cache-ttl" default:"10000"`
-		NXDomainsCacheSize        int      `yaml:"nonexistent-domains-cache-size" default:"3600"`
-		DefaultDomainsCacheTTL    int      `yaml:"default-domains-cache-ttl" default:"1000"`
-		DefaultDomainsCacheSize   int      `yaml:"default-domains-cache-size" default:"3600"`
+		Enable                    bool                     `yaml:"enable" default:"false"`
+		ListenIP                  string                   `yaml:"listen-ip" default:"127.0.0.1"`
+		ListenPort                int                      `yaml:"listen-port" default:"8081"`
+		TLSSupport                bool                     `yaml:"tls-support" default:"false"`
+		TLSMutual                 bool                     `yaml:"tls-mutual" default:"false"`
+		TLSMinVersion             string                   `yaml:"tls-min-version" default:"1.2"`
+		CertFile                  string                   `yaml:"cert-file" default:""`
+		KeyFile                   string                   `yaml:"key-file" default:""`
+		PromPrefix                string                   `yaml:"prometheus-prefix" default:"dnscollector"`
+		LabelsList                []string                 `yaml:"prometheus-labels" default:"[]"`
+		TopN                      int                      `yaml:"top-n" default:"10"`
+		BasicAuthLogin            string                   `yaml:"basic-auth-login" default:"admin"`
+		BasicAuthPwd              string                   `yaml:"basic-auth-pwd" default:"changeme"`
+		BasicAuthEnabled          bool                     `yaml:"basic-auth-enable" default:"true"`
+		ChannelBufferSize         int                      `yaml:"chan-buffer-size" default:"0"`
+		RequestersMetricsEnabled  bool                     `yaml:"requesters-metrics-enabled" default:"true"`
+		DomainsMetricsEnabled     bool                     `yaml:"domains-metrics-enabled" default:"true"`
+		NoErrorMetricsEnabled     bool                     `yaml:"noerror-metrics-enabled" default:"true"`
+		ServfailMetricsEnabled    bool                     `yaml:"servfail-metrics-enabled" default:"true"`
+		NonExistentMetricsEnabled bool                     `yaml:"nonexistent-metrics-enabled" default:"true"`
+		TimeoutMetricsEnabled     bool                     `yaml:"timeout-metrics-enabled" default:"false"`
+		HistogramMetricsEnabled   bool                     `yaml:"histogram-metrics-enabled" default:"false"`
+		RequestersCacheTTL        int                      `yaml:"requesters-cache-ttl" default:"250000"`
+		RequestersCacheSize       int                      `yaml:"requesters-cache-size" default:"3600"`
+		DomainsCacheTTL           int                      `yaml:"domains-cache-ttl" default:"500000"`
+		DomainsCacheSize          int                      `yaml:"domains-cache-size" default:"3600"`
+		NoErrorDomainsCacheTTL    int                      `yaml:"noerror-domains-cache-ttl" default:"100000"`
+		NoErrorDomainsCacheSize   int                      `yaml:"noerror-domains-cache-size" default:"3600"`
+		ServfailDomainsCacheTTL   int                      `yaml:"servfail-domains-cache-ttl" default:"10000"`
+		ServfailDomainsCacheSize  int                      `yaml:"servfail-domains-cache-size" default:"3600"`
+		NXDomainsCacheTTL         int                      `yaml:"nonexistent-domains-cache-ttl" default:"10000"`
+		NXDomainsCacheSize        int                      `yaml:"nonexistent-domains-cache-size" default:"3600"`
+		DefaultDomainsCacheTTL    int                      `yaml:"default-domains-cache-ttl" default:"1000"`
+		DefaultDomainsCacheSize   int                      `yaml:"default-domains-cache-size" default:"3600"`
+		CustomMetrics             []ConfigPrometheusMetric `yaml:"custom-metrics" default:"[]"`
+		CustomMetricsCardinality  int                      `yaml:"custom-metrics-max-cardinality" default:"1000"`
 	} `yaml:"prometheus"`
 	RestAPI struct {
 		Enable            bool   `yaml:"enable" default:"false"`
@@ -67,6 +103,12 @@ type ConfigLoggers struct {
 		CertFile          string `yaml:"cert-file" default:""`
 		KeyFile           string `yaml:"key-file" default:""`
 		TopN              int    `yaml:"top-n" default:"100"`
+		MaxCardinality    int    `yaml:"max-cardinality" default:"10000"`
+		MaxStreams        int    `yaml:"max-streams" default:"100"`
+		RingBufferSize    int    `yaml:"ring-buffer-size" default:"1000"`
+		TextFormat        string `yaml:"text-format" default:""`
+		StreamBufferSize  int    `yaml:"stream-buffer-size" default:"256"`
+		StreamMaxClients  int    `yaml:"stream-max-clients" default:"100"`
 		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
 	} `yaml:"restapi"`
 	LogFile struct {
@@ -88,15 +130,39 @@ type ConfigLoggers struct {
 		OverwriteDNSPortPcap bool   `yaml:"overwrite-dns-port-pcap" default:"false"`
 	} `yaml:"logfile"`
 	DNSTap struct {
+		Enable              bool     `yaml:"enable" default:"false"`
+		RemoteAddress       string   `yaml:"remote-address" default:"127.0.0.1"`
+		RemotePort          int      `yaml:"remote-port" default:"6000"`
+		Transport           string   `yaml:"transport" default:"tcp"`
+		SockPath            string   `yaml:"sock-path" default:""`
+		ConnectTimeout      int      `yaml:"connect-timeout" default:"5"`
+		RetryInterval       int      `yaml:"retry-interval" default:"10"`
+		FlushInterval       int      `yaml:"flush-interval" default:"30"`
+		TLSSupport          bool     `yaml:"tls-support" default:"false"`
+		TLSInsecure         bool     `yaml:"tls-insecure" default:"false"`
+		TLSMinVersion       string   `yaml:"tls-min-version" default:"1.2"`
+		CAFile              string   `yaml:"ca-file" default:""`
+		CertFile            string   `yaml:"cert-file" default:""`
+		KeyFile             string   `yaml:"key-file" default:""`
+		ServerID            string   `yaml:"server-id" default:""`
+		OverwriteIdentity   bool     `yaml:"overwrite-identity" default:"false"`
+		BufferSize          int      `yaml:"buffer-size" default:"100"`
+		ChannelBufferSize   int      `yaml:"chan-buffer-size" default:"0"`
+		ExtendedSupport     bool     `yaml:"extended-support" default:"false"`
+		Compression         string   `yaml:"compression" default:"none"`
+		Endpoints           []string `yaml:"endpoints,flow" default:"[]"`
+		LoadBalancing       string   `yaml:"load-balancing" default:"failover"`
+		HashKey             string   `yaml:"hash-key" default:"identity"`
+		HealthCheckInterval int      `yaml:"health-check-interval" default:"10"`
+	} `yaml:"dnstapclient"`
+	PowerDNSClient struct {
 		Enable            bool   `yaml:"enable" default:"false"`
 		RemoteAddress     string `yaml:"remote-address" default:"127.0.0.1"`
-		RemotePort        int    `yaml:"remote-port" default:"6000"`
+		RemotePort        int    `yaml:"remote-port" default:"6001"`
 		Transport         string `yaml:"transport" default:"tcp"`
-		SockPath          string `yaml:"sock-path" default:""`
 		ConnectTimeout    int    `yaml:"connect-timeout" default:"5"`
 		RetryInterval     int    `yaml:"retry-interval" default:"10"`
 		FlushInterval     int    `yaml:"flush-interval" default:"30"`
-		TLSSupport        bool   `yaml:"tls-support" default:"false"`
 		TLSInsecure       bool   `yaml:"tls-insecure" default:"false"`
 		TLSMinVersion     string `yaml:"tls-min-version" default:"1.2"`
 		CAFile            string `yaml:"ca-file" default:""`
@@ -106,29 +172,28 @@ type ConfigLoggers struct {
 		OverwriteIdentity bool   `yaml:"overwrite-identity" default:"false"`
 		BufferSize        int    `yaml:"buffer-size" default:"100"`
 		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
-		ExtendedSupport   bool   `yaml:"extended-support" default:"false"`
-		Compression       string `yaml:"compression" default:"none"`
-	} `yaml:"dnstapclient"`
+	} `yaml:"powerdnsclient"`
 	TCPClient struct {
-		Enable            bool   `yaml:"enable" default:"false"`
-		RemoteAddress     string `yaml:"remote-address" default:"127.0.0.1"`
-		RemotePort        int    `yaml:"remote-port" default:"9999"`
-		SockPath          string `yaml:"sock-path" default:""` // deprecated
-		RetryInterval     int    `yaml:"retry-interval" default:"10"`
-		Transport         string `yaml:"transport" default:"tcp"`
-		TLSSupport        bool   `yaml:"tls-support" default:"false"` // deprecated
-		TLSInsecure       bool   `yaml:"tls-insecure" default:"false"`
-		TLSMinVersion     string `yaml:"tls-min-version" default:"1.2"`
-		CAFile            string `yaml:"ca-file" default:""`
-		CertFile          string `yaml:"cert-file" default:""`
-		KeyFile           string `yaml:"key-file" default:""`
-		Mode              string `yaml:"mode" default:"flat-json"`
-		TextFormat        string `yaml:"text-format" default:""`
-		PayloadDelimiter  string `yaml:"delimiter" default:"\n"`
-		BufferSize        int    `yaml:"buffer-size" default:"100"`
-		FlushInterval     int    `yaml:"flush-interval" default:"30"`
-		ConnectTimeout    int    `yaml:"connect-timeout" default:"5"`
-		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
+		Enable            bool        `yaml:"enable" default:"false"`
+		RemoteAddress     string      `yaml:"remote-address" default:"127.0.0.1"`
+		RemotePort        int         `yaml:"remote-port" default:"9999"`
+		SockPath          string      `yaml:"sock-path" default:""` // deprecated
+		RetryInterval     int         `yaml:"retry-interval" default:"10"`
+		Transport         string      `yaml:"transport" default:"tcp"`
+		TLSSupport        bool        `yaml:"tls-support" default:"false"` // deprecated
+		TLSInsecure       bool        `yaml:"tls-insecure" default:"false"`
+		TLSMinVersion     string      `yaml:"tls-min-version" default:"1.2"`
+		CAFile            string      `yaml:"ca-file" default:""`
+		CertFile          string      `yaml:"cert-file" default:""`
+		KeyFile           string      `yaml:"key-file" default:""`
+		Mode              string      `yaml:"mode" default:"flat-json"`
+		TextFormat        string      `yaml:"text-format" default:""`
+		PayloadDelimiter  string      `yaml:"delimiter" default:"\n"`
+		BufferSize        int         `yaml:"buffer-size" default:"100"`
+		FlushInterval     int         `yaml:"flush-interval" default:"30"`
+		ConnectTimeout    int         `yaml:"connect-timeout" default:"5"`
+		ChannelBufferSize int         `yaml:"chan-buffer-size" default:"0"`
+		Spool             ConfigSpool `yaml:"spool"`
 	} `yaml:"tcpclient"`
 	Syslog struct {
 		Enable            bool   `yaml:"enable" default:"false"`
@@ -208,6 +273,7 @@ type ConfigLoggers struct {
 		TenantID          string            `yaml:"tenant-id" default:""`
 		RelabelConfigs    []*relabel.Config `yaml:"relabel-configs" default:"[]"`
 		ChannelBufferSize int               `yaml:"chan-buffer-size" default:"0"`
+		Spool             ConfigSpool       `yaml:"spool"`
 	} `yaml:"lokiclient"`
 	Statsd struct {
 		Enable            bool   `yaml:"enable" default:"false"`
@@ -233,24 +299,47 @@ type ConfigLoggers struct {
 		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
 	} `yaml:"nsq"`
 	ElasticSearchClient struct {
-		Enable            bool   `yaml:"enable" default:"false"`
-		Index             string `yaml:"index" default:"dnscollector"`
-		Server            string `yaml:"server" default:"http://127.0.0.1:9200/"`
-		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
-		BulkSize          int    `yaml:"bulk-size" default:"5242880"`
-		BulkChannelSize   int    `yaml:"bulk-channel-size" default:"10"`
-		FlushInterval     int    `yaml:"flush-interval" default:"10"`
-		Compression       string `yaml:"compression" default:"none"`
-		BasicAuthEnabled  bool   `yaml:"basic-auth-enable" default:"false"`
-		BasicAuthLogin    string `yaml:"basic-auth-login" default:""`
-		BasicAuthPwd      string `yaml:"basic-auth-pwd" default:""`
+		Enable            bool        `yaml:"enable" default:"false"`
+		Index             string      `yaml:"index" default:"dnscollector"`
+		Server            string      `yaml:"server" default:"http://127.0.0.1:9200/"`
+		ChannelBufferSize int         `yaml:"chan-buffer-size" default:"0"`
+		BulkSize          int         `yaml:"bulk-size" default:"5242880"`
+		BulkChannelSize   int         `yaml:"bulk-channel-size" default:"10"`
+		FlushInterval     int         `yaml:"flush-interval" default:"10"`
+		Compression       string      `yaml:"compression" default:"none"`
+		BasicAuthEnabled  bool        `yaml:"basic-auth-enable" default:"false"`
+		BasicAuthLogin    string      `yaml:"basic-auth-login" default:""`
+		BasicAuthPwd      string      `yaml:"basic-auth-pwd" default:""`
+		Spool             ConfigSpool `yaml:"spool"`
 	} `yaml:"elasticsearch"`
 	OpenTelemetryClient struct {
-		Enable               bool   `yaml:"enable" default:"false"`
-		ChannelBufferSize    int    `yaml:"chan-buffer-size" default:"0"`
-		CleanupSpansInterval int    `yaml:"cleanup-spans-interval" default:"30"`
-		MaxSpanTime          int    `yaml:"max-span-time" default:"120"`
-		OtelEndpoint         string `yaml:"otel-endpoint" default:""`
+		Enable               bool              `yaml:"enable" default:"false"`
+		ChannelBufferSize    int               `yaml:"chan-buffer-size" default:"0"`
+		CleanupSpansInterval int               `yaml:"cleanup-spans-interval" default:"30"`
+		MaxSpanTime          int               `yaml:"max-span-time" default:"120"`
+		OtelEndpoint         string            `yaml:"otel-endpoint" default:""`
+		Protocol             string            `yaml:"protocol" default:"grpc"`
+		TracesEnabled        bool              `yaml:"traces-enabled" default:"true"`
+		LogsEnabled          bool              `yaml:"logs-enabled" default:"false"`
+		MetricsEnabled       bool              `yaml:"metrics-enabled" default:"false"`
+		MetricsPrefix        string            `yaml:"metrics-prefix" default:"dnscollector"`
+		MetricsInterval      int               `yaml:"metrics-interval" default:"10"`
+		BatchSize            int               `yaml:"batch-size" default:"512"`
+		FlushInterval        int               `yaml:"flush-interval" default:"5"`
+		MaxRetries           int               `yaml:"max-retries" default:"3"`
+		RetryInterval        int               `yaml:"retry-interval" default:"5"`
+		Timeout              int               `yaml:"timeout" default:"10"`
+		ExportQueueSize      int               `yaml:"export-queue-size" default:"10"`
+		TLSSupport           bool              `yaml:"tls-support" default:"false"`
+		TLSInsecure          bool              `yaml:"tls-insecure" default:"false"`
+		TLSMinVersion        string            `yaml:"tls-min-version" default:"1.2"`
+		CAFile               string            `yaml:"ca-file" default:""`
+		CertFile             string            `yaml:"cert-file" default:""`
+		KeyFile              string            `yaml:"key-file" default:""`
+		Headers              map[string]string `yaml:"headers" default:"{}"`
+		BasicAuthLogin       string            `yaml:"basic-auth-login" default:""`
+		BasicAuthPwd         string            `yaml:"basic-auth-pwd" default:""`
+		BearerToken          string            `yaml:"bearer-token" default:""`
 	} `yaml:"opentelemetry"`
 	ScalyrClient struct {
 		Enable            bool                   `yaml:"enable" default:"false"`
@@ -293,30 +382,31 @@ type ConfigLoggers struct {
 		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
 	} `yaml:"redispub"`
 	KafkaProducer struct {
-		Enable            bool   `yaml:"enable" default:"false"`
-		RemoteAddress     string `yaml:"remote-address" default:"127.0.0.1"`
-		RemotePort        int    `yaml:"remote-port" default:"9092"`
-		RetryInterval     int    `yaml:"retry-interval" default:"10"`
-		TLSSupport        bool   `yaml:"tls-support" default:"false"`
-		TLSInsecure       bool   `yaml:"tls-insecure" default:"false"`
-		TLSMinVersion     string `yaml:"tls-min-version" default:"1.2"`
-		CAFile            string `yaml:"ca-file" default:""`
-		CertFile          string `yaml:"cert-file" default:""`
-		KeyFile           string `yaml:"key-file" default:""`
-		SaslSupport       bool   `yaml:"sasl-support" default:"false"`
-		SaslUsername      string `yaml:"sasl-username" default:""`
-		SaslPassword      string `yaml:"sasl-password" default:""`
-		SaslMechanism     string `yaml:"sasl-mechanism" default:"PLAIN"`
-		Mode              string `yaml:"mode" default:"flat-json"`
-		TextFormat        string `yaml:"text-format" default:""`
-		BatchSize         int    `yaml:"batch-size" default:"100"`
-		FlushInterval     int    `yaml:"flush-interval" default:"10"`
-		ConnectTimeout    int    `yaml:"connect-timeout" default:"5"`
-		CancelKafka       bool   `yaml:"cancel-kafka" default:"false"`
-		Topic             string `yaml:"topic" default:"dnscollector"`
-		Partition         *int   `yaml:"partition" default:"nil"`
-		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
-		Compression       string `yaml:"compression" default:"none"`
+		Enable            bool        `yaml:"enable" default:"false"`
+		RemoteAddress     string      `yaml:"remote-address" default:"127.0.0.1"`
+		RemotePort        int         `yaml:"remote-port" default:"9092"`
+		RetryInterval     int         `yaml:"retry-interval" default:"10"`
+		TLSSupport        bool        `yaml:"tls-support" default:"false"`
+		TLSInsecure       bool        `yaml:"tls-insecure" default:"false"`
+		TLSMinVersion     string      `yaml:"tls-min-version" default:"1.2"`
+		CAFile            string      `yaml:"ca-file" default:""`
+		CertFile          string      `yaml:"cert-file" default:""`
+		KeyFile           string      `yaml:"key-file" default:""`
+		SaslSupport       bool        `yaml:"sasl-support" default:"false"`
+		SaslUsername      string      `yaml:"sasl-username" default:""`
+		SaslPassword      string      `yaml:"sasl-password" default:""`
+		SaslMechanism     string      `yaml:"sasl-mechanism" default:"PLAIN"`
+		Mode              string      `yaml:"mode" default:"flat-json"`
+		TextFormat        string      `yaml:"text-format" default:""`
+		BatchSize         int         `yaml:"batch-size" default:"100"`
+		FlushInterval     int         `yaml:"flush-interval" default:"10"`
+		ConnectTimeout    int         `yaml:"connect-timeout" default:"5"`
+		CancelKafka       bool        `yaml:"cancel-kafka" default:"false"`
+		Topic             string      `yaml:"topic" default:"dnscollector"`
+		Partition         *int        `yaml:"partition" default:"nil"`
+		ChannelBufferSize int         `yaml:"chan-buffer-size" default:"0"`
+		Compression       string      `yaml:"compression" default:"none"`
+		Spool             ConfigSpool `yaml:"spool"`
 	} `yaml:"kafkaproducer"`
 	FalcoClient struct {
 		Enable            bool   `yaml:"enable" default:"false"`
@@ -324,13 +414,26 @@ type ConfigLoggers struct {
 		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
 	} `yaml:"falco"`
 	ClickhouseClient struct {
-		Enable            bool   `yaml:"enable" default:"false"`
-		URL               string `yaml:"url" default:"http://localhost:8123"`
-		User              string `yaml:"user" default:"default"`
-		Password          string `yaml:"password" default:"password"`
-		Database          string `yaml:"database" default:"dnscollector"`
-		Table             string `yaml:"table" default:"records"`
-		ChannelBufferSize int    `yaml:"chan-buffer-size" default:"0"`
+		Enable            bool                     `yaml:"enable" default:"false"`
+		URL               string                   `yaml:"url" default:"http://localhost:8123"`
+		User              string                   `yaml:"user" default:"default"`
+		Password          string                   `yaml:"password" default:"password"`
+		PasswordFile      string                   `yaml:"password-file" default:""`
+		Database          string                   `yaml:"database" default:"dnscollector"`
+		Table             string                   `yaml:"table" default:"records"`
+		ChannelBufferSize int                      `yaml:"chan-buffer-size" default:"0"`
+		Format            string                   `yaml:"format" default:"JSONEachRow"`
+		BatchSize         int                      `yaml:"batch-size" default:"1000"`
+		FlushInterval     int                      `yaml:"flush-interval" default:"5"`
+		Timeout           int                      `yaml:"timeout" default:"10"`
+		TLSInsecure       bool                     `yaml:"tls-insecure" default:"false"`
+		TLSMinVersion     string                   `yaml:"tls-min-version" default:"1.2"`
+		CAFile            string                   `yaml:"ca-file" default:""`
+		CertFile          string                   `yaml:"cert-file" default:""`
+		KeyFile           string                   `yaml:"key-file" default:""`
+		CreateTable       bool                     `yaml:"create-table" default:"false"`
+		TableEngine       string                   `yaml:"table-engine" default:"MergeTree ORDER BY tuple()"`
+		Columns           []ConfigClickhouseColumn `yaml:"columns" default:"[]"`
 	} `yaml:"clickhouse"`
 	MQTT struct {
 		Enable            bool   `yaml:"enable" default:"false"`
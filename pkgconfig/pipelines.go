@@ -8,7 +8,7 @@ import (
 
 type ConfigPipelines struct {
 	Name          string                 `yaml:"name"`
-	Transforms    map[string]interface{} `yaml:"transforms"`
+	Transforms    interface{}            `yaml:"transforms"`
 	Params        map[string]interface{} `yaml:",inline"`
 	RoutingPolicy PipelinesRouting       `yaml:"routing-policy"`
 }
@@ -21,8 +21,17 @@ func (c *ConfigPipelines) IsValid(userCfg map[string]interface{}) error {
 
 	if _, ok := userCfg["transforms"]; ok {
 		cfg := ConfigTransformers{}
-		if err := cfg.IsValid(userCfg["transforms"].(map[string]interface{})); err != nil {
-			return errors.Errorf("transform - %s", err)
+		switch transforms := userCfg["transforms"].(type) {
+		case map[string]interface{}:
+			if err := cfg.IsValid(transforms); err != nil {
+				return errors.Errorf("transform - %s", err)
+			}
+		case []interface{}:
+			if err := cfg.IsValidChain(transforms); err != nil {
+				return errors.Errorf("transform - %s", err)
+			}
+		default:
+			return errors.Errorf("transform - unexpected type, got %T", transforms)
 		}
 		delete(userCfg, "transforms")
 	}
@@ -61,15 +70,79 @@ func (c *ConfigPipelines) IsValid(userCfg map[string]interface{}) error {
 }
 
 type PipelinesRouting struct {
+	Forward []string                    `yaml:"forward,flow"`
+	Dropped []string                    `yaml:"dropped,flow"`
+	Routes  []PipelinesConditionalRoute `yaml:"routes"`
+	Default []string                    `yaml:"default,flow"`
+}
+
+// PipelinesConditionalRoute forwards the messages matching the conditions,
+// with the same syntax as the matching of the dnsmessage collector
+type PipelinesConditionalRoute struct {
+	Matching struct {
+		Include map[string]interface{} `yaml:"include"`
+		Exclude map[string]interface{} `yaml:"exclude"`
+	} `yaml:"matching"`
 	Forward []string `yaml:"forward,flow"`
-	Dropped []string `yaml:"dropped,flow"`
+}
+
+// GetTargets returns all the stanzas used by the routing policy
+func (c *PipelinesRouting) GetTargets() []string {
+	targets := append([]string{}, c.Forward...)
+	for _, route := range c.Routes {
+		targets = append(targets, route.Forward...)
+	}
+	return append(targets, c.Default...)
+}
+
+func (c *PipelinesRouting) IsDefined() bool {
+	return len(c.Forward) > 0 || len(c.Dropped) > 0 || len(c.Routes) > 0 || len(c.Default) > 0
 }
 
 func (c *PipelinesRouting) IsValid(userCfg map[string]interface{}) error {
 	for k := range userCfg {
-		if k != "forward" && k != "dropped" {
+		if k != "forward" && k != "dropped" && k != "routes" && k != "default" {
 			return fmt.Errorf("invalid key '%s'", k)
 		}
 	}
+
+	if routes, ok := userCfg["routes"]; ok {
+		list, ok := routes.([]interface{})
+		if !ok {
+			return fmt.Errorf("routes - list expected, got %T", routes)
+		}
+		for i, route := range list {
+			if err := isValidConditionalRoute(route); err != nil {
+				return fmt.Errorf("routes - index=%d - %s", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isValidConditionalRoute(route interface{}) error {
+	keys := map[string]interface{}{}
+	switch r := route.(type) {
+	case map[string]interface{}:
+		keys = r
+	case map[interface{}]interface{}:
+		for k, v := range r {
+			keys[fmt.Sprint(k)] = v
+		}
+	default:
+		return fmt.Errorf("unexpected type, got %T", route)
+	}
+
+	for k := range keys {
+		if k != "matching" && k != "forward" {
+			return fmt.Errorf("invalid key '%s'", k)
+		}
+	}
+	if _, ok := keys["matching"]; !ok {
+		return fmt.Errorf("matching key is required")
+	}
+	if _, ok := keys["forward"]; !ok {
+		return fmt.Errorf("forward key is required")
+	}
 	return nil
 }
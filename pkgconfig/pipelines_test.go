@@ -38,6 +38,49 @@ func TestConfigPipelines_IsValid(t *testing.T) {
 			expectErr: true,
 			errorMsg:  "routing-policy - invalid key 'invalid'",
 		},
+		{
+			name: "Valid Conditional Routes",
+			config: map[string]interface{}{
+				"name": "testPipeline",
+				"routing-policy": map[string]interface{}{
+					"routes": []interface{}{
+						map[interface{}]interface{}{
+							"matching": map[interface{}]interface{}{"include": map[interface{}]interface{}{"dns.rcode": "NXDOMAIN"}},
+							"forward":  []interface{}{"kafka-nx"},
+						},
+					},
+					"default": []string{"route1"},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Invalid Conditional Route Key",
+			config: map[string]interface{}{
+				"name": "testPipeline",
+				"routing-policy": map[string]interface{}{
+					"routes": []interface{}{
+						map[interface{}]interface{}{"matching": map[interface{}]interface{}{}, "forward": []interface{}{"route1"}},
+						map[interface{}]interface{}{"match": map[interface{}]interface{}{}, "forward": []interface{}{"route1"}},
+					},
+				},
+			},
+			expectErr: true,
+			errorMsg:  "routing-policy - routes - index=1 - invalid key 'match'",
+		},
+		{
+			name: "Conditional Route Without Forward",
+			config: map[string]interface{}{
+				"name": "testPipeline",
+				"routing-policy": map[string]interface{}{
+					"routes": []interface{}{
+						map[interface{}]interface{}{"matching": map[interface{}]interface{}{}},
+					},
+				},
+			},
+			expectErr: true,
+			errorMsg:  "routing-policy - routes - index=0 - forward key is required",
+		},
 		{
 			name: "Invalid Transforms",
 			config: map[string]interface{}{
@@ -49,6 +92,30 @@ func TestConfigPipelines_IsValid(t *testing.T) {
 			expectErr: true,
 			errorMsg:  "transform - unknown key=`invalidTransform`",
 		},
+		{
+			name: "Valid Ordered Transforms",
+			config: map[string]interface{}{
+				"name": "testPipeline",
+				"transforms": []interface{}{
+					map[string]interface{}{"geoip": map[string]interface{}{}},
+					map[string]interface{}{"user-privacy": map[string]interface{}{"anonymize-ip": true}},
+					map[string]interface{}{"geoip": map[string]interface{}{}},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Invalid Ordered Transforms",
+			config: map[string]interface{}{
+				"name": "testPipeline",
+				"transforms": []interface{}{
+					map[string]interface{}{"normalize": map[string]interface{}{}},
+					map[string]interface{}{"invalidTransform": map[string]interface{}{}},
+				},
+			},
+			expectErr: true,
+			errorMsg:  "transform - index=1 - unknown transformer=`invalidTransform`",
+		},
 	}
 
 	for _, tc := range testCases {
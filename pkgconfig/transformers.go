@@ -4,6 +4,8 @@ import (
 	"reflect"
 
 	"github.com/creasty/defaults"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
 )
 
 type RelabelingConfig struct {
@@ -11,25 +13,35 @@ type RelabelingConfig struct {
 	Replacement string `yaml:"replacement"`
 }
 
+// ConfigTransformersEntry is one step of an ordered chain of transformers
+type ConfigTransformersEntry struct {
+	Name   string
+	Config *ConfigTransformers
+}
+
 type ConfigTransformers struct {
 	UserPrivacy struct {
-		Enable            bool   `yaml:"enable" default:"false"`
-		AnonymizeIP       bool   `yaml:"anonymize-ip" default:"false"`
-		AnonymizeIPV4Bits string `yaml:"anonymize-v4bits" default:"0.0.0.0/16"`
-		AnonymizeIPV6Bits string `yaml:"anonymize-v6bits" default:"::/64"`
-		MinimizeQname     bool   `yaml:"minimize-qname" default:"false"`
-		HashQueryIP       bool   `yaml:"hash-query-ip" default:"false"`
-		HashReplyIP       bool   `yaml:"hash-reply-ip" default:"false"`
-		HashIPAlgo        string `yaml:"hash-ip-algo" default:"sha1"`
+		Enable             bool   `yaml:"enable" default:"false"`
+		AnonymizeIP        bool   `yaml:"anonymize-ip" default:"false"`
+		AnonymizeIPV4Bits  string `yaml:"anonymize-v4bits" default:"0.0.0.0/16"`
+		AnonymizeIPV6Bits  string `yaml:"anonymize-v6bits" default:"::/64"`
+		MinimizeQname      bool   `yaml:"minimize-qname" default:"false"`
+		HashQueryIP        bool   `yaml:"hash-query-ip" default:"false"`
+		HashReplyIP        bool   `yaml:"hash-reply-ip" default:"false"`
+		HashIPAlgo         string `yaml:"hash-ip-algo" default:"sha1"`
+		AnonymizeAnswers   bool   `yaml:"anonymize-answers-ip" default:"false"`
+		AnswersPrivateOnly bool   `yaml:"anonymize-answers-private-only" default:"true"`
+		MinimizeAnswerPTR  bool   `yaml:"minimize-answers-ptr" default:"false"`
 	} `yaml:"user-privacy"`
 	Normalize struct {
-		Enable              bool `yaml:"enable" default:"false"`
-		QnameLowerCase      bool `yaml:"qname-lowercase" default:"false"`
-		RRLowerCase         bool `yaml:"rr-lowercase" default:"false"`
-		QuietText           bool `yaml:"quiet-text" default:"false"`
-		AddTld              bool `yaml:"add-tld" default:"false"`
-		AddTldPlusOne       bool `yaml:"add-tld-plus-one" default:"false"`
-		ReplaceNonPrintable bool `yaml:"qname-replace-nonprintable" default:"false"`
+		Enable                bool `yaml:"enable" default:"false"`
+		QnameLowerCase        bool `yaml:"qname-lowercase" default:"false"`
+		RRLowerCase           bool `yaml:"rr-lowercase" default:"false"`
+		QuietText             bool `yaml:"quiet-text" default:"false"`
+		AddTld                bool `yaml:"add-tld" default:"false"`
+		AddTldPlusOne         bool `yaml:"add-tld-plus-one" default:"false"`
+		ReplaceNonPrintable   bool `yaml:"qname-replace-nonprintable" default:"false"`
+		RRReplaceNonPrintable bool `yaml:"rr-replace-nonprintable" default:"false"`
 	} `yaml:"normalize"`
 	Latency struct {
 		Enable            bool `yaml:"enable" default:"false"`
@@ -37,6 +49,12 @@ type ConfigTransformers struct {
 		UnansweredQueries bool `yaml:"unanswered-queries" default:"false"`
 		QueriesTimeout    int  `yaml:"queries-timeout" default:"2"`
 	} `yaml:"latency"`
+	Correlation struct {
+		Enable            bool `yaml:"enable" default:"false"`
+		QueriesTimeout    int  `yaml:"queries-timeout" default:"2"`
+		UnansweredQueries bool `yaml:"unanswered-queries" default:"true"`
+		MaxPendingQueries int  `yaml:"max-pending-queries" default:"100000"`
+	} `yaml:"correlation"`
 	Reducer struct {
 		Enable                    bool     `yaml:"enable" default:"false"`
 		RepetitiveTrafficDetector bool     `yaml:"repetitive-traffic-detector" default:"false"`
@@ -61,6 +79,7 @@ type ConfigTransformers struct {
 	GeoIP struct {
 		Enable        bool   `yaml:"enable" default:"false"`
 		LookupECS     bool   `yaml:"lookup-ecs" default:"false"`
+		LookupAnswers bool   `yaml:"lookup-answers" default:"false"`
 		DBCountryFile string `yaml:"mmdb-country-file" default:""`
 		DBCityFile    string `yaml:"mmdb-city-file" default:""`
 		DBASNFile     string `yaml:"mmdb-asn-file" default:""`
@@ -74,6 +93,8 @@ type ConfigTransformers struct {
 		UnallowedChars     []string `yaml:"unallowed-chars,flow" default:"[\"\\\"\", \"==\", \"/\", \":\"]"`
 		ThresholdMaxLabels int      `yaml:"threshold-max-labels" default:"10"`
 		WhitelistDomains   []string `yaml:"whitelist-domains,flow" default:"[\"\\\\.ip6\\\\.arpa\"]"`
+		CheckAnswers       bool     `yaml:"check-answers" default:"false"`
+		ThresholdRdataLen  int      `yaml:"threshold-rdata-len" default:"255"`
 	} `yaml:"suspicious"`
 	Extract struct {
 		Enable     bool `yaml:"enable" default:"false"`
@@ -83,6 +104,21 @@ type ConfigTransformers struct {
 		Enable      bool `yaml:"enable" default:"false"`
 		AddFeatures bool `yaml:"add-features" default:"false"`
 	} `yaml:"machine-learning"`
+	Detection struct {
+		Enable                    bool    `yaml:"enable" default:"false"`
+		WatchInterval             int     `yaml:"watch-interval" default:"60"`
+		MaxTrackedEntries         int     `yaml:"max-tracked-entries" default:"100000"`
+		ThresholdEntropy          float64 `yaml:"threshold-entropy" default:"4.0"`
+		ThresholdSubdomainLen     int     `yaml:"threshold-subdomain-len" default:"40"`
+		ThresholdUniqueSubdomains int     `yaml:"threshold-unique-subdomains" default:"100"`
+		ThresholdTxtNullBytes     int     `yaml:"threshold-txt-null-bytes" default:"10000"`
+		ThresholdQueryRate        int     `yaml:"threshold-query-rate" default:"1000"`
+		ThresholdNxdomains        int     `yaml:"threshold-nxdomains" default:"50"`
+		ThresholdConsonants       int     `yaml:"threshold-consonants" default:"8"`
+		ModelFile                 string  `yaml:"model-file" default:""`
+		DropScoreAbove            float64 `yaml:"drop-score-above" default:"0"`
+		DropScoreBelow            float64 `yaml:"drop-score-below" default:"0"`
+	} `yaml:"detection"`
 	ATags struct {
 		Enable  bool     `yaml:"enable" default:"false"`
 		AddTags []string `yaml:"add-tags,flow" default:"[]"`
@@ -116,6 +152,10 @@ type ConfigTransformers struct {
 		FlushInterval int  `yaml:"flush-interval" default:"30"`
 		MaxBufferSize int  `yaml:"max-buffer-size" default:"100"`
 	} `yaml:"reordering"`
+
+	// Chain is set when the transforms of a stanza are declared as a list,
+	// the transformers are applied in the declared order
+	Chain []ConfigTransformersEntry `yaml:"-"`
 }
 
 func (c *ConfigTransformers) SetDefault() {
@@ -126,6 +166,85 @@ func (c *ConfigTransformers) IsValid(userCfg map[string]interface{}) error {
 	return CheckConfigWithTags(reflect.ValueOf(*c), userCfg)
 }
 
+func (c *ConfigTransformers) GetNames() (ret []string) {
+	cl := reflect.TypeOf(*c)
+
+	for i := 0; i < cl.NumField(); i++ {
+		tag := cl.Field(i).Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		ret = append(ret, tag)
+	}
+	return ret
+}
+
+func (c *ConfigTransformers) IsExists(name string) bool {
+	tags := c.GetNames()
+	for i := range tags {
+		if name == tags[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidChain checks a list of transformers, each item must be a map
+// with only one key: the name of the transformer
+func (c *ConfigTransformers) IsValidChain(userCfg []interface{}) error {
+	for i, item := range userCfg {
+		kvMap, ok := item.(map[string]interface{})
+		if !ok || len(kvMap) != 1 {
+			return errors.Errorf("index=%d - one transformer expected per item", i)
+		}
+		for name := range kvMap {
+			if !c.IsExists(name) {
+				return errors.Errorf("index=%d - unknown transformer=`%s`", i, name)
+			}
+		}
+		if err := c.IsValid(kvMap); err != nil {
+			return errors.Errorf("index=%d - %s", i, err)
+		}
+	}
+	return nil
+}
+
+// NewConfigTransformersChain builds the ordered chain of transformers from a list,
+// the same transformer can be repeated with different settings
+func NewConfigTransformersChain(userCfg []interface{}) ([]ConfigTransformersEntry, error) {
+	chain := []ConfigTransformersEntry{}
+	for i, item := range userCfg {
+		kvMap, ok := item.(map[string]interface{})
+		if !ok || len(kvMap) != 1 {
+			return nil, errors.Errorf("index=%d - one transformer expected per item", i)
+		}
+
+		for name, params := range kvMap {
+			settings, ok := params.(map[string]interface{})
+			if !ok {
+				settings = make(map[string]interface{})
+			}
+			settings["enable"] = true
+
+			cfg := &ConfigTransformers{}
+			cfg.SetDefault()
+			if !cfg.IsExists(name) {
+				return nil, errors.Errorf("index=%d - unknown transformer=`%s`", i, name)
+			}
+
+			yamlcfg, err := yaml.Marshal(map[string]interface{}{name: settings})
+			if err != nil {
+				return nil, errors.Errorf("index=%d - %s", i, err)
+			}
+			if err := yaml.Unmarshal(yamlcfg, cfg); err != nil {
+				return nil, errors.Errorf("index=%d - %s", i, err)
+			}
+			chain = append(chain, ConfigTransformersEntry{Name: name, Config: cfg})
+		}
+	}
+	return chain, nil
+}
+
 func GetFakeConfigTransformers() *ConfigTransformers {
 	config := &ConfigTransformers{}
 	config.SetDefault()
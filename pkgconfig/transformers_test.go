@@ -19,3 +19,33 @@ func TestConfigTransformersSetDefault(t *testing.T) {
 		t.Errorf("geo should be disabled")
 	}
 }
+
+func TestConfigTransformersChain(t *testing.T) {
+	chain, err := NewConfigTransformersChain([]interface{}{
+		map[string]interface{}{"geoip": map[string]interface{}{"mmdb-country-file": "country.mmdb"}},
+		map[string]interface{}{"user-privacy": map[string]interface{}{"anonymize-ip": true}},
+		map[string]interface{}{"geoip": nil},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chain) != 3 {
+		t.Fatalf("3 entries expected, got %d", len(chain))
+	}
+	if chain[0].Name != "geoip" || chain[1].Name != "user-privacy" || chain[2].Name != "geoip" {
+		t.Errorf("invalid order: %v", chain)
+	}
+	if !chain[0].Config.GeoIP.Enable || chain[0].Config.GeoIP.DBCountryFile != "country.mmdb" {
+		t.Errorf("geoip should be enabled with the country file")
+	}
+	if !chain[1].Config.UserPrivacy.AnonymizeIP || chain[1].Config.GeoIP.Enable {
+		t.Errorf("only user-privacy should be enabled")
+	}
+	if chain[2].Config.GeoIP.DBCountryFile != "" {
+		t.Errorf("geoip settings should not be shared")
+	}
+
+	if _, err := NewConfigTransformersChain([]interface{}{map[string]interface{}{"unknown": nil}}); err == nil {
+		t.Errorf("unknown transformer should be rejected")
+	}
+}
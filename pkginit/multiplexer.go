@@ -116,6 +116,9 @@ func InitMultiplexer(mapLoggers map[string]workers.Worker, mapCollectors map[str
 		if subcfg.Loggers.DNSTap.Enable && IsLoggerRouted(config, output.Name) {
 			mapLoggers[output.Name] = workers.NewDnstapSender(subcfg, logger, output.Name)
 		}
+		if subcfg.Loggers.PowerDNSClient.Enable && IsLoggerRouted(config, output.Name) {
+			mapLoggers[output.Name] = workers.NewPdnsSender(subcfg, logger, output.Name)
+		}
 		if subcfg.Loggers.TCPClient.Enable && IsLoggerRouted(config, output.Name) {
 			mapLoggers[output.Name] = workers.NewTCPClient(subcfg, logger, output.Name)
 		}
@@ -188,6 +191,12 @@ func InitMultiplexer(mapLoggers map[string]workers.Worker, mapCollectors map[str
 		if subcfg.Collectors.Tzsp.Enable && IsCollectorRouted(config, input.Name) {
 			mapCollectors[input.Name] = workers.NewTZSP(nil, subcfg, logger, input.Name)
 		}
+		if subcfg.Collectors.KafkaConsumer.Enable && IsCollectorRouted(config, input.Name) {
+			mapCollectors[input.Name] = workers.NewKafkaConsumer(nil, subcfg, logger, input.Name)
+		}
+		if subcfg.Collectors.OtlpReceiver.Enable && IsCollectorRouted(config, input.Name) {
+			mapCollectors[input.Name] = workers.NewOtlpReceiver(nil, subcfg, logger, input.Name)
+		}
 	}
 
 	// here the multiplexer logic
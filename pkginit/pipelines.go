@@ -2,6 +2,7 @@ package pkginit
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-dnscollector/telemetry"
@@ -46,9 +47,20 @@ func GetStanzaConfig(config *pkgconfig.Config, item pkgconfig.ConfigPipelines) *
 	cfg[section+"-transformers"] = make(map[string]interface{})
 
 	// add transformers
-	for k, v := range item.Transforms {
-		v.(map[string]interface{})["enable"] = true
-		cfg[section+"-transformers"].(map[string]interface{})[k] = v
+	var chain []pkgconfig.ConfigTransformersEntry
+	switch transforms := item.Transforms.(type) {
+	case map[string]interface{}:
+		for k, v := range transforms {
+			v.(map[string]interface{})["enable"] = true
+			cfg[section+"-transformers"].(map[string]interface{})[k] = v
+		}
+	case []interface{}:
+		// ordered list of transformers
+		var err error
+		chain, err = pkgconfig.NewConfigTransformersChain(transforms)
+		if err != nil {
+			panic(fmt.Sprintf("main - yaml transformers config error: %v", err))
+		}
 	}
 
 	// copy global config
@@ -59,6 +71,12 @@ func GetStanzaConfig(config *pkgconfig.Config, item pkgconfig.ConfigPipelines) *
 		panic(fmt.Sprintf("main - yaml logger config error: %v", err))
 	}
 
+	if section == "loggers" {
+		subcfg.OutgoingTransformers.Chain = chain
+	} else {
+		subcfg.IngoingTransformers.Chain = chain
+	}
+
 	return subcfg
 }
 
@@ -122,6 +140,21 @@ func CreateRouting(stanza pkgconfig.ConfigPipelines, mapCollectors map[string]wo
 			return fmt.Errorf("main - routing error with dropped messages from stanza=%s to stanza=%s doest not exist", stanza.Name, route)
 		}
 	}
+
+	// conditional routing
+	if len(stanza.RoutingPolicy.Routes) > 0 || len(stanza.RoutingPolicy.Default) > 0 {
+		routes, fallback, err := GetStanzaConditionalRoutes(stanza, mapCollectors, mapLoggers)
+		if err != nil {
+			return fmt.Errorf("main - %s", err)
+		}
+		currentStanza.ReplaceConditionalRoutes(routes, fallback)
+		for i, route := range stanza.RoutingPolicy.Routes {
+			logger.Info("main - routing (policy=route-%d) stanza=[%s] to stanza=%v", i, stanza.Name, route.Forward)
+		}
+		if len(stanza.RoutingPolicy.Default) > 0 {
+			logger.Info("main - routing (policy=default) stanza=[%s] to stanza=%v", stanza.Name, stanza.RoutingPolicy.Default)
+		}
+	}
 	return nil
 }
 
@@ -147,6 +180,10 @@ func CreateStanza(stanzaName string, config *pkgconfig.Config, mapCollectors map
 		mapLoggers[stanzaName] = workers.NewDnstapSender(config, logger, stanzaName)
 		mapLoggers[stanzaName].SetMetrics(metrics)
 	}
+	if config.Loggers.PowerDNSClient.Enable {
+		mapLoggers[stanzaName] = workers.NewPdnsSender(config, logger, stanzaName)
+		mapLoggers[stanzaName].SetMetrics(metrics)
+	}
 	if config.Loggers.TCPClient.Enable {
 		mapLoggers[stanzaName] = workers.NewTCPClient(config, logger, stanzaName)
 		mapLoggers[stanzaName].SetMetrics(metrics)
@@ -249,16 +286,25 @@ func CreateStanza(stanzaName string, config *pkgconfig.Config, mapCollectors map
 		mapCollectors[stanzaName] = workers.NewWebhook(nil, config, logger, stanzaName)
 		mapCollectors[stanzaName].SetMetrics(metrics)
 	}
+	if config.Collectors.KafkaConsumer.Enable {
+		mapCollectors[stanzaName] = workers.NewKafkaConsumer(nil, config, logger, stanzaName)
+		mapCollectors[stanzaName].SetMetrics(metrics)
+	}
+	if config.Collectors.OtlpReceiver.Enable {
+		mapCollectors[stanzaName] = workers.NewOtlpReceiver(nil, config, logger, stanzaName)
+		mapCollectors[stanzaName].SetMetrics(metrics)
+	}
 }
 
-func InitPipelines(mapLoggers map[string]workers.Worker, mapCollectors map[string]workers.Worker, config *pkgconfig.Config, logger *logger.Logger, telemetry *telemetry.PrometheusCollector) error {
+// CheckPipelines validates the stanza names and the routes between them
+func CheckPipelines(config *pkgconfig.Config) error {
 	// check if the name of each stanza is uniq
 	routesDefined := false
 	for _, stanza := range config.Pipelines {
 		if err := StanzaNameIsUniq(stanza.Name, config); err != nil {
 			return errors.Errorf("stanza with name=[%s] is duplicated", stanza.Name)
 		}
-		if len(stanza.RoutingPolicy.Forward) > 0 || len(stanza.RoutingPolicy.Dropped) > 0 {
+		if stanza.RoutingPolicy.IsDefined() {
 			routesDefined = true
 		}
 	}
@@ -269,7 +315,10 @@ func InitPipelines(mapLoggers map[string]workers.Worker, mapCollectors map[strin
 
 	// check if all routes exists before continue
 	for _, stanza := range config.Pipelines {
-		for _, route := range stanza.RoutingPolicy.Forward {
+		for _, route := range stanza.RoutingPolicy.GetTargets() {
+			if route == stanza.Name {
+				return errors.Errorf("main - routing error loop with stanza=%s to stanza=%s", stanza.Name, route)
+			}
 			if err := IsRouteExist(route, config); err != nil {
 				return errors.Errorf("stanza=[%s] forward route=[%s] doest not exist", stanza.Name, route)
 			}
@@ -280,6 +329,13 @@ func InitPipelines(mapLoggers map[string]workers.Worker, mapCollectors map[strin
 			}
 		}
 	}
+	return nil
+}
+
+func InitPipelines(mapLoggers map[string]workers.Worker, mapCollectors map[string]workers.Worker, config *pkgconfig.Config, logger *logger.Logger, telemetry *telemetry.PrometheusCollector) error {
+	if err := CheckPipelines(config); err != nil {
+		return err
+	}
 
 	// read each stanza and init
 	for _, stanza := range config.Pipelines {
@@ -302,15 +358,214 @@ func InitPipelines(mapLoggers map[string]workers.Worker, mapCollectors map[strin
 	return nil
 }
 
-func ReloadPipelines(mapLoggers map[string]workers.Worker, mapCollectors map[string]workers.Worker, config *pkgconfig.Config, logger *logger.Logger) {
+// GetStanzaKind returns the type of worker (collector or logger) declared in the stanza
+func GetStanzaKind(config *pkgconfig.Config, item pkgconfig.ConfigPipelines) string {
+	for k := range item.Params {
+		if config.Loggers.IsExists(k) || config.Collectors.IsExists(k) {
+			return k
+		}
+	}
+	return ""
+}
+
+func GetStanzaWorker(name string, mapCollectors map[string]workers.Worker, mapLoggers map[string]workers.Worker) (workers.Worker, bool) {
+	if collector, ok := mapCollectors[name]; ok {
+		return collector, true
+	}
+	if logger, ok := mapLoggers[name]; ok {
+		return logger, true
+	}
+	return nil, false
+}
+
+// GetStanzaRoutes resolves the forward and dropped routes of the stanza to the running workers
+func GetStanzaRoutes(stanza pkgconfig.ConfigPipelines, mapCollectors map[string]workers.Worker, mapLoggers map[string]workers.Worker) ([]workers.Worker, []workers.Worker, error) {
+	defaults := []workers.Worker{}
+	dropped := []workers.Worker{}
+
+	for _, route := range stanza.RoutingPolicy.Forward {
+		if route == stanza.Name {
+			return nil, nil, fmt.Errorf("routing error loop with stanza=%s to stanza=%s", stanza.Name, route)
+		}
+		wrk, ok := GetStanzaWorker(route, mapCollectors, mapLoggers)
+		if !ok {
+			return nil, nil, fmt.Errorf("forward routing error from stanza=%s to stanza=%s doest not exist", stanza.Name, route)
+		}
+		defaults = append(defaults, wrk)
+	}
+
+	for _, route := range stanza.RoutingPolicy.Dropped {
+		wrk, ok := GetStanzaWorker(route, mapCollectors, mapLoggers)
+		if !ok {
+			return nil, nil, fmt.Errorf("routing error with dropped messages from stanza=%s to stanza=%s doest not exist", stanza.Name, route)
+		}
+		dropped = append(dropped, wrk)
+	}
+	return defaults, dropped, nil
+}
+
+// GetStanzaConditionalRoutes resolves the conditional routes and the default route of the stanza
+func GetStanzaConditionalRoutes(stanza pkgconfig.ConfigPipelines, mapCollectors map[string]workers.Worker, mapLoggers map[string]workers.Worker) ([]workers.ConditionalRoute, []workers.Worker, error) {
+	resolve := func(targets []string) ([]workers.Worker, error) {
+		next := []workers.Worker{}
+		for _, route := range targets {
+			if route == stanza.Name {
+				return nil, fmt.Errorf("routing error loop with stanza=%s to stanza=%s", stanza.Name, route)
+			}
+			wrk, ok := GetStanzaWorker(route, mapCollectors, mapLoggers)
+			if !ok {
+				return nil, fmt.Errorf("conditional routing error from stanza=%s to stanza=%s doest not exist", stanza.Name, route)
+			}
+			next = append(next, wrk)
+		}
+		return next, nil
+	}
+
+	routes := []workers.ConditionalRoute{}
+	for i, route := range stanza.RoutingPolicy.Routes {
+		next, err := resolve(route.Forward)
+		if err != nil {
+			return nil, nil, err
+		}
+		conditionalRoute, err := workers.NewConditionalRoute(route.Matching.Include, route.Matching.Exclude, next)
+		if err != nil {
+			return nil, nil, fmt.Errorf("stanza=%s route=%d matching error: %w", stanza.Name, i, err)
+		}
+		routes = append(routes, conditionalRoute)
+	}
+
+	fallback, err := resolve(stanza.RoutingPolicy.Default)
+	if err != nil {
+		return nil, nil, err
+	}
+	return routes, fallback, nil
+}
+
+// DrainStanza waits until the input channel of the worker is empty or the timeout is reached
+func DrainStanza(wrk workers.Worker, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for len(wrk.GetInputChannel()) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+// ReloadPipelines applies the new pipelines on the running ones: new stanzas are started,
+// removed stanzas are drained and stopped, existing ones reload their config and all
+// routes are rewired before stopping anything so no messages are sent to a stopped worker.
+func ReloadPipelines(previous []pkgconfig.ConfigPipelines, mapLoggers map[string]workers.Worker, mapCollectors map[string]workers.Worker,
+	config *pkgconfig.Config, logger *logger.Logger, metrics *telemetry.PrometheusCollector) error {
+
+	// keep the current pipelines running if the new ones are invalid, the config is
+	// restored so the next reload computes the changes from the running pipelines
+	if err := CheckPipelines(config); err != nil {
+		config.Pipelines = previous
+		return err
+	}
+
+	currentKinds := make(map[string]string)
+	for _, stanza := range config.Pipelines {
+		currentKinds[stanza.Name] = GetStanzaKind(config, stanza)
+	}
+
+	// detach the removed stanzas or the ones with a different worker type
+	removedLoggers := make(map[string]workers.Worker)
+	removedCollectors := make(map[string]workers.Worker)
+	for _, stanza := range previous {
+		if kind, ok := currentKinds[stanza.Name]; ok && kind == GetStanzaKind(config, stanza) {
+			continue
+		}
+		if wrk, ok := mapLoggers[stanza.Name]; ok {
+			removedLoggers[stanza.Name] = wrk
+			delete(mapLoggers, stanza.Name)
+		}
+		if wrk, ok := mapCollectors[stanza.Name]; ok {
+			removedCollectors[stanza.Name] = wrk
+			delete(mapCollectors, stanza.Name)
+		}
+	}
+
+	// create the new stanzas or reload the existing ones
+	addedLoggers := make(map[string]workers.Worker)
+	addedCollectors := make(map[string]workers.Worker)
 	for _, stanza := range config.Pipelines {
 		newCfg := GetStanzaConfig(config, stanza)
-		if _, ok := mapLoggers[stanza.Name]; ok {
-			mapLoggers[stanza.Name].ReloadConfig(newCfg)
-		} else if _, ok := mapCollectors[stanza.Name]; ok {
-			mapCollectors[stanza.Name].ReloadConfig(newCfg)
-		} else {
-			logger.Info("main - reload config stanza=%v doest not exist", stanza.Name)
+		if wrk, ok := GetStanzaWorker(stanza.Name, mapCollectors, mapLoggers); ok {
+			wrk.ReloadConfig(newCfg)
+			continue
 		}
+
+		logger.Info("main - reload config stanza=%v is new", stanza.Name)
+		CreateStanza(stanza.Name, newCfg, mapCollectors, mapLoggers, logger, metrics)
+		if wrk, ok := mapLoggers[stanza.Name]; ok {
+			addedLoggers[stanza.Name] = wrk
+		}
+		if wrk, ok := mapCollectors[stanza.Name]; ok {
+			addedCollectors[stanza.Name] = wrk
+		}
+	}
+
+	// resolve all routes before to apply them
+	type stanzaRoutes struct {
+		defaults, dropped, fallback []workers.Worker
+		conditional                 []workers.ConditionalRoute
+	}
+	routes := make(map[string]stanzaRoutes)
+	for _, stanza := range config.Pipelines {
+		defaults, dropped, err := GetStanzaRoutes(stanza, mapCollectors, mapLoggers)
+		if err != nil {
+			return errors.Wrap(err, "routing")
+		}
+		conditional, fallback, err := GetStanzaConditionalRoutes(stanza, mapCollectors, mapLoggers)
+		if err != nil {
+			return errors.Wrap(err, "routing")
+		}
+		routes[stanza.Name] = stanzaRoutes{defaults: defaults, dropped: dropped, conditional: conditional, fallback: fallback}
 	}
+
+	// new loggers must be ready before receiving traffic
+	for name, wrk := range addedLoggers {
+		wrk.ReplaceConditionalRoutes(routes[name].conditional, routes[name].fallback)
+		wrk.ReplaceRoutes(routes[name].defaults, routes[name].dropped)
+		go wrk.StartCollect()
+	}
+
+	// rewire the existing stanzas
+	for _, stanza := range config.Pipelines {
+		if _, ok := addedLoggers[stanza.Name]; ok {
+			continue
+		}
+		if _, ok := addedCollectors[stanza.Name]; ok {
+			continue
+		}
+		wrk, _ := GetStanzaWorker(stanza.Name, mapCollectors, mapLoggers)
+		wrk.ReplaceConditionalRoutes(routes[stanza.Name].conditional, routes[stanza.Name].fallback)
+		wrk.ReplaceRoutes(routes[stanza.Name].defaults, routes[stanza.Name].dropped)
+		logger.Info("main - reload routing stanza=[%s] forward=%v dropped=%v", stanza.Name, stanza.RoutingPolicy.Forward, stanza.RoutingPolicy.Dropped)
+	}
+
+	// then start the new collectors
+	for name, wrk := range addedCollectors {
+		wrk.ReplaceConditionalRoutes(routes[name].conditional, routes[name].fallback)
+		wrk.ReplaceRoutes(routes[name].defaults, routes[name].dropped)
+		go wrk.StartCollect()
+	}
+
+	// finally stop the collectors removed and drain the loggers before to stop them
+	for name, wrk := range removedCollectors {
+		logger.Info("main - reload config stanza=%v removed", name)
+		wrk.Stop()
+	}
+	for name, wrk := range removedLoggers {
+		logger.Info("main - reload config stanza=%v removed", name)
+		if !DrainStanza(wrk, time.Duration(config.Global.Worker.InternalMonitor)*time.Second) {
+			logger.Warning("main - reload config stanza=%v not fully drained", name)
+		}
+		wrk.Stop()
+	}
+
+	return nil
 }
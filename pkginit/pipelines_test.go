@@ -1,6 +1,7 @@
 package pkginit
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -111,3 +112,135 @@ func TestPipelines_RoutingLoop(t *testing.T) {
 	}
 
 }
+
+func TestPipelines_Reload(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	config.Pipelines = []pkgconfig.ConfigPipelines{
+		{
+			Name:          "collector",
+			Params:        map[string]interface{}{"dnsmessage": map[string]interface{}{"enable": true}},
+			RoutingPolicy: pkgconfig.PipelinesRouting{Forward: []string{"loggerA"}},
+		},
+		{
+			Name:   "loggerA",
+			Params: map[string]interface{}{"devnull": map[string]interface{}{"enable": true}},
+		},
+	}
+
+	mapLoggers := make(map[string]workers.Worker)
+	mapCollectors := make(map[string]workers.Worker)
+	lg := logger.New(false)
+	metrics := telemetry.NewPrometheusCollector(config)
+	if err := InitPipelines(mapLoggers, mapCollectors, config, lg, metrics); err != nil {
+		t.Fatalf("init pipelines error: %v", err)
+	}
+	for _, l := range mapLoggers {
+		go l.StartCollect()
+	}
+	for _, c := range mapCollectors {
+		go c.StartCollect()
+	}
+
+	// replace loggerA by loggerB
+	previous := config.Pipelines
+	config.Pipelines = []pkgconfig.ConfigPipelines{
+		{
+			Name:          "collector",
+			Params:        map[string]interface{}{"dnsmessage": map[string]interface{}{"enable": true}},
+			RoutingPolicy: pkgconfig.PipelinesRouting{Forward: []string{"loggerB"}},
+		},
+		{
+			Name:   "loggerB",
+			Params: map[string]interface{}{"devnull": map[string]interface{}{"enable": true}},
+		},
+	}
+	if err := ReloadPipelines(previous, mapLoggers, mapCollectors, config, lg, metrics); err != nil {
+		t.Fatalf("reload pipelines error: %v", err)
+	}
+
+	if _, ok := mapLoggers["loggerA"]; ok {
+		t.Errorf("loggerA should be removed")
+	}
+	if _, ok := mapLoggers["loggerB"]; !ok {
+		t.Fatalf("loggerB should be added")
+	}
+
+	routes := mapCollectors["collector"].(*workers.DNSMessage).GetDefaultRoutes()
+	if len(routes) != 1 || routes[0].GetName() != "loggerB" {
+		t.Errorf("collector should be routed to loggerB only")
+	}
+
+	// invalid config keeps the running pipelines
+	previous = config.Pipelines
+	config.Pipelines = []pkgconfig.ConfigPipelines{
+		{
+			Name:          "collector",
+			Params:        map[string]interface{}{"dnsmessage": map[string]interface{}{"enable": true}},
+			RoutingPolicy: pkgconfig.PipelinesRouting{Forward: []string{"notexist"}},
+		},
+	}
+	if err := ReloadPipelines(previous, mapLoggers, mapCollectors, config, lg, metrics); err == nil {
+		t.Errorf("reload with invalid routes should fail")
+	}
+	if _, ok := mapLoggers["loggerB"]; !ok {
+		t.Errorf("loggerB should be kept")
+	}
+	if !reflect.DeepEqual(config.Pipelines, previous) {
+		t.Errorf("running pipelines should be restored in the config")
+	}
+
+	for _, c := range mapCollectors {
+		c.Stop()
+	}
+	for _, l := range mapLoggers {
+		l.Stop()
+	}
+}
+
+func TestPipelines_ConditionalRoutes(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	routing := pkgconfig.PipelinesRouting{Default: []string{"loggerB"}}
+	route := pkgconfig.PipelinesConditionalRoute{Forward: []string{"loggerA"}}
+	route.Matching.Include = map[string]interface{}{"dns.rcode": "NXDOMAIN"}
+	routing.Routes = append(routing.Routes, route)
+
+	config.Pipelines = []pkgconfig.ConfigPipelines{
+		{
+			Name:          "collector",
+			Params:        map[string]interface{}{"dnsmessage": map[string]interface{}{"enable": true}},
+			RoutingPolicy: routing,
+		},
+		{
+			Name:   "loggerA",
+			Params: map[string]interface{}{"devnull": map[string]interface{}{"enable": true}},
+		},
+		{
+			Name:   "loggerB",
+			Params: map[string]interface{}{"devnull": map[string]interface{}{"enable": true}},
+		},
+	}
+
+	mapLoggers := make(map[string]workers.Worker)
+	mapCollectors := make(map[string]workers.Worker)
+	metrics := telemetry.NewPrometheusCollector(config)
+	if err := InitPipelines(mapLoggers, mapCollectors, config, logger.New(false), metrics); err != nil {
+		t.Fatalf("init pipelines error: %v", err)
+	}
+
+	routes, fallback, err := GetStanzaConditionalRoutes(config.Pipelines[0], mapCollectors, mapLoggers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(routes) != 1 || len(routes[0].Workers) != 1 || routes[0].Workers[0].GetName() != "loggerA" {
+		t.Errorf("invalid conditional routes: %v", routes)
+	}
+	if len(fallback) != 1 || fallback[0].GetName() != "loggerB" {
+		t.Errorf("invalid default route: %v", fallback)
+	}
+
+	// conditional routes must target existing stanzas
+	config.Pipelines[0].RoutingPolicy.Routes[0].Forward = []string{"notexist"}
+	if err := CheckPipelines(config); err == nil {
+		t.Errorf("conditional route to an unknown stanza should fail")
+	}
+}
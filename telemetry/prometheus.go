@@ -35,25 +35,42 @@ type WorkerStats struct {
 	TotalForwardedPolicy int
 	TotalDroppedPolicy   int
 	TotalDiscarded       int
+	TotalSpooled         int
+	TotalReplayed        int
+	TotalEvicted         int
+}
+
+// EndpointStats are the cumulative counters of a remote endpoint of a logger
+type EndpointStats struct {
+	Worker      string
+	Endpoint    string
+	Up          bool
+	TotalSent   int
+	TotalErrors int
+	TotalConns  int
 }
 
 type PrometheusCollector struct {
 	sync.Mutex
-	config     *pkgconfig.Config
-	metrics    map[string]*prometheus.Desc
-	Record     chan WorkerStats
-	data       map[string]WorkerStats // To store the worker stats
-	stop       chan struct{}          // Channel to signal stopping
-	stopOnce   sync.Once
-	promPrefix string
+	config         *pkgconfig.Config
+	metrics        map[string]*prometheus.Desc
+	Record         chan WorkerStats
+	data           map[string]WorkerStats // To store the worker stats
+	RecordEndpoint chan EndpointStats
+	endpoints      map[string]EndpointStats // To store the endpoint stats by worker and endpoint
+	stop           chan struct{}            // Channel to signal stopping
+	stopOnce       sync.Once
+	promPrefix     string
 }
 
 func NewPrometheusCollector(config *pkgconfig.Config) *PrometheusCollector {
 	t := &PrometheusCollector{
-		config: config,
-		Record: make(chan WorkerStats),
-		data:   make(map[string]WorkerStats),
-		stop:   make(chan struct{}),
+		config:         config,
+		Record:         make(chan WorkerStats),
+		data:           make(map[string]WorkerStats),
+		RecordEndpoint: make(chan EndpointStats),
+		endpoints:      make(map[string]EndpointStats),
+		stop:           make(chan struct{}),
 	}
 
 	t.promPrefix = SanitizeMetricName(config.Global.Telemetry.PromPrefix)
@@ -74,6 +91,27 @@ func NewPrometheusCollector(config *pkgconfig.Config) *PrometheusCollector {
 		"policy_dropped_total": prometheus.NewDesc(
 			fmt.Sprintf("%s_policy_dropped_total", t.promPrefix),
 			"Total number of dropped policy", []string{"worker"}, nil),
+		"spool_spooled_total": prometheus.NewDesc(
+			fmt.Sprintf("%s_worker_spooled_total", t.promPrefix),
+			"Total number of records written to the disk spool", []string{"worker"}, nil),
+		"spool_replayed_total": prometheus.NewDesc(
+			fmt.Sprintf("%s_worker_replayed_total", t.promPrefix),
+			"Total number of records replayed from the disk spool", []string{"worker"}, nil),
+		"spool_evicted_total": prometheus.NewDesc(
+			fmt.Sprintf("%s_worker_evicted_total", t.promPrefix),
+			"Total number of records evicted from the full disk spool", []string{"worker"}, nil),
+		"endpoint_up": prometheus.NewDesc(
+			fmt.Sprintf("%s_worker_endpoint_up", t.promPrefix),
+			"Health of each remote endpoint of the worker", []string{"worker", "endpoint"}, nil),
+		"endpoint_sent_total": prometheus.NewDesc(
+			fmt.Sprintf("%s_worker_endpoint_sent_total", t.promPrefix),
+			"Total number of messages sent to each remote endpoint", []string{"worker", "endpoint"}, nil),
+		"endpoint_errors_total": prometheus.NewDesc(
+			fmt.Sprintf("%s_worker_endpoint_errors_total", t.promPrefix),
+			"Total number of errors with each remote endpoint", []string{"worker", "endpoint"}, nil),
+		"endpoint_connections_total": prometheus.NewDesc(
+			fmt.Sprintf("%s_worker_endpoint_connections_total", t.promPrefix),
+			"Total number of connections established with each remote endpoint", []string{"worker", "endpoint"}, nil),
 	}
 	return t
 }
@@ -92,9 +130,16 @@ func (t *PrometheusCollector) UpdateStats() {
 				updatedWs.TotalIngress += ws.TotalIngress
 				updatedWs.TotalEgress += ws.TotalEgress
 				updatedWs.TotalDiscarded += ws.TotalDiscarded
+				updatedWs.TotalSpooled += ws.TotalSpooled
+				updatedWs.TotalReplayed += ws.TotalReplayed
+				updatedWs.TotalEvicted += ws.TotalEvicted
 				t.data[ws.Name] = updatedWs
 			}
 			t.Unlock()
+		case es := <-t.RecordEndpoint:
+			t.Lock()
+			t.endpoints[es.Worker+"/"+es.Endpoint] = es
+			t.Unlock()
 		case <-t.stop:
 			// Received stop signal, exit the goroutine
 			return
@@ -109,6 +154,13 @@ func (t *PrometheusCollector) GetWorkerStats(workerName string) (WorkerStats, bo
 	return ws, ok
 }
 
+func (t *PrometheusCollector) GetEndpointStats(workerName, endpoint string) (EndpointStats, bool) {
+	t.Lock()
+	defer t.Unlock()
+	es, ok := t.endpoints[workerName+"/"+endpoint]
+	return es, ok
+}
+
 func (t *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
 	t.Lock()
 	defer t.Unlock()
@@ -145,6 +197,36 @@ func (t *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
 			float64(ws.TotalDroppedPolicy),
 			ws.Name,
 		)
+		ch <- prometheus.MustNewConstMetric(
+			t.metrics["spool_spooled_total"],
+			prometheus.CounterValue,
+			float64(ws.TotalSpooled),
+			ws.Name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			t.metrics["spool_replayed_total"],
+			prometheus.CounterValue,
+			float64(ws.TotalReplayed),
+			ws.Name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			t.metrics["spool_evicted_total"],
+			prometheus.CounterValue,
+			float64(ws.TotalEvicted),
+			ws.Name,
+		)
+	}
+
+	// Collect the metrics of the remote endpoints
+	for _, es := range t.endpoints {
+		up := 0.0
+		if es.Up {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(t.metrics["endpoint_up"], prometheus.GaugeValue, up, es.Worker, es.Endpoint)
+		ch <- prometheus.MustNewConstMetric(t.metrics["endpoint_sent_total"], prometheus.CounterValue, float64(es.TotalSent), es.Worker, es.Endpoint)
+		ch <- prometheus.MustNewConstMetric(t.metrics["endpoint_errors_total"], prometheus.CounterValue, float64(es.TotalErrors), es.Worker, es.Endpoint)
+		ch <- prometheus.MustNewConstMetric(t.metrics["endpoint_connections_total"], prometheus.CounterValue, float64(es.TotalConns), es.Worker, es.Endpoint)
 	}
 }
 
@@ -48,3 +48,20 @@ func TestTelemetry_PrometheusCollectorUpdateStats(t *testing.T) {
 	assert.Equal(t, ws.TotalDroppedPolicy, storedWS.TotalDroppedPolicy)
 	assert.Equal(t, ws.TotalDiscarded, storedWS.TotalDiscarded)
 }
+
+func TestTelemetry_PrometheusCollectorEndpointStats(t *testing.T) {
+	collector := NewPrometheusCollector(&pkgconfig.Config{})
+	go collector.UpdateStats()
+	defer collector.Stop()
+
+	// the endpoint stats are cumulative, the last record is kept
+	collector.RecordEndpoint <- EndpointStats{Worker: "dnstap", Endpoint: "10.0.0.1:6000", Up: true, TotalSent: 10, TotalConns: 1}
+	collector.RecordEndpoint <- EndpointStats{Worker: "dnstap", Endpoint: "10.0.0.1:6000", Up: false, TotalSent: 15, TotalErrors: 1, TotalConns: 1}
+	collector.Record <- WorkerStats{Name: "dnstap"}
+
+	es, ok := collector.GetEndpointStats("dnstap", "10.0.0.1:6000")
+	assert.True(t, ok, "Endpoint stats should be present in the collector")
+	assert.False(t, es.Up)
+	assert.Equal(t, 15, es.TotalSent)
+	assert.Equal(t, 1, es.TotalErrors)
+}
@@ -0,0 +1,187 @@
+package transformers
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+)
+
+const (
+	CorrelationAnswered   = "answered"
+	CorrelationUnanswered = "unanswered"
+	CorrelationUnmatched  = "unmatched"
+)
+
+type pendingQuery struct {
+	dm    dnsutils.DNSMessage
+	timer *time.Timer
+}
+
+// correlation transformer, the queries are kept until the reply is received
+// and one message is emitted per transaction
+type CorrelationTransform struct {
+	GenericTransformer
+	sync.Mutex
+	pending map[uint64]*pendingQuery
+	dropped int
+}
+
+func NewCorrelationTransform(config *pkgconfig.ConfigTransformers, logger *logger.Logger, name string, instance int, nextWorkers []chan dnsutils.DNSMessage) *CorrelationTransform {
+	t := &CorrelationTransform{GenericTransformer: NewTransformer(config, logger, "correlation", name, instance, nextWorkers)}
+	t.pending = make(map[uint64]*pendingQuery)
+	return t
+}
+
+func (t *CorrelationTransform) GetTransforms() ([]Subtransform, error) {
+	subtransforms := []Subtransform{}
+	if t.config.Correlation.Enable {
+		subtransforms = append(subtransforms, Subtransform{name: "correlation:merge", processFunc: t.correlate})
+	}
+	return subtransforms, nil
+}
+
+func (t *CorrelationTransform) Reset() {
+	t.Lock()
+	defer t.Unlock()
+	for key, q := range t.pending {
+		q.timer.Stop()
+		delete(t.pending, key)
+	}
+}
+
+// SetNextWorkers updates the workers receiving the unanswered queries
+func (t *CorrelationTransform) SetNextWorkers(nextWorkers []chan dnsutils.DNSMessage) {
+	t.Lock()
+	defer t.Unlock()
+	t.nextWorkers = nextWorkers
+}
+
+// Dropped returns the number of unanswered queries discarded because the next workers were busy
+func (t *CorrelationTransform) Dropped() int {
+	t.Lock()
+	defer t.Unlock()
+	return t.dropped
+}
+
+// Pending returns the number of queries waiting for a reply
+func (t *CorrelationTransform) Pending() int {
+	t.Lock()
+	defer t.Unlock()
+	return len(t.pending)
+}
+
+func (t *CorrelationTransform) hashTransaction(dm *dnsutils.DNSMessage) uint64 {
+	hashData := []string{
+		dm.DNSTap.Identity,
+		dm.NetworkInfo.QueryIP, dm.NetworkInfo.QueryPort,
+		dm.NetworkInfo.ResponseIP, dm.NetworkInfo.ResponsePort,
+		strconv.Itoa(dm.DNS.ID), strings.ToLower(dm.DNS.Qname),
+	}
+	hashfnv := fnv.New64a()
+	hashfnv.Write([]byte(strings.Join(hashData, "+")))
+	return hashfnv.Sum64()
+}
+
+func (t *CorrelationTransform) correlate(dm *dnsutils.DNSMessage) (int, error) {
+	queryport, _ := strconv.Atoi(dm.NetworkInfo.QueryPort)
+	if len(dm.NetworkInfo.QueryIP) == 0 || queryport == 0 || dm.DNS.MalformedPacket {
+		return ReturnKeep, nil
+	}
+
+	key := t.hashTransaction(dm)
+	if dm.DNS.Type == dnsutils.DNSQuery || dm.DNS.Type == dnsutils.DNSQueryQuiet {
+		return t.holdQuery(key, dm), nil
+	}
+
+	// reply received, merge it with the query
+	t.Lock()
+	q, ok := t.pending[key]
+	if ok {
+		q.timer.Stop()
+		delete(t.pending, key)
+	}
+	t.Unlock()
+
+	if !ok {
+		dm.Correlation = &dnsutils.TransformCorrelation{
+			Status:            CorrelationUnmatched,
+			QueryTimestamp:    "-",
+			ResponseTimestamp: dm.DNSTap.TimestampRFC3339,
+			ResponseLength:    dm.DNS.Length,
+		}
+		return ReturnKeep, nil
+	}
+
+	latency := float64(dm.DNSTap.Timestamp-q.dm.DNSTap.Timestamp) / float64(1000000000)
+	dm.DNSTap.Latency = latency
+	dm.Correlation = &dnsutils.TransformCorrelation{
+		Status:            CorrelationAnswered,
+		QueryTimestamp:    q.dm.DNSTap.TimestampRFC3339,
+		ResponseTimestamp: dm.DNSTap.TimestampRFC3339,
+		Latency:           latency,
+		QueryLength:       q.dm.DNS.Length,
+		ResponseLength:    dm.DNS.Length,
+	}
+	return ReturnKeep, nil
+}
+
+// holdQuery keeps the query until the reply or the timeout, a retransmission
+// replaces the previous query
+func (t *CorrelationTransform) holdQuery(key uint64, dm *dnsutils.DNSMessage) int {
+	t.Lock()
+	defer t.Unlock()
+
+	if q, ok := t.pending[key]; ok {
+		q.timer.Stop()
+	} else if len(t.pending) >= t.config.Correlation.MaxPendingQueries {
+		// too many pending queries, the query is not correlated
+		return ReturnKeep
+	}
+
+	t.pending[key] = &pendingQuery{
+		dm: *dm,
+		timer: time.AfterFunc(time.Duration(t.config.Correlation.QueriesTimeout)*time.Second, func() {
+			t.expireQuery(key)
+		}),
+	}
+	return ReturnDrop
+}
+
+// expireQuery sends the unanswered query to the next workers, the query is
+// discarded if a worker is busy to not block the timer
+func (t *CorrelationTransform) expireQuery(key uint64) {
+	t.Lock()
+	q, ok := t.pending[key]
+	delete(t.pending, key)
+	nextWorkers := t.nextWorkers
+	t.Unlock()
+
+	if !ok || !t.config.Correlation.UnansweredQueries {
+		return
+	}
+
+	dm := q.dm
+	dm.DNS.Rcode = "TIMEOUT"
+	dm.Correlation = &dnsutils.TransformCorrelation{
+		Status:            CorrelationUnanswered,
+		QueryTimestamp:    dm.DNSTap.TimestampRFC3339,
+		ResponseTimestamp: "-",
+		QueryLength:       dm.DNS.Length,
+	}
+	for i := range nextWorkers {
+		select {
+		case nextWorkers[i] <- dm:
+		default:
+			t.Lock()
+			t.dropped++
+			t.Unlock()
+			t.LogError("next worker is busy, unanswered query discarded")
+		}
+	}
+}
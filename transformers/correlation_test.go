@@ -0,0 +1,191 @@
+package transformers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+)
+
+func TestCorrelation_MergeQueryReply(t *testing.T) {
+	// enable feature
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Correlation.Enable = true
+
+	outChannels := []chan dnsutils.DNSMessage{}
+
+	// init transformer
+	correlation := NewCorrelationTransform(config, logger.New(false), "test", 0, outChannels)
+	correlation.GetTransforms()
+	defer correlation.Reset()
+
+	// register the query
+	CQ := dnsutils.GetFakeDNSMessage()
+	CQ.DNS.Type = dnsutils.DNSQuery
+	CQ.DNS.ID = 42
+	CQ.DNS.Length = 30
+	CQ.DNSTap.Timestamp = 1704486841216166066
+	CQ.DNSTap.TimestampRFC3339 = "2024-01-05T20:34:01.216166066Z"
+
+	if ret, _ := correlation.correlate(&CQ); ret != ReturnDrop {
+		t.Errorf("query should be held")
+	}
+	if correlation.Pending() != 1 {
+		t.Errorf("expected one pending query, got %d", correlation.Pending())
+	}
+
+	// the reply
+	CR := dnsutils.GetFakeDNSMessage()
+	CR.InitTransforms()
+	CR.DNS.Type = dnsutils.DNSReply
+	CR.DNS.ID = 42
+	CR.DNS.Length = 46
+	CR.DNS.Rcode = "NXDOMAIN"
+	CR.DNSTap.Timestamp = 1704486841227961611
+	CR.DNSTap.TimestampRFC3339 = "2024-01-05T20:34:01.227961611Z"
+
+	if ret, _ := correlation.correlate(&CR); ret != ReturnKeep {
+		t.Errorf("merged message should be kept")
+	}
+	if correlation.Pending() != 0 {
+		t.Errorf("pending query not removed")
+	}
+
+	if CR.Correlation.Status != CorrelationAnswered {
+		t.Errorf("invalid status, got %s", CR.Correlation.Status)
+	}
+	if CR.Correlation.QueryTimestamp != CQ.DNSTap.TimestampRFC3339 || CR.Correlation.ResponseTimestamp != CR.DNSTap.TimestampRFC3339 {
+		t.Errorf("invalid timestamps, got %s / %s", CR.Correlation.QueryTimestamp, CR.Correlation.ResponseTimestamp)
+	}
+	if CR.Correlation.Latency == 0.0 || CR.Correlation.Latency != CR.DNSTap.Latency {
+		t.Errorf("incorrect latency, got %f", CR.Correlation.Latency)
+	}
+	if CR.Correlation.QueryLength != 30 || CR.Correlation.ResponseLength != 46 {
+		t.Errorf("invalid lengths, got %d / %d", CR.Correlation.QueryLength, CR.Correlation.ResponseLength)
+	}
+	if CR.DNS.Rcode != "NXDOMAIN" {
+		t.Errorf("response rcode expected, got %s", CR.DNS.Rcode)
+	}
+}
+
+func TestCorrelation_UnmatchedReply(t *testing.T) {
+	// enable feature
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Correlation.Enable = true
+
+	correlation := NewCorrelationTransform(config, logger.New(false), "test", 0, []chan dnsutils.DNSMessage{})
+
+	// register a query for another transaction
+	CQ := dnsutils.GetFakeDNSMessage()
+	CQ.DNS.ID = 1
+	correlation.correlate(&CQ)
+	defer correlation.Reset()
+
+	CR := dnsutils.GetFakeDNSMessage()
+	CR.DNS.Type = dnsutils.DNSReply
+	CR.DNS.ID = 2
+
+	if ret, _ := correlation.correlate(&CR); ret != ReturnKeep {
+		t.Errorf("unmatched reply should be kept")
+	}
+	if CR.Correlation.Status != CorrelationUnmatched {
+		t.Errorf("invalid status, got %s", CR.Correlation.Status)
+	}
+	if correlation.Pending() != 1 {
+		t.Errorf("the other query should still be pending")
+	}
+}
+
+func TestCorrelation_UnansweredQueries(t *testing.T) {
+	// enable feature
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Correlation.Enable = true
+	config.Correlation.QueriesTimeout = 1
+
+	outChannels := []chan dnsutils.DNSMessage{}
+	outChannels = append(outChannels, make(chan dnsutils.DNSMessage, 1))
+
+	// init transformer
+	correlation := NewCorrelationTransform(config, logger.New(false), "test", 0, outChannels)
+	correlation.GetTransforms()
+
+	CQ := dnsutils.GetFakeDNSMessage()
+	CQ.DNS.Type = dnsutils.DNSQueryQuiet
+	correlation.correlate(&CQ)
+
+	select {
+	case dm := <-outChannels[0]:
+		if dm.DNS.Rcode != "TIMEOUT" {
+			t.Errorf("incorrect rcode, expected=TIMEOUT, got=%s", dm.DNS.Rcode)
+		}
+		if dm.Correlation.Status != CorrelationUnanswered {
+			t.Errorf("invalid status, got %s", dm.Correlation.Status)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("unanswered query not emitted")
+	}
+}
+
+func TestCorrelation_UnansweredQueries_Routes(t *testing.T) {
+	// enable feature
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Correlation.Enable = true
+	config.Correlation.QueriesTimeout = 1
+
+	// init transformer, the routes change before the timeout
+	correlation := NewCorrelationTransform(config, logger.New(false), "test", 0, []chan dnsutils.DNSMessage{make(chan dnsutils.DNSMessage)})
+	correlation.GetTransforms()
+	outChannels := []chan dnsutils.DNSMessage{make(chan dnsutils.DNSMessage, 1)}
+	correlation.SetNextWorkers(outChannels)
+
+	CQ1 := dnsutils.GetFakeDNSMessage()
+	CQ1.DNS.ID = 1
+	correlation.correlate(&CQ1)
+	CQ2 := dnsutils.GetFakeDNSMessage()
+	CQ2.DNS.ID = 2
+	correlation.correlate(&CQ2)
+
+	// one query is emitted, the other one is discarded because the worker is busy
+	for i := 0; i < 30 && correlation.Dropped() == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if correlation.Dropped() != 1 {
+		t.Errorf("expected one discarded query, got %d", correlation.Dropped())
+	}
+	select {
+	case dm := <-outChannels[0]:
+		if dm.Correlation.Status != CorrelationUnanswered {
+			t.Errorf("invalid status, got %s", dm.Correlation.Status)
+		}
+	default:
+		t.Fatalf("unanswered query not emitted")
+	}
+}
+
+func TestCorrelation_MaxPendingQueries(t *testing.T) {
+	// enable feature
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Correlation.Enable = true
+	config.Correlation.MaxPendingQueries = 1
+
+	correlation := NewCorrelationTransform(config, logger.New(false), "test", 0, []chan dnsutils.DNSMessage{})
+	defer correlation.Reset()
+
+	CQ1 := dnsutils.GetFakeDNSMessage()
+	CQ1.DNS.ID = 1
+	if ret, _ := correlation.correlate(&CQ1); ret != ReturnDrop {
+		t.Errorf("first query should be held")
+	}
+
+	// pending table full, the query is not correlated
+	CQ2 := dnsutils.GetFakeDNSMessage()
+	CQ2.DNS.ID = 2
+	if ret, _ := correlation.correlate(&CQ2); ret != ReturnKeep {
+		t.Errorf("second query should be kept")
+	}
+	if correlation.Pending() != 1 {
+		t.Errorf("expected one pending query, got %d", correlation.Pending())
+	}
+}
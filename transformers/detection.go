@@ -0,0 +1,390 @@
+package transformers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+	lru "github.com/hashicorp/golang-lru/v2"
+	publicsuffixlist "golang.org/x/net/publicsuffix"
+)
+
+const (
+	DetectionTunneling = "tunneling"
+	DetectionDGA       = "dga"
+
+	ModelLogisticRegression = "logistic-regression"
+	ModelDecisionTree       = "decision-tree"
+
+	// maximum number of reasons provided by a logistic regression
+	maxModelReasons = 3
+)
+
+// DetectionFeatures lists the features available for the scoring,
+// computed from the query name and from the state per client and per eTLD+1
+var DetectionFeatures = []string{
+	"entropy", "length", "labels", "ratio-digits", "ratio-letters", "consecutive-consonants",
+	"consecutive-digits", "uncommon-qtypes", "subdomain-length", "qtype-txt-null",
+	"domain-unique-subdomains", "domain-rate", "domain-txt-null-bytes",
+	"client-rate", "client-nxdomains", "client-txt-null-bytes",
+}
+
+// DecisionNode is a node of a decision tree, the left branch is followed
+// when the feature is lower or equal to the threshold, a node without branch is a leaf
+type DecisionNode struct {
+	Feature   string        `json:"feature"`
+	Threshold float64       `json:"threshold"`
+	Left      *DecisionNode `json:"left"`
+	Right     *DecisionNode `json:"right"`
+	Value     float64       `json:"value"`
+}
+
+// DetectionModel is a model exported as JSON: logistic regression or decision tree
+type DetectionModel struct {
+	Type      string             `json:"type"`
+	Intercept float64            `json:"intercept"`
+	Weights   map[string]float64 `json:"weights"`
+	Tree      *DecisionNode      `json:"tree"`
+}
+
+func isDetectionFeature(name string) bool {
+	for _, feature := range DetectionFeatures {
+		if feature == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *DecisionNode) isValid() error {
+	if n.Left == nil && n.Right == nil {
+		return nil
+	}
+	if n.Left == nil || n.Right == nil {
+		return fmt.Errorf("node on feature %s must have two branches", n.Feature)
+	}
+	if !isDetectionFeature(n.Feature) {
+		return fmt.Errorf("unknown feature %s", n.Feature)
+	}
+	if err := n.Left.isValid(); err != nil {
+		return err
+	}
+	return n.Right.isValid()
+}
+
+func (m *DetectionModel) IsValid() error {
+	switch m.Type {
+	case ModelLogisticRegression:
+		for feature := range m.Weights {
+			if !isDetectionFeature(feature) {
+				return fmt.Errorf("unknown feature %s", feature)
+			}
+		}
+	case ModelDecisionTree:
+		if m.Tree == nil {
+			return fmt.Errorf("tree is missing")
+		}
+		return m.Tree.isValid()
+	default:
+		return fmt.Errorf("unsupported model type %s", m.Type)
+	}
+	return nil
+}
+
+// Predict returns the score between 0 and 1 and the features explaining it
+func (m *DetectionModel) Predict(features map[string]float64) (float64, []string) {
+	reasons := []string{}
+	if m.Type == ModelDecisionTree {
+		node := m.Tree
+		for node.Left != nil {
+			value := features[node.Feature]
+			if value <= node.Threshold {
+				reasons = append(reasons, fmt.Sprintf("%s<=%s", node.Feature, formatFeature(node.Threshold)))
+				node = node.Left
+			} else {
+				reasons = append(reasons, fmt.Sprintf("%s>%s", node.Feature, formatFeature(node.Threshold)))
+				node = node.Right
+			}
+		}
+		return node.Value, reasons
+	}
+
+	// logistic regression, the reasons are the features with the highest contributions
+	type contribution struct {
+		feature string
+		value   float64
+	}
+	contributions := []contribution{}
+	z := m.Intercept
+	for feature, weight := range m.Weights {
+		c := weight * features[feature]
+		z += c
+		if c > 0 {
+			contributions = append(contributions, contribution{feature, c})
+		}
+	}
+	sort.Slice(contributions, func(i, j int) bool {
+		return contributions[i].value > contributions[j].value
+	})
+	for i := 0; i < len(contributions) && i < maxModelReasons; i++ {
+		feature := contributions[i].feature
+		reasons = append(reasons, fmt.Sprintf("%s=%s", feature, formatFeature(features[feature])))
+	}
+	return 1 / (1 + math.Exp(-z)), reasons
+}
+
+// LoadDetectionModels reads the models per target (tunneling, dga) from a JSON file
+func LoadDetectionModels(filename string) (map[string]*DetectionModel, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	models := make(map[string]*DetectionModel)
+	if err := json.Unmarshal(data, &models); err != nil {
+		return nil, err
+	}
+	for target, model := range models {
+		if target != DetectionTunneling && target != DetectionDGA {
+			return nil, fmt.Errorf("unknown target %s", target)
+		}
+		if err := model.IsValid(); err != nil {
+			return nil, fmt.Errorf("%s model - %w", target, err)
+		}
+	}
+	return models, nil
+}
+
+func formatFeature(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// detectionCounters is the state of a client or of an eTLD+1 during the watch interval
+type detectionCounters struct {
+	start       time.Time
+	messages    int
+	nxdomains   int
+	txtNullSize int
+	subdomains  map[string]struct{}
+}
+
+type DetectionTransform struct {
+	GenericTransformer
+	models  map[string]*DetectionModel
+	clients *lru.Cache[string, *detectionCounters]
+	domains *lru.Cache[string, *detectionCounters]
+	now     func() time.Time
+}
+
+func NewDetectionTransform(config *pkgconfig.ConfigTransformers, logger *logger.Logger, name string, instance int, nextWorkers []chan dnsutils.DNSMessage) *DetectionTransform {
+	t := &DetectionTransform{GenericTransformer: NewTransformer(config, logger, "detection", name, instance, nextWorkers)}
+	t.now = time.Now
+	return t
+}
+
+func (t *DetectionTransform) GetTransforms() ([]Subtransform, error) {
+	subtransforms := []Subtransform{}
+	if t.config.Detection.Enable {
+		t.models = make(map[string]*DetectionModel)
+		if len(t.config.Detection.ModelFile) > 0 {
+			models, err := LoadDetectionModels(t.config.Detection.ModelFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load model file: %w", err)
+			}
+			t.models = models
+			t.LogInfo("loaded with %d model(s)", len(t.models))
+		}
+
+		var err error
+		if t.clients, err = lru.New[string, *detectionCounters](t.config.Detection.MaxTrackedEntries); err != nil {
+			return nil, err
+		}
+		if t.domains, err = lru.New[string, *detectionCounters](t.config.Detection.MaxTrackedEntries); err != nil {
+			return nil, err
+		}
+
+		subtransforms = append(subtransforms, Subtransform{name: "detection:score", processFunc: t.scoreMessage})
+	}
+	return subtransforms, nil
+}
+
+// getCounters returns the state of the key, reset at the end of the watch interval
+func (t *DetectionTransform) getCounters(cache *lru.Cache[string, *detectionCounters], key string, now time.Time) *detectionCounters {
+	counters, ok := cache.Get(key)
+	if !ok || now.Sub(counters.start) >= time.Duration(t.config.Detection.WatchInterval)*time.Second {
+		counters = &detectionCounters{start: now, subdomains: make(map[string]struct{})}
+		cache.Add(key, counters)
+	}
+	return counters
+}
+
+func getEffectiveTldPlusOne(dm *dnsutils.DNSMessage) string {
+	if dm.PublicSuffix != nil && len(dm.PublicSuffix.QnameEffectiveTLDPlusOne) > 0 && dm.PublicSuffix.QnameEffectiveTLDPlusOne != "-" {
+		return dm.PublicSuffix.QnameEffectiveTLDPlusOne
+	}
+	qname := strings.ToLower(strings.TrimSuffix(dm.DNS.Qname, "."))
+	if etld, err := publicsuffixlist.EffectiveTLDPlusOne(qname); err == nil {
+		return etld
+	}
+	return qname
+}
+
+// updateState records the message in the state of the client and of the eTLD+1
+func (t *DetectionTransform) updateState(dm *dnsutils.DNSMessage, domain, subdomain string) (*detectionCounters, *detectionCounters) {
+	now := t.now()
+	client := t.getCounters(t.clients, dm.NetworkInfo.QueryIP, now)
+	etld := t.getCounters(t.domains, domain, now)
+
+	client.messages++
+	etld.messages++
+	if dm.DNS.Rcode == dnsutils.DNSRcodeNXDomain {
+		client.nxdomains++
+		etld.nxdomains++
+	}
+
+	for _, rr := range dm.DNS.DNSRRs.Answers {
+		if rr.Rdatatype == "TXT" || rr.Rdatatype == "NULL" {
+			client.txtNullSize += len(rr.Rdata)
+			etld.txtNullSize += len(rr.Rdata)
+		}
+	}
+
+	// the number of subdomains kept is limited, the threshold is enough to score
+	if len(subdomain) > 0 && len(etld.subdomains) < 2*t.config.Detection.ThresholdUniqueSubdomains {
+		etld.subdomains[subdomain] = struct{}{}
+	}
+	return client, etld
+}
+
+func (t *DetectionTransform) getFeatures(dm *dnsutils.DNSMessage, ml *dnsutils.TransformML, subdomain string, client, etld *detectionCounters) map[string]float64 {
+	features := map[string]float64{
+		"entropy":                  ml.Entropy,
+		"length":                   float64(ml.Length),
+		"labels":                   float64(ml.Labels),
+		"ratio-digits":             ml.RatioDigits,
+		"ratio-letters":            ml.RatioLetters,
+		"consecutive-consonants":   float64(ml.ConsecutiveConsonants),
+		"consecutive-digits":       float64(ml.ConsecutiveDigits),
+		"uncommon-qtypes":          float64(ml.UncommonQtypes),
+		"subdomain-length":         float64(len(subdomain)),
+		"qtype-txt-null":           0,
+		"domain-unique-subdomains": float64(len(etld.subdomains)),
+		"domain-rate":              float64(etld.messages),
+		"domain-txt-null-bytes":    float64(etld.txtNullSize),
+		"client-rate":              float64(client.messages),
+		"client-nxdomains":         float64(client.nxdomains),
+		"client-txt-null-bytes":    float64(client.txtNullSize),
+	}
+	if dm.DNS.Qtype == "TXT" || dm.DNS.Qtype == "NULL" {
+		features["qtype-txt-null"] = 1
+	}
+	return features
+}
+
+// scoreTunneling applies the default rules when no model is provided
+func (t *DetectionTransform) scoreTunneling(features map[string]float64) (float64, []string) {
+	cfg := t.config.Detection
+	rules := []struct {
+		matched bool
+		weight  float64
+		reason  string
+	}{
+		{features["domain-unique-subdomains"] >= float64(cfg.ThresholdUniqueSubdomains), 0.4, "domain-unique-subdomains"},
+		{features["domain-txt-null-bytes"] >= float64(cfg.ThresholdTxtNullBytes), 0.3, "domain-txt-null-bytes"},
+		{features["client-rate"] >= float64(cfg.ThresholdQueryRate), 0.2, "client-rate"},
+		{features["subdomain-length"] >= float64(cfg.ThresholdSubdomainLen) && features["entropy"] >= cfg.ThresholdEntropy, 0.3, "subdomain-length"},
+		{features["qtype-txt-null"] == 1, 0.1, "qtype-txt-null"},
+	}
+
+	score, reasons := 0.0, []string{}
+	for _, rule := range rules {
+		if rule.matched {
+			score += rule.weight
+			reasons = append(reasons, fmt.Sprintf("%s=%s", rule.reason, formatFeature(features[rule.reason])))
+		}
+	}
+	return score, reasons
+}
+
+// scoreDGA applies the default rules when no model is provided
+func (t *DetectionTransform) scoreDGA(features map[string]float64) (float64, []string) {
+	cfg := t.config.Detection
+	rules := []struct {
+		matched bool
+		weight  float64
+		reason  string
+	}{
+		{features["entropy"] >= cfg.ThresholdEntropy, 0.3, "entropy"},
+		{features["ratio-digits"] >= 0.3, 0.2, "ratio-digits"},
+		{features["consecutive-consonants"] >= float64(cfg.ThresholdConsonants), 0.2, "consecutive-consonants"},
+		{features["client-nxdomains"] >= float64(cfg.ThresholdNxdomains), 0.3, "client-nxdomains"},
+	}
+
+	score, reasons := 0.0, []string{}
+	for _, rule := range rules {
+		if rule.matched {
+			score += rule.weight
+			reasons = append(reasons, fmt.Sprintf("%s=%s", rule.reason, formatFeature(features[rule.reason])))
+		}
+	}
+	return score, reasons
+}
+
+func (t *DetectionTransform) score(target string, features map[string]float64) (float64, []string) {
+	var score float64
+	var reasons []string
+	if model, ok := t.models[target]; ok {
+		score, reasons = model.Predict(features)
+	} else if target == DetectionTunneling {
+		score, reasons = t.scoreTunneling(features)
+	} else {
+		score, reasons = t.scoreDGA(features)
+	}
+
+	for i := range reasons {
+		reasons[i] = target + ":" + reasons[i]
+	}
+	return math.Round(math.Min(math.Max(score, 0), 1)*100) / 100, reasons
+}
+
+func (t *DetectionTransform) scoreMessage(dm *dnsutils.DNSMessage) (int, error) {
+	// the features of the machine-learning transformer are required, they are
+	// computed on a copy when the transformer is disabled to keep the message unchanged
+	ml := dm.MachineLearning
+	if ml == nil {
+		tmp := dnsutils.DNSMessage{DNS: dm.DNS, Reducer: dm.Reducer}
+		AddMLFeatures(&tmp)
+		ml = tmp.MachineLearning
+	}
+
+	domain := getEffectiveTldPlusOne(dm)
+	subdomain := strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(strings.TrimSuffix(dm.DNS.Qname, ".")), domain), ".")
+
+	client, etld := t.updateState(dm, domain, subdomain)
+	features := t.getFeatures(dm, ml, subdomain, client, etld)
+
+	tunnelingScore, tunnelingReasons := t.score(DetectionTunneling, features)
+	dgaScore, dgaReasons := t.score(DetectionDGA, features)
+
+	dm.Detection = &dnsutils.TransformDetection{
+		Score:          math.Max(tunnelingScore, dgaScore),
+		TunnelingScore: tunnelingScore,
+		DGAScore:       dgaScore,
+		Reasons:        append(tunnelingReasons, dgaReasons...),
+	}
+
+	if t.config.Detection.DropScoreAbove > 0 && dm.Detection.Score >= t.config.Detection.DropScoreAbove {
+		return ReturnDrop, nil
+	}
+	if t.config.Detection.DropScoreBelow > 0 && dm.Detection.Score < t.config.Detection.DropScoreBelow {
+		return ReturnDrop, nil
+	}
+	return ReturnKeep, nil
+}
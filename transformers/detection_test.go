@@ -0,0 +1,210 @@
+package transformers
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+)
+
+func TestDetection_DGA(t *testing.T) {
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Detection.Enable = true
+
+	outChans := []chan dnsutils.DNSMessage{}
+	detection := NewDetectionTransform(config, logger.New(false), "test", 0, outChans)
+	detection.GetTransforms()
+
+	// benign domain
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNS.Qname = "www.google.com"
+	if _, err := detection.scoreMessage(&dm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dm.Detection.Score != 0 || len(dm.Detection.Reasons) != 0 {
+		t.Errorf("unexpected detection for benign domain: %+v", dm.Detection)
+	}
+	// the features are not added when the machine-learning transformer is disabled
+	if dm.MachineLearning != nil {
+		t.Errorf("the machine-learning features must not be added: %+v", dm.MachineLearning)
+	}
+
+	// generated domain
+	dm = dnsutils.GetFakeDNSMessage()
+	dm.DNS.Qname = "x7k29vz4mq8t8wbr3n5xk.com"
+	detection.scoreMessage(&dm)
+	if dm.Detection.DGAScore != 0.5 {
+		t.Errorf("unexpected dga score: %+v", dm.Detection)
+	}
+	if dm.Detection.Score != dm.Detection.DGAScore {
+		t.Errorf("score must be the highest one: %+v", dm.Detection)
+	}
+	if !strings.HasPrefix(dm.Detection.Reasons[0], "dga:entropy=") {
+		t.Errorf("unexpected reasons: %v", dm.Detection.Reasons)
+	}
+}
+
+func TestDetection_Tunneling(t *testing.T) {
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Detection.Enable = true
+	config.Detection.ThresholdUniqueSubdomains = 10
+	config.Detection.ThresholdTxtNullBytes = 100
+
+	outChans := []chan dnsutils.DNSMessage{}
+	detection := NewDetectionTransform(config, logger.New(false), "test", 0, outChans)
+	detection.GetTransforms()
+
+	dm := dnsutils.GetFakeDNSMessage()
+	for i := 0; i < 10; i++ {
+		dm = dnsutils.GetFakeDNSMessage()
+		dm.DNS.Qname = "chunk" + strconv.Itoa(i) + ".tunnel.example.com"
+		dm.DNS.Qtype = "TXT"
+		dm.DNS.DNSRRs.Answers = []dnsutils.DNSAnswer{{Name: dm.DNS.Qname, Rdatatype: "TXT", Rdata: strings.Repeat("a", 20)}}
+		detection.scoreMessage(&dm)
+	}
+
+	if dm.Detection.TunnelingScore != 0.8 {
+		t.Errorf("unexpected tunneling score: %+v", dm.Detection)
+	}
+	expected := "tunneling:domain-unique-subdomains=10,tunneling:domain-txt-null-bytes=200,tunneling:qtype-txt-null=1"
+	if strings.Join(dm.Detection.Reasons, ",") != expected {
+		t.Errorf("unexpected reasons: %v", dm.Detection.Reasons)
+	}
+}
+
+func TestDetection_WatchInterval(t *testing.T) {
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Detection.Enable = true
+	config.Detection.WatchInterval = 10
+	config.Detection.ThresholdQueryRate = 2
+
+	outChans := []chan dnsutils.DNSMessage{}
+	detection := NewDetectionTransform(config, logger.New(false), "test", 0, outChans)
+	detection.GetTransforms()
+
+	now := time.Now()
+	detection.now = func() time.Time { return now }
+
+	dm := dnsutils.GetFakeDNSMessage()
+	detection.scoreMessage(&dm)
+	detection.scoreMessage(&dm)
+	if dm.Detection.TunnelingScore != 0.2 {
+		t.Errorf("query rate not detected: %+v", dm.Detection)
+	}
+
+	// the state is reset after the watch interval
+	now = now.Add(11 * time.Second)
+	detection.scoreMessage(&dm)
+	if dm.Detection.TunnelingScore != 0 {
+		t.Errorf("state not reset: %+v", dm.Detection)
+	}
+}
+
+func TestDetection_Models(t *testing.T) {
+	modelFile := filepath.Join(t.TempDir(), "model.json")
+	model := `{
+		"tunneling": {
+			"type": "logistic-regression",
+			"intercept": -2,
+			"weights": {"subdomain-length": 0.1, "qtype-txt-null": 1, "labels": -0.1}
+		},
+		"dga": {
+			"type": "decision-tree",
+			"tree": {
+				"feature": "entropy", "threshold": 3,
+				"left": {"value": 0.1},
+				"right": {"feature": "ratio-digits", "threshold": 0.2, "left": {"value": 0.5}, "right": {"value": 0.9}}
+			}
+		}
+	}`
+	if err := os.WriteFile(modelFile, []byte(model), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Detection.Enable = true
+	config.Detection.ModelFile = modelFile
+
+	outChans := []chan dnsutils.DNSMessage{}
+	detection := NewDetectionTransform(config, logger.New(false), "test", 0, outChans)
+	if _, err := detection.GetTransforms(); err != nil {
+		t.Fatalf("unable to load models: %v", err)
+	}
+
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNS.Qname = "x7k29vz4mq8t8wbr3n5xk.example.com"
+	dm.DNS.Qtype = "TXT"
+	detection.scoreMessage(&dm)
+
+	// sigmoid(-2 + 2.1 + 1 - 0.3)
+	if dm.Detection.TunnelingScore != 0.69 {
+		t.Errorf("unexpected tunneling score: %+v", dm.Detection)
+	}
+	if dm.Detection.DGAScore != 0.9 {
+		t.Errorf("unexpected dga score: %+v", dm.Detection)
+	}
+	expected := "tunneling:subdomain-length=21,tunneling:qtype-txt-null=1,dga:entropy>3,dga:ratio-digits>0.2"
+	if strings.Join(dm.Detection.Reasons, ",") != expected {
+		t.Errorf("unexpected reasons: %v", dm.Detection.Reasons)
+	}
+}
+
+func TestDetection_InvalidModels(t *testing.T) {
+	testcases := []struct {
+		name  string
+		model string
+	}{
+		{"invalid_json", `{`},
+		{"unknown_target", `{"malware": {"type": "logistic-regression"}}`},
+		{"unknown_type", `{"dga": {"type": "svm"}}`},
+		{"unknown_feature", `{"dga": {"type": "logistic-regression", "weights": {"foo": 1}}}`},
+		{"missing_tree", `{"dga": {"type": "decision-tree"}}`},
+		{"missing_branch", `{"dga": {"type": "decision-tree", "tree": {"feature": "entropy", "left": {"value": 1}}}}`},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			modelFile := filepath.Join(t.TempDir(), "model.json")
+			if err := os.WriteFile(modelFile, []byte(tc.model), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadDetectionModels(modelFile); err == nil {
+				t.Errorf("error expected for model %s", tc.model)
+			}
+		})
+	}
+}
+
+func TestDetection_Drop(t *testing.T) {
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Detection.Enable = true
+	config.Detection.DropScoreBelow = 0.5
+
+	outChans := []chan dnsutils.DNSMessage{}
+	detection := NewDetectionTransform(config, logger.New(false), "test", 0, outChans)
+	detection.GetTransforms()
+
+	dm := dnsutils.GetFakeDNSMessage()
+	if ret, _ := detection.scoreMessage(&dm); ret != ReturnDrop {
+		t.Errorf("benign message must be dropped")
+	}
+
+	dm = dnsutils.GetFakeDNSMessage()
+	dm.DNS.Qname = "x7k29vz4mq8t8wbr3n5xk.com"
+	if ret, _ := detection.scoreMessage(&dm); ret != ReturnKeep {
+		t.Errorf("detected message must be kept")
+	}
+
+	// drop the detected messages
+	config.Detection.DropScoreBelow = 0
+	config.Detection.DropScoreAbove = 0.5
+	if ret, _ := detection.scoreMessage(&dm); ret != ReturnDrop {
+		t.Errorf("detected message must be dropped")
+	}
+}
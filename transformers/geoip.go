@@ -47,6 +47,9 @@ func (t *GeoIPTransform) GetTransforms() ([]Subtransform, error) {
 			return nil, fmt.Errorf("open error %w", err)
 		}
 		subtransforms = append(subtransforms, Subtransform{name: "geoip:lookup", processFunc: t.geoipTransform})
+		if t.config.GeoIP.LookupAnswers {
+			subtransforms = append(subtransforms, Subtransform{name: "geoip:lookup-answers", processFunc: t.geoipAnswersTransform})
+		}
 	}
 	return subtransforms, nil
 }
@@ -163,6 +166,33 @@ func (t *GeoIPTransform) geoipTransform(dm *dnsutils.DNSMessage) (int, error) {
 	return ReturnKeep, nil
 }
 
+// geoipAnswersTransform enriches each address found in the A/AAAA answer records
+func (t *GeoIPTransform) geoipAnswersTransform(dm *dnsutils.DNSMessage) (int, error) {
+	if dm.Geo == nil {
+		dm.Geo = &dnsutils.TransformDNSGeo{CountryIsoCode: "-", City: "-", Continent: "-", AutonomousSystemNumber: "-", AutonomousSystemOrg: "-"}
+	}
+
+	dm.Geo.Answers = nil
+	for _, rr := range dm.DNS.DNSRRs.Answers {
+		if rr.Rdatatype != "A" && rr.Rdatatype != "AAAA" {
+			continue
+		}
+		geoInfo, err := t.Lookup(rr.Rdata)
+		if err != nil {
+			return ReturnKeep, err
+		}
+		dm.Geo.Answers = append(dm.Geo.Answers, dnsutils.TransformDNSGeoAnswer{
+			IP:                     rr.Rdata,
+			City:                   geoInfo.City,
+			Continent:              geoInfo.Continent,
+			CountryIsoCode:         geoInfo.CountryISOCode,
+			AutonomousSystemNumber: geoInfo.ASN,
+			AutonomousSystemOrg:    geoInfo.ASO,
+		})
+	}
+	return ReturnKeep, nil
+}
+
 // lookupECSIP extracts the ECS IP from the EDNS options if available and valid.
 func lookupECSIP(dm *dnsutils.DNSMessage) string {
 	for _, opt := range dm.EDNS.Options {
@@ -157,3 +157,40 @@ func TestGeoIP_Lookup_ECS(t *testing.T) {
 	// Ensure the return code is ReturnKeep
 	require.Equal(t, ReturnKeep, returnCode, "unexpected return code")
 }
+
+func TestGeoIP_LookupAnswers(t *testing.T) {
+	// enable geoip without database, the records are populated with the default values
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.GeoIP.Enable = true
+	config.GeoIP.LookupAnswers = true
+
+	outChans := []chan dnsutils.DNSMessage{}
+
+	// init the processor
+	geoip := NewDNSGeoIPTransform(config, logger.New(false), "test", 0, outChans)
+	subtransforms, err := geoip.GetTransforms()
+	if err != nil {
+		t.Fatalf("geoip init failed: %v+", err)
+	}
+	defer geoip.Close()
+	if len(subtransforms) != 2 || subtransforms[1].name != "geoip:lookup-answers" {
+		t.Fatalf("lookup-answers transform not enabled: %v", subtransforms)
+	}
+
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNS.DNSRRs.Answers = []dnsutils.DNSAnswer{
+		{Name: "dns.google", Rdatatype: "A", Rdata: "8.8.8.8"},
+		{Name: "dns.google", Rdatatype: "TXT", Rdata: "hello"},
+		{Name: "dns.google", Rdatatype: "AAAA", Rdata: "2001:4860:4860::8888"},
+	}
+
+	returnCode, err := geoip.geoipAnswersTransform(&dm)
+	require.NoError(t, err, "process transform failed")
+	require.Equal(t, ReturnKeep, returnCode)
+
+	expected := []dnsutils.TransformDNSGeoAnswer{
+		{IP: "8.8.8.8", City: "-", Continent: "-", CountryIsoCode: "-", AutonomousSystemNumber: "-", AutonomousSystemOrg: "-"},
+		{IP: "2001:4860:4860::8888", City: "-", Continent: "-", CountryIsoCode: "-", AutonomousSystemNumber: "-", AutonomousSystemOrg: "-"},
+	}
+	require.Equal(t, expected, dm.Geo.Answers)
+}
@@ -39,7 +39,12 @@ func (t *MlTransform) GetTransforms() ([]Subtransform, error) {
 }
 
 func (t *MlTransform) addFeatures(dm *dnsutils.DNSMessage) (int, error) {
+	AddMLFeatures(dm)
+	return ReturnKeep, nil
+}
 
+// AddMLFeatures computes the features of the query name
+func AddMLFeatures(dm *dnsutils.DNSMessage) {
 	if dm.MachineLearning == nil {
 		dm.MachineLearning = &dnsutils.TransformML{}
 	}
@@ -180,6 +185,4 @@ func (t *MlTransform) addFeatures(dm *dnsutils.DNSMessage) (int, error) {
 	dm.MachineLearning.ConsecutiveVowels = consecutiveVowelCount
 	dm.MachineLearning.ConsecutiveDigits = consecutiveDigitCount
 	dm.MachineLearning.ConsecutiveConsonants = consecutiveConsonantCount
-
-	return ReturnKeep, nil
 }
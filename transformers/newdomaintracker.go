@@ -130,8 +130,10 @@ func NewNewDomainTrackerTransform(config *pkgconfig.ConfigTransformers, logger *
 // ReloadConfig reloads the configuration
 func (t *NewDomainTrackerTransform) ReloadConfig(config *pkgconfig.ConfigTransformers) {
 	t.GenericTransformer.ReloadConfig(config)
-	ttl := time.Duration(config.NewDomainTracker.TTL) * time.Second
-	t.domainTracker.ttl = ttl
+	// the tracker is created only when the transformer is enabled
+	if t.domainTracker != nil {
+		t.domainTracker.ttl = time.Duration(config.NewDomainTracker.TTL) * time.Second
+	}
 	t.LogInfo("new-domain-transformer configuration reloaded")
 }
 
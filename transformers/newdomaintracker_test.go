@@ -113,3 +113,14 @@ func TestNewDomainTracker_LRUCacheFull(t *testing.T) {
 		t.Errorf("recheck, this domain should be new!!")
 	}
 }
+
+func TestNewDomainTracker_ReloadDisabled(t *testing.T) {
+	config := pkgconfig.GetFakeConfigTransformers()
+	tracker := NewNewDomainTrackerTransform(config, logger.New(false), "test", 0, []chan dnsutils.DNSMessage{})
+	if _, err := tracker.GetTransforms(); err != nil {
+		t.Error("fail to init transform", err)
+	}
+
+	// the tracker is not created when the transformer is disabled
+	tracker.ReloadConfig(config)
+}
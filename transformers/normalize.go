@@ -47,6 +47,23 @@ func processRecords(records []dnsutils.DNSAnswer) {
 	}
 }
 
+// replaceNonprintable replaces the non printable characters and the spaces with the decimal value
+func replaceNonprintable(value string) string {
+	var builder strings.Builder
+	for _, r := range value {
+		if unicode.IsPrint(r) {
+			if unicode.IsSpace(r) {
+				builder.WriteString(fmt.Sprintf("\\%03d", r))
+			} else {
+				builder.WriteRune(r)
+			}
+		} else {
+			builder.WriteString(fmt.Sprintf("\\%03d", r))
+		}
+	}
+	return builder.String()
+}
+
 type NormalizeTransform struct {
 	GenericTransformer
 }
@@ -61,6 +78,9 @@ func (t *NormalizeTransform) GetTransforms() ([]Subtransform, error) {
 	if t.config.Normalize.Enable && t.config.Normalize.ReplaceNonPrintable {
 		subprocessors = append(subprocessors, Subtransform{name: "normalize:qname-replace-nonprintable", processFunc: t.ReplaceNonprintable})
 	}
+	if t.config.Normalize.Enable && t.config.Normalize.RRReplaceNonPrintable {
+		subprocessors = append(subprocessors, Subtransform{name: "normalize:rr-replace-nonprintable", processFunc: t.RRReplaceNonprintable})
+	}
 	if t.config.Normalize.Enable && t.config.Normalize.RRLowerCase {
 		subprocessors = append(subprocessors, Subtransform{name: "normalize:rr-lowercase", processFunc: t.RRLowercase})
 	}
@@ -92,22 +112,20 @@ func (t *NormalizeTransform) RRLowercase(dm *dnsutils.DNSMessage) (int, error) {
 }
 
 func (t *NormalizeTransform) ReplaceNonprintable(dm *dnsutils.DNSMessage) (int, error) {
+	dm.DNS.Qname = replaceNonprintable(dm.DNS.Qname)
+	return ReturnKeep, nil
+}
 
-	var builder strings.Builder
-	qname := dm.DNS.Qname
-	for _, r := range qname {
-		if unicode.IsPrint(r) {
-			if unicode.IsSpace(r) {
-				builder.WriteString(fmt.Sprintf("\\%03d", r))
-			} else {
-				builder.WriteRune(r)
+func (t *NormalizeTransform) RRReplaceNonprintable(dm *dnsutils.DNSMessage) (int, error) {
+	for _, records := range [][]dnsutils.DNSAnswer{dm.DNS.DNSRRs.Answers, dm.DNS.DNSRRs.Nameservers, dm.DNS.DNSRRs.Records} {
+		for i := range records {
+			records[i].Name = replaceNonprintable(records[i].Name)
+			switch records[i].Rdatatype {
+			case "CNAME", "DNAME", "NS", "PTR":
+				records[i].Rdata = replaceNonprintable(records[i].Rdata)
 			}
-		} else {
-			builder.WriteString(fmt.Sprintf("\\%03d", r))
 		}
 	}
-	dm.DNS.Qname = builder.String()
-
 	return ReturnKeep, nil
 }
 
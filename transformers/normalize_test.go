@@ -78,6 +78,44 @@ func TestNormalize_RRLowercaseQname(t *testing.T) {
 	}
 }
 
+func TestNormalize_RRReplaceNonprintable(t *testing.T) {
+	// enable feature
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Normalize.Enable = true
+	config.Normalize.RRReplaceNonPrintable = true
+
+	outChans := []chan dnsutils.DNSMessage{}
+
+	// init the processor
+	normTransformer := NewNormalizeTransform(config, logger.New(false), "test", 0, outChans)
+
+	// create DNSMessage with answers
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNS.DNSRRs.Answers = append(dm.DNS.DNSRRs.Answers,
+		dnsutils.DNSAnswer{Name: "invalid\tinvalid . com", Rdatatype: "CNAME", Rdata: "www\tgoogle.com"},
+		dnsutils.DNSAnswer{Name: "google.com", Rdatatype: "TXT", Rdata: "hello world"})
+
+	// process DNSMessage
+	returnCode, err := normTransformer.RRReplaceNonprintable(&dm)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if returnCode != ReturnKeep {
+		t.Errorf("Return code is %v, want %v", returnCode, ReturnKeep)
+	}
+
+	// checks
+	if dm.DNS.DNSRRs.Answers[0].Name != "invalid\\009invalid\\032.\\032com" {
+		t.Errorf("RR name replacement failed, got %s", dm.DNS.DNSRRs.Answers[0].Name)
+	}
+	if dm.DNS.DNSRRs.Answers[0].Rdata != "www\\009google.com" {
+		t.Errorf("RR rdata replacement failed, got %s", dm.DNS.DNSRRs.Answers[0].Rdata)
+	}
+	if dm.DNS.DNSRRs.Answers[1].Rdata != "hello world" {
+		t.Errorf("TXT rdata must be unchanged, got %s", dm.DNS.DNSRRs.Answers[1].Rdata)
+	}
+}
+
 func TestNormalize_QuietText(t *testing.T) {
 	// enable feature
 	config := pkgconfig.GetFakeConfigTransformers()
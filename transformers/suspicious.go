@@ -1,12 +1,14 @@
 package transformers
 
 import (
+	"net"
 	"regexp"
 	"strings"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-logger"
+	"golang.org/x/net/publicsuffix"
 )
 
 type SuspiciousTransform struct {
@@ -44,9 +46,21 @@ func (t *SuspiciousTransform) GetTransforms() ([]Subtransform, error) {
 	if t.config.Suspicious.Enable {
 		subtransforms = append(subtransforms, Subtransform{name: "suspicious:check", processFunc: t.checkIfSuspicious})
 	}
+	if t.config.Suspicious.Enable && t.config.Suspicious.CheckAnswers {
+		subtransforms = append(subtransforms, Subtransform{name: "suspicious:check-answers", processFunc: t.checkAnswers})
+	}
 	return subtransforms, nil
 }
 
+func (t *SuspiciousTransform) isWhitelisted(qname string) bool {
+	for _, d := range t.whitelistDomainsRegex {
+		if d.MatchString(qname) {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *SuspiciousTransform) checkIfSuspicious(dm *dnsutils.DNSMessage) (int, error) {
 
 	if dm.Suspicious == nil {
@@ -54,10 +68,8 @@ func (t *SuspiciousTransform) checkIfSuspicious(dm *dnsutils.DNSMessage) (int, e
 	}
 
 	// ignore some domains ?
-	for _, d := range t.whitelistDomainsRegex {
-		if d.MatchString(dm.DNS.Qname) {
-			return ReturnKeep, nil
-		}
+	if t.isWhitelisted(dm.DNS.Qname) {
+		return ReturnKeep, nil
 	}
 
 	// dns decoding error?
@@ -107,3 +119,61 @@ func (t *SuspiciousTransform) checkIfSuspicious(dm *dnsutils.DNSMessage) (int, e
 
 	return ReturnKeep, nil
 }
+
+// checkAnswers applies the checks to the answer records: large rdata, internal addresses
+// returned for public domains and the domain names found in the rdata
+func (t *SuspiciousTransform) checkAnswers(dm *dnsutils.DNSMessage) (int, error) {
+	if dm.Suspicious == nil {
+		dm.Suspicious = &dnsutils.TransformSuspicious{}
+	}
+
+	// ignore some domains ?
+	if t.isWhitelisted(dm.DNS.Qname) {
+		return ReturnKeep, nil
+	}
+
+	_, icann := publicsuffix.PublicSuffix(dm.DNS.Qname)
+	for _, rr := range dm.DNS.DNSRRs.Answers {
+		// large rdata ?
+		if len(rr.Rdata) > t.config.Suspicious.ThresholdRdataLen && !dm.Suspicious.LargeRdata {
+			dm.Suspicious.Score += 1.0
+			dm.Suspicious.LargeRdata = true
+		}
+
+		switch rr.Rdatatype {
+		case "A", "AAAA":
+			// internal address for a public domain, dns rebinding ?
+			ip := net.ParseIP(rr.Rdata)
+			if icann && ip != nil && isInternalIP(ip) && !dm.Suspicious.PrivateAnswer {
+				dm.Suspicious.Score += 1.0
+				dm.Suspicious.PrivateAnswer = true
+			}
+
+		case "CNAME", "DNAME", "PTR", "NS", "MX", "SRV":
+			// the domain name is the last field of the rdata
+			fields := strings.Fields(rr.Rdata)
+			if len(fields) == 0 {
+				continue
+			}
+			target := fields[len(fields)-1]
+
+			if len(target) > t.config.Suspicious.ThresholdQnameLen && !dm.Suspicious.LongDomain {
+				dm.Suspicious.Score += 1.0
+				dm.Suspicious.LongDomain = true
+			}
+			if strings.Count(target, ".") > t.config.Suspicious.ThresholdMaxLabels && !dm.Suspicious.ExcessiveNumberLabels {
+				dm.Suspicious.Score += 1.0
+				dm.Suspicious.ExcessiveNumberLabels = true
+			}
+			for _, v := range t.config.Suspicious.UnallowedChars {
+				if strings.Contains(target, v) && !dm.Suspicious.UnallowedChars {
+					dm.Suspicious.Score += 1.0
+					dm.Suspicious.UnallowedChars = true
+					break
+				}
+			}
+		}
+	}
+
+	return ReturnKeep, nil
+}
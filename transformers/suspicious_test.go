@@ -332,3 +332,87 @@ func TestSuspicious_WhitelistDomains(t *testing.T) {
 		t.Errorf("suspicious score should be equal to 0.0, got: %d", int(dm.Suspicious.Score))
 	}
 }
+
+func TestSuspicious_CheckAnswers(t *testing.T) {
+	// config
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Suspicious.Enable = true
+	config.Suspicious.CheckAnswers = true
+	config.Suspicious.ThresholdRdataLen = 30
+
+	outChans := []chan dnsutils.DNSMessage{}
+
+	// init subprocessor
+	suspicious := NewSuspiciousTransform(config, logger.New(false), "test", 0, outChans)
+	suspicious.GetTransforms()
+
+	testCases := []struct {
+		name    string
+		qname   string
+		answers []dnsutils.DNSAnswer
+		score   float64
+		check   func(s *dnsutils.TransformSuspicious) bool
+	}{
+		{
+			name:    "normal answers",
+			qname:   "www.google.com",
+			answers: []dnsutils.DNSAnswer{{Rdatatype: "CNAME", Rdata: "www.l.google.com"}, {Rdatatype: "A", Rdata: "8.8.8.8"}},
+			score:   0,
+			check:   func(s *dnsutils.TransformSuspicious) bool { return !s.PrivateAnswer && !s.LargeRdata },
+		},
+		{
+			name:    "private answer",
+			qname:   "www.google.com",
+			answers: []dnsutils.DNSAnswer{{Rdatatype: "A", Rdata: "192.168.1.1"}, {Rdatatype: "A", Rdata: "127.0.0.1"}},
+			score:   1,
+			check:   func(s *dnsutils.TransformSuspicious) bool { return s.PrivateAnswer },
+		},
+		{
+			name:    "private answer for internal domain",
+			qname:   "nas.home.lan",
+			answers: []dnsutils.DNSAnswer{{Rdatatype: "A", Rdata: "192.168.1.1"}},
+			score:   0,
+			check:   func(s *dnsutils.TransformSuspicious) bool { return !s.PrivateAnswer },
+		},
+		{
+			name:    "large rdata",
+			qname:   "www.google.com",
+			answers: []dnsutils.DNSAnswer{{Rdatatype: "TXT", Rdata: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}},
+			score:   1,
+			check:   func(s *dnsutils.TransformSuspicious) bool { return s.LargeRdata },
+		},
+		{
+			name:    "unallowed chars in target",
+			qname:   "www.google.com",
+			answers: []dnsutils.DNSAnswer{{Rdatatype: "MX", Rdata: "10 mail/google.com"}},
+			score:   1,
+			check:   func(s *dnsutils.TransformSuspicious) bool { return s.UnallowedChars },
+		},
+		{
+			name:    "excessive labels in target",
+			qname:   "www.google.com",
+			answers: []dnsutils.DNSAnswer{{Rdatatype: "CNAME", Rdata: "a.b.c.d.e.f.g.h.i.j.k.l"}},
+			score:   1,
+			check:   func(s *dnsutils.TransformSuspicious) bool { return s.ExcessiveNumberLabels },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dm := dnsutils.GetFakeDNSMessage()
+			dm.DNS.Qname = tc.qname
+			dm.DNS.DNSRRs.Answers = tc.answers
+
+			returnCode, err := suspicious.checkAnswers(&dm)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if returnCode != ReturnKeep {
+				t.Errorf("Return code is %v, want keep(%v)", returnCode, ReturnKeep)
+			}
+			if dm.Suspicious.Score != tc.score || !tc.check(dm.Suspicious) {
+				t.Errorf("unexpected suspicious result: %+v", dm.Suspicious)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package transformers
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
@@ -25,6 +26,12 @@ type Transformation interface {
 	Reset()
 }
 
+// NextWorkersUpdater is implemented by the transformers sending messages to the
+// next workers on their own, they follow the routes of the worker
+type NextWorkersUpdater interface {
+	SetNextWorkers(nextWorkers []chan dnsutils.DNSMessage)
+}
+
 type GenericTransformer struct {
 	config            *pkgconfig.ConfigTransformers
 	logger            *logger.Logger
@@ -58,11 +65,85 @@ type TransformEntry struct {
 	Transformation
 }
 
+type TransformConstructor func(config *pkgconfig.ConfigTransformers, logger *logger.Logger, name string, instance int, nextWorkers []chan dnsutils.DNSMessage) Transformation
+
+type RegisteredTransform struct {
+	Name string
+	New  TransformConstructor
+}
+
+// RegisteredTransforms lists the transformers by config name, the order of definition
+// is important, it's the default processing order when no chain is provided
+var RegisteredTransforms = []RegisteredTransform{
+	{"normalize", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewNormalizeTransform(c, l, n, i, w)
+	}},
+	{"filtering", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewFilteringTransform(c, l, n, i, w)
+	}},
+	{"reducer", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewReducerTransform(c, l, n, i, w)
+	}},
+	{"atags", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewATagsTransform(c, l, n, i, w)
+	}},
+	{"rest", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewRestTransform(c, l, n, i, w)
+	}},
+	{"relabeling", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewRelabelTransform(c, l, n, i, w)
+	}},
+	{"user-privacy", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewUserPrivacyTransform(c, l, n, i, w)
+	}},
+	{"extract", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewExtractTransform(c, l, n, i, w)
+	}},
+	{"suspicious", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewSuspiciousTransform(c, l, n, i, w)
+	}},
+	{"machine-learning", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewMachineLearningTransform(c, l, n, i, w)
+	}},
+	{"detection", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewDetectionTransform(c, l, n, i, w)
+	}},
+	{"latency", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewLatencyTransform(c, l, n, i, w)
+	}},
+	{"correlation", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewCorrelationTransform(c, l, n, i, w)
+	}},
+	{"geoip", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewDNSGeoIPTransform(c, l, n, i, w)
+	}},
+	{"rewrite", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewRewriteTransform(c, l, n, i, w)
+	}},
+	{"new-domain-tracker", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewNewDomainTrackerTransform(c, l, n, i, w)
+	}},
+	{"reordering", func(c *pkgconfig.ConfigTransformers, l *logger.Logger, n string, i int, w []chan dnsutils.DNSMessage) Transformation {
+		return NewReorderingTransform(c, l, n, i, w)
+	}},
+}
+
+func GetTransformConstructor(name string) (TransformConstructor, bool) {
+	for _, t := range RegisteredTransforms {
+		if t.Name == name {
+			return t.New, true
+		}
+	}
+	return nil, false
+}
+
 type Transforms struct {
-	config   *pkgconfig.ConfigTransformers
-	logger   *logger.Logger
-	name     string
-	instance int
+	config      *pkgconfig.ConfigTransformers
+	logger      *logger.Logger
+	name        string
+	instance    int
+	nextWorkers []chan dnsutils.DNSMessage
+	chain       []string
 
 	availableTransforms     []TransformEntry
 	activeTransforms        []TransformEntry
@@ -71,39 +152,76 @@ type Transforms struct {
 
 func NewTransforms(config *pkgconfig.ConfigTransformers, logger *logger.Logger, name string, nextWorkers []chan dnsutils.DNSMessage, instance int) Transforms {
 
-	d := Transforms{config: config, logger: logger, name: name, instance: instance}
-
-	// order definition important
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewNormalizeTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewFilteringTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewReducerTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewATagsTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewRestTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewRelabelTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewUserPrivacyTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewExtractTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewSuspiciousTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewMachineLearningTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewLatencyTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewDNSGeoIPTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewRewriteTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewNewDomainTrackerTransform(config, logger, name, instance, nextWorkers)})
-	d.availableTransforms = append(d.availableTransforms, TransformEntry{NewReorderingTransform(config, logger, name, instance, nextWorkers)})
-
+	d := Transforms{config: config, logger: logger, name: name, instance: instance, nextWorkers: nextWorkers}
+	d.buildTransforms()
 	d.Prepare()
 	return d
 }
 
+func getChainNames(config *pkgconfig.ConfigTransformers) []string {
+	names := []string{}
+	for _, entry := range config.Chain {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+// buildTransforms instantiates the transformers in the order of the chain if provided,
+// otherwise in the default order
+func (p *Transforms) buildTransforms() {
+	p.availableTransforms = p.availableTransforms[:0]
+	p.chain = p.chain[:0]
+
+	if len(p.config.Chain) == 0 {
+		for _, t := range RegisteredTransforms {
+			p.availableTransforms = append(p.availableTransforms, TransformEntry{t.New(p.config, p.logger, p.name, p.instance, p.nextWorkers)})
+		}
+		return
+	}
+
+	for _, entry := range p.config.Chain {
+		newTransform, ok := GetTransformConstructor(entry.Name)
+		if !ok {
+			p.LogError("unknown transformer: %s", entry.Name)
+			continue
+		}
+		p.availableTransforms = append(p.availableTransforms, TransformEntry{newTransform(entry.Config, p.logger, p.name, p.instance, p.nextWorkers)})
+		p.chain = append(p.chain, entry.Name)
+	}
+}
+
 func (p *Transforms) ReloadConfig(config *pkgconfig.ConfigTransformers) {
 	p.config = config
 
-	for _, transform := range p.availableTransforms {
-		transform.ReloadConfig(config)
+	// the chain has changed, rebuild all transformers
+	if strings.Join(getChainNames(config), ",") != strings.Join(p.chain, ",") {
+		p.Reset()
+		p.buildTransforms()
+		p.Prepare()
+		return
+	}
+
+	for i, transform := range p.availableTransforms {
+		if len(config.Chain) > 0 {
+			transform.ReloadConfig(config.Chain[i].Config)
+		} else {
+			transform.ReloadConfig(config)
+		}
 	}
 
 	p.Prepare()
 }
 
+// SetNextWorkers updates the next workers of the transformers when the routes change
+func (p *Transforms) SetNextWorkers(nextWorkers []chan dnsutils.DNSMessage) {
+	p.nextWorkers = nextWorkers
+	for _, transform := range p.availableTransforms {
+		if t, ok := transform.Transformation.(NextWorkersUpdater); ok {
+			t.SetNextWorkers(nextWorkers)
+		}
+	}
+}
+
 func (p *Transforms) Prepare() error {
 	// clean the slice
 	p.activeProcessTransforms = p.activeProcessTransforms[:0]
@@ -90,3 +90,48 @@ func TestTransforms_ProcessOrder(t *testing.T) {
 		t.Errorf("Ipv6 anonymization failed, got %s", dm.NetworkInfo.QueryIP)
 	}
 }
+
+func TestTransforms_ChainOrder(t *testing.T) {
+	// atags repeated with different settings around the normalize transformer
+	chain, err := pkgconfig.NewConfigTransformersChain([]interface{}{
+		map[string]interface{}{"atags": map[string]interface{}{"add-tags": []string{"first"}}},
+		map[string]interface{}{"normalize": map[string]interface{}{"qname-lowercase": true}},
+		map[string]interface{}{"atags": map[string]interface{}{"add-tags": []string{"second"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.Chain = chain
+
+	// init the transformer
+	subprocessors := NewTransforms(config, logger.New(false), "test", []chan dnsutils.DNSMessage{}, 0)
+	if len(subprocessors.activeTransforms) != 3 {
+		t.Fatalf("3 transformers expected, got %d", len(subprocessors.activeTransforms))
+	}
+
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNS.Qname = CapsAddress
+
+	if _, err := subprocessors.ProcessMessage(&dm); err != nil {
+		t.Errorf("process transform err %s", err.Error())
+	}
+	if dm.DNS.Qname != NormAddress {
+		t.Errorf("qname should be normalized, got %s", dm.DNS.Qname)
+	}
+	if len(dm.ATags.Tags) != 2 || dm.ATags.Tags[0] != "first" || dm.ATags.Tags[1] != "second" {
+		t.Errorf("invalid tags order: %v", dm.ATags.Tags)
+	}
+
+	// reload with a new chain
+	newChain, _ := pkgconfig.NewConfigTransformersChain([]interface{}{
+		map[string]interface{}{"atags": map[string]interface{}{"add-tags": []string{"reloaded"}}},
+	})
+	newConfig := pkgconfig.GetFakeConfigTransformers()
+	newConfig.Chain = newChain
+	subprocessors.ReloadConfig(newConfig)
+	if len(subprocessors.activeTransforms) != 1 {
+		t.Fatalf("1 transformer expected after reload, got %d", len(subprocessors.activeTransforms))
+	}
+}
@@ -34,6 +34,45 @@ func HashIP(ip string, algo string) string {
 	}
 }
 
+// isInternalIP returns true for the private, loopback and link-local addresses
+func isInternalIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()
+}
+
+// ReverseNameToIP converts a reverse name (in-addr.arpa or ip6.arpa) to the ip address,
+// returns nil if the name is not a valid reverse name
+func ReverseNameToIP(name string) net.IP {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case strings.HasSuffix(name, ".in-addr.arpa"):
+		labels := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+		if len(labels) != 4 {
+			return nil
+		}
+		for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+			labels[i], labels[j] = labels[j], labels[i]
+		}
+		return net.ParseIP(strings.Join(labels, ".")).To4()
+	case strings.HasSuffix(name, ".ip6.arpa"):
+		nibbles := strings.Split(strings.TrimSuffix(name, ".ip6.arpa"), ".")
+		if len(nibbles) != 32 {
+			return nil
+		}
+		var builder strings.Builder
+		for i := len(nibbles) - 1; i >= 0; i-- {
+			if len(nibbles[i]) != 1 {
+				return nil
+			}
+			builder.WriteString(nibbles[i])
+			if i > 0 && i%4 == 0 {
+				builder.WriteString(":")
+			}
+		}
+		return net.ParseIP(builder.String())
+	}
+	return nil
+}
+
 type UserPrivacyTransform struct {
 	GenericTransformer
 	v4Mask, v6Mask net.IPMask
@@ -75,6 +114,12 @@ func (t *UserPrivacyTransform) GetTransforms() ([]Subtransform, error) {
 	if t.config.UserPrivacy.HashReplyIP {
 		subprocessors = append(subprocessors, Subtransform{name: "userprivacy:hash-reply-ip", processFunc: t.hashReplyIP})
 	}
+	if t.config.UserPrivacy.AnonymizeAnswers {
+		subprocessors = append(subprocessors, Subtransform{name: "userprivacy:anonymize-answers-ip", processFunc: t.anonymizeAnswersIP})
+	}
+	if t.config.UserPrivacy.MinimizeAnswerPTR {
+		subprocessors = append(subprocessors, Subtransform{name: "userprivacy:minimize-answers-ptr", processFunc: t.minimizeAnswersPTR})
+	}
 
 	return subprocessors, nil
 }
@@ -111,3 +156,46 @@ func (t *UserPrivacyTransform) minimizeQname(dm *dnsutils.DNSMessage) (int, erro
 	}
 	return ReturnKeep, nil
 }
+
+// anonymizeAnswersIP masks the addresses of the A/AAAA records, only the internal ones by default
+func (t *UserPrivacyTransform) anonymizeAnswersIP(dm *dnsutils.DNSMessage) (int, error) {
+	for _, records := range [][]dnsutils.DNSAnswer{dm.DNS.DNSRRs.Answers, dm.DNS.DNSRRs.Nameservers, dm.DNS.DNSRRs.Records} {
+		for i := range records {
+			if records[i].Rdatatype != "A" && records[i].Rdatatype != "AAAA" {
+				continue
+			}
+			ip := net.ParseIP(records[i].Rdata)
+			if ip == nil || (t.config.UserPrivacy.AnswersPrivateOnly && !isInternalIP(ip)) {
+				continue
+			}
+			if ip.To4() != nil {
+				records[i].Rdata = ip.Mask(t.v4Mask).String()
+			} else {
+				records[i].Rdata = ip.Mask(t.v6Mask).String()
+			}
+		}
+	}
+	return ReturnKeep, nil
+}
+
+// minimizeAnswersPTR keeps only the second level domain of the PTR records,
+// only for the reverse names of internal addresses by default
+func (t *UserPrivacyTransform) minimizeAnswersPTR(dm *dnsutils.DNSMessage) (int, error) {
+	for _, records := range [][]dnsutils.DNSAnswer{dm.DNS.DNSRRs.Answers, dm.DNS.DNSRRs.Nameservers, dm.DNS.DNSRRs.Records} {
+		for i := range records {
+			if records[i].Rdatatype != "PTR" {
+				continue
+			}
+			if t.config.UserPrivacy.AnswersPrivateOnly {
+				ip := ReverseNameToIP(records[i].Name)
+				if ip == nil || !isInternalIP(ip) {
+					continue
+				}
+			}
+			if etpo, err := publicsuffix.EffectiveTLDPlusOne(records[i].Rdata); err == nil {
+				records[i].Rdata = etpo
+			}
+		}
+	}
+	return ReturnKeep, nil
+}
@@ -268,3 +268,77 @@ func TestUserPrivacy_AnonymizeIPRemove(t *testing.T) {
 		})
 	}
 }
+
+func TestUserPrivacy_AnonymizeAnswersIP(t *testing.T) {
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.UserPrivacy.Enable = true
+	config.UserPrivacy.AnonymizeAnswers = true
+
+	userPrivacy := NewUserPrivacyTransform(config, logger.New(false), "test", 0, []chan dnsutils.DNSMessage{})
+	userPrivacy.GetTransforms()
+
+	testCases := []struct {
+		name        string
+		privateOnly bool
+		rdatatype   string
+		rdata       string
+		expected    string
+	}{
+		{"IPv4 private", true, "A", TestIP4, "192.168.0.0"},
+		{"IPv6 link-local", true, "AAAA", TestIP6, "fe80::"},
+		{"IPv4 public kept", true, "A", "8.8.8.8", "8.8.8.8"},
+		{"IPv4 public masked", false, "A", "8.8.8.8", "8.8.0.0"},
+		{"Not an address", false, "CNAME", "www.google.com", "www.google.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config.UserPrivacy.AnswersPrivateOnly = tc.privateOnly
+
+			dm := dnsutils.GetFakeDNSMessage()
+			dm.DNS.DNSRRs.Answers = []dnsutils.DNSAnswer{{Name: "dns.collector", Rdatatype: tc.rdatatype, Rdata: tc.rdata}}
+			dm.DNS.DNSRRs.Records = []dnsutils.DNSAnswer{{Name: "dns.collector", Rdatatype: tc.rdatatype, Rdata: tc.rdata}}
+
+			userPrivacy.anonymizeAnswersIP(&dm)
+			if dm.DNS.DNSRRs.Answers[0].Rdata != tc.expected || dm.DNS.DNSRRs.Records[0].Rdata != tc.expected {
+				t.Errorf("anonymization failed got %s, want %s", dm.DNS.DNSRRs.Answers[0].Rdata, tc.expected)
+			}
+		})
+	}
+}
+
+func TestUserPrivacy_MinimizeAnswersPTR(t *testing.T) {
+	config := pkgconfig.GetFakeConfigTransformers()
+	config.UserPrivacy.Enable = true
+	config.UserPrivacy.MinimizeAnswerPTR = true
+
+	userPrivacy := NewUserPrivacyTransform(config, logger.New(false), "test", 0, []chan dnsutils.DNSMessage{})
+	userPrivacy.GetTransforms()
+
+	testCases := []struct {
+		name        string
+		privateOnly bool
+		owner       string
+		expected    string
+	}{
+		{"IPv4 private", true, "2.1.168.192.in-addr.arpa", "collector.org"},
+		{"IPv6 link-local", true, "3.5.3.2.2.b.1.c.6.2.6.0.1.1.1.6.0.0.0.0.0.0.0.0.0.0.0.0.0.8.e.f.ip6.arpa", "collector.org"},
+		{"IPv4 public kept", true, "8.8.8.8.in-addr.arpa", "laptop.dns.collector.org"},
+		{"IPv4 public minimized", false, "8.8.8.8.in-addr.arpa", "collector.org"},
+		{"Invalid reverse name", true, "dns.collector.org", "laptop.dns.collector.org"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config.UserPrivacy.AnswersPrivateOnly = tc.privateOnly
+
+			dm := dnsutils.GetFakeDNSMessage()
+			dm.DNS.DNSRRs.Answers = []dnsutils.DNSAnswer{{Name: tc.owner, Rdatatype: "PTR", Rdata: "laptop.dns.collector.org"}}
+
+			userPrivacy.minimizeAnswersPTR(&dm)
+			if dm.DNS.DNSRRs.Answers[0].Rdata != tc.expected {
+				t.Errorf("minimization failed got %s, want %s", dm.DNS.DNSRRs.Answers[0].Rdata, tc.expected)
+			}
+		})
+	}
+}
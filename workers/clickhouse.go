@@ -1,34 +1,382 @@
 package workers
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-dnscollector/transformers"
 	"github.com/dmachard/go-logger"
+	"github.com/dmachard/go-netutils"
 )
 
-var (
-	separator = "','"
+// default columns, compatible with the table used by the previous versions
+var ClickhouseDefaultColumns = []pkgconfig.ConfigClickhouseColumn{
+	{Name: "identity", Field: "dnstap.identity", Type: "String"},
+	{Name: "queryip", Field: "network.query-ip", Type: "String"},
+	{Name: "qname", Field: "dns.qname", Type: "String"},
+	{Name: "operation", Field: "dnstap.operation", Type: "String"},
+	{Name: "family", Field: "network.family", Type: "String"},
+	{Name: "protocol", Field: "network.protocol", Type: "String"},
+	{Name: "qtype", Field: "dns.qtype", Type: "String"},
+	{Name: "rcode", Field: "dns.rcode", Type: "String"},
+	{Name: "timensec", Field: "dnstap.timestamp-rfc3339ns", Type: "DateTime64(9)"},
+	{Name: "timestamp", Field: "dnstap.timestamp-rfc3339ns", Type: "DateTime"},
+}
+
+const (
+	chString     = "String"
+	chBool       = "Bool"
+	chUInt8      = "UInt8"
+	chUInt16     = "UInt16"
+	chUInt32     = "UInt32"
+	chUInt64     = "UInt64"
+	chInt8       = "Int8"
+	chInt16      = "Int16"
+	chInt32      = "Int32"
+	chInt64      = "Int64"
+	chFloat32    = "Float32"
+	chFloat64    = "Float64"
+	chDateTime   = "DateTime"
+	chDateTime64 = "DateTime64"
+	chArray      = "Array(String)"
+	chMap        = "Map(String,String)"
 )
 
-type ClickhouseData struct {
-	Identity  string `json:"identity"`
-	QueryIP   string `json:"query_ip"`
-	QName     string `json:"q_name"`
-	Operation string `json:"operation"`
-	Family    string `json:"family"`
-	Protocol  string `json:"protocol"`
-	QType     string `json:"q_type"`
-	RCode     string `json:"r_code"`
-	TimeNSec  string `json:"timensec"`
-	TimeStamp string `json:"timestamp"`
+type ClickhouseColumn struct {
+	Name, Field, Type string
+	kind              string
+	precision         int
+}
+
+// NewClickhouseColumn checks the type of the column, only the types which
+// can be converted from a flat-json field are supported
+func NewClickhouseColumn(cfg pkgconfig.ConfigClickhouseColumn) (*ClickhouseColumn, error) {
+	if len(cfg.Name) == 0 || len(cfg.Field) == 0 {
+		return nil, fmt.Errorf("column name and field are mandatory")
+	}
+	c := &ClickhouseColumn{Name: cfg.Name, Field: cfg.Field, Type: cfg.Type}
+	if len(c.Type) == 0 {
+		c.Type = chString
+	}
+
+	kind := strings.ReplaceAll(c.Type, " ", "")
+	if strings.HasPrefix(kind, "LowCardinality(") && strings.HasSuffix(kind, ")") {
+		kind = strings.TrimSuffix(strings.TrimPrefix(kind, "LowCardinality("), ")")
+	}
+
+	switch kind {
+	case chString, chBool, chUInt8, chUInt16, chUInt32, chUInt64, chInt8, chInt16, chInt32, chInt64,
+		chFloat32, chFloat64, chDateTime, chArray, chMap:
+		c.kind = kind
+	default:
+		if !strings.HasPrefix(kind, chDateTime64+"(") || !strings.HasSuffix(kind, ")") {
+			return nil, fmt.Errorf("column=%s - unsupported type %s", c.Name, c.Type)
+		}
+		// DateTime64(precision[, timezone])
+		args := strings.Split(strings.TrimSuffix(strings.TrimPrefix(kind, chDateTime64+"("), ")"), ",")
+		precision, err := strconv.Atoi(args[0])
+		if err != nil || precision < 0 || precision > 9 {
+			return nil, fmt.Errorf("column=%s - invalid precision in %s", c.Name, c.Type)
+		}
+		c.kind = chDateTime64
+		c.precision = precision
+	}
+	return c, nil
+}
+
+// Lookup returns the value of the field, fields like `dns.resource-records.an`
+// or `atags.tags` match all the flat-json keys under this prefix
+func (c *ClickhouseColumn) Lookup(flat map[string]interface{}) interface{} {
+	if v, ok := flat[c.Field]; ok && c.kind != chMap {
+		return v
+	}
+
+	prefix := c.Field + "."
+	keys := []string{}
+	for k := range flat {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// sort numeric suffixes (atags.tags.10 after atags.tags.9)
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(strings.TrimPrefix(keys[i], prefix))
+		b, errB := strconv.Atoi(strings.TrimPrefix(keys[j], prefix))
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+
+	if c.kind == chMap {
+		m := make(map[string]string, len(keys))
+		for _, k := range keys {
+			m[strings.TrimPrefix(k, prefix)] = fmt.Sprint(flat[k])
+		}
+		return m
+	}
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, fmt.Sprint(flat[k]))
+	}
+	return values
+}
+
+func (c *ClickhouseColumn) toString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []string:
+		return strings.Join(value, "|")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func (c *ClickhouseColumn) toFloat(v interface{}) float64 {
+	switch value := v.(type) {
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case uint64:
+		return float64(value)
+	case float32:
+		return float64(value)
+	case float64:
+		return value
+	case bool:
+		if value {
+			return 1
+		}
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return f
+		}
+	}
+	return 0
+}
+
+func (c *ClickhouseColumn) toInt(v interface{}) int64 {
+	switch value := v.(type) {
+	case int:
+		return int64(value)
+	case int64:
+		return value
+	case uint64:
+		return int64(value)
+	case string:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	}
+	return int64(c.toFloat(v))
+}
+
+func (c *ClickhouseColumn) toTime(v interface{}) time.Time {
+	if s, ok := v.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t
+		}
+	}
+	f := c.toFloat(v)
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+func (c *ClickhouseColumn) toList(v interface{}) []string {
+	switch value := v.(type) {
+	case []string:
+		return value
+	case nil:
+		return []string{}
+	}
+	s := c.toString(v)
+	if s == "-" || len(s) == 0 {
+		return []string{}
+	}
+	return strings.Split(s, "|")
+}
+
+func (c *ClickhouseColumn) toMap(v interface{}) map[string]string {
+	if m, ok := v.(map[string]string); ok {
+		return m
+	}
+	return map[string]string{}
+}
+
+// JSONValue converts the field for the JSONEachRow format
+func (c *ClickhouseColumn) JSONValue(flat map[string]interface{}) interface{} {
+	v := c.Lookup(flat)
+	switch c.kind {
+	case chBool:
+		return c.toInt(v) != 0 || c.toString(v) == "true"
+	case chUInt8, chUInt16, chUInt32, chUInt64, chInt8, chInt16, chInt32, chInt64:
+		return c.toInt(v)
+	case chFloat32, chFloat64:
+		return c.toFloat(v)
+	case chDateTime:
+		return c.toTime(v).Unix()
+	case chDateTime64:
+		// unix timestamp to be independent of the timezone of the server
+		t := c.toTime(v)
+		if c.precision == 0 {
+			return strconv.FormatInt(t.Unix(), 10)
+		}
+		frac := int64(t.Nanosecond()) / int64(math.Pow10(9-c.precision))
+		return fmt.Sprintf("%d.%0*d", t.Unix(), c.precision, frac)
+	case chArray:
+		return c.toList(v)
+	case chMap:
+		return c.toMap(v)
+	}
+	return c.toString(v)
+}
+
+func appendRowBinaryString(buf *bytes.Buffer, s string) {
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(s)))
+	buf.Write(size[:n])
+	buf.WriteString(s)
+}
+
+// AppendRowBinary encodes the field for the RowBinary format
+func (c *ClickhouseColumn) AppendRowBinary(buf *bytes.Buffer, flat map[string]interface{}) {
+	v := c.Lookup(flat)
+	switch c.kind {
+	case chBool:
+		if c.toInt(v) != 0 || c.toString(v) == "true" {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case chUInt8, chInt8:
+		buf.WriteByte(byte(c.toInt(v)))
+	case chUInt16, chInt16:
+		buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(c.toInt(v))))
+	case chUInt32, chInt32:
+		buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(c.toInt(v))))
+	case chUInt64, chInt64:
+		buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(c.toInt(v))))
+	case chFloat32:
+		buf.Write(binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(c.toFloat(v)))))
+	case chFloat64:
+		buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(c.toFloat(v))))
+	case chDateTime:
+		buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(c.toTime(v).Unix())))
+	case chDateTime64:
+		ticks := c.toTime(v).UnixNano() / int64(math.Pow10(9-c.precision))
+		buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(ticks)))
+	case chArray:
+		list := c.toList(v)
+		var size [binary.MaxVarintLen64]byte
+		buf.Write(size[:binary.PutUvarint(size[:], uint64(len(list)))])
+		for _, s := range list {
+			appendRowBinaryString(buf, s)
+		}
+	case chMap:
+		m := c.toMap(v)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var size [binary.MaxVarintLen64]byte
+		buf.Write(size[:binary.PutUvarint(size[:], uint64(len(keys)))])
+		for _, k := range keys {
+			appendRowBinaryString(buf, k)
+			appendRowBinaryString(buf, m[k])
+		}
+	default:
+		appendRowBinaryString(buf, c.toString(v))
+	}
+}
+
+// ClickhouseQuoteIdentifier escapes a database, table or column name
+func ClickhouseQuoteIdentifier(name string) string {
+	name = strings.ReplaceAll(name, "\\", "\\\\")
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// GetClickhouseColumns returns the configured columns or the default ones
+func GetClickhouseColumns(config *pkgconfig.Config) ([]*ClickhouseColumn, error) {
+	cfgColumns := config.Loggers.ClickhouseClient.Columns
+	if len(cfgColumns) == 0 {
+		cfgColumns = ClickhouseDefaultColumns
+	}
+	columns := []*ClickhouseColumn{}
+	names := map[string]bool{}
+	for _, cfg := range cfgColumns {
+		c, err := NewClickhouseColumn(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("column=%s - defined twice", c.Name)
+		}
+		names[c.Name] = true
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// GenerateClickhouseDDL returns the CREATE TABLE statement matching the columns
+func GenerateClickhouseDDL(config *pkgconfig.Config) (string, error) {
+	columns, err := GetClickhouseColumns(config)
+	if err != nil {
+		return "", err
+	}
+	var ddl strings.Builder
+	ddl.WriteString("CREATE TABLE IF NOT EXISTS ")
+	ddl.WriteString(ClickhouseQuoteIdentifier(config.Loggers.ClickhouseClient.Database))
+	ddl.WriteString(".")
+	ddl.WriteString(ClickhouseQuoteIdentifier(config.Loggers.ClickhouseClient.Table))
+	ddl.WriteString("\n(\n")
+	for i, c := range columns {
+		ddl.WriteString("    " + ClickhouseQuoteIdentifier(c.Name) + " " + c.Type)
+		if i < len(columns)-1 {
+			ddl.WriteString(",")
+		}
+		ddl.WriteString("\n")
+	}
+	ddl.WriteString(")\nENGINE = " + config.Loggers.ClickhouseClient.TableEngine)
+	return ddl.String(), nil
+}
+
+// clickhouseSettings is built from the config, the rows of a batch are always
+// encoded and inserted with the same settings
+type clickhouseSettings struct {
+	format      string
+	columns     []*ClickhouseColumn
+	insertQuery string
+	httpClient  *http.Client
+	password    string
 }
 
 type ClickhouseClient struct {
 	*GenericWorker
+	settings    *clickhouseSettings
+	newSettings chan *clickhouseSettings
 }
 
 func NewClickhouseClient(config *pkgconfig.Config, console *logger.Logger, name string) *ClickhouseClient {
@@ -36,11 +384,81 @@ func NewClickhouseClient(config *pkgconfig.Config, console *logger.Logger, name
 	if config.Loggers.ClickhouseClient.ChannelBufferSize > 0 {
 		bufSize = config.Loggers.ClickhouseClient.ChannelBufferSize
 	}
-	w := &ClickhouseClient{GenericWorker: NewGenericWorker(config, console, name, "clickhouse", bufSize, pkgconfig.DefaultMonitor)}
-	w.ReadConfig()
+	w := &ClickhouseClient{
+		GenericWorker: NewGenericWorker(config, console, name, "clickhouse", bufSize, pkgconfig.DefaultMonitor),
+		newSettings:   make(chan *clickhouseSettings, 1),
+	}
+	w.settings = w.readSettings()
 	return w
 }
 
+// ReadConfig hands the new settings to the logging goroutine,
+// the rows already buffered are inserted with the previous ones
+func (w *ClickhouseClient) ReadConfig() {
+	settings := w.readSettings()
+	select {
+	case <-w.newSettings:
+	default:
+	}
+	w.newSettings <- settings
+}
+
+func (w *ClickhouseClient) readSettings() *clickhouseSettings {
+	cfg := w.GetConfig().Loggers.ClickhouseClient
+	settings := &clickhouseSettings{format: cfg.Format, password: cfg.Password}
+
+	switch cfg.Format {
+	case pkgconfig.ClickhouseJSONEachRow, pkgconfig.ClickhouseRowBinary:
+	default:
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] clickhouse - invalid format: ", cfg.Format)
+	}
+
+	columns, err := GetClickhouseColumns(w.GetConfig())
+	if err != nil {
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] clickhouse - invalid columns: ", err)
+	}
+	settings.columns = columns
+
+	names := []string{}
+	for _, c := range columns {
+		names = append(names, ClickhouseQuoteIdentifier(c.Name))
+	}
+	settings.insertQuery = fmt.Sprintf("INSERT INTO %s.%s (%s) FORMAT %s",
+		ClickhouseQuoteIdentifier(cfg.Database), ClickhouseQuoteIdentifier(cfg.Table),
+		strings.Join(names, ","), cfg.Format)
+
+	// tls client config
+	tlsOptions := netutils.TLSOptions{
+		InsecureSkipVerify: cfg.TLSInsecure,
+		MinVersion:         cfg.TLSMinVersion,
+		CAFile:             cfg.CAFile,
+		CertFile:           cfg.CertFile,
+		KeyFile:            cfg.KeyFile,
+	}
+	tlsConfig, err := netutils.TLSClientConfig(tlsOptions)
+	if err != nil {
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] clickhouse - tls config failed:", err)
+	}
+
+	settings.httpClient = &http.Client{
+		Timeout: time.Duration(cfg.Timeout) * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:    10,
+			IdleConnTimeout: 30 * time.Second,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	if cfg.PasswordFile != "" {
+		content, err := os.ReadFile(cfg.PasswordFile)
+		if err != nil {
+			w.LogFatal("logger=clickhouse - unable to load password from file: ", err)
+		}
+		settings.password = strings.TrimSpace(string(content))
+	}
+	return settings
+}
+
 func (w *ClickhouseClient) StartCollect() {
 	w.LogInfo("starting data collection")
 	defer w.CollectDone()
@@ -58,6 +476,10 @@ func (w *ClickhouseClient) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -98,53 +520,122 @@ func (w *ClickhouseClient) StartCollect() {
 	}
 }
 
+// EncodeRow appends the dns message to the batch in the configured format
+func (w *ClickhouseClient) EncodeRow(buf *bytes.Buffer, dm *dnsutils.DNSMessage) error {
+	flat, err := dm.Flatten()
+	if err != nil {
+		return err
+	}
+
+	if w.settings.format == pkgconfig.ClickhouseRowBinary {
+		for _, c := range w.settings.columns {
+			c.AppendRowBinary(buf, flat)
+		}
+		return nil
+	}
+
+	row := make(map[string]interface{}, len(w.settings.columns))
+	for _, c := range w.settings.columns {
+		row[c.Name] = c.JSONValue(flat)
+	}
+	return json.NewEncoder(buf).Encode(row)
+}
+
+// Exec sends the query to the server, the body contains the data if any
+func (w *ClickhouseClient) Exec(query string, body io.Reader) error {
+	params := url.Values{}
+	params.Set("query", query)
+	req, err := http.NewRequest("POST", w.GetConfig().Loggers.ClickhouseClient.URL+"/?"+params.Encode(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("X-ClickHouse-User", w.GetConfig().Loggers.ClickhouseClient.User)
+	req.Header.Set("X-ClickHouse-Key", w.settings.password)
+
+	resp, err := w.settings.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("server returned HTTP status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (w *ClickhouseClient) FlushBuffer(buf *bytes.Buffer, rows *int) {
+	if *rows == 0 {
+		return
+	}
+	if err := w.Exec(w.settings.insertQuery, bytes.NewReader(buf.Bytes())); err != nil {
+		w.LogError("insert of %d row(s) failed: %s", *rows, err)
+		w.CountDiscardedTraffic(*rows)
+	}
+	buf.Reset()
+	*rows = 0
+}
+
 func (w *ClickhouseClient) StartLogging() {
 	w.LogInfo("logging has started")
 	defer w.LoggingDone()
 
+	// create the table ?
+	if w.GetConfig().Loggers.ClickhouseClient.CreateTable {
+		ddl, err := GenerateClickhouseDDL(w.GetConfig())
+		if err == nil {
+			err = w.Exec(ddl, nil)
+		}
+		if err != nil {
+			w.LogError("unable to create the table: %s", err)
+		}
+	}
+
+	// init buffer
+	buffer := new(bytes.Buffer)
+	rows := 0
+
+	// init flush timer for buffer
+	flushInterval := time.Duration(w.GetConfig().Loggers.ClickhouseClient.FlushInterval) * time.Second
+	flushTimer := time.NewTimer(flushInterval)
+
 	for {
 		select {
 		case <-w.OnLoggerStopped():
+			w.FlushBuffer(buffer, &rows)
 			return
 
+		// new settings, the buffered rows are encoded with the previous columns
+		case settings := <-w.newSettings:
+			w.FlushBuffer(buffer, &rows)
+			w.settings = settings
+			flushInterval = time.Duration(w.GetConfig().Loggers.ClickhouseClient.FlushInterval) * time.Second
+
 			// incoming dns message to process
 		case dm, opened := <-w.GetOutputChannel():
 			if !opened {
 				w.LogInfo("output channel closed!")
 				return
 			}
-			t, err := time.Parse(time.RFC3339, dm.DNSTap.TimestampRFC3339)
-			timensec := ""
-			if err == nil {
-				timensec = strconv.Itoa(int(t.UnixNano()))
-			}
-			data := ClickhouseData{
-				Identity:  dm.DNSTap.Identity,
-				QueryIP:   dm.NetworkInfo.QueryIP,
-				QName:     dm.DNS.Qname,
-				Operation: dm.DNSTap.Operation,
-				Family:    dm.NetworkInfo.Family,
-				Protocol:  dm.NetworkInfo.Protocol,
-				QType:     dm.DNS.Qtype,
-				RCode:     dm.DNS.Rcode,
-				TimeNSec:  timensec,
-				TimeStamp: strconv.Itoa(int(int64(dm.DNSTap.TimeSec))),
+
+			if err := w.EncodeRow(buffer, &dm); err != nil {
+				w.LogError("encoding DNS message failed: %s", err)
+				continue
 			}
-			url := w.GetConfig().Loggers.ClickhouseClient.URL + "?query=INSERT%20INTO%20"
-			url += w.GetConfig().Loggers.ClickhouseClient.Database + "." + w.GetConfig().Loggers.ClickhouseClient.Table
-			url += "(identity,queryip,qname,operation,family,protocol,qtype,rcode,timensec,timestamp)%20VALUES%20('" + data.Identity + separator
-			url += data.QueryIP + separator + data.QName + separator + data.Operation + separator + data.Family + separator + data.Protocol
-			url += separator + data.QType + separator + data.RCode + separator + data.TimeNSec + separator + data.TimeStamp + "')"
-			req, _ := http.NewRequest("POST", url, nil)
-
-			req.Header.Add("Accept", "*/*")
-			req.Header.Add("X-ClickHouse-User", w.GetConfig().Loggers.ClickhouseClient.User)
-			req.Header.Add("X-ClickHouse-Key", w.GetConfig().Loggers.ClickhouseClient.Password)
-
-			_, errReq := http.DefaultClient.Do(req)
-			if errReq != nil {
-				w.LogError(errReq.Error())
+			rows++
+
+			// buffer is full ?
+			if rows >= w.GetConfig().Loggers.ClickhouseClient.BatchSize {
+				w.FlushBuffer(buffer, &rows)
 			}
+
+		// flush the buffer
+		case <-flushTimer.C:
+			w.FlushBuffer(buffer, &rows)
+			flushTimer.Reset(flushInterval)
 		}
 	}
 }
@@ -2,10 +2,18 @@ package workers
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
@@ -15,12 +23,16 @@ import (
 func Test_ClickhouseClient(t *testing.T) {
 
 	testcases := []struct {
-		mode    string
+		format  string
 		pattern string
 	}{
 		{
-			mode:    pkgconfig.ModeJSON,
-			pattern: pkgconfig.ProgQname,
+			format:  pkgconfig.ClickhouseJSONEachRow,
+			pattern: `"qname":"dns.collector'\); DROP TABLE records; --"`,
+		},
+		{
+			format:  pkgconfig.ClickhouseRowBinary,
+			pattern: `dns.collector'\); DROP TABLE records; --`,
 		},
 	}
 	cfg := pkgconfig.GetDefaultConfig()
@@ -29,6 +41,7 @@ func Test_ClickhouseClient(t *testing.T) {
 	cfg.Loggers.ClickhouseClient.Password = "password"
 	cfg.Loggers.ClickhouseClient.Database = "database"
 	cfg.Loggers.ClickhouseClient.Table = "table"
+	cfg.Loggers.ClickhouseClient.BatchSize = 1
 	fakeRcvr, err := net.Listen("tcp", "127.0.0.1:8123")
 	if err != nil {
 		t.Fatal(err)
@@ -36,12 +49,14 @@ func Test_ClickhouseClient(t *testing.T) {
 	defer fakeRcvr.Close()
 
 	for _, tc := range testcases {
-		t.Run(tc.mode, func(t *testing.T) {
+		t.Run(tc.format, func(t *testing.T) {
+			cfg.Loggers.ClickhouseClient.Format = tc.format
 			g := NewClickhouseClient(cfg, logger.New(false), "test")
 
 			go g.StartCollect()
 
 			dm := dnsutils.GetFakeDNSMessage()
+			dm.DNS.Qname = "dns.collector'); DROP TABLE records; --"
 			g.GetInputChannel() <- dm
 			// accept conn
 			conn, err := fakeRcvr.Accept()
@@ -56,12 +71,176 @@ func Test_ClickhouseClient(t *testing.T) {
 				t.Fatal(err)
 			}
 			query := request.URL.Query().Get("query")
+			body, _ := io.ReadAll(request.Body)
 			conn.Write([]byte(pkgconfig.HTTPOK))
 
+			wantQuery := "INSERT INTO `database`.`table` (`identity`,`queryip`,`qname`,`operation`,`family`,`protocol`,`qtype`,`rcode`,`timensec`,`timestamp`) FORMAT " + tc.format
+			if query != wantQuery {
+				t.Errorf("clickhouse test error want %s, got: %s", wantQuery, query)
+			}
+			if request.Header.Get("X-ClickHouse-User") != "default" {
+				t.Errorf("clickhouse user header missing")
+			}
+
 			pattern := regexp.MustCompile(tc.pattern)
-			if !pattern.MatchString(query) {
-				t.Errorf("clickhouse test error want %s, got: %s", tc.pattern, query)
+			if !pattern.Match(body) {
+				t.Errorf("clickhouse test error want %s, got: %s", tc.pattern, body)
 			}
 		})
 	}
 }
+
+func Test_ClickhouseClient_Columns(t *testing.T) {
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.ClickhouseClient.Columns = []pkgconfig.ConfigClickhouseColumn{
+		{Name: "qname", Field: "dns.qname"},
+		{Name: "country", Field: "geoip.country-isocode", Type: "LowCardinality(String)"},
+		{Name: "an_rdatas", Field: "dns.resource-records.an.rdatas", Type: "Array(String)"},
+		{Name: "answers", Field: "dns.resource-records.an", Type: "Map(String, String)"},
+		{Name: "tags", Field: "atags.tags", Type: "Array(String)"},
+		{Name: "length", Field: "dns.length", Type: "UInt32"},
+		{Name: "latency", Field: "dnstap.latency", Type: "Float64"},
+		{Name: "qr", Field: "dns.flags.qr", Type: "Bool"},
+		{Name: "time", Field: "dnstap.timestamp-rfc3339ns", Type: "DateTime64(3)"},
+	}
+	columns, err := GetClickhouseColumns(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dm := dnsutils.GetFakeDNSMessageWithPayload()
+	dm.DNS.Length = 42
+	dm.DNSTap.Latency = 0.5
+	dm.DNSTap.TimestampRFC3339 = "2024-01-02T03:04:05.123456789Z"
+	dm.DNS.DNSRRs.Answers = []dnsutils.DNSAnswer{
+		{Name: "a.com", Rdatatype: "A", Rdata: "1.1.1.1", TTL: 10, Class: "IN"},
+		{Name: "a.com", Rdatatype: "A", Rdata: "2.2.2.2", TTL: 10, Class: "IN"},
+	}
+	dm.Geo = &dnsutils.TransformDNSGeo{CountryIsoCode: "FR"}
+	dm.ATags = &dnsutils.TransformATags{Tags: []string{"tag1", "tag2"}}
+	flat, err := dm.Flatten()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := map[string]interface{}{}
+	for _, c := range columns {
+		row[c.Name] = c.JSONValue(flat)
+	}
+	data, _ := json.Marshal(row)
+
+	for _, want := range []string{
+		`"country":"FR"`,
+		`"an_rdatas":["1.1.1.1","2.2.2.2"]`,
+		`"rdatas":"1.1.1.1|2.2.2.2"`,
+		`"tags":["tag1","tag2"]`,
+		`"length":42`,
+		`"latency":0.5`,
+		`"time":"1704164645.123"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("want %s in row, got: %s", want, data)
+		}
+	}
+
+	// RowBinary of UInt32 and String
+	buf := new(bytes.Buffer)
+	columns[5].AppendRowBinary(buf, flat)
+	if !bytes.Equal(buf.Bytes(), []byte{42, 0, 0, 0}) {
+		t.Errorf("invalid UInt32 encoding: %v", buf.Bytes())
+	}
+	buf.Reset()
+	columns[1].AppendRowBinary(buf, flat)
+	if !bytes.Equal(buf.Bytes(), []byte{2, 'F', 'R'}) {
+		t.Errorf("invalid String encoding: %v", buf.Bytes())
+	}
+}
+
+func Test_ClickhouseClient_InvalidColumns(t *testing.T) {
+	cfg := pkgconfig.GetDefaultConfig()
+
+	cfg.Loggers.ClickhouseClient.Columns = []pkgconfig.ConfigClickhouseColumn{{Name: "qname", Field: "dns.qname", Type: "Tuple(String)"}}
+	if _, err := GetClickhouseColumns(cfg); err == nil {
+		t.Errorf("unsupported type must be rejected")
+	}
+
+	cfg.Loggers.ClickhouseClient.Columns = []pkgconfig.ConfigClickhouseColumn{{Name: "qname", Field: "dns.qname"}, {Name: "qname", Field: "dns.qtype"}}
+	if _, err := GetClickhouseColumns(cfg); err == nil {
+		t.Errorf("duplicated column must be rejected")
+	}
+}
+
+func Test_ClickhouseClient_DDL(t *testing.T) {
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.ClickhouseClient.Columns = []pkgconfig.ConfigClickhouseColumn{
+		{Name: "qname", Field: "dns.qname"},
+		{Name: "country", Field: "geoip.country-isocode", Type: "LowCardinality(String)"},
+	}
+
+	ddl, err := GenerateClickhouseDDL(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "CREATE TABLE IF NOT EXISTS `dnscollector`.`records`\n(\n    `qname` String,\n    `country` LowCardinality(String)\n)\nENGINE = MergeTree ORDER BY tuple()"
+	if ddl != want {
+		t.Errorf("want ddl:\n%s\ngot:\n%s", want, ddl)
+	}
+}
+
+func Test_ClickhouseClient_Reload(t *testing.T) {
+	queries := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		queries <- r.URL.Query().Get("query") + " key=" + r.Header.Get("X-ClickHouse-Key")
+	}))
+	defer server.Close()
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	os.WriteFile(passwordFile, []byte("secret\n"), 0600)
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.ClickhouseClient.URL = server.URL
+	cfg.Loggers.ClickhouseClient.Table = "table"
+	cfg.Loggers.ClickhouseClient.PasswordFile = passwordFile
+	cfg.Loggers.ClickhouseClient.FlushInterval = 3600
+	cfg.Loggers.ClickhouseClient.Columns = []pkgconfig.ConfigClickhouseColumn{{Name: "qname", Field: "dns.qname"}}
+
+	g := NewClickhouseClient(cfg, logger.New(false), "test")
+	if cfg.Loggers.ClickhouseClient.Password != "password" {
+		t.Errorf("the password file should not modify the config")
+	}
+	go g.StartCollect()
+
+	// one row is buffered before the reload with another column set
+	g.GetInputChannel() <- dnsutils.GetFakeDNSMessage()
+	time.Sleep(100 * time.Millisecond)
+
+	newCfg := pkgconfig.GetDefaultConfig()
+	*newCfg = *cfg
+	newCfg.Loggers.ClickhouseClient.Columns = []pkgconfig.ConfigClickhouseColumn{{Name: "qtype", Field: "dns.qtype"}}
+	g.NewConfig() <- newCfg
+
+	select {
+	case query := <-queries:
+		want := "INSERT INTO `dnscollector`.`table` (`qname`) FORMAT JSONEachRow key=secret"
+		if query != want {
+			t.Errorf("want query %s, got %s", want, query)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the buffered row was not inserted on reload")
+	}
+
+	// the next rows use the new columns
+	g.GetInputChannel() <- dnsutils.GetFakeDNSMessage()
+	time.Sleep(100 * time.Millisecond)
+	g.Stop()
+
+	select {
+	case query := <-queries:
+		if !strings.Contains(query, "(`qtype`)") {
+			t.Errorf("the new columns should be used after the reload, got %s", query)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the row was not inserted on stop")
+	}
+}
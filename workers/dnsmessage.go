@@ -44,32 +44,12 @@ func NewDNSMessage(next []Worker, config *pkgconfig.Config, logger *logger.Logge
 }
 
 func (w *DNSMessage) ReadConfigMatching(value interface{}) {
-	reflectedValue := reflect.ValueOf(value)
-	if reflectedValue.Kind() == reflect.Map {
-		keys := reflectedValue.MapKeys()
-		matchSrc := ""
-		srcKind := dnsutils.MatchingKindString
-		for _, k := range keys {
-			v := reflectedValue.MapIndex(k)
-			if k.Interface().(string) == "match-source" {
-				matchSrc = v.Interface().(string)
-			}
-			if k.Interface().(string) == "source-kind" {
-				srcKind = v.Interface().(string)
-			}
-		}
-		if len(matchSrc) > 0 {
-			sourceData, err := w.LoadData(matchSrc, srcKind)
-			if err != nil {
-				w.LogFatal(err)
-			}
-			if len(sourceData.regexList) > 0 {
-				value.(map[interface{}]interface{})[srcKind] = sourceData.regexList
-			}
-			if len(sourceData.stringList) > 0 {
-				value.(map[interface{}]interface{})[srcKind] = sourceData.stringList
-			}
-		}
+	sourceData, err := ReadMatchingSource(value)
+	if err != nil {
+		w.LogFatal(err)
+	}
+	if entries := sourceData.Len(); entries > 0 {
+		w.LogInfo("matching source loaded with %d entries", entries)
 	}
 }
 
@@ -88,25 +68,72 @@ func (w *DNSMessage) ReadConfig() {
 	}
 }
 
-func (w *DNSMessage) LoadData(matchSource string, srcKind string) (MatchSource, error) {
-	if isFileSource(matchSource) {
-		dataSource, err := w.LoadFromFile(matchSource, srcKind)
-		if err != nil {
-			w.LogFatal(err)
+func (ms MatchSource) Len() int {
+	return len(ms.regexList) + len(ms.stringList)
+}
+
+// ReadMatchingSource loads the external source of a matching condition, if any,
+// the values read are added to the condition under the source kind key
+func ReadMatchingSource(value interface{}) (MatchSource, error) {
+	reflectedValue := reflect.ValueOf(value)
+	if reflectedValue.Kind() != reflect.Map {
+		return MatchSource{}, nil
+	}
+
+	keys := reflectedValue.MapKeys()
+	matchSrc := ""
+	srcKind := dnsutils.MatchingKindString
+	for _, k := range keys {
+		v := reflectedValue.MapIndex(k)
+		if k.Interface().(string) == "match-source" {
+			matchSrc = v.Interface().(string)
 		}
-		return dataSource, nil
-	} else if isURLSource(matchSource) {
-		dataSource, err := w.LoadFromURL(matchSource, srcKind)
-		if err != nil {
-			w.LogFatal(err)
+		if k.Interface().(string) == "source-kind" {
+			srcKind = v.Interface().(string)
 		}
-		return dataSource, nil
+	}
+	if len(matchSrc) == 0 {
+		return MatchSource{}, nil
+	}
+
+	sourceData, err := LoadMatchSource(matchSrc, srcKind)
+	if err != nil {
+		return sourceData, err
+	}
+
+	var values interface{}
+	switch {
+	case len(sourceData.regexList) > 0:
+		values = sourceData.regexList
+	case len(sourceData.stringList) > 0:
+		values = sourceData.stringList
+	default:
+		return sourceData, nil
+	}
+
+	// yaml.v3 decodes the nested maps with string keys, yaml.v2 with interface keys
+	switch condition := value.(type) {
+	case map[string]interface{}:
+		condition[srcKind] = values
+	case map[interface{}]interface{}:
+		condition[srcKind] = values
+	default:
+		return MatchSource{}, fmt.Errorf("unsupported matching condition type: %T", value)
+	}
+	return sourceData, nil
+}
+
+// LoadMatchSource reads the list of strings or regexps from a local file or an url
+func LoadMatchSource(matchSource string, srcKind string) (MatchSource, error) {
+	if isFileSource(matchSource) {
+		return LoadMatchSourceFromFile(matchSource, srcKind)
+	} else if isURLSource(matchSource) {
+		return LoadMatchSourceFromURL(matchSource, srcKind)
 	}
 	return MatchSource{}, fmt.Errorf("match source not supported %s", matchSource)
 }
 
-func (w *DNSMessage) LoadFromURL(matchSource string, srcKind string) (MatchSource, error) {
-	w.LogInfo("loading matching source from url=%s", matchSource)
+func LoadMatchSourceFromURL(matchSource string, srcKind string) (MatchSource, error) {
 	resp, err := http.Get(matchSource)
 	if err != nil {
 		return MatchSource{}, err
@@ -116,52 +143,37 @@ func (w *DNSMessage) LoadFromURL(matchSource string, srcKind string) (MatchSourc
 	if resp.StatusCode != http.StatusOK {
 		return MatchSource{}, fmt.Errorf("invalid status code: %d", resp.StatusCode)
 	}
-
-	matchSources := MatchSource{}
-	scanner := bufio.NewScanner(resp.Body)
-
-	switch srcKind {
-	case dnsutils.MatchingKindRegexp:
-		for scanner.Scan() {
-			matchSources.regexList = append(matchSources.regexList, regexp.MustCompile(scanner.Text()))
-		}
-		w.LogInfo("remote source loaded with %d entries kind=%s", len(matchSources.regexList), srcKind)
-	case dnsutils.MatchingKindString:
-		for scanner.Scan() {
-			matchSources.stringList = append(matchSources.stringList, scanner.Text())
-		}
-		w.LogInfo("remote source loaded with %d entries kind=%s", len(matchSources.stringList), srcKind)
-	}
-
-	return matchSources, nil
+	return readMatchSource(bufio.NewScanner(resp.Body), srcKind)
 }
 
-func (w *DNSMessage) LoadFromFile(filePath string, srcKind string) (MatchSource, error) {
+func LoadMatchSourceFromFile(filePath string, srcKind string) (MatchSource, error) {
 	localFile := strings.TrimPrefix(filePath, "file://")
 
-	w.LogInfo("loading matching source from file=%s", localFile)
 	file, err := os.Open(localFile)
 	if err != nil {
 		return MatchSource{}, fmt.Errorf("unable to open file: %w", err)
 	}
+	defer file.Close()
+	return readMatchSource(bufio.NewScanner(file), srcKind)
+}
 
+func readMatchSource(scanner *bufio.Scanner, srcKind string) (MatchSource, error) {
 	matchSources := MatchSource{}
-	scanner := bufio.NewScanner(file)
-
 	switch srcKind {
 	case dnsutils.MatchingKindRegexp:
 		for scanner.Scan() {
-			matchSources.regexList = append(matchSources.regexList, regexp.MustCompile(scanner.Text()))
+			re, err := regexp.Compile(scanner.Text())
+			if err != nil {
+				return MatchSource{}, err
+			}
+			matchSources.regexList = append(matchSources.regexList, re)
 		}
-		w.LogInfo("file loaded with %d entries kind=%s", len(matchSources.regexList), srcKind)
 	case dnsutils.MatchingKindString:
 		for scanner.Scan() {
 			matchSources.stringList = append(matchSources.stringList, scanner.Text())
 		}
-		w.LogInfo("file loaded with %d entries kind=%s", len(matchSources.stringList), srcKind)
 	}
-
-	return matchSources, nil
+	return matchSources, scanner.Err()
 }
 
 func (w *DNSMessage) StartCollect() {
@@ -181,6 +193,11 @@ func (w *DNSMessage) StartCollect() {
 	w.LogInfo("waiting dns message to process...")
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+			subprocessors.SetNextWorkers(defaultRoutes)
+
 		case <-w.OnStop():
 			subprocessors.Reset()
 			return
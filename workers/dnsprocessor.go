@@ -32,6 +32,11 @@ func (w *DNSProcessor) StartCollect() {
 	// read incoming dns message
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+			transforms.SetNextWorkers(defaultRoutes)
+
 		case cfg := <-w.NewConfig():
 			w.SetConfig(cfg)
 			transforms.ReloadConfig(&cfg.IngoingTransformers)
@@ -53,42 +58,9 @@ func (w *DNSProcessor) StartCollect() {
 			dm.DNSTap.Timestamp = ts.UnixNano()
 			dm.DNSTap.TimestampRFC3339 = ts.UTC().Format(time.RFC3339Nano)
 
-			// decode the dns payload
-			dnsHeader, err := dnsutils.DecodeDNS(dm.DNS.Payload)
-			if err != nil {
-				dm.DNS.MalformedPacket = true
-				w.LogError("dns parser malformed packet: %s - %v+", err, dm)
-			}
-
-			// get number of questions and answers
-			dm.DNS.QdCount = dnsHeader.Qdcount
-			dm.DNS.AnCount = dnsHeader.Ancount
-			dm.DNS.ArCount = dnsHeader.Arcount
-			dm.DNS.NsCount = dnsHeader.Nscount
-
-			// dns reply ?
-			if dnsHeader.Qr == 1 {
-				dm.DNSTap.Operation = "CLIENT_RESPONSE"
-				dm.DNS.Type = dnsutils.DNSReply
-				qip := dm.NetworkInfo.QueryIP
-				qport := dm.NetworkInfo.QueryPort
-				dm.NetworkInfo.QueryIP = dm.NetworkInfo.ResponseIP
-				dm.NetworkInfo.QueryPort = dm.NetworkInfo.ResponsePort
-				dm.NetworkInfo.ResponseIP = qip
-				dm.NetworkInfo.ResponsePort = qport
-			} else {
-				dm.DNS.Type = dnsutils.DNSQuery
-				dm.DNSTap.Operation = dnsutils.DNSTapClientQuery
-			}
-
-			if err = dnsutils.DecodePayload(&dm, &dnsHeader, w.GetConfig()); err != nil {
-				w.LogError("%v - %v", err, dm)
-			}
-
-			if dm.DNS.MalformedPacket {
-				if w.GetConfig().Global.Trace.LogMalformed {
-					w.LogInfo("payload: %v", dm.DNS.Payload)
-				}
+			// metadata of an encrypted session, no payload to decode
+			if dm.DNS.Type != dnsutils.DNSSession {
+				w.decodeMessage(&dm)
 			}
 
 			// count output packets
@@ -109,3 +81,44 @@ func (w *DNSProcessor) StartCollect() {
 		}
 	}
 }
+
+// decodeMessage decodes the DNS payload and sets the direction of the message
+func (w *DNSProcessor) decodeMessage(dm *dnsutils.DNSMessage) {
+	// decode the dns payload
+	dnsHeader, err := dnsutils.DecodeDNS(dm.DNS.Payload)
+	if err != nil {
+		dm.DNS.MalformedPacket = true
+		w.LogError("dns parser malformed packet: %s - %v+", err, *dm)
+	}
+
+	// get number of questions and answers
+	dm.DNS.QdCount = dnsHeader.Qdcount
+	dm.DNS.AnCount = dnsHeader.Ancount
+	dm.DNS.ArCount = dnsHeader.Arcount
+	dm.DNS.NsCount = dnsHeader.Nscount
+
+	// dns reply ?
+	if dnsHeader.Qr == 1 {
+		dm.DNSTap.Operation = "CLIENT_RESPONSE"
+		dm.DNS.Type = dnsutils.DNSReply
+		qip := dm.NetworkInfo.QueryIP
+		qport := dm.NetworkInfo.QueryPort
+		dm.NetworkInfo.QueryIP = dm.NetworkInfo.ResponseIP
+		dm.NetworkInfo.QueryPort = dm.NetworkInfo.ResponsePort
+		dm.NetworkInfo.ResponseIP = qip
+		dm.NetworkInfo.ResponsePort = qport
+	} else {
+		dm.DNS.Type = dnsutils.DNSQuery
+		dm.DNSTap.Operation = dnsutils.DNSTapClientQuery
+	}
+
+	if err = dnsutils.DecodePayload(dm, &dnsHeader, w.GetConfig()); err != nil {
+		w.LogError("%v - %v", err, *dm)
+	}
+
+	if dm.DNS.MalformedPacket {
+		if w.GetConfig().Global.Trace.LogMalformed {
+			w.LogInfo("payload: %v", dm.DNS.Payload)
+		}
+	}
+}
@@ -2,21 +2,118 @@ package workers
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-dnstap-protobuf"
 	"github.com/dmachard/go-framestream"
 	"github.com/dmachard/go-logger"
 	"github.com/dmachard/go-netutils"
 )
 
+// DnstapRelayFilter selects and rewrites the frames from the dnstap envelope only
+type DnstapRelayFilter struct {
+	keepTypes, dropTypes []string
+	identity             string
+	overwriteIdentity    bool
+	sampleRate           int
+}
+
+func NewDnstapRelayFilter(config *pkgconfig.Config) (*DnstapRelayFilter, error) {
+	cfg := config.Collectors.DnstapProxifier
+	for _, pattern := range append(append([]string{}, cfg.KeepMessageTypes...), cfg.DropMessageTypes...) {
+		valid := false
+		for _, name := range dnstap.Message_Type_name {
+			if matchDnstapType(pattern, name) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid dnstap message type: %s", pattern)
+		}
+	}
+	if cfg.SampleRate < 1 {
+		return nil, errors.New("sample rate must be greater than or equal to 1")
+	}
+	return &DnstapRelayFilter{
+		keepTypes:         cfg.KeepMessageTypes,
+		dropTypes:         cfg.DropMessageTypes,
+		identity:          cfg.Identity,
+		overwriteIdentity: cfg.OverwriteIdentity,
+		sampleRate:        cfg.SampleRate,
+	}, nil
+}
+
+// matchDnstapType supports a trailing wildcard, CLIENT_* matches CLIENT_QUERY and CLIENT_RESPONSE
+func matchDnstapType(pattern, msgType string) bool {
+	if prefix, found := strings.CutSuffix(pattern, "*"); found {
+		return strings.HasPrefix(msgType, prefix)
+	}
+	return pattern == msgType
+}
+
+func matchDnstapTypes(patterns []string, msgType string) bool {
+	for _, pattern := range patterns {
+		if matchDnstapType(pattern, msgType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Keep returns false if the frame must be dropped, seq is used to sample
+// the frames without query address
+func (f *DnstapRelayFilter) Keep(env *DnstapEnvelope, seq uint64) bool {
+	if len(f.keepTypes) > 0 && !matchDnstapTypes(f.keepTypes, env.MessageType) {
+		return false
+	}
+	if matchDnstapTypes(f.dropTypes, env.MessageType) {
+		return false
+	}
+	if f.sampleRate > 1 {
+		// the messages of a client are kept or dropped together
+		if key := env.SampleKey(); key != "" {
+			return dnstapHash(key)%uint32(f.sampleRate) == 0
+		}
+		return seq%uint64(f.sampleRate) == 0
+	}
+	return true
+}
+
+// Passthrough returns true if no filter is configured, the frames are not decoded
+func (f *DnstapRelayFilter) Passthrough() bool {
+	return len(f.keepTypes) == 0 && len(f.dropTypes) == 0 && f.identity == "" && f.sampleRate <= 1
+}
+
+// NeedsMessageType returns true if the frames are filtered by message type
+func (f *DnstapRelayFilter) NeedsMessageType() bool {
+	return len(f.keepTypes) > 0 || len(f.dropTypes) > 0
+}
+
+// Identity returns the identity to set in the frame, if any
+func (f *DnstapRelayFilter) Identity(env *DnstapEnvelope) (string, bool) {
+	if f.identity == "" || f.identity == env.Identity {
+		return "", false
+	}
+	if env.Identity != "" && !f.overwriteIdentity {
+		return "", false
+	}
+	return f.identity, true
+}
+
 type DnstapProxifier struct {
 	*GenericWorker
 	connCounter uint64
+	filter      atomic.Pointer[DnstapRelayFilter]
 }
 
 func NewDnstapProxifier(next []Worker, config *pkgconfig.Config, logger *logger.Logger, name string) *DnstapProxifier {
@@ -34,27 +131,102 @@ func (w *DnstapProxifier) CheckConfig() {
 	if !netutils.IsValidTLS(w.GetConfig().Collectors.DnstapProxifier.TLSMinVersion) {
 		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] dnstaprelay - invalid tls min version")
 	}
+
+	filter, err := NewDnstapRelayFilter(w.GetConfig())
+	if err != nil {
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] dnstaprelay - ", err)
+	}
+	w.filter.Store(filter)
 }
 
-func (w *DnstapProxifier) HandleFrame(recvFrom chan []byte, sendTo []chan dnsutils.DNSMessage) {
+func (w *DnstapProxifier) HandleFrame(recvFrom chan []byte) {
 	defer w.LogInfo("frame handler terminated")
 
+	sendTo, _ := GetRoutes(w.GetDefaultRoutes())
+	droppedRoutes, droppedNames := GetRoutes(w.GetDroppedRoutes())
+
 	dm := dnsutils.DNSMessage{}
+	var seq uint64
 
-	for data := range recvFrom {
-		// init DNS message container
-		dm.Init()
+	for {
+		select {
+		// routes updated on reload
+		case <-w.OnRoutesChanged():
+			sendTo, _ = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
 
-		// register payload
-		dm.DNSTap.Payload = data
+		case data, opened := <-recvFrom:
+			if !opened {
+				return
+			}
+			w.CountIngressTraffic()
+			seq++
 
-		// forward to outputs
-		for i := range sendTo {
-			sendTo[i] <- dm
+			// init DNS message container
+			dm.Init()
+
+			// without filter the frames are relayed untouched
+			filter := w.filter.Load()
+			if !filter.Passthrough() {
+				var keep bool
+				if data, keep = w.ApplyFilter(filter, data, seq, &dm); !keep {
+					w.SendDroppedTo(droppedRoutes, droppedNames, dm)
+					continue
+				}
+			}
+
+			// register payload
+			dm.DNSTap.Payload = data
+
+			// forward to outputs
+			w.CountEgressTraffic()
+			for i := range sendTo {
+				sendTo[i] <- dm
+			}
 		}
 	}
 }
 
+// ApplyFilter decodes the envelope of the frame to filter it and rewrite the identity,
+// the envelope is also copied to the dns message for the routing. The frames that
+// can't be decoded are relayed untouched, unless the message type is required.
+func (w *DnstapProxifier) ApplyFilter(filter *DnstapRelayFilter, data []byte, seq uint64, dm *dnsutils.DNSMessage) ([]byte, bool) {
+	env, err := DecodeDnstapEnvelope(data)
+	if err != nil {
+		w.LogError("dnstap envelope decoding error: %s", err)
+		if filter.NeedsMessageType() {
+			dm.DNSTap.Payload = data
+			return data, false
+		}
+		return data, filter.Keep(&DnstapEnvelope{}, seq)
+	}
+
+	if identity, rewrite := filter.Identity(env); rewrite {
+		if frame, err := SetDnstapIdentity(data, identity); err != nil {
+			w.LogError("dnstap identity rewriting error: %s", err)
+		} else {
+			data = frame
+			env.Identity = identity
+		}
+	}
+
+	dm.DNSTap.Payload = data
+	if env.Identity != "" {
+		dm.DNSTap.Identity = env.Identity
+	}
+	if env.MessageType != "" {
+		dm.DNSTap.Operation = env.MessageType
+	}
+	if ipVersion, valid := netutils.IPVersion[env.SocketFamily]; valid {
+		dm.NetworkInfo.Family = ipVersion
+	}
+	if len(env.QueryAddress) > 0 {
+		dm.NetworkInfo.QueryIP = env.QueryAddress.String()
+		dm.NetworkInfo.QueryPort = strconv.Itoa(int(env.QueryPort))
+	}
+	return data, filter.Keep(env, seq)
+}
+
 func (w *DnstapProxifier) HandleConn(conn net.Conn, connID uint64, forceClose chan bool, wg *sync.WaitGroup) {
 	// close connection on function exit
 	defer func() {
@@ -67,14 +239,13 @@ func (w *DnstapProxifier) HandleConn(conn net.Conn, connID uint64, forceClose ch
 	peer := conn.RemoteAddr().String()
 	w.LogInfo("new connection from %s\n", peer)
 
-	bufSize := w.config.Global.Worker.ChannelBufferSize
-	if w.config.Collectors.DnstapProxifier.ChannelBufferSize > 0 {
-		bufSize = w.config.Collectors.DnstapProxifier.ChannelBufferSize
+	bufSize := w.GetConfig().Global.Worker.ChannelBufferSize
+	if w.GetConfig().Collectors.DnstapProxifier.ChannelBufferSize > 0 {
+		bufSize = w.GetConfig().Collectors.DnstapProxifier.ChannelBufferSize
 	}
 
 	recvChan := make(chan []byte, bufSize)
-	defaultRoutes, _ := GetRoutes(w.GetDefaultRoutes())
-	go w.HandleFrame(recvChan, defaultRoutes)
+	go w.HandleFrame(recvChan)
 
 	// frame stream library
 	fsReader := bufio.NewReader(conn)
@@ -0,0 +1,148 @@
+package workers
+
+import (
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/dmachard/go-dnstap-protobuf"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// fields of the dnstap protobuf used by the relay
+const (
+	dnstapFieldIdentity     protowire.Number = 1
+	dnstapFieldMessage      protowire.Number = 14
+	dnstapFieldMessageType  protowire.Number = 1
+	dnstapFieldSocketFamily protowire.Number = 2
+	dnstapFieldQueryAddress protowire.Number = 4
+	dnstapFieldQueryPort    protowire.Number = 6
+)
+
+var ErrDnstapEnvelope = errors.New("invalid dnstap envelope")
+
+// DnstapEnvelope is a dnstap frame decoded without the dns messages
+type DnstapEnvelope struct {
+	Identity     string
+	MessageType  string
+	SocketFamily string
+	QueryAddress net.IP
+	QueryPort    uint32
+}
+
+// scanProtoFields calls fn with the number, the value and the raw bytes of each field
+func scanProtoFields(data []byte, fn func(num protowire.Number, typ protowire.Type, value, field []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		m := protowire.ConsumeFieldValue(num, typ, data[n:])
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		if err := fn(num, typ, data[n:n+m], data[:n+m]); err != nil {
+			return err
+		}
+		data = data[n+m:]
+	}
+	return nil
+}
+
+func consumeProtoBytes(typ protowire.Type, value []byte) ([]byte, error) {
+	if typ != protowire.BytesType {
+		return nil, ErrDnstapEnvelope
+	}
+	v, n := protowire.ConsumeBytes(value)
+	if n < 0 {
+		return nil, protowire.ParseError(n)
+	}
+	return v, nil
+}
+
+func consumeProtoVarint(typ protowire.Type, value []byte) (uint64, error) {
+	if typ != protowire.VarintType {
+		return 0, ErrDnstapEnvelope
+	}
+	v, n := protowire.ConsumeVarint(value)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return v, nil
+}
+
+// DecodeDnstapEnvelope reads the identity and the message header of the frame,
+// the query and response messages are skipped
+func DecodeDnstapEnvelope(frame []byte) (*DnstapEnvelope, error) {
+	env := &DnstapEnvelope{}
+	err := scanProtoFields(frame, func(num protowire.Number, typ protowire.Type, value, _ []byte) error {
+		switch num {
+		case dnstapFieldIdentity:
+			identity, err := consumeProtoBytes(typ, value)
+			env.Identity = string(identity)
+			return err
+		case dnstapFieldMessage:
+			msg, err := consumeProtoBytes(typ, value)
+			if err != nil {
+				return err
+			}
+			return env.decodeMessage(msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func (env *DnstapEnvelope) decodeMessage(msg []byte) error {
+	return scanProtoFields(msg, func(num protowire.Number, typ protowire.Type, value, _ []byte) error {
+		switch num {
+		case dnstapFieldMessageType:
+			v, err := consumeProtoVarint(typ, value)
+			env.MessageType = dnstap.Message_Type(v).String()
+			return err
+		case dnstapFieldSocketFamily:
+			v, err := consumeProtoVarint(typ, value)
+			env.SocketFamily = dnstap.SocketFamily(v).String()
+			return err
+		case dnstapFieldQueryAddress:
+			v, err := consumeProtoBytes(typ, value)
+			env.QueryAddress = net.IP(v)
+			return err
+		case dnstapFieldQueryPort:
+			v, err := consumeProtoVarint(typ, value)
+			env.QueryPort = uint32(v)
+			return err
+		}
+		return nil
+	})
+}
+
+// SampleKey is the client of the message, the query and the reply have the same key
+func (env *DnstapEnvelope) SampleKey() string {
+	if len(env.QueryAddress) == 0 {
+		return ""
+	}
+	return env.QueryAddress.String() + "#" + strconv.Itoa(int(env.QueryPort))
+}
+
+// SetDnstapIdentity returns a copy of the frame with the identity replaced,
+// the other fields are copied without decoding
+func SetDnstapIdentity(frame []byte, identity string) ([]byte, error) {
+	out := make([]byte, 0, len(frame)+len(identity)+2)
+	out = protowire.AppendTag(out, dnstapFieldIdentity, protowire.BytesType)
+	out = protowire.AppendString(out, identity)
+
+	err := scanProtoFields(frame, func(num protowire.Number, _ protowire.Type, _, field []byte) error {
+		if num != dnstapFieldIdentity {
+			out = append(out, field...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
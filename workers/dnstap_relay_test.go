@@ -2,12 +2,14 @@ package workers
 
 import (
 	"bufio"
+	"bytes"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-dnstap-protobuf"
 	"github.com/dmachard/go-framestream"
 	"github.com/dmachard/go-logger"
 	"github.com/dmachard/go-netutils"
@@ -105,3 +107,205 @@ func Test_DnstapRelay(t *testing.T) {
 		})
 	}
 }
+
+func Test_DnstapRelay_DecodeEnvelope(t *testing.T) {
+	dnsquery, _ := dnsutils.GetFakeDNS()
+	data, _ := proto.Marshal(GetFakeDNSTap(dnsquery))
+
+	env, err := DecodeDnstapEnvelope(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Identity != "dnstap-generator" || env.MessageType != "CLIENT_QUERY" || env.SocketFamily != "INET" {
+		t.Errorf("invalid envelope: %s %s %s", env.Identity, env.MessageType, env.SocketFamily)
+	}
+	if env.QueryAddress.String() != "127.0.0.1" || env.QueryPort != 5300 {
+		t.Errorf("invalid query address: %s %d", env.QueryAddress, env.QueryPort)
+	}
+
+	if _, err := DecodeDnstapEnvelope(data[:len(data)-3]); err == nil {
+		t.Errorf("truncated frame must be invalid")
+	}
+}
+
+func Test_DnstapRelay_SetIdentity(t *testing.T) {
+	dnsquery, _ := dnsutils.GetFakeDNS()
+	dt := GetFakeDNSTap(dnsquery)
+	data, _ := proto.Marshal(dt)
+
+	out, err := SetDnstapIdentity(data, "relay1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dtOut := &dnstap.Dnstap{}
+	if err := proto.Unmarshal(out, dtOut); err != nil {
+		t.Fatal(err)
+	}
+	if string(dtOut.GetIdentity()) != "relay1" {
+		t.Errorf("identity not rewritten: %s", dtOut.GetIdentity())
+	}
+	if !bytes.Equal(dtOut.GetMessage().GetQueryMessage(), dnsquery) || string(dtOut.GetVersion()) != "-" {
+		t.Errorf("other fields must be unchanged")
+	}
+}
+
+func Test_DnstapRelay_Filter(t *testing.T) {
+	testcases := []struct {
+		name     string
+		keep     []string
+		drop     []string
+		msgType  string
+		expected bool
+	}{
+		{name: "no_filter", msgType: "RESOLVER_QUERY", expected: true},
+		{name: "keep_wildcard", keep: []string{"CLIENT_*"}, msgType: "CLIENT_RESPONSE", expected: true},
+		{name: "keep_other", keep: []string{"CLIENT_*"}, msgType: "RESOLVER_QUERY", expected: false},
+		{name: "drop", drop: []string{"CLIENT_QUERY"}, msgType: "CLIENT_QUERY", expected: false},
+		{name: "drop_other", drop: []string{"CLIENT_QUERY"}, msgType: "CLIENT_RESPONSE", expected: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := pkgconfig.GetDefaultConfig()
+			config.Collectors.DnstapProxifier.KeepMessageTypes = tc.keep
+			config.Collectors.DnstapProxifier.DropMessageTypes = tc.drop
+			filter, err := NewDnstapRelayFilter(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filter.Keep(&DnstapEnvelope{MessageType: tc.msgType}, 1) != tc.expected {
+				t.Errorf("%s must be kept: %v", tc.msgType, tc.expected)
+			}
+		})
+	}
+
+	// unknown message type
+	config := pkgconfig.GetDefaultConfig()
+	config.Collectors.DnstapProxifier.KeepMessageTypes = []string{"CLIENT_QUERIES"}
+	if _, err := NewDnstapRelayFilter(config); err == nil {
+		t.Errorf("invalid message type must be rejected")
+	}
+}
+
+func Test_DnstapRelay_Sampling(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	config.Collectors.DnstapProxifier.SampleRate = 4
+	filter, _ := NewDnstapRelayFilter(config)
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		query := &DnstapEnvelope{MessageType: "CLIENT_QUERY", QueryAddress: net.IPv4(10, 0, byte(i/256), byte(i%256)), QueryPort: 5300}
+		reply := &DnstapEnvelope{MessageType: "CLIENT_RESPONSE", QueryAddress: query.QueryAddress, QueryPort: 5300}
+
+		// the query and the reply are sampled together
+		keep := filter.Keep(query, uint64(i))
+		if filter.Keep(reply, uint64(i)+1) != keep {
+			t.Fatalf("query and reply of %s sampled differently", query.QueryAddress)
+		}
+		if keep {
+			kept++
+		}
+	}
+	if kept < 200 || kept > 300 {
+		t.Errorf("invalid sampling: %d kept of 1000", kept)
+	}
+}
+
+func Test_DnstapRelay_HandleFrame(t *testing.T) {
+	g := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	dropped := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+
+	config := pkgconfig.GetDefaultConfig()
+	config.Collectors.DnstapProxifier.KeepMessageTypes = []string{"CLIENT_*"}
+	config.Collectors.DnstapProxifier.Identity = "relay1"
+	config.Collectors.DnstapProxifier.OverwriteIdentity = true
+	c := NewDnstapProxifier([]Worker{g}, config, logger.New(false), "test")
+	c.AddDroppedRoute(dropped)
+
+	recvChan := make(chan []byte, 2)
+	go c.HandleFrame(recvChan)
+
+	dnsquery, _ := dnsutils.GetFakeDNS()
+	dtResolver := GetFakeDNSTap(dnsquery)
+	mt := dnstap.Message_RESOLVER_QUERY
+	dtResolver.Message.Type = &mt
+	resolverData, _ := proto.Marshal(dtResolver)
+	clientData, _ := proto.Marshal(GetFakeDNSTap(dnsquery))
+
+	recvChan <- resolverData
+	recvChan <- clientData
+	close(recvChan)
+
+	// the client query is relayed with the new identity
+	dm := <-g.GetInputChannel()
+	if dm.DNSTap.Identity != "relay1" || dm.DNSTap.Operation != "CLIENT_QUERY" || dm.NetworkInfo.QueryIP != "127.0.0.1" {
+		t.Errorf("invalid envelope in dns message: %s %s %s", dm.DNSTap.Identity, dm.DNSTap.Operation, dm.NetworkInfo.QueryIP)
+	}
+	dt := &dnstap.Dnstap{}
+	if err := proto.Unmarshal(dm.DNSTap.Payload, dt); err != nil || string(dt.GetIdentity()) != "relay1" {
+		t.Errorf("identity not rewritten in the payload")
+	}
+
+	// the resolver query is dropped
+	dm = <-dropped.GetInputChannel()
+	if dm.DNSTap.Operation != "RESOLVER_QUERY" {
+		t.Errorf("invalid dropped message: %s", dm.DNSTap.Operation)
+	}
+	if len(g.GetInputChannel()) != 0 {
+		t.Errorf("the resolver query must not be relayed")
+	}
+}
+
+func Test_DnstapRelay_HandleFrameInvalid(t *testing.T) {
+	invalid := []byte{0x0a, 0xff}
+
+	testcases := []struct {
+		name      string
+		keepTypes []string
+		identity  string
+		relayed   bool
+	}{
+		// frames relayed byte for byte without decoding
+		{name: "passthrough", relayed: true},
+		// the identity can't be rewritten, the frame is relayed untouched
+		{name: "identity", identity: "relay1", relayed: true},
+		// the message type is required
+		{name: "keep_types", keepTypes: []string{"CLIENT_*"}, relayed: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+			dropped := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+
+			config := pkgconfig.GetDefaultConfig()
+			config.Collectors.DnstapProxifier.KeepMessageTypes = tc.keepTypes
+			config.Collectors.DnstapProxifier.Identity = tc.identity
+			c := NewDnstapProxifier([]Worker{g}, config, logger.New(false), "test")
+			c.AddDroppedRoute(dropped)
+
+			recvChan := make(chan []byte, 1)
+			go c.HandleFrame(recvChan)
+			recvChan <- invalid
+			close(recvChan)
+
+			var dm dnsutils.DNSMessage
+			select {
+			case dm = <-g.GetInputChannel():
+				if !tc.relayed {
+					t.Fatalf("the frame must be dropped")
+				}
+			case dm = <-dropped.GetInputChannel():
+				if tc.relayed {
+					t.Fatalf("the frame must be relayed")
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("no frame received")
+			}
+			if !bytes.Equal(dm.DNSTap.Payload, invalid) {
+				t.Errorf("the frame must be untouched: %x", dm.DNSTap.Payload)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package workers
 import (
 	"bufio"
 	"crypto/tls"
+	"fmt"
 	"net"
 	"strconv"
 	"time"
@@ -18,11 +19,10 @@ import (
 
 type DnstapSender struct {
 	*GenericWorker
-	fs                                 *framestream.Fstrm
-	fsReady                            bool
-	transport                          string
-	transportConn                      net.Conn
-	transportReady, transportReconnect chan bool
+	transport      string
+	endpoints      *DnstapEndpoints
+	transportReady chan dnstapConnection
+	stopConnect    chan bool
 }
 
 func NewDnstapSender(config *pkgconfig.Config, logger *logger.Logger, name string) *DnstapSender {
@@ -31,9 +31,10 @@ func NewDnstapSender(config *pkgconfig.Config, logger *logger.Logger, name strin
 		bufSize = config.Loggers.DNSTap.ChannelBufferSize
 	}
 	w := &DnstapSender{GenericWorker: NewGenericWorker(config, logger, name, "dnstap", bufSize, pkgconfig.DefaultMonitor)}
-	w.transportReady = make(chan bool)
-	w.transportReconnect = make(chan bool)
+	w.transportReady = make(chan dnstapConnection)
+	w.stopConnect = make(chan bool)
 	w.ReadConfig()
+	w.endpoints = NewDnstapEndpoints(w.GetAddresses(), w.GetConfig().Loggers.DNSTap.LoadBalancing, w.GetConfig().Loggers.DNSTap.HashKey)
 	return w
 }
 
@@ -57,96 +58,170 @@ func (w *DnstapSender) ReadConfig() {
 	if !netutils.IsValidTLS(w.GetConfig().Loggers.DNSTap.TLSMinVersion) {
 		w.LogFatal(pkgconfig.PrefixLogWorker + "invalid tls min version")
 	}
-}
 
-func (w *DnstapSender) Disconnect() {
-	if w.transportConn != nil {
-		// reset framestream and ignore errors
-		w.LogInfo("closing framestream")
-		w.fs.ResetSender()
-
-		// closing tcp
-		w.LogInfo("closing tcp connection")
-		w.transportConn.Close()
-		w.LogInfo("closed")
+	switch w.GetConfig().Loggers.DNSTap.LoadBalancing {
+	case pkgconfig.LoadBalancingFailover, pkgconfig.LoadBalancingRoundRobin, pkgconfig.LoadBalancingConsistentHash:
+	default:
+		w.LogFatal(pkgconfig.PrefixLogWorker+"invalid load balancing: ", w.GetConfig().Loggers.DNSTap.LoadBalancing)
+	}
+
+	switch w.GetConfig().Loggers.DNSTap.HashKey {
+	case pkgconfig.HashKeyIdentity, pkgconfig.HashKeyClientIP:
+	default:
+		w.LogFatal(pkgconfig.PrefixLogWorker+"invalid hash key: ", w.GetConfig().Loggers.DNSTap.HashKey)
 	}
 }
 
-func (w *DnstapSender) ConnectToRemote() {
-	for {
-		if w.transportConn != nil {
-			w.transportConn.Close()
-			w.transportConn = nil
+// GetAddresses returns the addresses of the endpoints, the remote address and port
+// or the socket path are used when no endpoints are provided
+func (w *DnstapSender) GetAddresses() []string {
+	cfg := w.GetConfig().Loggers.DNSTap
+	if len(cfg.Endpoints) > 0 {
+		return cfg.Endpoints
+	}
+	if w.transport == netutils.SocketUnix {
+		if len(cfg.SockPath) > 0 {
+			return []string{cfg.SockPath}
 		}
+		return []string{cfg.RemoteAddress}
+	}
+	return []string{net.JoinHostPort(cfg.RemoteAddress, strconv.Itoa(cfg.RemotePort))}
+}
 
-		address := net.JoinHostPort(
-			w.GetConfig().Loggers.DNSTap.RemoteAddress,
-			strconv.Itoa(w.GetConfig().Loggers.DNSTap.RemotePort),
-		)
-		connTimeout := time.Duration(w.GetConfig().Loggers.DNSTap.ConnectTimeout) * time.Second
-
-		// make the connection
-		var conn net.Conn
-		var err error
-
-		switch w.transport {
-		case netutils.SocketUnix:
-			address = w.GetConfig().Loggers.DNSTap.RemoteAddress
-			if len(w.GetConfig().Loggers.DNSTap.SockPath) > 0 {
-				address = w.GetConfig().Loggers.DNSTap.SockPath
+func (w *DnstapSender) Disconnect() {
+	close(w.stopConnect)
+	for _, ep := range w.endpoints.List {
+		if ep.conn != nil {
+			if ep.up {
+				// reset framestream and ignore errors
+				w.LogInfo("closing framestream with %s", ep.Address)
+				ep.fs.ResetSender()
 			}
-			w.LogInfo("connecting to %s://%s", w.transport, address)
-			conn, err = net.DialTimeout(w.transport, address, connTimeout)
 
-		case netutils.SocketTCP:
-			w.LogInfo("connecting to %s://%s", w.transport, address)
-			conn, err = net.DialTimeout(w.transport, address, connTimeout)
+			// closing tcp
+			w.LogInfo("closing connection with %s", ep.Address)
+			ep.conn.Close()
+			w.LogInfo("closed")
+		}
+	}
+}
+
+func (w *DnstapSender) Dial(address string) (net.Conn, error) {
+	connTimeout := time.Duration(w.GetConfig().Loggers.DNSTap.ConnectTimeout) * time.Second
+	w.LogInfo("connecting to %s://%s", w.transport, address)
 
-		case netutils.SocketTLS:
-			w.LogInfo("connecting to %s://%s", w.transport, address)
+	switch w.transport {
+	case netutils.SocketUnix, netutils.SocketTCP:
+		return net.DialTimeout(w.transport, address, connTimeout)
 
-			var tlsConfig *tls.Config
+	case netutils.SocketTLS:
+		tlsOptions := netutils.TLSOptions{
+			InsecureSkipVerify: w.GetConfig().Loggers.DNSTap.TLSInsecure, MinVersion: w.GetConfig().Loggers.DNSTap.TLSMinVersion,
+			CAFile: w.GetConfig().Loggers.DNSTap.CAFile, CertFile: w.GetConfig().Loggers.DNSTap.CertFile, KeyFile: w.GetConfig().Loggers.DNSTap.KeyFile,
+		}
 
-			tlsOptions := netutils.TLSOptions{
-				InsecureSkipVerify: w.GetConfig().Loggers.DNSTap.TLSInsecure, MinVersion: w.GetConfig().Loggers.DNSTap.TLSMinVersion,
-				CAFile: w.GetConfig().Loggers.DNSTap.CAFile, CertFile: w.GetConfig().Loggers.DNSTap.CertFile, KeyFile: w.GetConfig().Loggers.DNSTap.KeyFile,
-			}
+		tlsConfig, err := netutils.TLSClientConfig(tlsOptions)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: connTimeout}
+		return tls.DialWithDialer(dialer, netutils.SocketTCP, address, tlsConfig)
+	default:
+		w.LogFatal("invalid transport:", w.transport)
+	}
+	return nil, nil
+}
 
-			tlsConfig, err = netutils.TLSClientConfig(tlsOptions)
-			if err == nil {
-				dialer := &net.Dialer{Timeout: connTimeout}
-				conn, err = tls.DialWithDialer(dialer, netutils.SocketTCP, address, tlsConfig)
+// ConnectToRemote connects the endpoint, and reconnects it when the logging
+// goroutine detects an error or a failed health check
+func (w *DnstapSender) ConnectToRemote(ep *DnstapEndpoint) {
+	retryInterval := time.Duration(w.GetConfig().Loggers.DNSTap.RetryInterval) * time.Second
+	for {
+		conn, err := w.Dial(ep.Address)
+
+		// init framestream protocol, without blocking the other endpoints
+		var fs *framestream.Fstrm
+		var reader *bufio.Reader
+		if err == nil {
+			reader = bufio.NewReader(conn)
+			fs = framestream.NewFstrm(reader, bufio.NewWriter(conn), conn, 5*time.Second, []byte("protobuf:dnstap.Dnstap"), true)
+			if err = fs.InitSender(); err != nil {
+				conn.Close()
+				err = fmt.Errorf("sender protocol initialization error %w", err)
 			}
-		default:
-			w.LogFatal("invalid transport:", w.transport)
 		}
 
 		// something is wrong during connection ?
 		if err != nil {
-			w.LogError("%s", err)
-			w.LogInfo("retry to connect in %d seconds", w.GetConfig().Loggers.DNSTap.RetryInterval)
-			time.Sleep(time.Duration(w.GetConfig().Loggers.DNSTap.RetryInterval) * time.Second)
-			continue
+			w.LogError("%s: %s", ep.Address, err)
+			w.LogInfo("retry to connect to %s in %d seconds", ep.Address, w.GetConfig().Loggers.DNSTap.RetryInterval)
+			select {
+			case <-w.stopConnect:
+				return
+			case <-time.After(retryInterval):
+				continue
+			}
 		}
-
-		w.transportConn = conn
-
 		// block until framestream is ready
-		w.transportReady <- true
+		select {
+		case w.transportReady <- dnstapConnection{endpoint: ep, conn: conn, reader: reader, fs: fs}:
+		case <-w.stopConnect:
+			conn.Close()
+			return
+		}
 
 		// block until an error occurred, need to reconnect
-		w.transportReconnect <- true
+		select {
+		case <-ep.reconnect:
+		case <-w.stopConnect:
+			return
+		}
 	}
 }
 
-func (w *DnstapSender) FlushBuffer(buf *[]dnsutils.DNSMessage) {
+// SetEndpointDown closes the connection of the endpoint, the connector is notified
+// to reconnect and the messages not sent are dispatched to the other endpoints
+func (w *DnstapSender) SetEndpointDown(ep *DnstapEndpoint, unsent []dnsutils.DNSMessage) {
+	ep.up = false
+	ep.totalErrors++
+	ep.conn.Close()
+	ep.buffer = nil
+	ep.reconnect <- true
+
+	for _, dm := range unsent {
+		w.Dispatch(dm)
+	}
+}
+
+// Dispatch appends the message to the buffer of the endpoint selected,
+// the message is dropped if all the endpoints are down
+func (w *DnstapSender) Dispatch(dm dnsutils.DNSMessage) {
+	ep := w.endpoints.Pick(&dm)
+	if ep == nil {
+		return
+	}
+
+	// append dns message to buffer
+	ep.buffer = append(ep.buffer, dm)
+
+	// buffer is full ?
+	if len(ep.buffer) >= w.GetConfig().Loggers.DNSTap.BufferSize {
+		w.FlushBuffer(ep)
+	}
+}
+
+func (w *DnstapSender) FlushBuffer(ep *DnstapEndpoint) {
 
 	var data []byte
 	var err error
 	bulkFrame := &framestream.Frame{}
 	subFrame := &framestream.Frame{}
+	buf := ep.buffer
+
+	// reset buffer
+	ep.buffer = nil
 
-	for _, dm := range *buf {
+	for i, dm := range buf {
 		// update identity ?
 		if w.GetConfig().Loggers.DNSTap.OverwriteIdentity {
 			dm.DNSTap.Identity = w.GetConfig().Loggers.DNSTap.ServerID
@@ -162,12 +237,12 @@ func (w *DnstapSender) FlushBuffer(buf *[]dnsutils.DNSMessage) {
 		if w.GetConfig().Loggers.DNSTap.Compression == pkgconfig.CompressNone {
 			// send the frame
 			bulkFrame.Write(data)
-			if err := w.fs.SendFrame(bulkFrame); err != nil {
-				w.LogError("send frame error %s", err)
-				w.fsReady = false
-				<-w.transportReconnect
-				break
+			if err := ep.fs.SendFrame(bulkFrame); err != nil {
+				w.LogError("%s: send frame error %s", ep.Address, err)
+				w.SetEndpointDown(ep, buf[i:])
+				return
 			}
+			ep.totalSent++
 		} else {
 			subFrame.Write(data)
 			bulkFrame.AppendData(subFrame.Data())
@@ -176,15 +251,13 @@ func (w *DnstapSender) FlushBuffer(buf *[]dnsutils.DNSMessage) {
 
 	if w.GetConfig().Loggers.DNSTap.Compression != pkgconfig.CompressNone {
 		bulkFrame.Encode()
-		if err := w.fs.SendCompressedFrame(&compress.GzipCodec, bulkFrame); err != nil {
-			w.LogError("send bulk frame error %s", err)
-			w.fsReady = false
-			<-w.transportReconnect
+		if err := ep.fs.SendCompressedFrame(&compress.GzipCodec, bulkFrame); err != nil {
+			w.LogError("%s: send bulk frame error %s", ep.Address, err)
+			w.SetEndpointDown(ep, buf)
+			return
 		}
+		ep.totalSent += len(buf)
 	}
-
-	// reset buffer
-	*buf = nil
 }
 
 func (w *DnstapSender) StartCollect() {
@@ -201,12 +274,13 @@ func (w *DnstapSender) StartCollect() {
 	// goroutine to process transformed dns messages
 	go w.StartLogging()
 
-	// init remote conn
-	go w.ConnectToRemote()
-
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -250,69 +324,90 @@ func (w *DnstapSender) StartLogging() {
 	w.LogInfo("logging has started")
 	defer w.LoggingDone()
 
-	// init buffer
-	bufferDm := []dnsutils.DNSMessage{}
+	// init remote conns
+	for _, ep := range w.endpoints.List {
+		go w.ConnectToRemote(ep)
+	}
 
 	// init flush timer for buffer
 	flushInterval := time.Duration(w.GetConfig().Loggers.DNSTap.FlushInterval) * time.Second
 	flushTimer := time.NewTimer(flushInterval)
 
+	// init health check of the connected endpoints
+	healthInterval := time.Duration(w.GetConfig().Loggers.DNSTap.HealthCheckInterval) * time.Second
+	var healthCheck <-chan time.Time
+	if healthInterval > 0 {
+		healthTicker := time.NewTicker(healthInterval)
+		defer healthTicker.Stop()
+		healthCheck = healthTicker.C
+	}
+
+	// init the export of the endpoints metrics
+	monitorTicker := time.NewTicker(time.Duration(w.GetConfig().Global.Worker.InternalMonitor) * time.Second)
+	defer monitorTicker.Stop()
+
 	w.LogInfo("ready to process")
 	for {
 		select {
 		case <-w.OnLoggerStopped():
-			// closing remote connection if exist
+			// closing remote connections if exist
 			w.Disconnect()
 			return
 
-		// init framestream
-		case <-w.transportReady:
-			w.LogInfo("transport connected with success")
-			// frame stream library
-			fsReader := bufio.NewReader(w.transportConn)
-			fsWriter := bufio.NewWriter(w.transportConn)
-			w.fs = framestream.NewFstrm(fsReader, fsWriter, w.transportConn, 5*time.Second, []byte("protobuf:dnstap.Dnstap"), true)
-
-			// init framestream protocol
-			if err := w.fs.InitSender(); err != nil {
-				w.LogError("sender protocol initialization error %s", err)
-				w.fsReady = false
-				w.transportConn.Close()
-				<-w.transportReconnect
-			} else {
-				w.fsReady = true
-				w.LogInfo("framestream initialized with success")
-			}
-			// incoming dns message to process
+		// endpoint connected
+		case ready := <-w.transportReady:
+			ep := ready.endpoint
+			ep.conn = ready.conn
+			ep.reader = ready.reader
+			ep.fs = ready.fs
+			ep.up = true
+			ep.totalConns++
+			w.LogInfo("framestream initialized with success with %s", ep.Address)
+
+		// incoming dns message to process
 		case dm, opened := <-w.GetOutputChannel():
 			if !opened {
 				w.LogInfo("output channel closed!")
 				return
 			}
 
-			// drop dns message if the connection is not ready to avoid memory leak or
+			// the message is dropped if no endpoint is ready to avoid memory leak or
 			// to block the channel
-			if !w.fsReady {
-				continue
-			}
+			w.Dispatch(dm)
 
-			// append dns message to buffer
-			bufferDm = append(bufferDm, dm)
-
-			// buffer is full ?
-			if len(bufferDm) >= w.GetConfig().Loggers.DNSTap.BufferSize {
-				w.FlushBuffer(&bufferDm)
-			}
-
-		// flush the buffer
+		// flush the buffers
 		case <-flushTimer.C:
-			// force to flush the buffer
-			if len(bufferDm) > 0 {
-				w.FlushBuffer(&bufferDm)
+			// force to flush the buffers
+			for _, ep := range w.endpoints.List {
+				if ep.up && len(ep.buffer) > 0 {
+					w.FlushBuffer(ep)
+				}
 			}
 
 			// restart timer
 			flushTimer.Reset(flushInterval)
+
+		// check the connected endpoints
+		case <-healthCheck:
+			for _, ep := range w.endpoints.List {
+				if ep.up && !ep.IsAlive() {
+					w.LogError("%s: health check failed, connection closed by peer", ep.Address)
+					w.SetEndpointDown(ep, ep.buffer)
+				}
+			}
+
+		// send the endpoints metrics to telemetry
+		case <-monitorTicker.C:
+			if w.GetConfig().Global.Telemetry.Enabled && w.metrics != nil {
+				// the stats are cumulative, they are sent again on the next tick
+				// if the telemetry is busy, to not block the logging
+				for _, ep := range w.endpoints.List {
+					select {
+					case w.metrics.RecordEndpoint <- ep.Stats(w.GetName()):
+					default:
+					}
+				}
+			}
 		}
 	}
 }
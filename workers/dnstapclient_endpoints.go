@@ -0,0 +1,161 @@
+package workers
+
+import (
+	"bufio"
+	"errors"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-dnscollector/telemetry"
+	"github.com/dmachard/go-framestream"
+)
+
+// number of points of each endpoint on the hash ring
+const dnstapHashRingReplicas = 100
+
+// DnstapEndpoint is a remote dnstap receiver, the connection and the buffer
+// are owned by the logging goroutine
+type DnstapEndpoint struct {
+	Address   string
+	conn      net.Conn
+	reader    *bufio.Reader
+	fs        *framestream.Fstrm
+	up        bool
+	buffer    []dnsutils.DNSMessage
+	reconnect chan bool
+
+	totalSent, totalErrors, totalConns int
+}
+
+func NewDnstapEndpoint(address string) *DnstapEndpoint {
+	return &DnstapEndpoint{Address: address, reconnect: make(chan bool, 1)}
+}
+
+func (ep *DnstapEndpoint) IsUp() bool {
+	return ep.up
+}
+
+// IsAlive checks that the peer has not closed the connection, the read
+// returns immediately with a timeout error if the connection is still open.
+// The probe is done with the reader of the framestream, the bytes received
+// are kept in the buffer for the framestream, like the finish control frame
+func (ep *DnstapEndpoint) IsAlive() bool {
+	if ep.conn == nil || ep.reader == nil {
+		return false
+	}
+	ep.conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+	defer ep.conn.SetReadDeadline(time.Time{})
+
+	_, err := ep.reader.Peek(1)
+	var netErr net.Error
+	if err == nil || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return true
+	}
+	return false
+}
+
+func (ep *DnstapEndpoint) Stats(worker string) telemetry.EndpointStats {
+	return telemetry.EndpointStats{
+		Worker:      worker,
+		Endpoint:    ep.Address,
+		Up:          ep.up,
+		TotalSent:   ep.totalSent,
+		TotalErrors: ep.totalErrors,
+		TotalConns:  ep.totalConns,
+	}
+}
+
+// dnstapConnection is a new connection of an endpoint with the framestream initialized,
+// sent to the logging goroutine
+type dnstapConnection struct {
+	endpoint *DnstapEndpoint
+	conn     net.Conn
+	reader   *bufio.Reader
+	fs       *framestream.Fstrm
+}
+
+type dnstapRingPoint struct {
+	hash  uint32
+	index int
+}
+
+// DnstapEndpoints selects the endpoint of each message among the healthy ones
+type DnstapEndpoints struct {
+	List    []*DnstapEndpoint
+	mode    string
+	hashKey string
+	next    int
+	ring    []dnstapRingPoint
+}
+
+func NewDnstapEndpoints(addresses []string, mode, hashKey string) *DnstapEndpoints {
+	p := &DnstapEndpoints{mode: mode, hashKey: hashKey}
+	for i, address := range addresses {
+		p.List = append(p.List, NewDnstapEndpoint(address))
+
+		// place the endpoint on the hash ring
+		for r := 0; r < dnstapHashRingReplicas; r++ {
+			p.ring = append(p.ring, dnstapRingPoint{hash: dnstapHash(address + "#" + strconv.Itoa(r)), index: i})
+		}
+	}
+	sort.Slice(p.ring, func(i, j int) bool { return p.ring[i].hash < p.ring[j].hash })
+	return p
+}
+
+// dnstapHash is fnv-1a with the murmur3 finalizer, to spread the similar keys on the ring
+func dnstapHash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	v := h.Sum32()
+	v ^= v >> 16
+	v *= 0x85ebca6b
+	v ^= v >> 13
+	v *= 0xc2b2ae35
+	v ^= v >> 16
+	return v
+}
+
+// Pick returns the endpoint of the message, nil if all the endpoints are down
+func (p *DnstapEndpoints) Pick(dm *dnsutils.DNSMessage) *DnstapEndpoint {
+	n := len(p.List)
+	switch p.mode {
+	case pkgconfig.LoadBalancingRoundRobin:
+		for i := 0; i < n; i++ {
+			ep := p.List[(p.next+i)%n]
+			if ep.up {
+				p.next = (p.next + i + 1) % n
+				return ep
+			}
+		}
+
+	case pkgconfig.LoadBalancingConsistentHash:
+		key := dm.DNSTap.Identity
+		if p.hashKey == pkgconfig.HashKeyClientIP {
+			key = dm.NetworkInfo.QueryIP
+		}
+
+		// first healthy endpoint clockwise on the ring
+		h := dnstapHash(key)
+		start := sort.Search(len(p.ring), func(i int) bool { return p.ring[i].hash >= h })
+		for i := 0; i < len(p.ring); i++ {
+			ep := p.List[p.ring[(start+i)%len(p.ring)].index]
+			if ep.up {
+				return ep
+			}
+		}
+
+	default:
+		// failover, the endpoints are ordered by priority
+		for _, ep := range p.List {
+			if ep.up {
+				return ep
+			}
+		}
+	}
+	return nil
+}
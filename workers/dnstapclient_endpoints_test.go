@@ -0,0 +1,144 @@
+package workers
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+)
+
+func newTestDnstapEndpoints(mode, hashKey string) *DnstapEndpoints {
+	p := NewDnstapEndpoints([]string{"10.0.0.1:6000", "10.0.0.2:6000", "10.0.0.3:6000"}, mode, hashKey)
+	for _, ep := range p.List {
+		ep.up = true
+	}
+	return p
+}
+
+func TestDnstapEndpoints_Failover(t *testing.T) {
+	p := newTestDnstapEndpoints(pkgconfig.LoadBalancingFailover, pkgconfig.HashKeyIdentity)
+	dm := dnsutils.GetFakeDNSMessage()
+
+	if ep := p.Pick(&dm); ep != p.List[0] {
+		t.Errorf("first endpoint expected")
+	}
+
+	p.List[0].up = false
+	if ep := p.Pick(&dm); ep != p.List[1] {
+		t.Errorf("second endpoint expected")
+	}
+
+	for _, ep := range p.List {
+		ep.up = false
+	}
+	if ep := p.Pick(&dm); ep != nil {
+		t.Errorf("no endpoint expected")
+	}
+}
+
+func TestDnstapEndpoints_RoundRobin(t *testing.T) {
+	p := newTestDnstapEndpoints(pkgconfig.LoadBalancingRoundRobin, pkgconfig.HashKeyIdentity)
+	dm := dnsutils.GetFakeDNSMessage()
+
+	for _, expected := range []int{0, 1, 2, 0} {
+		if ep := p.Pick(&dm); ep != p.List[expected] {
+			t.Errorf("endpoint %d expected, got %s", expected, ep.Address)
+		}
+	}
+
+	// the endpoint down is skipped
+	p.List[2].up = false
+	for _, expected := range []int{1, 0, 1} {
+		if ep := p.Pick(&dm); ep != p.List[expected] {
+			t.Errorf("endpoint %d expected, got %s", expected, ep.Address)
+		}
+	}
+}
+
+func TestDnstapEndpoints_ConsistentHash(t *testing.T) {
+	p := newTestDnstapEndpoints(pkgconfig.LoadBalancingConsistentHash, pkgconfig.HashKeyIdentity)
+
+	// distribution of the identities
+	before := make(map[string]*DnstapEndpoint)
+	count := make(map[*DnstapEndpoint]int)
+	for i := 0; i < 300; i++ {
+		dm := dnsutils.GetFakeDNSMessage()
+		dm.DNSTap.Identity = "resolver" + strconv.Itoa(i)
+		ep := p.Pick(&dm)
+		before[dm.DNSTap.Identity] = ep
+		count[ep]++
+
+		// same key, same endpoint
+		if p.Pick(&dm) != ep {
+			t.Errorf("the endpoint of %s must be stable", dm.DNSTap.Identity)
+		}
+	}
+	for _, ep := range p.List {
+		if count[ep] < 50 {
+			t.Errorf("unbalanced distribution for %s: %d", ep.Address, count[ep])
+		}
+	}
+
+	// only the identities of the endpoint down are moved
+	p.List[1].up = false
+	for identity, epBefore := range before {
+		dm := dnsutils.GetFakeDNSMessage()
+		dm.DNSTap.Identity = identity
+		ep := p.Pick(&dm)
+		if epBefore != p.List[1] && ep != epBefore {
+			t.Errorf("%s must not be moved", identity)
+		}
+		if ep == p.List[1] {
+			t.Errorf("%s must be moved", identity)
+		}
+	}
+}
+
+func TestDnstapEndpoints_HashClientIP(t *testing.T) {
+	p := newTestDnstapEndpoints(pkgconfig.LoadBalancingConsistentHash, pkgconfig.HashKeyClientIP)
+
+	// the identity is ignored
+	dm := dnsutils.GetFakeDNSMessage()
+	ep := p.Pick(&dm)
+	for i := 0; i < 10; i++ {
+		dm.DNSTap.Identity = "resolver" + strconv.Itoa(i)
+		if p.Pick(&dm) != ep {
+			t.Errorf("the endpoint must depend on the client ip only")
+		}
+	}
+}
+
+func TestDnstapEndpoint_IsAlive(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	ep := NewDnstapEndpoint("10.0.0.1:6000")
+	ep.conn = client
+	ep.reader = bufio.NewReader(client)
+
+	// no data received, the connection is open
+	if !ep.IsAlive() {
+		t.Errorf("endpoint alive expected")
+	}
+
+	// the data received are kept for the framestream
+	go server.Write([]byte{0x01})
+	for i := 0; i < 100 && ep.reader.Buffered() == 0; i++ {
+		ep.IsAlive()
+	}
+	if !ep.IsAlive() {
+		t.Errorf("endpoint alive expected")
+	}
+	if b, err := ep.reader.ReadByte(); err != nil || b != 0x01 {
+		t.Errorf("the byte received must be kept in the reader: %v %v", b, err)
+	}
+
+	// closed by peer
+	server.Close()
+	if ep.IsAlive() {
+		t.Errorf("endpoint not alive expected")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/dmachard/go-dnstap-protobuf"
 	"github.com/dmachard/go-framestream"
 	"github.com/dmachard/go-logger"
+	"github.com/dmachard/go-netutils"
 	"github.com/segmentio/kafka-go/compress"
 	"google.golang.org/protobuf/proto"
 )
@@ -133,3 +134,115 @@ func Test_DnstapClient(t *testing.T) {
 		})
 	}
 }
+
+// acceptDnstapReceiver accepts the connection of the logger and inits the framestream
+func acceptDnstapReceiver(t *testing.T, listener net.Listener) (net.Conn, *framestream.Fstrm) {
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsSvr := framestream.NewFstrm(bufio.NewReader(conn), bufio.NewWriter(conn), conn, 5*time.Second, []byte("protobuf:dnstap.Dnstap"), true)
+	if err := fsSvr.InitReceiver(); err != nil {
+		t.Fatalf("error to init framestream receiver: %s", err)
+	}
+	return conn, fsSvr
+}
+
+func recvDnstapIdentity(t *testing.T, fsSvr *framestream.Fstrm) string {
+	fs, err := fsSvr.RecvFrame(true)
+	if err != nil {
+		t.Fatalf("error to receive frame: %s", err)
+	}
+	dt := &dnstap.Dnstap{}
+	if err := proto.Unmarshal(fs.Data(), dt); err != nil {
+		t.Fatalf("error to decode dnstap")
+	}
+	return string(dt.GetIdentity())
+}
+
+func Test_DnstapClient_Failover(t *testing.T) {
+	// fake dnstap receivers
+	rcvr1, err := net.Listen(netutils.SocketTCP, "127.0.0.1:16001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rcvr1.Close()
+	rcvr2, err := net.Listen(netutils.SocketTCP, "127.0.0.1:16002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rcvr2.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.DNSTap.Endpoints = []string{"127.0.0.1:16001", "127.0.0.1:16002"}
+	cfg.Loggers.DNSTap.BufferSize = 0
+	cfg.Loggers.DNSTap.HealthCheckInterval = 1
+	g := NewDnstapSender(cfg, logger.New(false), "test")
+	go g.StartCollect()
+	defer g.Stop()
+
+	conn1, fs1 := acceptDnstapReceiver(t, rcvr1)
+	conn2, fs2 := acceptDnstapReceiver(t, rcvr2)
+	defer conn2.Close()
+	time.Sleep(500 * time.Millisecond)
+
+	// the first endpoint is used
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNSTap.Identity = "before"
+	g.GetInputChannel() <- dm
+	if identity := recvDnstapIdentity(t, fs1); identity != "before" {
+		t.Errorf("invalid identity: %s", identity)
+	}
+
+	// the first endpoint is down, detected by the health check
+	conn1.Close()
+	rcvr1.Close()
+	time.Sleep(1500 * time.Millisecond)
+
+	dm.DNSTap.Identity = "after"
+	g.GetInputChannel() <- dm
+	if identity := recvDnstapIdentity(t, fs2); identity != "after" {
+		t.Errorf("invalid identity: %s", identity)
+	}
+}
+
+func Test_DnstapClient_RoundRobin(t *testing.T) {
+	// fake dnstap receivers
+	rcvr1, err := net.Listen(netutils.SocketTCP, "127.0.0.1:16003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rcvr1.Close()
+	rcvr2, err := net.Listen(netutils.SocketTCP, "127.0.0.1:16004")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rcvr2.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.DNSTap.Endpoints = []string{"127.0.0.1:16003", "127.0.0.1:16004"}
+	cfg.Loggers.DNSTap.LoadBalancing = pkgconfig.LoadBalancingRoundRobin
+	cfg.Loggers.DNSTap.BufferSize = 0
+	g := NewDnstapSender(cfg, logger.New(false), "test")
+	go g.StartCollect()
+	defer g.Stop()
+
+	conn1, fs1 := acceptDnstapReceiver(t, rcvr1)
+	defer conn1.Close()
+	conn2, fs2 := acceptDnstapReceiver(t, rcvr2)
+	defer conn2.Close()
+	time.Sleep(500 * time.Millisecond)
+
+	for _, identity := range []string{"first", "second"} {
+		dm := dnsutils.GetFakeDNSMessage()
+		dm.DNSTap.Identity = identity
+		g.GetInputChannel() <- dm
+	}
+
+	if identity := recvDnstapIdentity(t, fs1); identity != "first" {
+		t.Errorf("invalid identity on first endpoint: %s", identity)
+	}
+	if identity := recvDnstapIdentity(t, fs2); identity != "second" {
+		t.Errorf("invalid identity on second endpoint: %s", identity)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path"
 	"time"
@@ -30,6 +31,7 @@ func NewElasticSearchClient(config *pkgconfig.Config, console *logger.Logger, na
 	w := &ElasticSearchClient{GenericWorker: NewGenericWorker(config, console, name, "elasticsearch", bufSize, pkgconfig.DefaultMonitor)}
 	w.ReadConfig()
 	w.httpClient = &http.Client{Timeout: 5 * time.Second}
+	w.InitSpool(config.Loggers.ElasticSearchClient.Spool)
 	return w
 }
 
@@ -73,6 +75,10 @@ func (w *ElasticSearchClient) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -125,16 +131,22 @@ func (w *ElasticSearchClient) StartLogging() {
 	dataBuffer := make(chan []byte, w.GetConfig().Loggers.ElasticSearchClient.BulkChannelSize)
 	go func() {
 		for data := range dataBuffer {
-			var err error
-			if w.GetConfig().Loggers.ElasticSearchClient.Compression == pkgconfig.CompressGzip {
-				err = w.sendCompressedBulk(data)
-			} else {
-				err = w.sendBulk(data)
+			// send the bulks spooled before to keep the order,
+			// the new one waits in the spool if the remote is still unavailable
+			if err := w.ReplaySpool(w.replay); err != nil {
+				w.LogError("spool - replay interrupted: %v", err)
+				w.SpoolData(data)
+				continue
 			}
-			if err != nil {
+
+			if err := w.send(data); err != nil {
 				w.LogError("error sending bulk data: %v", err)
+				if !errors.Is(err, ErrElasticRejected) {
+					w.SpoolData(data)
+				}
 			}
 		}
+		w.CloseSpool()
 	}()
 
 	for {
@@ -167,7 +179,9 @@ func (w *ElasticSearchClient) StartLogging() {
 				select {
 				case dataBuffer <- bufCopy:
 				default:
-					w.LogWarning("Send buffer is full, bulk dropped")
+					if !w.SpoolData(bufCopy) {
+						w.LogWarning("Send buffer is full, bulk dropped")
+					}
 				}
 			}
 
@@ -183,7 +197,9 @@ func (w *ElasticSearchClient) StartLogging() {
 				select {
 				case dataBuffer <- bufCopy:
 				default:
-					w.LogWarning("automatic flush, send buffer is full, bulk dropped")
+					if !w.SpoolData(bufCopy) {
+						w.LogWarning("automatic flush, send buffer is full, bulk dropped")
+					}
 				}
 			}
 
@@ -193,6 +209,26 @@ func (w *ElasticSearchClient) StartLogging() {
 	}
 }
 
+// ErrElasticRejected is returned when the bulk is invalid, sending it again would fail
+var ErrElasticRejected = errors.New("bulk rejected")
+
+// replay sends a spooled bulk, the rejected ones are dropped so they don't block the spool
+func (w *ElasticSearchClient) replay(bulk []byte) error {
+	err := w.send(bulk)
+	if errors.Is(err, ErrElasticRejected) {
+		w.LogError("spool - bulk dropped: %v", err)
+		return nil
+	}
+	return err
+}
+
+func (w *ElasticSearchClient) send(bulk []byte) error {
+	if w.GetConfig().Loggers.ElasticSearchClient.Compression == pkgconfig.CompressGzip {
+		return w.sendCompressedBulk(bulk)
+	}
+	return w.sendBulk(bulk)
+}
+
 func (w *ElasticSearchClient) sendBulk(bulk []byte) error {
 	return w.sendBulkInternal(bytes.NewReader(bulk), false)
 }
@@ -230,6 +266,9 @@ func (w *ElasticSearchClient) sendBulkInternal(bodyReader *bytes.Reader, compres
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("%w: unexpected status code: %d", ErrElasticRejected, resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -227,3 +227,41 @@ func Test_ElasticSearchClient_sendBulk_WithBasicAuth(t *testing.T) {
 	err := client.sendBulk([]byte("test payload"))
 	assert.NoError(t, err, "Unexpected error when sending request with Basic Auth")
 }
+
+func Test_ElasticSearchClient_SpoolOrder(t *testing.T) {
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+		if strings.HasPrefix(string(body), "rejected") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := pkgconfig.GetDefaultConfig()
+	config.Loggers.ElasticSearchClient.Server = server.URL
+	config.Loggers.ElasticSearchClient.BulkSize = 1
+	config.Loggers.ElasticSearchClient.Spool = getSpoolConfigForTest(t)
+
+	client := NewElasticSearchClient(config, logger.New(false), "test-client")
+	client.SpoolData([]byte("spooled"))
+	client.SpoolData([]byte("rejected"))
+	go client.StartCollect()
+	defer client.Stop()
+
+	client.GetInputChannel() <- dnsutils.GetFakeDNSMessage()
+
+	// the spooled bulks are sent first, the rejected one is not retried
+	for _, expected := range []string{"spooled", "rejected", "{ \"create\" : {}}"} {
+		select {
+		case body := <-received:
+			assert.True(t, strings.HasPrefix(body, expected), "want %s, got %s", expected, body)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("bulk %s not received", expected)
+		}
+	}
+	assert.False(t, client.SpoolPending())
+}
@@ -41,6 +41,10 @@ func (w *FalcoClient) StartCollect() {
 
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
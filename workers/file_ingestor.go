@@ -1,17 +1,21 @@
 package workers
 
 import (
+	"bufio"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-dnscollector/transformers"
 	"github.com/dmachard/go-logger"
 	"github.com/dmachard/go-netutils"
 	framestream "github.com/farsightsec/golang-framestream"
@@ -21,13 +25,20 @@ import (
 	"github.com/google/gopacket/pcapgo"
 )
 
-var waitFor = 10 * time.Second
+var (
+	waitFor = 10 * time.Second
+
+	// maximum size of a line in json files
+	maxJSONLineSize = 1024 * 1024
+)
 
 func IsValidMode(mode string) bool {
 	switch mode {
 	case
 		pkgconfig.ModePCAP,
-		pkgconfig.ModeDNSTap:
+		pkgconfig.ModeDNSTap,
+		pkgconfig.ModeJSON,
+		pkgconfig.ModeFlatJSON:
 		return true
 	}
 	return false
@@ -38,9 +49,21 @@ type FileIngestor struct {
 	watcherTimers   map[string]*time.Timer
 	dnsProcessor    DNSProcessor
 	dnstapProcessor DNSTapProcessor
+	jsonChan        chan dnsutils.DNSMessage
+	stopJSON        chan bool
 	mu              sync.Mutex
 }
 
+// isJSONFile returns true for the files written by the loggers in json modes,
+// optionally compressed after rotation
+func isJSONFile(filePath string) bool {
+	switch filepath.Ext(strings.TrimSuffix(filePath, ".gz")) {
+	case ".json", ".jsonl", ".log":
+		return true
+	}
+	return false
+}
+
 func NewFileIngestor(next []Worker, config *pkgconfig.Config, logger *logger.Logger, name string) *FileIngestor {
 	bufSize := config.Global.Worker.ChannelBufferSize
 	if config.Collectors.FileIngestor.ChannelBufferSize > 0 {
@@ -48,7 +71,9 @@ func NewFileIngestor(next []Worker, config *pkgconfig.Config, logger *logger.Log
 	}
 	w := &FileIngestor{
 		GenericWorker: NewGenericWorker(config, logger, name, "fileingestor", bufSize, pkgconfig.DefaultMonitor),
-		watcherTimers: make(map[string]*time.Timer)}
+		watcherTimers: make(map[string]*time.Timer),
+		jsonChan:      make(chan dnsutils.DNSMessage, bufSize),
+		stopJSON:      make(chan bool)}
 	w.SetDefaultRoutes(next)
 	w.CheckConfig()
 	return w
@@ -78,6 +103,12 @@ func (w *FileIngestor) ProcessFile(filePath string) {
 			w.LogInfo("file ready to process %s", filePath)
 			go w.ProcessDnstap(filePath)
 		}
+	case pkgconfig.ModeJSON, pkgconfig.ModeFlatJSON:
+		// process json lines
+		if isJSONFile(filePath) {
+			w.LogInfo("file ready to process %s", filePath)
+			go w.ProcessJSON(filePath)
+		}
 	}
 }
 
@@ -283,6 +314,79 @@ func (w *FileIngestor) ProcessDnstap(filePath string) error {
 	return nil
 }
 
+// ProcessJSON reads a file with one DNS message per line, as written by the loggers
+// in json or flat-json mode
+func (w *FileIngestor) ProcessJSON(filePath string) error {
+	// open the file
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if filepath.Ext(filePath) == ".gz" {
+		gzReader, err := gzip.NewReader(f)
+		if err != nil {
+			w.LogError("unable to read gzip file: %s", err)
+			return err
+		}
+		defer gzReader.Close()
+		reader = gzReader
+	}
+
+	fileName := filepath.Base(filePath)
+	w.LogInfo("processing %s file [%s]", w.GetConfig().Collectors.FileIngestor.WatchMode, fileName)
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLineSize)
+
+	nbLines, nbErrors := 0, 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		nbLines++
+
+		dm := dnsutils.DNSMessage{}
+		if w.GetConfig().Collectors.FileIngestor.WatchMode == pkgconfig.ModeFlatJSON {
+			err = dm.FromFlatJSON(line)
+		} else {
+			err = dm.FromJSON(line)
+		}
+		if err != nil {
+			nbErrors++
+			continue
+		}
+		// the file is not removed if the collector is stopped in the meantime
+		select {
+		case w.jsonChan <- dm:
+		case <-w.stopJSON:
+			w.LogInfo("processing of [%s] interrupted after %d line(s)", fileName, nbLines)
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		w.LogError("unable to read file [%s]: %s", fileName, err)
+	}
+	if nbErrors > 0 {
+		w.LogWarning("file [%s]: %d invalid line(s) ignored", fileName, nbErrors)
+	}
+
+	// remove it ?
+	w.LogInfo("processing of [%s] terminated, %d line(s) read", fileName, nbLines)
+	if w.GetConfig().Collectors.FileIngestor.DeleteAfter {
+		w.LogInfo("delete file [%s]", fileName)
+		os.Remove(filePath)
+	}
+
+	// remove event timer for this file
+	w.RemoveEvent(filePath)
+
+	return nil
+}
+
 func (w *FileIngestor) RegisterEvent(filePath string) {
 	// Get timer.
 	w.mu.Lock()
@@ -319,19 +423,22 @@ func (w *FileIngestor) StartCollect() {
 	}
 
 	dnsProcessor := NewDNSProcessor(w.GetConfig(), w.GetLogger(), w.GetName(), bufSize)
-	dnsProcessor.SetDefaultRoutes(w.GetDefaultRoutes())
-	dnsProcessor.SetDefaultDropped(w.GetDroppedRoutes())
+	dnsProcessor.FollowRoutes(w.GenericWorker)
 	go dnsProcessor.StartCollect()
 
 	// start dnstap subprocessor
 	dnstapProcessor := NewDNSTapProcessor(0, "", w.GetConfig(), w.GetLogger(), w.GetName(), bufSize)
-	dnstapProcessor.SetDefaultRoutes(w.GetDefaultRoutes())
-	dnstapProcessor.SetDefaultDropped(w.GetDroppedRoutes())
+	dnstapProcessor.FollowRoutes(w.GenericWorker)
 	go dnstapProcessor.StartCollect()
 
 	w.dnstapProcessor = dnstapProcessor
 	w.dnsProcessor = dnsProcessor
 
+	// prepare next channels and transformers for the messages read from json files
+	defaultRoutes, defaultNames := GetRoutes(w.GetDefaultRoutes())
+	droppedRoutes, droppedNames := GetRoutes(w.GetDroppedRoutes())
+	transforms := transformers.NewTransforms(&w.GetConfig().IngoingTransformers, w.GetLogger(), w.GetName(), defaultRoutes, 0)
+
 	// read current folder content
 	entries, err := os.ReadDir(w.GetConfig().Collectors.FileIngestor.WatchDir)
 	if err != nil {
@@ -358,6 +465,11 @@ func (w *FileIngestor) StartCollect() {
 			if filepath.Ext(fn) == ".fstrm" {
 				go w.ProcessDnstap(fn)
 			}
+		case pkgconfig.ModeJSON, pkgconfig.ModeFlatJSON:
+			// process json lines
+			if isJSONFile(fn) {
+				go w.ProcessJSON(fn)
+			}
 		}
 	}
 
@@ -374,11 +486,18 @@ func (w *FileIngestor) StartCollect() {
 
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+			transforms.SetNextWorkers(defaultRoutes)
+
 		case <-w.OnStop():
 			w.LogInfo("stop to listen...")
+			transforms.Reset()
 
-			// stop watching
+			// stop watching and reading json files
 			watcher.Close()
+			close(w.stopJSON)
 
 			// stop processors
 			dnsProcessor.Stop()
@@ -392,6 +511,25 @@ func (w *FileIngestor) StartCollect() {
 
 			dnsProcessor.NewConfig() <- cfg
 			dnstapProcessor.NewConfig() <- cfg
+			transforms.ReloadConfig(&cfg.IngoingTransformers)
+
+		case dm := <-w.jsonChan:
+			// count global messages
+			w.CountIngressTraffic()
+
+			// apply all enabled transformers
+			transformResult, err := transforms.ProcessMessage(&dm)
+			if err != nil {
+				w.LogError(err.Error())
+			}
+			if transformResult == transformers.ReturnDrop {
+				w.SendDroppedTo(droppedRoutes, droppedNames, dm)
+				continue
+			}
+
+			// count output packets and dispatch dns message to connected routes
+			w.CountEgressTraffic()
+			w.SendForwardedTo(defaultRoutes, defaultNames, dm)
 
 		case event, ok := <-watcher.Events:
 			if !ok { // Channel was closed (i.e. Watcher.Close() was called).
@@ -1,7 +1,11 @@
 package workers
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
@@ -52,3 +56,80 @@ func Test_FileIngestor(t *testing.T) {
 		})
 	}
 }
+
+func Test_FileIngestor_JSON(t *testing.T) {
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNSTap.TimestampRFC3339 = "2024-01-02T03:04:05.123456789Z"
+	flat, _ := dm.ToFlatJSON()
+
+	tests := []struct {
+		watchMode string
+		line      string
+	}{
+		{watchMode: pkgconfig.ModeJSON, line: dm.ToJSON()},
+		{watchMode: pkgconfig.ModeFlatJSON, line: flat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.watchMode, func(t *testing.T) {
+			// write a file with an invalid line before the message
+			watchDir := t.TempDir()
+			content := "invalid line\n" + strings.TrimSpace(tt.line) + "\n"
+			if err := os.WriteFile(filepath.Join(watchDir, "dnscollector.log"), []byte(content), 0o644); err != nil {
+				t.Fatalf("unable to write file: %s", err)
+			}
+
+			g := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+			config := pkgconfig.GetDefaultConfig()
+			config.Collectors.FileIngestor.WatchMode = tt.watchMode
+			config.Collectors.FileIngestor.WatchDir = watchDir
+
+			// init collector
+			c := NewFileIngestor([]Worker{g}, config, logger.New(false), "test")
+			go c.StartCollect()
+
+			msg := <-g.GetInputChannel()
+			if msg.DNS.Qname != dm.DNS.Qname || msg.NetworkInfo.QueryIP != dm.NetworkInfo.QueryIP {
+				t.Errorf("invalid dns message: %s %s", msg.DNS.Qname, msg.NetworkInfo.QueryIP)
+			}
+			if msg.DNSTap.Operation != dnsutils.DNSTapClientQuery || msg.DNS.Type != dnsutils.DNSQuery {
+				t.Errorf("invalid operation or type: %s %s", msg.DNSTap.Operation, msg.DNS.Type)
+			}
+		})
+	}
+}
+
+func Test_FileIngestor_JSON_Stop(t *testing.T) {
+	dm := dnsutils.GetFakeDNSMessage()
+	watchDir := t.TempDir()
+	filePath := filepath.Join(watchDir, "dnscollector.log")
+	content := strings.Repeat(dm.ToJSON()+"\n", 3)
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("unable to write file: %s", err)
+	}
+
+	g := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	config := pkgconfig.GetDefaultConfig()
+	config.Collectors.FileIngestor.WatchMode = pkgconfig.ModeJSON
+	config.Collectors.FileIngestor.WatchDir = watchDir
+	config.Collectors.FileIngestor.DeleteAfter = true
+	config.Collectors.FileIngestor.ChannelBufferSize = 1
+
+	// nobody reads the messages, the processing is blocked until the stop
+	c := NewFileIngestor([]Worker{g}, config, logger.New(false), "test")
+	done := make(chan error)
+	go func() { done <- c.ProcessJSON(filePath) }()
+	close(c.stopJSON)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("processing not interrupted")
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		t.Errorf("file removed after an interrupted processing: %s", err)
+	}
+}
@@ -71,6 +71,11 @@ func (w *Tail) StartCollect() {
 
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+			subprocessors.SetNextWorkers(defaultRoutes)
+
 		// save the new config
 		case cfg := <-w.NewConfig():
 			w.SetConfig(cfg)
@@ -189,6 +189,10 @@ func (w *FluentdClient) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -0,0 +1,268 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-dnscollector/transformers"
+	"github.com/dmachard/go-dnstap-protobuf"
+	"github.com/dmachard/go-logger"
+	"github.com/dmachard/go-netutils"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// KafkaReader is the part of the kafka reader used by the consumer
+type KafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+type KafkaConsumer struct {
+	*GenericWorker
+	dt  *dnstap.Dnstap
+	edt *dnsutils.ExtendedDnstap
+}
+
+func NewKafkaConsumer(next []Worker, config *pkgconfig.Config, logger *logger.Logger, name string) *KafkaConsumer {
+	bufSize := config.Global.Worker.ChannelBufferSize
+	if config.Collectors.KafkaConsumer.ChannelBufferSize > 0 {
+		bufSize = config.Collectors.KafkaConsumer.ChannelBufferSize
+	}
+	w := &KafkaConsumer{
+		GenericWorker: NewGenericWorker(config, logger, name, "kafka consumer", bufSize, pkgconfig.DefaultMonitor),
+		dt:            &dnstap.Dnstap{},
+		edt:           &dnsutils.ExtendedDnstap{},
+	}
+	w.SetDefaultRoutes(next)
+	w.ReadConfig()
+	return w
+}
+
+func (w *KafkaConsumer) ReadConfig() {
+	switch w.GetConfig().Collectors.KafkaConsumer.Mode {
+	case pkgconfig.ModeJSON, pkgconfig.ModeFlatJSON, pkgconfig.ModeDNSTap:
+	default:
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] kafkaconsumer - invalid mode: ", w.GetConfig().Collectors.KafkaConsumer.Mode)
+	}
+
+	switch w.GetConfig().Collectors.KafkaConsumer.StartOffset {
+	case pkgconfig.KafkaOffsetEarliest, pkgconfig.KafkaOffsetLatest:
+	default:
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] kafkaconsumer - invalid start offset: ", w.GetConfig().Collectors.KafkaConsumer.StartOffset)
+	}
+
+	if len(w.GetConfig().Collectors.KafkaConsumer.GroupID) == 0 {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] kafkaconsumer - group-id is required")
+	}
+	if w.GetConfig().Collectors.KafkaConsumer.CommitInterval < 0 {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] kafkaconsumer - invalid commit interval")
+	}
+	if len(w.GetConfig().Collectors.KafkaConsumer.Topics) == 0 {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] kafkaconsumer - no topic to read")
+	}
+}
+
+// NewReader creates a kafka reader joining the consumer group on the topics configured
+func (w *KafkaConsumer) NewReader() (*kafka.Reader, error) {
+	config := w.GetConfig().Collectors.KafkaConsumer
+
+	dialer := &kafka.Dialer{
+		Timeout:   time.Duration(config.ConnectTimeout) * time.Second,
+		DualStack: true,
+	}
+
+	// enable TLS
+	if config.TLSSupport {
+		tlsOptions := netutils.TLSOptions{
+			InsecureSkipVerify: config.TLSInsecure,
+			MinVersion:         config.TLSMinVersion,
+			CAFile:             config.CAFile,
+			CertFile:           config.CertFile,
+			KeyFile:            config.KeyFile,
+		}
+
+		tlsConfig, err := netutils.TLSClientConfig(tlsOptions)
+		if err != nil {
+			return nil, err
+		}
+		dialer.TLS = tlsConfig
+	}
+
+	// SASL Support
+	if config.SaslSupport {
+		switch config.SaslMechanism {
+		case pkgconfig.SASLMechanismPlain:
+			dialer.SASLMechanism = plain.Mechanism{
+				Username: config.SaslUsername,
+				Password: config.SaslPassword,
+			}
+		case pkgconfig.SASLMechanismScram:
+			mechanism, err := scram.Mechanism(scram.SHA512, config.SaslUsername, config.SaslPassword)
+			if err != nil {
+				return nil, err
+			}
+			dialer.SASLMechanism = mechanism
+		default:
+			return nil, errors.New("invalid sasl mechanism: " + config.SaslMechanism)
+		}
+	}
+
+	// get list of brokers
+	brokers := []string{}
+	for _, address := range strings.Split(config.RemoteAddress, ",") {
+		brokers = append(brokers, address+":"+strconv.Itoa(config.RemotePort))
+	}
+
+	startOffset := kafka.LastOffset
+	if config.StartOffset == pkgconfig.KafkaOffsetEarliest {
+		startOffset = kafka.FirstOffset
+	}
+
+	// offsets are committed manually once the messages are delivered,
+	// and flushed to the brokers every commit interval
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        brokers,
+		GroupID:        config.GroupID,
+		GroupTopics:    config.Topics,
+		Dialer:         dialer,
+		StartOffset:    startOffset,
+		CommitInterval: time.Duration(config.CommitInterval) * time.Millisecond,
+	}), nil
+}
+
+// DecodeMessage converts the value of a kafka message to a DNS message according to the mode
+func (w *KafkaConsumer) DecodeMessage(data []byte) (dnsutils.DNSMessage, error) {
+	dm := dnsutils.DNSMessage{}
+	var err error
+
+	switch w.GetConfig().Collectors.KafkaConsumer.Mode {
+	case pkgconfig.ModeJSON:
+		err = dm.FromJSON(data)
+	case pkgconfig.ModeFlatJSON:
+		err = dm.FromFlatJSON(data)
+	case pkgconfig.ModeDNSTap:
+		dm, err = w.DecodeDnstapMessage(data, w.dt, w.edt, DnstapDecodeOptions{
+			PeerName:         w.GetConfig().Collectors.KafkaConsumer.RemoteAddress,
+			ExtendedSupport:  w.GetConfig().Collectors.KafkaConsumer.ExtendedSupport,
+			DisableDNSParser: w.GetConfig().Collectors.KafkaConsumer.DisableDNSParser,
+		})
+	}
+	return dm, err
+}
+
+func (w *KafkaConsumer) StartCollect() {
+	w.LogInfo("starting data collection")
+	defer w.CollectDone()
+
+	w.LogInfo("connecting to kafka=%s on port=%d group=%s topics=%s",
+		w.GetConfig().Collectors.KafkaConsumer.RemoteAddress, w.GetConfig().Collectors.KafkaConsumer.RemotePort,
+		w.GetConfig().Collectors.KafkaConsumer.GroupID, strings.Join(w.GetConfig().Collectors.KafkaConsumer.Topics, ","))
+
+	reader, err := w.NewReader()
+	if err != nil {
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] kafkaconsumer - unable to create reader: ", err)
+	}
+	w.Consume(reader)
+}
+
+// Consume reads the messages until the worker is stopped, the offset of each message
+// is committed after the message is delivered to the next workers
+func (w *KafkaConsumer) Consume(reader KafkaReader) {
+	// prepare next channels
+	defaultRoutes, _ := GetRoutes(w.GetDefaultRoutes())
+	droppedRoutes, droppedNames := GetRoutes(w.GetDroppedRoutes())
+
+	// prepare enabled transformers
+	transforms := transformers.NewTransforms(&w.GetConfig().IngoingTransformers, w.GetLogger(), w.GetName(), defaultRoutes, 0)
+
+	// goroutine to fetch messages from the brokers
+	ctx, cancel := context.WithCancel(context.Background())
+	msgChan := make(chan kafka.Message)
+	go w.FetchMessages(ctx, reader, msgChan)
+
+	for {
+		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, _ = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+			transforms.SetNextWorkers(defaultRoutes)
+
+		case <-w.OnStop():
+			transforms.Reset()
+			cancel()
+			reader.Close()
+			return
+
+		// new config provided?
+		case cfg := <-w.NewConfig():
+			w.SetConfig(cfg)
+			w.ReadConfig()
+			transforms.ReloadConfig(&cfg.IngoingTransformers)
+
+		case msg := <-msgChan:
+			// count global messages
+			w.CountIngressTraffic()
+
+			dm, err := w.DecodeMessage(msg.Value)
+			if err != nil {
+				w.LogError("[topic=%s partition=%d offset=%d] unable to decode message: %s", msg.Topic, msg.Partition, msg.Offset, err)
+			} else {
+				// count output packets
+				w.CountEgressTraffic()
+
+				// apply all enabled transformers
+				transformResult, err := transforms.ProcessMessage(&dm)
+				if err != nil {
+					w.LogError(err.Error())
+				}
+				if transformResult == transformers.ReturnDrop {
+					w.SendDroppedTo(droppedRoutes, droppedNames, dm)
+				} else if !w.DeliverTo(defaultRoutes, dm) {
+					// stopped before the delivery, the offset is not committed
+					transforms.Reset()
+					cancel()
+					reader.Close()
+					return
+				}
+			}
+
+			// the message is handled, commit the offset
+			if err := reader.CommitMessages(ctx, msg); err != nil {
+				w.LogError("[topic=%s partition=%d offset=%d] unable to commit offset: %s", msg.Topic, msg.Partition, msg.Offset, err)
+			}
+		}
+	}
+}
+
+// FetchMessages reads the messages from the brokers until the context is cancelled
+func (w *KafkaConsumer) FetchMessages(ctx context.Context, reader KafkaReader, msgChan chan<- kafka.Message) {
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.LogError("unable to fetch message: %s", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+				continue
+			}
+		}
+
+		select {
+		case msgChan <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
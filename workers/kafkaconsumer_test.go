@@ -0,0 +1,174 @@
+package workers
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeKafkaReader delivers the messages provided and records the commits
+type fakeKafkaReader struct {
+	sync.Mutex
+	msgs      chan kafka.Message
+	committed []int64
+	// number of messages forwarded to the next worker when the offset is committed
+	forwarded []int
+	next      *GenericWorker
+}
+
+func newFakeKafkaReader(next *GenericWorker) *fakeKafkaReader {
+	return &fakeKafkaReader{msgs: make(chan kafka.Message, 10), next: next}
+}
+
+func (r *fakeKafkaReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case msg, opened := <-r.msgs:
+		if !opened {
+			return kafka.Message{}, io.EOF
+		}
+		return msg, nil
+	}
+}
+
+func (r *fakeKafkaReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.Lock()
+	defer r.Unlock()
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+		r.forwarded = append(r.forwarded, len(r.next.GetInputChannel()))
+	}
+	return nil
+}
+
+func (r *fakeKafkaReader) Close() error { return nil }
+
+func (r *fakeKafkaReader) waitCommits(t *testing.T, n int) ([]int64, []int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.Lock()
+		if len(r.committed) >= n {
+			defer r.Unlock()
+			return r.committed, r.forwarded
+		}
+		r.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d commits", n)
+	return nil, nil
+}
+
+func Test_KafkaConsumer(t *testing.T) {
+	dm := dnsutils.GetFakeDNSMessageWithPayload()
+	dm.DNSTap.TimestampRFC3339 = "2024-01-02T03:04:05.123456789Z"
+	flat, _ := dm.ToFlatJSON()
+	dnstapData, _ := dm.ToDNSTap(false)
+
+	testcases := []struct {
+		mode  string
+		data  []byte
+		qname string
+	}{
+		{mode: pkgconfig.ModeJSON, data: []byte(dm.ToJSON()), qname: dm.DNS.Qname},
+		{mode: pkgconfig.ModeFlatJSON, data: []byte(flat), qname: dm.DNS.Qname},
+		// the qname is decoded from the dns payload
+		{mode: pkgconfig.ModeDNSTap, data: dnstapData, qname: pkgconfig.ExpectedQname},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.mode, func(t *testing.T) {
+			// simulate next workers
+			kept := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+
+			// config for the collector
+			config := pkgconfig.GetDefaultConfig()
+			config.Collectors.KafkaConsumer.Mode = tc.mode
+
+			c := NewKafkaConsumer([]Worker{kept}, config, logger.New(false), "test")
+			reader := newFakeKafkaReader(kept)
+			go c.Consume(reader)
+
+			reader.msgs <- kafka.Message{Topic: "dnscollector", Offset: 42, Value: tc.data}
+
+			committed, forwarded := reader.waitCommits(t, 1)
+			if committed[0] != 42 {
+				t.Errorf("invalid offset committed: %d", committed[0])
+			}
+			if forwarded[0] != 1 {
+				t.Errorf("offset committed before forwarding the message")
+			}
+
+			dmOut := <-kept.GetInputChannel()
+			if dmOut.DNS.Qname != tc.qname {
+				t.Errorf("invalid qname in dns message: %s", dmOut.DNS.Qname)
+			}
+			if dmOut.NetworkInfo.QueryIP != dm.NetworkInfo.QueryIP {
+				t.Errorf("invalid query ip in dns message: %s", dmOut.NetworkInfo.QueryIP)
+			}
+			if dmOut.DNS.Type != dnsutils.DNSQuery {
+				t.Errorf("invalid dns type: %s", dmOut.DNS.Type)
+			}
+		})
+	}
+}
+
+func Test_KafkaConsumer_InvalidMessage(t *testing.T) {
+	kept := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+
+	config := pkgconfig.GetDefaultConfig()
+	config.Collectors.KafkaConsumer.Mode = pkgconfig.ModeJSON
+
+	c := NewKafkaConsumer([]Worker{kept}, config, logger.New(false), "test")
+	reader := newFakeKafkaReader(kept)
+	go c.Consume(reader)
+
+	// an invalid message is not forwarded but the offset is committed to not block the partition
+	reader.msgs <- kafka.Message{Offset: 1, Value: []byte("dnscollector")}
+	committed, forwarded := reader.waitCommits(t, 1)
+	if committed[0] != 1 || forwarded[0] != 0 {
+		t.Errorf("invalid commit: %v %v", committed, forwarded)
+	}
+}
+
+func Test_KafkaConsumer_BusyWorker(t *testing.T) {
+	// next worker with room for one message only
+	kept := GetWorkerForTest(1)
+
+	config := pkgconfig.GetDefaultConfig()
+	config.Collectors.KafkaConsumer.Mode = pkgconfig.ModeJSON
+
+	c := NewKafkaConsumer([]Worker{kept}, config, logger.New(false), "test")
+	reader := newFakeKafkaReader(kept)
+	go c.Consume(reader)
+
+	dm := dnsutils.GetFakeDNSMessage()
+	reader.msgs <- kafka.Message{Offset: 1, Value: []byte(dm.ToJSON())}
+	reader.msgs <- kafka.Message{Offset: 2, Value: []byte(dm.ToJSON())}
+
+	// the second message waits for the next worker, its offset is not committed
+	reader.waitCommits(t, 1)
+	time.Sleep(100 * time.Millisecond)
+	reader.Lock()
+	if len(reader.committed) != 1 {
+		t.Errorf("offset committed before delivery: %v", reader.committed)
+	}
+	reader.Unlock()
+
+	// the next worker is available, the message is delivered then committed
+	<-kept.GetInputChannel()
+	committed, _ := reader.waitCommits(t, 2)
+	if committed[1] != 2 {
+		t.Errorf("invalid offset committed: %v", committed)
+	}
+	if len(kept.GetInputChannel()) != 1 {
+		t.Errorf("the message must be delivered")
+	}
+}
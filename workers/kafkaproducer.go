@@ -1,11 +1,12 @@
 package workers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
@@ -21,14 +22,18 @@ import (
 
 type KafkaProducer struct {
 	*GenericWorker
-	textFormat                 []string
+	encoder                    dnsutils.Encoder
 	kafkaReady, kafkaReconnect chan bool
 	kafkaConnected             bool
 	compressCodec              compress.Codec
 	kafkaConns                 map[int]*kafka.Conn // Map to store connections by partition
 	lastPartitionIndex         *int
+	// the connections are shared with the goroutine connecting to kafka
+	connsLock sync.Mutex
 }
 
+var errKafkaNotConnected = errors.New("not connected")
+
 func NewKafkaProducer(config *pkgconfig.Config, logger *logger.Logger, name string) *KafkaProducer {
 	bufSize := config.Global.Worker.ChannelBufferSize
 	if config.Loggers.KafkaProducer.ChannelBufferSize > 0 {
@@ -41,15 +46,12 @@ func NewKafkaProducer(config *pkgconfig.Config, logger *logger.Logger, name stri
 		kafkaConns:     make(map[int]*kafka.Conn),
 	}
 	w.ReadConfig()
+	w.InitSpool(config.Loggers.KafkaProducer.Spool)
 	return w
 }
 
 func (w *KafkaProducer) ReadConfig() {
-	if len(w.GetConfig().Loggers.KafkaProducer.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.KafkaProducer.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
-	}
+	w.encoder = w.NewEncoder(w.GetConfig().Loggers.KafkaProducer.Mode, w.GetEncoderOptions(w.GetConfig().Loggers.KafkaProducer.TextFormat, ""))
 
 	if w.GetConfig().Loggers.KafkaProducer.Compression != pkgconfig.CompressNone {
 		switch w.GetConfig().Loggers.KafkaProducer.Compression {
@@ -70,6 +72,9 @@ func (w *KafkaProducer) ReadConfig() {
 }
 
 func (w *KafkaProducer) Disconnect() {
+	w.connsLock.Lock()
+	defer w.connsLock.Unlock()
+
 	// Close all Kafka connections
 	for _, conn := range w.kafkaConns {
 		if conn != nil {
@@ -84,9 +89,7 @@ func (w *KafkaProducer) ConnectToKafka(ctx context.Context, readyTimer *time.Tim
 	for {
 		readyTimer.Reset(time.Duration(10) * time.Second)
 
-		if len(w.kafkaConns) > 0 {
-			w.Disconnect()
-		}
+		w.Disconnect()
 
 		topic := w.GetConfig().Loggers.KafkaProducer.Topic
 		partition := w.GetConfig().Loggers.KafkaProducer.Partition
@@ -184,7 +187,9 @@ func (w *KafkaProducer) ConnectToKafka(ctx context.Context, readyTimer *time.Tim
 					time.Sleep(time.Duration(w.GetConfig().Loggers.KafkaProducer.RetryInterval) * time.Second)
 					continue
 				}
+				w.connsLock.Lock()
 				w.kafkaConns[p.ID] = conn
+				w.connsLock.Unlock()
 				w.LogInfo("[partition=%d] connected with success to tcp://%s and topic=%s", p.ID, address, p.Topic)
 			}
 		} else {
@@ -204,7 +209,9 @@ func (w *KafkaProducer) ConnectToKafka(ctx context.Context, readyTimer *time.Tim
 				time.Sleep(time.Duration(w.GetConfig().Loggers.KafkaProducer.RetryInterval) * time.Second)
 				continue
 			}
+			w.connsLock.Lock()
 			w.kafkaConns[*partition] = conn
+			w.connsLock.Unlock()
 		}
 
 		// block until is ready
@@ -214,36 +221,42 @@ func (w *KafkaProducer) ConnectToKafka(ctx context.Context, readyTimer *time.Tim
 }
 
 func (w *KafkaProducer) FlushBuffer(buf *[]dnsutils.DNSMessage) {
+	if err := w.WriteMessages(w.BuildMessages(*buf)); err != nil {
+		w.SpoolBuffer(*buf)
+		w.kafkaConnected = false
+		w.LogWarning("retry to re-connect")
+		<-w.kafkaReconnect
+	}
+
+	// reset buffer
+	*buf = nil
+}
+
+func (w *KafkaProducer) BuildMessages(buf []dnsutils.DNSMessage) []kafka.Message {
 	msgs := []kafka.Message{}
-	buffer := new(bytes.Buffer)
-	strDm := ""
-	partition := w.GetConfig().Loggers.KafkaProducer.Partition
 
-	for _, dm := range *buf {
-		switch w.GetConfig().Loggers.KafkaProducer.Mode {
-		case pkgconfig.ModeText:
-			strDm = dm.String(w.textFormat, w.GetConfig().Global.TextFormatDelimiter, w.GetConfig().Global.TextFormatBoundary)
-		case pkgconfig.ModeJSON:
-			json.NewEncoder(buffer).Encode(dm)
-			strDm = buffer.String()
-			buffer.Reset()
-		case pkgconfig.ModeFlatJSON:
-			flat, err := dm.Flatten()
-			if err != nil {
-				w.LogError("flattening DNS message failed: %e", err)
-			}
-			json.NewEncoder(buffer).Encode(flat)
-			strDm = buffer.String()
-			buffer.Reset()
+	for _, dm := range buf {
+		data, err := w.encoder.Encode(&dm)
+		if err != nil {
+			w.LogError("encoding DNS message failed: %s", err)
+			continue
 		}
 
 		msg := kafka.Message{
 			Key:   []byte(dm.DNSTap.Identity),
-			Value: []byte(strDm),
+			Value: data,
 		}
 		msgs = append(msgs, msg)
 
 	}
+	return msgs
+}
+
+func (w *KafkaProducer) WriteMessages(msgs []kafka.Message) error {
+	w.connsLock.Lock()
+	defer w.connsLock.Unlock()
+
+	partition := w.GetConfig().Loggers.KafkaProducer.Partition
 
 	// add support for msg compression and round robin
 	var err error
@@ -252,7 +265,14 @@ func (w *KafkaProducer) FlushBuffer(buf *[]dnsutils.DNSMessage) {
 			w.lastPartitionIndex = new(int)
 		}
 		numPartitions := len(w.kafkaConns)
+		if numPartitions == 0 {
+			return errKafkaNotConnected
+		}
+		*w.lastPartitionIndex %= numPartitions
 		conn := w.kafkaConns[*w.lastPartitionIndex]
+		if conn == nil {
+			return errKafkaNotConnected
+		}
 		if w.GetConfig().Loggers.KafkaProducer.Compression == pkgconfig.CompressNone {
 			_, err = conn.WriteMessages(msgs...)
 		} else {
@@ -260,15 +280,15 @@ func (w *KafkaProducer) FlushBuffer(buf *[]dnsutils.DNSMessage) {
 		}
 		if err != nil {
 			w.LogError("[partition=%d] unable to write message: %v", *w.lastPartitionIndex, err.Error())
-			w.kafkaConnected = false
-			w.LogWarning("retry to re-connect")
-			<-w.kafkaReconnect
 		}
 
 		// Move to the next partition in round-robin fashion
 		*w.lastPartitionIndex = (*w.lastPartitionIndex + 1) % numPartitions
 	} else {
 		conn := w.kafkaConns[*partition]
+		if conn == nil {
+			return errKafkaNotConnected
+		}
 		if w.GetConfig().Loggers.KafkaProducer.Compression == pkgconfig.CompressNone {
 			_, err = conn.WriteMessages(msgs...)
 		} else {
@@ -276,13 +296,49 @@ func (w *KafkaProducer) FlushBuffer(buf *[]dnsutils.DNSMessage) {
 		}
 		if err != nil {
 			w.LogError("[partition=%d] unable to write message: %v", *partition, err.Error())
-			w.kafkaConnected = false
-			<-w.kafkaReconnect
 		}
 	}
+	return err
+}
 
-	// reset buffer
-	*buf = nil
+// SpoolBuffer writes the dns messages to the disk spool, if enabled
+func (w *KafkaProducer) SpoolBuffer(buf []dnsutils.DNSMessage) {
+	if !w.SpoolEnabled() {
+		return
+	}
+	for _, dm := range buf {
+		data, err := json.Marshal(dm)
+		if err != nil {
+			continue
+		}
+		w.SpoolData(data)
+	}
+}
+
+// FlushSpool sends the spooled dns messages by batch once the producer is connected,
+// before the new messages to keep them in order
+func (w *KafkaProducer) FlushSpool() {
+	batchSize := max(w.GetConfig().Loggers.KafkaProducer.BatchSize, 1)
+	err := w.ReplaySpoolBatch(batchSize, func(records [][]byte) error {
+		buf := make([]dnsutils.DNSMessage, 0, len(records))
+		for _, data := range records {
+			var dm dnsutils.DNSMessage
+			if err := json.Unmarshal(data, &dm); err != nil {
+				w.LogError("spool - invalid record: %s", err)
+				continue
+			}
+			buf = append(buf, dm)
+		}
+		if len(buf) == 0 {
+			return nil
+		}
+		return w.WriteMessages(w.BuildMessages(buf))
+	})
+	if err != nil {
+		w.LogError("spool - replay interrupted: %s", err)
+		w.kafkaConnected = false
+		<-w.kafkaReconnect
+	}
 }
 
 func (w *KafkaProducer) StartCollect() {
@@ -302,6 +358,10 @@ func (w *KafkaProducer) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -365,6 +425,8 @@ func (w *KafkaProducer) StartLogging() {
 		case <-w.OnLoggerStopped():
 			// closing kafka connection if exist
 			w.Disconnect()
+			w.SpoolBuffer(bufferDm)
+			w.CloseSpool()
 			return
 
 		case <-readyTimer.C:
@@ -379,6 +441,9 @@ func (w *KafkaProducer) StartLogging() {
 			readyTimer.Stop()
 			w.kafkaConnected = true
 
+			// send messages spooled while kafka was down
+			w.FlushSpool()
+
 		// incoming dns message to process
 		case dm, opened := <-w.GetOutputChannel():
 			if !opened {
@@ -386,9 +451,10 @@ func (w *KafkaProducer) StartLogging() {
 				return
 			}
 
-			// drop dns message if the connection is not ready to avoid memory leak or
-			// to block the channel
+			// spool or drop dns message if the connection is not ready to avoid
+			// memory leak or to block the channel
 			if !w.kafkaConnected {
+				w.SpoolBuffer([]dnsutils.DNSMessage{dm})
 				continue
 			}
 
@@ -403,6 +469,7 @@ func (w *KafkaProducer) StartLogging() {
 		// flush the buffer
 		case <-flushTimer.C:
 			if !w.kafkaConnected {
+				w.SpoolBuffer(bufferDm)
 				bufferDm = nil
 			}
 
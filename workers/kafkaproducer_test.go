@@ -163,3 +163,33 @@ func Test_KafkaProducer_Reconnect(t *testing.T) {
 		t.Fatal("No ProduceRequest received by broker after reconnect")
 	}
 }
+
+func Test_KafkaProducer_SpoolReplay(t *testing.T) {
+	listener, broker := createMockBroker(t, 1, testAddress+":"+testPort, testTopic)
+	defer listener.Close()
+	defer broker.Close()
+
+	cfg := setupKafkaProducerConfig(testAddress, testTopic, "none")
+	cfg.Loggers.KafkaProducer.BatchSize = 5
+	cfg.Loggers.KafkaProducer.Spool = getSpoolConfigForTest(t)
+	producer := NewKafkaProducer(cfg, logger.New(false), "test")
+
+	// messages spooled while kafka was down
+	buf := []dnsutils.DNSMessage{}
+	for i := 0; i < 5; i++ {
+		buf = append(buf, dnsutils.GetFakeDNSMessage())
+	}
+	producer.SpoolBuffer(buf)
+
+	go producer.StartCollect()
+	defer producer.StopLogger()
+
+	// replayed in one batch once connected
+	time.Sleep(2 * time.Second)
+	if producer.SpoolPending() {
+		t.Fatal("spool not replayed")
+	}
+	if count := countProduceRequests(broker); count != 1 {
+		t.Errorf("one ProduceRequest expected, got %d", count)
+	}
+}
@@ -3,7 +3,7 @@ package workers
 import (
 	"bufio"
 	"bytes"
-	"encoding/json"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
@@ -25,26 +25,36 @@ import (
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcapgo"
+	"github.com/parquet-go/parquet-go"
 
 	framestream "github.com/farsightsec/golang-framestream"
 )
 
 const (
 	compressSuffix = ".gz"
+	// rows buffered by the parquet writer before writing a row group
+	parquetRowGroupSize = 100000
 )
 
 func IsValid(mode string) bool {
-	switch mode {
-	case
-		pkgconfig.ModeJinja,
-		pkgconfig.ModeText,
-		pkgconfig.ModeJSON,
-		pkgconfig.ModeFlatJSON,
-		pkgconfig.ModePCAP,
-		pkgconfig.ModeDNSTap:
-		return true
-	}
-	return false
+	return mode == pkgconfig.ModePCAP || mode == pkgconfig.ModeParquet || pkgconfig.IsValidMode(mode)
+}
+
+// isPlainMode returns true if the file is written with the encoder of the mode
+func isPlainMode(mode string) bool {
+	return mode != pkgconfig.ModePCAP && mode != pkgconfig.ModeParquet && mode != pkgconfig.ModeDNSTap
+}
+
+// sizeWriter counts the bytes written to the file
+type sizeWriter struct {
+	w    io.Writer
+	size *int64
+}
+
+func (sw *sizeWriter) Write(p []byte) (int, error) {
+	n, err := sw.w.Write(p)
+	*sw.size += int64(n)
+	return n, err
 }
 
 type LogFile struct {
@@ -52,13 +62,13 @@ type LogFile struct {
 	writerPlain                            *bufio.Writer
 	writerPcap                             *pcapgo.Writer
 	writerDnstap                           *framestream.Encoder
+	writerParquet                          *parquet.GenericWriter[dnsutils.ParquetDNSMessage]
 	rotationTimer                          *time.Timer
 	rotationInterval                       time.Duration
 	fileFd                                 *os.File
 	fileSize                               int64
 	fileDir, fileName, fileExt, filePrefix string
-	textFormat                             []string
-	jinjaFormat                            string
+	encoder                                dnsutils.Encoder
 	compressQueue                          chan string
 	commandQueue                           chan string
 	queueWg                                sync.WaitGroup
@@ -98,16 +108,9 @@ func (w *LogFile) ReadConfig() {
 	w.fileExt = filepath.Ext(w.fileName)
 	w.filePrefix = strings.TrimSuffix(w.fileName, w.fileExt)
 
-	if len(w.GetConfig().Loggers.LogFile.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.LogFile.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
-	}
-
-	if len(w.GetConfig().Loggers.Stdout.JinjaFormat) > 0 {
-		w.jinjaFormat = w.GetConfig().Loggers.LogFile.JinjaFormat
-	} else {
-		w.jinjaFormat = w.GetConfig().Global.TextJinja
+	if isPlainMode(w.GetConfig().Loggers.LogFile.Mode) {
+		opts := w.GetEncoderOptions(w.GetConfig().Loggers.LogFile.TextFormat, w.GetConfig().Loggers.LogFile.JinjaFormat)
+		w.encoder = w.NewEncoder(w.GetConfig().Loggers.LogFile.Mode, opts)
 	}
 
 	w.LogInfo("running in mode: %s", w.GetConfig().Loggers.LogFile.Mode)
@@ -169,6 +172,15 @@ func (w *LogFile) RemoveOldFiles() error {
 func (w *LogFile) OpenCurrentFile() error {
 	w.LogInfo("create new log file: %s", w.GetConfig().Loggers.LogFile.FilePath)
 
+	// parquet files can not be appended, the existing one is rotated first
+	if w.GetConfig().Loggers.LogFile.Mode == pkgconfig.ModeParquet {
+		if fileinfo, err := os.Stat(w.GetConfig().Loggers.LogFile.FilePath); err == nil && fileinfo.Size() > 0 {
+			if err := w.MoveCurrentFile(); err != nil {
+				return err
+			}
+		}
+	}
+
 	fd, err := os.OpenFile(w.GetConfig().Loggers.LogFile.FilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 	if err != nil {
 		return err
@@ -183,9 +195,6 @@ func (w *LogFile) OpenCurrentFile() error {
 	w.fileSize = fileinfo.Size()
 
 	switch w.GetConfig().Loggers.LogFile.Mode {
-	case pkgconfig.ModeText, pkgconfig.ModeJSON, pkgconfig.ModeFlatJSON:
-		w.writerPlain = bufio.NewWriterSize(fd, w.config.Loggers.LogFile.MaxBatchSize)
-
 	case pkgconfig.ModePCAP:
 		w.writerPcap = pcapgo.NewWriter(fd)
 		if w.fileSize == 0 {
@@ -201,6 +210,15 @@ func (w *LogFile) OpenCurrentFile() error {
 			return err
 		}
 
+	case pkgconfig.ModeParquet:
+		w.writerParquet = parquet.NewGenericWriter[dnsutils.ParquetDNSMessage](
+			&sizeWriter{w: fd, size: &w.fileSize},
+			parquet.Compression(&parquet.Snappy),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+		)
+
+	default:
+		w.writerPlain = bufio.NewWriterSize(fd, w.GetConfig().Loggers.LogFile.MaxBatchSize)
 	}
 
 	w.LogInfo("new log file created")
@@ -217,7 +235,7 @@ func (w *LogFile) compressFile(filename string) {
 	// prepare dest filename
 	baseName := filepath.Base(filename)
 	baseName = strings.TrimPrefix(baseName, "tocompress-")
-	if len(w.config.Loggers.LogFile.PostRotateCommand) > 0 {
+	if len(w.GetConfig().Loggers.LogFile.PostRotateCommand) > 0 {
 		baseName = "toprocess-" + baseName
 	}
 	tmpFile := filename + compressSuffix
@@ -281,7 +299,7 @@ func (w *LogFile) compressFile(filename string) {
 	}
 
 	// run post command on compressed file ?
-	if len(w.config.Loggers.LogFile.PostRotateCommand) > 0 {
+	if len(w.GetConfig().Loggers.LogFile.PostRotateCommand) > 0 {
 		w.queueWg.Add(1)
 		go func() {
 			w.commandQueue <- dstFile
@@ -313,10 +331,25 @@ func (w *LogFile) postRotateCommand(fullPath string) {
 
 func (w *LogFile) FlushWriters() {
 	switch w.GetConfig().Loggers.LogFile.Mode {
-	case pkgconfig.ModeText, pkgconfig.ModeJSON, pkgconfig.ModeFlatJSON:
-		w.writerPlain.Flush()
+	case pkgconfig.ModePCAP:
 	case pkgconfig.ModeDNSTap:
 		w.writerDnstap.Flush()
+	case pkgconfig.ModeParquet:
+		// the row groups are written when they are full or when the file is closed
+	default:
+		w.writerPlain.Flush()
+	}
+}
+
+// CloseWriters writes the trailer of the file if needed
+func (w *LogFile) CloseWriters() {
+	switch w.GetConfig().Loggers.LogFile.Mode {
+	case pkgconfig.ModeDNSTap:
+		w.writerDnstap.Close()
+	case pkgconfig.ModeParquet:
+		if err := w.writerParquet.Close(); err != nil {
+			w.LogError("failed to close parquet writer: %s", err)
+		}
 	}
 }
 
@@ -327,20 +360,40 @@ func (w *LogFile) RotateFile() error {
 	}
 	// close writer and existing file
 	w.FlushWriters()
+	w.CloseWriters()
 
-	if w.GetConfig().Loggers.LogFile.Mode == pkgconfig.ModeDNSTap {
-		w.writerDnstap.Close()
+	if err := w.fileFd.Close(); err != nil {
+		return err
 	}
 
-	if err := w.fileFd.Close(); err != nil {
+	if err := w.MoveCurrentFile(); err != nil {
 		return err
 	}
 
+	// keep only max files
+	err := w.RemoveOldFiles()
+	if err != nil {
+		w.LogError("unable to cleanup log files: %s", err)
+		return err
+	}
+
+	// re-create new one
+	if err := w.OpenCurrentFile(); err != nil {
+		w.LogError("unable to re-create file: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// MoveCurrentFile renames the closed log file with a timestamp and queues it
+// for compression or for the postrotate command
+func (w *LogFile) MoveCurrentFile() error {
 	// Rename current log file
 	newFilename := fmt.Sprintf("%s-%d%s", w.filePrefix, time.Now().UnixNano(), w.fileExt)
-	if w.config.Loggers.LogFile.Compress {
+	if w.GetConfig().Loggers.LogFile.Compress {
 		newFilename = fmt.Sprintf("tocompress-%s", newFilename)
-	} else if len(w.config.Loggers.LogFile.PostRotateCommand) > 0 {
+	} else if len(w.GetConfig().Loggers.LogFile.PostRotateCommand) > 0 {
 		newFilename = fmt.Sprintf("toprocess-%s", newFilename)
 	}
 	bfpath := filepath.Join(w.fileDir, newFilename)
@@ -350,7 +403,7 @@ func (w *LogFile) RotateFile() error {
 	}
 
 	// post rotate command?
-	if w.config.Loggers.LogFile.Compress {
+	if w.GetConfig().Loggers.LogFile.Compress {
 		w.queueWg.Add(1)
 		go func() {
 			w.compressQueue <- bfpath
@@ -362,19 +415,6 @@ func (w *LogFile) RotateFile() error {
 		}()
 	}
 
-	// keep only max files
-	err = w.RemoveOldFiles()
-	if err != nil {
-		w.LogError("unable to cleanup log files: %s", err)
-		return err
-	}
-
-	// re-create new one
-	if err := w.OpenCurrentFile(); err != nil {
-		w.LogError("unable to re-create file: %s", err)
-		return err
-	}
-
 	return nil
 }
 
@@ -447,6 +487,20 @@ func (w *LogFile) WriteToDnstap(data []byte) {
 	w.fileSize += int64(n)
 }
 
+func (w *LogFile) WriteToParquet(dm dnsutils.DNSMessage) {
+	// rotate file ? the size is the one of the row groups already flushed
+	if w.fileSize > w.GetMaxSize() {
+		if err := w.RotateFile(); err != nil {
+			w.LogError("failed to rotate file: %s", err)
+			return
+		}
+	}
+
+	if _, err := w.writerParquet.Write([]dnsutils.ParquetDNSMessage{dm.ToParquet()}); err != nil {
+		w.LogError("failed to write parquet row: %s", err)
+	}
+}
+
 func (w *LogFile) initializeCompressionQueue() {
 	// Get all files in the log directory
 	files, err := os.ReadDir(w.fileDir)
@@ -510,6 +564,10 @@ func (w *LogFile) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -559,13 +617,12 @@ func (w *LogFile) StartLogging() {
 	flushInterval := time.Duration(w.GetConfig().Loggers.LogFile.FlushInterval) * time.Second
 	flushTimer := time.NewTimer(flushInterval)
 
-	buffer := new(bytes.Buffer)
 	var data []byte
 	var err error
 
 	// Max size of a batch before forcing a write
 	batch := new(bytes.Buffer)
-	maxBatchSize := w.config.Loggers.LogFile.MaxBatchSize
+	maxBatchSize := w.GetConfig().Loggers.LogFile.MaxBatchSize
 	accumulatedBatchSize := 0 // Current batch size
 
 	rotationInterval := w.GetConfig().Loggers.LogFile.RotationInterval
@@ -592,9 +649,7 @@ func (w *LogFile) StartLogging() {
 
 			// closing file
 			w.LogInfo("closing log file")
-			if w.GetConfig().Loggers.LogFile.Mode == pkgconfig.ModeDNSTap {
-				w.writerDnstap.Close()
-			}
+			w.CloseWriters()
 			w.fileFd.Close()
 
 			/* wait until queues are processed */
@@ -613,41 +668,8 @@ func (w *LogFile) StartLogging() {
 			}
 
 			// Process the message based on the configured mode
-			var message []byte
 			switch w.GetConfig().Loggers.LogFile.Mode {
 
-			// with basic text mode
-			case pkgconfig.ModeText:
-				message = dm.Bytes(w.textFormat, w.GetConfig().Global.TextFormatDelimiter, w.GetConfig().Global.TextFormatBoundary)
-				batch.Write(message)
-				batch.WriteString("\n")
-
-			// with custom text mode
-			case pkgconfig.ModeJinja:
-				textLine, err := dm.ToTextTemplate(w.jinjaFormat)
-				if err != nil {
-					w.LogError("jinja template: %s", err)
-					continue
-				}
-				batch.Write([]byte(textLine))
-
-			// with json mode
-			case pkgconfig.ModeFlatJSON:
-				flat, err := dm.Flatten()
-				if err != nil {
-					w.LogError("flattening DNS message failed: %e", err)
-					continue
-				}
-				json.NewEncoder(buffer).Encode(flat)
-				w.WriteToPlain(buffer.Bytes())
-				buffer.Reset()
-
-			// with json mode
-			case pkgconfig.ModeJSON:
-				json.NewEncoder(buffer).Encode(dm)
-				batch.Write(buffer.Bytes())
-				buffer.Reset()
-
 			// with dnstap mode
 			case pkgconfig.ModeDNSTap:
 				data, err = dm.ToDNSTap(w.GetConfig().Loggers.LogFile.ExtendedSupport)
@@ -667,6 +689,27 @@ func (w *LogFile) StartLogging() {
 
 				// write the packet
 				w.WriteToPcap(dm, pkt)
+
+			// with parquet mode
+			case pkgconfig.ModeParquet:
+				w.WriteToParquet(dm)
+
+			// with the encoder of the mode, binary messages are prefixed with their length
+			default:
+				data, err = w.encoder.Encode(&dm)
+				if err != nil {
+					w.LogError("failed to encode: %s", err)
+					continue
+				}
+				if w.encoder.IsBinary() {
+					binary.Write(batch, binary.BigEndian, uint32(len(data)))
+					batch.Write(data)
+				} else {
+					batch.Write(data)
+					if !bytes.HasSuffix(data, []byte("\n")) {
+						batch.WriteString("\n")
+					}
+				}
 			}
 
 			// Update the batch size
@@ -690,8 +733,7 @@ func (w *LogFile) StartLogging() {
 			// flush writer
 			w.FlushWriters()
 
-			// reset flush timer and buffer
-			buffer.Reset()
+			// reset flush timer
 			flushTimer.Reset(flushInterval)
 
 		case <-w.rotationTimer.C:
@@ -17,6 +17,7 @@ import (
 	"github.com/dmachard/go-logger"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
+	"github.com/parquet-go/parquet-go"
 )
 
 func Test_LogFileText(t *testing.T) {
@@ -136,6 +137,76 @@ func Test_LogFileWrite_PcapMode(t *testing.T) {
 	}
 }
 
+func Test_LogFileWrite_ParquetMode(t *testing.T) {
+	fileDir := t.TempDir()
+	filePath := filepath.Join(fileDir, "dnscollector.parquet")
+
+	// config
+	config := pkgconfig.GetDefaultConfig()
+	config.Loggers.LogFile.FilePath = filePath
+	config.Loggers.LogFile.Mode = pkgconfig.ModeParquet
+
+	// run the logger twice, the existing file must be rotated and not appended
+	for run := 0; run < 2; run++ {
+		g := NewLogFile(config, logger.New(false), "test")
+		go g.StartCollect()
+
+		// the flush interval must not write a row group per tick
+		dm := dnsutils.GetFakeDNSMessage()
+		dm.Suspicious = &dnsutils.TransformSuspicious{Score: 1.5, LongDomain: true}
+		g.GetInputChannel() <- dm
+		time.Sleep(1500 * time.Millisecond)
+		g.GetInputChannel() <- dnsutils.GetFakeDNSMessage()
+
+		time.Sleep(time.Second)
+		g.Stop()
+	}
+
+	// read the current parquet file and check content
+	rows, err := parquet.ReadFile[dnsutils.ParquetDNSMessage](filePath)
+	if err != nil {
+		t.Fatalf("unable to read parquet file: %s", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].DNS.Qname != pkgconfig.ProgQname || rows[0].Network.QueryIP != "1.2.3.4" {
+		t.Errorf("invalid row: %v", rows[0])
+	}
+	if rows[0].Suspicious == nil || rows[0].Suspicious.Score != 1.5 || !rows[0].Suspicious.LongDomain {
+		t.Errorf("invalid suspicious section: %v", rows[0].Suspicious)
+	}
+	if rows[1].Suspicious != nil {
+		t.Errorf("suspicious section should be null: %v", rows[1].Suspicious)
+	}
+	fd, err := os.Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	stat, _ := fd.Stat()
+	pf, err := parquet.OpenFile(fd, stat.Size())
+	if err != nil {
+		t.Fatalf("unable to open parquet file: %s", err)
+	}
+	if len(pf.RowGroups()) != 1 {
+		t.Errorf("expected one row group, got %d", len(pf.RowGroups()))
+	}
+
+	// the file of the first run must be rotated and still readable
+	logFiles, err := getLogFiles(fileDir, "dnscollector-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logFiles["parquet"]) != 1 {
+		t.Fatalf("expected one rotated file, got %v", logFiles)
+	}
+	rows, err = parquet.ReadFile[dnsutils.ParquetDNSMessage](filepath.Join(fileDir, logFiles["parquet"][0]))
+	if err != nil || len(rows) != 2 {
+		t.Errorf("invalid rotated parquet file: %d rows, %v", len(rows), err)
+	}
+}
+
 func removeLogFiles(fileDir string, filePattern string) error {
 	entries, err := os.ReadDir(fileDir)
 
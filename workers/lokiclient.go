@@ -4,7 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-dnscollector/transformers"
 	"github.com/dmachard/go-logger"
@@ -67,7 +68,7 @@ func (w *LokiStream) Encode2Proto() ([]byte, error) {
 type LokiClient struct {
 	*GenericWorker
 	httpclient *http.Client
-	textFormat []string
+	encoder    dnsutils.Encoder
 	streams    map[string]*LokiStream
 }
 
@@ -79,14 +80,14 @@ func NewLokiClient(config *pkgconfig.Config, logger *logger.Logger, name string)
 	w := &LokiClient{GenericWorker: NewGenericWorker(config, logger, name, "loki", bufSize, pkgconfig.DefaultMonitor)}
 	w.streams = make(map[string]*LokiStream)
 	w.ReadConfig()
+	w.InitSpool(config.Loggers.LokiClient.Spool)
 	return w
 }
 
 func (w *LokiClient) ReadConfig() {
-	if len(w.GetConfig().Loggers.LokiClient.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.LokiClient.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
+	w.encoder = w.NewEncoder(w.GetConfig().Loggers.LokiClient.Mode, w.GetEncoderOptions(w.GetConfig().Loggers.LokiClient.TextFormat, ""))
+	if w.encoder.IsBinary() {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] loki - binary mode not supported: " + w.GetConfig().Loggers.LokiClient.Mode)
 	}
 
 	// tls client config
@@ -148,6 +149,10 @@ func (w *LokiClient) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -192,7 +197,6 @@ func (w *LokiClient) StartLogging() {
 	defer w.LoggingDone()
 
 	// prepare buffer
-	buffer := new(bytes.Buffer)
 	var byteBuffer []byte
 
 	// prepare timers
@@ -202,6 +206,7 @@ func (w *LokiClient) StartLogging() {
 	for {
 		select {
 		case <-w.OnLoggerStopped():
+			w.CloseSpool()
 			return
 
 		// incoming dns message to process
@@ -215,14 +220,8 @@ func (w *LokiClient) StartLogging() {
 				labels.Label{Name: "identity", Value: dm.DNSTap.Identity},
 				labels.Label{Name: "job", Value: w.GetConfig().Loggers.LokiClient.JobName},
 			}
-			var err error
-			var flat map[string]interface{}
 			if len(w.GetConfig().Loggers.LokiClient.RelabelConfigs) > 0 {
-				// Save flattened JSON in case it's used when populating the message of the log entry.
-				// There is more room for improvement for reusing data though. Flatten() internally
-				// does a JSON encode of the DnsMessage, but it's not saved to use when the mode
-				// is JSON.
-				flat, err = dm.Flatten()
+				flat, err := dm.Flatten()
 				if err != nil {
 					w.LogError("flattening DNS message failed: %e", err)
 				}
@@ -255,26 +254,12 @@ func (w *LokiClient) StartLogging() {
 			entry := logproto.Entry{}
 			entry.Timestamp = time.Unix(int64(dm.DNSTap.TimeSec), int64(dm.DNSTap.TimeNsec))
 
-			switch w.GetConfig().Loggers.LokiClient.Mode {
-			case pkgconfig.ModeText:
-				entry.Line = string(dm.Bytes(w.textFormat,
-					w.GetConfig().Global.TextFormatDelimiter,
-					w.GetConfig().Global.TextFormatBoundary))
-			case pkgconfig.ModeJSON:
-				json.NewEncoder(buffer).Encode(dm)
-				entry.Line = buffer.String()
-				buffer.Reset()
-			case pkgconfig.ModeFlatJSON:
-				if len(flat) == 0 {
-					flat, err = dm.Flatten()
-					if err != nil {
-						w.LogError("flattening DNS message failed: %e", err)
-					}
-				}
-				json.NewEncoder(buffer).Encode(flat)
-				entry.Line = buffer.String()
-				buffer.Reset()
+			line, err := w.encoder.Encode(&dm)
+			if err != nil {
+				w.LogError("encoding DNS message failed: %s", err)
+				continue
 			}
+			entry.Line = string(line)
 			key := string(lbls.Bytes(byteBuffer))
 			ls, ok := w.streams[key]
 			if !ok {
@@ -299,7 +284,7 @@ func (w *LokiClient) StartLogging() {
 				}
 
 				// send all entries
-				w.SendEntries(buf)
+				w.PushEntries(buf)
 
 				// reset entries and push request
 				ls.ResetEntries()
@@ -321,7 +306,7 @@ func (w *LokiClient) StartLogging() {
 					}
 
 					// send all entries
-					w.SendEntries(buf)
+					w.PushEntries(buf)
 
 					// reset entries and push request
 					s.ResetEntries()
@@ -334,7 +319,62 @@ func (w *LokiClient) StartLogging() {
 	}
 }
 
-func (w *LokiClient) SendEntries(buf []byte) {
+// LokiHTTPError is returned when loki rejects the push request
+type LokiHTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *LokiHTTPError) Error() string {
+	return fmt.Sprintf("server returned HTTP status %d: %s", e.StatusCode, e.Message)
+}
+
+// Permanent returns true if the request is invalid, like the entries out of order
+// or too old, sending it again would be rejected in the same way
+func (e *LokiHTTPError) Permanent() bool {
+	return e.StatusCode == http.StatusBadRequest
+}
+
+func isLokiPermanentError(err error) bool {
+	var httpErr *LokiHTTPError
+	return errors.As(err, &httpErr) && httpErr.Permanent()
+}
+
+// PushEntries sends the push request after the spooled ones, loki rejects the entries
+// older than the last received so the order must be kept. The request is spooled
+// on failure or if the spool can't be emptied.
+func (w *LokiClient) PushEntries(buf []byte) {
+	if err := w.ReplaySpool(w.ReplayEntries); err != nil {
+		w.LogError("spool - replay interrupted: %s", err)
+		if w.SpoolData(buf) {
+			w.LogWarning("push request spooled after the pending ones")
+		}
+		return
+	}
+
+	if err := w.SendEntries(buf); err != nil {
+		if isLokiPermanentError(err) {
+			w.LogError("push request rejected, entries dropped: %s", err)
+			return
+		}
+		if w.SpoolData(buf) {
+			w.LogWarning("push request spooled: %s", err)
+		}
+	}
+}
+
+// ReplayEntries sends a spooled push request, the requests rejected by loki are
+// dropped with an error so they don't block the spool
+func (w *LokiClient) ReplayEntries(buf []byte) error {
+	err := w.SendEntries(buf)
+	if isLokiPermanentError(err) {
+		w.LogError("spool - push request rejected, entries dropped: %s", err)
+		return nil
+	}
+	return err
+}
+
+func (w *LokiClient) SendEntries(buf []byte) error {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -354,7 +394,7 @@ func (w *LokiClient) SendEntries(buf []byte) {
 		post, err := http.NewRequest("POST", w.GetConfig().Loggers.LokiClient.ServerURL, bytes.NewReader(buf))
 		if err != nil {
 			w.LogError("new http error: %s", err)
-			return
+			return err
 		}
 		post = post.WithContext(ctx)
 		post.Header.Set("Content-Type", "application/x-protobuf")
@@ -372,22 +412,27 @@ func (w *LokiClient) SendEntries(buf []byte) {
 		resp, err := w.httpclient.Do(post)
 		if err != nil {
 			w.LogError("do http error: %s", err)
-			return
+			return err
 		}
 
 		// success ?
-		if resp.StatusCode > 0 && resp.StatusCode != 429 && resp.StatusCode/100 != 5 {
-			break
+		if resp.StatusCode/100 == 2 {
+			resp.Body.Close()
+			return nil
 		}
 
 		// something is wrong, retry ?
-		if resp.StatusCode/100 != 2 {
-			scanner := bufio.NewScanner(io.LimitReader(resp.Body, 1024))
-			line := ""
-			if scanner.Scan() {
-				line = scanner.Text()
-			}
-			w.LogError("server returned HTTP status %s (%d): %s", resp.Status, resp.StatusCode, line)
+		scanner := bufio.NewScanner(io.LimitReader(resp.Body, 1024))
+		line := ""
+		if scanner.Scan() {
+			line = scanner.Text()
+		}
+		resp.Body.Close()
+		w.LogError("server returned HTTP status %s (%d): %s", resp.Status, resp.StatusCode, line)
+
+		// the client errors are not retried, except too many requests
+		if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+			return &LokiHTTPError{StatusCode: resp.StatusCode, Message: line}
 		}
 
 		// wait before retry
@@ -395,7 +440,7 @@ func (w *LokiClient) SendEntries(buf []byte) {
 
 		// Make sure it sends at least once before checking for retry.
 		if !backoff.Ongoing() {
-			break
+			return fmt.Errorf("max retries reached: %w", backoff.Err())
 		}
 	}
 }
@@ -6,7 +6,9 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
@@ -195,3 +197,44 @@ func Test_LokiClientRelabel(t *testing.T) {
 		}
 	}
 }
+
+func Test_LokiClientSpoolOrder(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		// entries too old
+		if strings.HasPrefix(string(body), "rejected") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.LokiClient.ServerURL = server.URL + "/loki/api/v1/push"
+	cfg.Loggers.LokiClient.Spool = getSpoolConfigForTest(t)
+	g := NewLokiClient(cfg, logger.New(false), "test")
+
+	// the spooled requests are sent before the new one,
+	// the rejected ones are not kept in the spool
+	g.SpoolData([]byte("spooled"))
+	g.SpoolData([]byte("rejected-spooled"))
+	g.PushEntries([]byte("new"))
+
+	expected := []string{"spooled", "rejected-spooled", "new"}
+	if strings.Join(received, ",") != strings.Join(expected, ",") {
+		t.Errorf("invalid order, want %v, got %v", expected, received)
+	}
+	if g.SpoolPending() {
+		t.Errorf("spool must be empty")
+	}
+
+	// the request rejected is not retried and not spooled
+	received = nil
+	g.PushEntries([]byte("rejected"))
+	if len(received) != 1 || g.SpoolPending() {
+		t.Errorf("rejected request must be dropped, %d request(s) sent", len(received))
+	}
+}
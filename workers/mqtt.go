@@ -1,8 +1,6 @@
 package workers
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
@@ -22,7 +20,7 @@ const (
 
 type MQTT struct {
 	*GenericWorker
-	textFormat               []string
+	encoder                  dnsutils.Encoder
 	mqttClient               mqtt.Client
 	mqttReady, mqttReconnect chan bool
 	writerReady              bool
@@ -45,11 +43,7 @@ func NewMQTT(config *pkgconfig.Config, logger *logger.Logger, name string) *MQTT
 }
 
 func (w *MQTT) ReadConfig() {
-	if len(w.GetConfig().Loggers.MQTT.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.MQTT.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
-	}
+	w.encoder = w.NewEncoder(w.GetConfig().Loggers.MQTT.Mode, w.GetEncoderOptions(w.GetConfig().Loggers.MQTT.TextFormat, ""))
 
 	if !netutils.IsValidTLS(w.GetConfig().Loggers.MQTT.TLSMinVersion) {
 		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "]mqtt - invalid tls min version")
@@ -195,26 +189,11 @@ func (w *MQTT) ConnectToMQTT() {
 }
 
 func (w *MQTT) FlushBuffer(buf *[]dnsutils.DNSMessage) {
-	buffer := new(bytes.Buffer)
-
 	for _, dm := range *buf {
-		buffer.Reset()
-
-		var payload string
-		switch w.GetConfig().Loggers.MQTT.Mode {
-		case pkgconfig.ModeText:
-			payload = dm.String(w.textFormat, w.GetConfig().Global.TextFormatDelimiter, w.GetConfig().Global.TextFormatBoundary)
-		case pkgconfig.ModeJSON:
-			json.NewEncoder(buffer).Encode(dm)
-			payload = buffer.String()
-		case pkgconfig.ModeFlatJSON:
-			flat, err := dm.Flatten()
-			if err != nil {
-				w.LogError("flattening DNS message failed: %e", err)
-				continue
-			}
-			json.NewEncoder(buffer).Encode(flat)
-			payload = buffer.String()
+		payload, err := w.encoder.Encode(&dm)
+		if err != nil {
+			w.LogError("encoding DNS message failed: %s", err)
+			continue
 		}
 
 		token := w.mqttClient.Publish(
@@ -255,6 +234,10 @@ func (w *MQTT) StartCollect() {
 
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -164,7 +164,12 @@ func TestMQTT_V5_ProtocolFeatures(t *testing.T) {
 					}
 
 				case pkgconfig.ModeText:
-					payload = dm.String(mqttWorker.textFormat, config.Global.TextFormatDelimiter, config.Global.TextFormatBoundary)
+					data, err := mqttWorker.encoder.Encode(&dm)
+					if err != nil {
+						t.Errorf("Text encoding failed: %v", err)
+						continue
+					}
+					payload = string(data)
 					if len(payload) == 0 {
 						t.Errorf("Empty text payload")
 					}
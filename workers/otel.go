@@ -2,16 +2,18 @@ package workers
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
@@ -24,9 +26,25 @@ type trackedSpan struct {
 	startTime time.Time
 }
 
+// otlpExportJob is a batch of log records or metrics waiting to be exported
+type otlpExportJob struct {
+	signal string
+	export func(ctx context.Context) error
+}
+
 type OpenTelemetryClient struct {
 	*GenericWorker
 	tracerProviders map[string]*sdktrace.TracerProvider
+	exporter        OtlpExporter
+	encoder         dnsutils.Encoder
+	logsBatch       *OtlpLogsBatch
+	metrics         *OtlpMetricsAggregator
+	exportQueue     chan *otlpExportJob
+	exportStop      chan struct{}
+	newEncoder      chan dnsutils.Encoder
+	newExporter     chan OtlpExporter
+	ctx             context.Context
+	cancel          context.CancelFunc
 }
 
 func NewOpenTelemetryClient(config *pkgconfig.Config, console *logger.Logger, name string) *OpenTelemetryClient {
@@ -38,36 +56,298 @@ func NewOpenTelemetryClient(config *pkgconfig.Config, console *logger.Logger, na
 	w := &OpenTelemetryClient{
 		GenericWorker:   NewGenericWorker(config, console, name, "opentelemetry", bufSize, pkgconfig.DefaultMonitor),
 		tracerProviders: make(map[string]*sdktrace.TracerProvider),
+		logsBatch:       NewOtlpLogsBatch(),
+		metrics:         NewOtlpMetricsAggregator(config.Loggers.OpenTelemetryClient.MetricsPrefix),
+		exportQueue:     make(chan *otlpExportJob, config.Loggers.OpenTelemetryClient.ExportQueueSize),
+		exportStop:      make(chan struct{}),
+		newEncoder:      make(chan dnsutils.Encoder, 1),
+		newExporter:     make(chan OtlpExporter, 1),
 	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	w.CheckConfig()
+	w.encoder = w.NewLogEncoder()
+	w.exporter = w.NewExporter()
 	return w
 }
 
-func (w *OpenTelemetryClient) initTracerProvider(serviceName string) *sdktrace.TracerProvider {
-	exporter, err := otlptrace.New(context.Background(), otlptracegrpc.NewClient(
-		otlptracegrpc.WithEndpoint(w.config.Loggers.OpenTelemetryClient.OtelEndpoint),
-		otlptracegrpc.WithInsecure(),
-	))
+func (w *OpenTelemetryClient) CheckConfig() {
+	cfg := w.GetConfig().Loggers.OpenTelemetryClient
+	if cfg.Protocol != pkgconfig.OtlpProtocolGRPC && cfg.Protocol != pkgconfig.OtlpProtocolHTTP {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] opentelemetry - invalid protocol, grpc or http expected")
+	}
+	if cfg.ExportQueueSize < 1 {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] opentelemetry - invalid export queue size, at least 1 expected")
+	}
+	if _, err := OtlpTLSConfig(w.GetConfig()); err != nil {
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] opentelemetry - invalid tls config: ", err)
+	}
+}
+
+// ReadConfig is called on reload, the encoder is replaced by the logging goroutine
+// and the exporter by the export goroutine, between two exports
+func (w *OpenTelemetryClient) ReadConfig() {
+	w.CheckConfig()
+
+	select {
+	case <-w.newEncoder:
+	default:
+	}
+	w.newEncoder <- w.NewLogEncoder()
+
+	select {
+	case exporter := <-w.newExporter:
+		if exporter != nil {
+			exporter.Close()
+		}
+	default:
+	}
+	w.newExporter <- w.NewExporter()
+}
+
+// NewLogEncoder returns the encoder of the log records, the body is the text line of the message
+func (w *OpenTelemetryClient) NewLogEncoder() dnsutils.Encoder {
+	return w.NewEncoder(pkgconfig.ModeText, w.GetEncoderOptions("", ""))
+}
+
+// NewExporter returns the exporter of the logs and metrics, sent with our own exporter
+// to control batching and retries, nil if both are disabled
+func (w *OpenTelemetryClient) NewExporter() OtlpExporter {
+	cfg := w.GetConfig().Loggers.OpenTelemetryClient
+	if !cfg.LogsEnabled && !cfg.MetricsEnabled {
+		return nil
+	}
+	exporter, err := NewOtlpExporter(w.GetConfig())
 	if err != nil {
-		log.Fatalf("failed to create OTLP exporter: %v", err)
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] opentelemetry - unable to create exporter: ", err)
+	}
+	return exporter
+}
+
+// setExporter closes the previous exporter, it's called by the export goroutine
+func (w *OpenTelemetryClient) setExporter(exporter OtlpExporter) {
+	if w.exporter != nil {
+		w.exporter.Close()
 	}
+	w.exporter = exporter
+}
 
+func (w *OpenTelemetryClient) newTraceExporter() (*otlptrace.Exporter, error) {
+	cfg := w.GetConfig().Loggers.OpenTelemetryClient
+	tlsConfig, err := OtlpTLSConfig(w.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	retryInterval := time.Duration(cfg.RetryInterval) * time.Second
+
+	if cfg.Protocol == pkgconfig.OtlpProtocolHTTP {
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.OtelEndpoint),
+			otlptracehttp.WithHeaders(OtlpHeaders(w.GetConfig())),
+			otlptracehttp.WithTimeout(timeout),
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
+				Enabled:         cfg.MaxRetries > 0,
+				InitialInterval: retryInterval,
+				MaxInterval:     retryInterval,
+				MaxElapsedTime:  retryInterval * time.Duration(cfg.MaxRetries),
+			}),
+		}
+		if tlsConfig != nil {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+		} else {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptrace.New(w.ctx, otlptracehttp.NewClient(opts...))
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.OtelEndpoint),
+		otlptracegrpc.WithHeaders(OtlpHeaders(w.GetConfig())),
+		otlptracegrpc.WithTimeout(timeout),
+		otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+			Enabled:         cfg.MaxRetries > 0,
+			InitialInterval: retryInterval,
+			MaxInterval:     retryInterval,
+			MaxElapsedTime:  retryInterval * time.Duration(cfg.MaxRetries),
+		}),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	return otlptrace.New(w.ctx, otlptracegrpc.NewClient(opts...))
+}
+
+func (w *OpenTelemetryClient) initTracerProvider(serviceName string) (*sdktrace.TracerProvider, error) {
+	exporter, err := w.newTraceExporter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	cfg := w.GetConfig().Loggers.OpenTelemetryClient
 	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxExportBatchSize(cfg.BatchSize),
+			sdktrace.WithBatchTimeout(time.Duration(cfg.FlushInterval)*time.Second),
+		),
 		sdktrace.WithResource(resource.NewSchemaless(
 			attribute.String("service.name", serviceName),
 		)),
 	)
-	return tracerProvider
+	return tracerProvider, nil
 }
 
-func (w *OpenTelemetryClient) getTracer(serviceName string) trace.Tracer {
+func (w *OpenTelemetryClient) getTracer(serviceName string) (trace.Tracer, error) {
 	if tp, exists := w.tracerProviders[serviceName]; exists {
-		return tp.Tracer("")
+		return tp.Tracer(""), nil
 	}
 
-	tp := w.initTracerProvider(serviceName)
+	tp, err := w.initTracerProvider(serviceName)
+	if err != nil {
+		return nil, err
+	}
 	w.tracerProviders[serviceName] = tp
-	return tp.Tracer("")
+	return tp.Tracer(""), nil
+}
+
+// exportWithRetry retries the export on transient errors only,
+// the retries are interrupted when the worker is stopped
+func (w *OpenTelemetryClient) exportWithRetry(signal string, maxRetries int, export func(ctx context.Context) error) {
+	cfg := w.GetConfig().Loggers.OpenTelemetryClient
+
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			w.LogWarning("%s export failed, retry %d/%d in %ds: %v", signal, attempt, maxRetries, cfg.RetryInterval, err)
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-w.exportStop:
+				w.LogError("%s export failed, worker stopped: %v", signal, err)
+				return
+			case <-time.After(time.Duration(cfg.RetryInterval) * time.Second):
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(w.ctx, time.Duration(cfg.Timeout)*time.Second)
+		err = export(ctx)
+		cancel()
+		if err == nil {
+			return
+		}
+		if !IsOtlpRetryable(err) {
+			break
+		}
+	}
+	w.LogError("%s export failed: %v", signal, err)
+}
+
+// logsJob takes the pending log records, nil if there is nothing to export
+func (w *OpenTelemetryClient) logsJob() *otlpExportJob {
+	if w.logsBatch.Len() == 0 {
+		return nil
+	}
+	req := w.logsBatch.Request()
+	w.logsBatch.Reset()
+	return &otlpExportJob{signal: "logs", export: func(ctx context.Context) error {
+		return w.exporter.ExportLogs(ctx, req)
+	}}
+}
+
+// metricsJob takes the current value of the counters, nil if there is nothing to export
+func (w *OpenTelemetryClient) metricsJob() *otlpExportJob {
+	req := w.metrics.Request(time.Now())
+	if len(req.ResourceMetrics) == 0 {
+		return nil
+	}
+	return &otlpExportJob{signal: "metrics", export: func(ctx context.Context) error {
+		return w.exporter.ExportMetrics(ctx, req)
+	}}
+}
+
+// FlushLogs exports the pending log records and waits for the result
+func (w *OpenTelemetryClient) FlushLogs(maxRetries int) {
+	if w.exporter == nil {
+		return
+	}
+	if job := w.logsJob(); job != nil {
+		w.exportWithRetry(job.signal, maxRetries, job.export)
+	}
+}
+
+// FlushMetrics exports the counters and waits for the result
+func (w *OpenTelemetryClient) FlushMetrics(maxRetries int) {
+	if w.exporter == nil {
+		return
+	}
+	if job := w.metricsJob(); job != nil {
+		w.exportWithRetry(job.signal, maxRetries, job.export)
+	}
+}
+
+// queueExport hands the job to the export goroutine, the job is dropped if the queue is full
+func (w *OpenTelemetryClient) queueExport(job *otlpExportJob) {
+	if job == nil {
+		return
+	}
+	select {
+	case w.exportQueue <- job:
+	default:
+		w.LogWarning("export queue is full, %s batch dropped", job.signal)
+	}
+}
+
+// runExports exports the queued jobs until the worker is stopped, so a slow or
+// unavailable receiver doesn't block the processing of the messages
+func (w *OpenTelemetryClient) runExports(done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-w.exportStop:
+			// the last exports are done with the exporter of the current config
+			select {
+			case exporter := <-w.newExporter:
+				w.setExporter(exporter)
+			default:
+			}
+			return
+		case exporter := <-w.newExporter:
+			w.setExporter(exporter)
+		case job := <-w.exportQueue:
+			if w.exporter == nil {
+				w.LogWarning("no exporter, %s batch dropped", job.signal)
+				continue
+			}
+			w.exportWithRetry(job.signal, w.GetConfig().Loggers.OpenTelemetryClient.MaxRetries, job.export)
+		}
+	}
+}
+
+// the exporter is owned by the export goroutine, the config is used to know the enabled signals
+func (w *OpenTelemetryClient) logsEnabled() bool {
+	return w.GetConfig().Loggers.OpenTelemetryClient.LogsEnabled
+}
+
+func (w *OpenTelemetryClient) metricsEnabled() bool {
+	return w.GetConfig().Loggers.OpenTelemetryClient.MetricsEnabled
+}
+
+func (w *OpenTelemetryClient) RecordLog(dm *dnsutils.DNSMessage) {
+	body, err := w.encoder.Encode(dm)
+	if err != nil {
+		w.LogError("unable to encode the log body: %v", err)
+		return
+	}
+	record, err := OtlpLogRecord(dm, body)
+	if err != nil {
+		w.LogError("unable to convert to log record: %v", err)
+		return
+	}
+	w.logsBatch.Add(dm.DNSTap.Identity, record)
+	if w.logsBatch.Len() >= w.GetConfig().Loggers.OpenTelemetryClient.BatchSize {
+		w.queueExport(w.logsJob())
+	}
 }
 
 func (w *OpenTelemetryClient) StartCollect() {
@@ -86,9 +366,24 @@ func (w *OpenTelemetryClient) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
+			w.cancel()
+			for _, tp := range w.tracerProviders {
+				tp.Shutdown(context.Background())
+			}
+			w.setExporter(nil)
+			select {
+			case exporter := <-w.newExporter:
+				if exporter != nil {
+					exporter.Close()
+				}
+			default:
+			}
 			return
 
 			// new config provided?
@@ -130,18 +425,69 @@ func (w *OpenTelemetryClient) StartLogging() {
 	// prepare next channels
 	defaultRoutes, defaultNames := GetRoutes(w.GetDefaultRoutes())
 
+	cfg := w.GetConfig().Loggers.OpenTelemetryClient
+
 	// Maps to follow the state of the spans
 	requestorSpans := sync.Map{}
 	messageSpans := sync.Map{}
 	resolverSpans := sync.Map{}
 
-	go w.cleanupSpans(&requestorSpans, &messageSpans, &resolverSpans, time.Duration(w.config.Loggers.OpenTelemetryClient.MaxSpanTime)*time.Second)
+	if cfg.TracesEnabled {
+		go w.cleanupSpans(&requestorSpans, &messageSpans, &resolverSpans, time.Duration(cfg.MaxSpanTime)*time.Second)
+	}
+
+	// the batches are exported in background
+	exportDone := make(chan struct{})
+	go w.runExports(exportDone)
+
+	// timers to flush the log records and to export the counters,
+	// the intervals are read again on each tick to follow the reloads
+	flushTimer := time.NewTicker(time.Duration(cfg.FlushInterval) * time.Second)
+	defer flushTimer.Stop()
+	metricsTimer := time.NewTicker(time.Duration(cfg.MetricsInterval) * time.Second)
+	defer metricsTimer.Stop()
 
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+
+		case encoder := <-w.newEncoder:
+			w.encoder = encoder
+			// the tracer providers are created again with the new settings of the collector
+			for serviceName, tp := range w.tracerProviders {
+				tp.Shutdown(context.Background())
+				delete(w.tracerProviders, serviceName)
+			}
+
 		case <-w.OnLoggerStopped():
+			close(w.exportStop)
+			<-exportDone
+			if pending := len(w.exportQueue); pending > 0 {
+				w.LogWarning("%d batches not exported", pending)
+			}
+
+			// last export, without retry
+			if w.logsEnabled() {
+				w.FlushLogs(0)
+			}
+			if w.metricsEnabled() {
+				w.FlushMetrics(0)
+			}
 			return
 
+		case <-flushTimer.C:
+			if w.logsEnabled() {
+				w.queueExport(w.logsJob())
+			}
+			flushTimer.Reset(time.Duration(w.GetConfig().Loggers.OpenTelemetryClient.FlushInterval) * time.Second)
+
+		case <-metricsTimer.C:
+			if w.metricsEnabled() {
+				w.queueExport(w.metricsJob())
+			}
+			metricsTimer.Reset(time.Duration(w.GetConfig().Loggers.OpenTelemetryClient.MetricsInterval) * time.Second)
+
 		// incoming dns message to process
 		case dm, opened := <-w.GetOutputChannel():
 			if !opened {
@@ -149,25 +495,17 @@ func (w *OpenTelemetryClient) StartLogging() {
 				return
 			}
 
-			timestamp, err := time.Parse(time.RFC3339, dm.DNSTap.TimestampRFC3339)
-			if err != nil {
-				w.LogWarning("invalid timestamp: %v", err)
-				continue
+			if w.metricsEnabled() {
+				w.metrics.Record(&dm)
 			}
-			tracer := w.getTracer(dm.DNSTap.Identity)
-
-			// ini opentelemetry with default values
-			dm.OpenTelemetry = &dnsutils.LoggerOpenTelemetry{}
-
-			switch dm.DNSTap.Operation {
-			case "CLIENT_QUERY":
-				w.handleClientQuery(&requestorSpans, &messageSpans, tracer, &dm, timestamp)
-			case "CLIENT_RESPONSE":
-				w.handleClientResponse(&requestorSpans, &messageSpans, &dm, timestamp)
-			case "RESOLVER_QUERY":
-				w.handleResolverQuery(&messageSpans, &resolverSpans, tracer, &dm, timestamp)
-			case "RESOLVER_RESPONSE":
-				w.handleResolverResponse(&resolverSpans, &dm, timestamp)
+
+			// spans are built from the powerdns metadata
+			if cfg.TracesEnabled && dm.PowerDNS != nil {
+				w.RecordTrace(&dm, &requestorSpans, &messageSpans, &resolverSpans)
+			}
+
+			if w.logsEnabled() {
+				w.RecordLog(&dm)
 			}
 
 			// send to next ?
@@ -176,6 +514,33 @@ func (w *OpenTelemetryClient) StartLogging() {
 	}
 }
 
+func (w *OpenTelemetryClient) RecordTrace(dm *dnsutils.DNSMessage, requestorSpans, messageSpans, resolverSpans *sync.Map) {
+	timestamp, err := time.Parse(time.RFC3339, dm.DNSTap.TimestampRFC3339)
+	if err != nil {
+		w.LogWarning("invalid timestamp: %v", err)
+		return
+	}
+	tracer, err := w.getTracer(dm.DNSTap.Identity)
+	if err != nil {
+		w.LogError("%v", err)
+		return
+	}
+
+	// ini opentelemetry with default values
+	dm.OpenTelemetry = &dnsutils.LoggerOpenTelemetry{}
+
+	switch dm.DNSTap.Operation {
+	case "CLIENT_QUERY":
+		w.handleClientQuery(requestorSpans, messageSpans, tracer, dm, timestamp)
+	case "CLIENT_RESPONSE":
+		w.handleClientResponse(requestorSpans, messageSpans, dm, timestamp)
+	case "RESOLVER_QUERY":
+		w.handleResolverQuery(messageSpans, resolverSpans, tracer, dm, timestamp)
+	case "RESOLVER_RESPONSE":
+		w.handleResolverResponse(resolverSpans, dm, timestamp)
+	}
+}
+
 func (w *OpenTelemetryClient) handleClientQuery(requestorSpans, messageSpans *sync.Map, tracer trace.Tracer, dm *dnsutils.DNSMessage, timestamp time.Time) {
 	if parentSpan, ok := requestorSpans.Load(dm.PowerDNS.RequestorID); ok {
 		_, childSpan := tracer.Start(trace.ContextWithSpan(context.Background(), parentSpan.(trackedSpan).span), "Client Query "+dm.NetworkInfo.ResponseIP+" ("+dm.DNS.Qname+" / "+dm.DNS.Qtype+" )", trace.WithTimestamp(timestamp))
@@ -260,7 +625,7 @@ func (w *OpenTelemetryClient) handleResolverResponse(resolverSpans *sync.Map, dm
 }
 
 func (w *OpenTelemetryClient) cleanupSpans(requestorSpans, messageSpans, resolverSpans *sync.Map, maxSpanDuration time.Duration) {
-	ticker := time.NewTicker(time.Duration(w.config.Loggers.OpenTelemetryClient.CleanupSpansInterval) * time.Second)
+	ticker := time.NewTicker(time.Duration(w.GetConfig().Loggers.OpenTelemetryClient.CleanupSpansInterval) * time.Second)
 	defer ticker.Stop()
 
 	for range ticker.C {
@@ -0,0 +1,263 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-netutils"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	otlpPathLogs    = "/v1/logs"
+	otlpPathMetrics = "/v1/metrics"
+)
+
+// ErrOtlpRejected is returned when the receiver refuses a part of the export request,
+// the request is not retried
+var ErrOtlpRejected = errors.New("otlp export partially rejected")
+
+// OtlpExporter sends the OTLP requests of the logs and metrics signals
+type OtlpExporter interface {
+	ExportLogs(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error
+	ExportMetrics(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error
+	Close() error
+}
+
+// OtlpHeaders returns the user headers with the authorization one
+func OtlpHeaders(config *pkgconfig.Config) map[string]string {
+	cfg := config.Loggers.OpenTelemetryClient
+
+	headers := make(map[string]string, len(cfg.Headers)+1)
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	switch {
+	case cfg.BearerToken != "":
+		headers["Authorization"] = "Bearer " + cfg.BearerToken
+	case cfg.BasicAuthLogin != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.BasicAuthLogin+":"+cfg.BasicAuthPwd))
+	}
+	return headers
+}
+
+// OtlpTLSConfig returns the tls client config, nil if tls is disabled
+func OtlpTLSConfig(config *pkgconfig.Config) (*tls.Config, error) {
+	cfg := config.Loggers.OpenTelemetryClient
+	if !cfg.TLSSupport {
+		return nil, nil
+	}
+
+	tlsOptions := netutils.TLSOptions{
+		InsecureSkipVerify: cfg.TLSInsecure,
+		MinVersion:         cfg.TLSMinVersion,
+		CAFile:             cfg.CAFile,
+		CertFile:           cfg.CertFile,
+		KeyFile:            cfg.KeyFile,
+	}
+	return netutils.TLSClientConfig(tlsOptions)
+}
+
+func NewOtlpExporter(config *pkgconfig.Config) (OtlpExporter, error) {
+	tlsConfig, err := OtlpTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	headers := OtlpHeaders(config)
+
+	switch config.Loggers.OpenTelemetryClient.Protocol {
+	case pkgconfig.OtlpProtocolGRPC:
+		return newOtlpGRPCExporter(config.Loggers.OpenTelemetryClient.OtelEndpoint, tlsConfig, headers)
+	case pkgconfig.OtlpProtocolHTTP:
+		return newOtlpHTTPExporter(config.Loggers.OpenTelemetryClient.OtelEndpoint, tlsConfig, headers), nil
+	default:
+		return nil, fmt.Errorf("invalid protocol %q, grpc or http expected", config.Loggers.OpenTelemetryClient.Protocol)
+	}
+}
+
+// gRPC transport
+type otlpGRPCExporter struct {
+	conn    *grpc.ClientConn
+	logs    collogspb.LogsServiceClient
+	metrics colmetricspb.MetricsServiceClient
+	headers metadata.MD
+}
+
+func newOtlpGRPCExporter(endpoint string, tlsConfig *tls.Config, headers map[string]string) (*otlpGRPCExporter, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &otlpGRPCExporter{
+		conn:    conn,
+		logs:    collogspb.NewLogsServiceClient(conn),
+		metrics: colmetricspb.NewMetricsServiceClient(conn),
+		headers: metadata.New(headers),
+	}, nil
+}
+
+func (e *otlpGRPCExporter) ExportLogs(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	resp, err := e.logs.Export(metadata.NewOutgoingContext(ctx, e.headers), req)
+	if err != nil {
+		return err
+	}
+	if rejected := resp.GetPartialSuccess().GetRejectedLogRecords(); rejected > 0 {
+		return fmt.Errorf("%w: %d log records, %s", ErrOtlpRejected, rejected, resp.GetPartialSuccess().GetErrorMessage())
+	}
+	return nil
+}
+
+func (e *otlpGRPCExporter) ExportMetrics(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error {
+	resp, err := e.metrics.Export(metadata.NewOutgoingContext(ctx, e.headers), req)
+	if err != nil {
+		return err
+	}
+	if rejected := resp.GetPartialSuccess().GetRejectedDataPoints(); rejected > 0 {
+		return fmt.Errorf("%w: %d data points, %s", ErrOtlpRejected, rejected, resp.GetPartialSuccess().GetErrorMessage())
+	}
+	return nil
+}
+
+func (e *otlpGRPCExporter) Close() error {
+	return e.conn.Close()
+}
+
+// HTTP transport, protobuf encoding
+type otlpHTTPExporter struct {
+	client  *http.Client
+	baseURL string
+	headers map[string]string
+}
+
+// OtlpHTTPError is returned when the receiver replies with an unexpected status code
+type OtlpHTTPError struct {
+	StatusCode int
+}
+
+func (e *OtlpHTTPError) Error() string {
+	return fmt.Sprintf("otlp http export failed with status %d", e.StatusCode)
+}
+
+func newOtlpHTTPExporter(endpoint string, tlsConfig *tls.Config, headers map[string]string) *otlpHTTPExporter {
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	tr := &http.Transport{
+		MaxIdleConns:    10,
+		IdleConnTimeout: 30 * time.Second,
+		TLSClientConfig: tlsConfig,
+	}
+	return &otlpHTTPExporter{
+		client:  &http.Client{Transport: tr},
+		baseURL: scheme + "://" + endpoint,
+		headers: headers,
+	}
+}
+
+func (e *otlpHTTPExporter) post(ctx context.Context, path string, msg, reply proto.Message) error {
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &OtlpHTTPError{StatusCode: resp.StatusCode}
+	}
+	return proto.Unmarshal(data, reply)
+}
+
+func (e *otlpHTTPExporter) ExportLogs(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	resp := &collogspb.ExportLogsServiceResponse{}
+	if err := e.post(ctx, otlpPathLogs, req, resp); err != nil {
+		return err
+	}
+	if rejected := resp.GetPartialSuccess().GetRejectedLogRecords(); rejected > 0 {
+		return fmt.Errorf("%w: %d log records, %s", ErrOtlpRejected, rejected, resp.GetPartialSuccess().GetErrorMessage())
+	}
+	return nil
+}
+
+func (e *otlpHTTPExporter) ExportMetrics(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error {
+	resp := &colmetricspb.ExportMetricsServiceResponse{}
+	if err := e.post(ctx, otlpPathMetrics, req, resp); err != nil {
+		return err
+	}
+	if rejected := resp.GetPartialSuccess().GetRejectedDataPoints(); rejected > 0 {
+		return fmt.Errorf("%w: %d data points, %s", ErrOtlpRejected, rejected, resp.GetPartialSuccess().GetErrorMessage())
+	}
+	return nil
+}
+
+func (e *otlpHTTPExporter) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// IsOtlpRetryable returns true for the transient errors defined by the OTLP specification
+func IsOtlpRetryable(err error) bool {
+	if errors.Is(err, ErrOtlpRejected) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var httpErr *OtlpHTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+			codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+			return true
+		}
+		return false
+	}
+
+	// network errors
+	return true
+}
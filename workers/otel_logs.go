@@ -0,0 +1,138 @@
+package workers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const otlpScopeName = "dnscollector"
+
+// OtlpAnyValue converts a flat-json value to an OTLP value
+func OtlpAnyValue(value interface{}) *commonpb.AnyValue {
+	switch v := value.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+	case []string:
+		values := make([]*commonpb.AnyValue, 0, len(v))
+		for _, item := range v {
+			values = append(values, OtlpAnyValue(item))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case []interface{}:
+		values := make([]*commonpb.AnyValue, 0, len(v))
+		for _, item := range v {
+			values = append(values, OtlpAnyValue(item))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprintf("%v", v)}}
+	}
+}
+
+func otlpStringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: OtlpAnyValue(value)}
+}
+
+// OtlpLogRecord converts the dns message to an OTLP log record,
+// the attributes are the flat-json keys of the message
+func OtlpLogRecord(dm *dnsutils.DNSMessage, body []byte) (*logspb.LogRecord, error) {
+	flat, err := dm.Flatten()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		attributes = append(attributes, &commonpb.KeyValue{Key: key, Value: OtlpAnyValue(flat[key])})
+	}
+
+	observed := uint64(time.Now().UnixNano())
+	record := &logspb.LogRecord{
+		TimeUnixNano:         uint64(dm.DNSTap.TimeSec)*1e9 + uint64(dm.DNSTap.TimeNsec),
+		ObservedTimeUnixNano: observed,
+		SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+		SeverityText:         "INFO",
+		Body:                 OtlpAnyValue(string(body)),
+		Attributes:           attributes,
+	}
+	if record.TimeUnixNano == 0 {
+		record.TimeUnixNano = observed
+	}
+
+	// correlate with the trace when the span is known
+	if dm.OpenTelemetry != nil && dm.OpenTelemetry.TraceID != "" {
+		if traceID, err := hex.DecodeString(dm.OpenTelemetry.TraceID); err == nil && len(traceID) == 16 {
+			record.TraceId = traceID
+		}
+	}
+	return record, nil
+}
+
+// OtlpLogsBatch groups the log records by stream identity, used as service name
+type OtlpLogsBatch struct {
+	records map[string][]*logspb.LogRecord
+	size    int
+}
+
+func NewOtlpLogsBatch() *OtlpLogsBatch {
+	return &OtlpLogsBatch{records: make(map[string][]*logspb.LogRecord)}
+}
+
+func (b *OtlpLogsBatch) Add(identity string, record *logspb.LogRecord) {
+	b.records[identity] = append(b.records[identity], record)
+	b.size++
+}
+
+func (b *OtlpLogsBatch) Len() int {
+	return b.size
+}
+
+func (b *OtlpLogsBatch) Reset() {
+	b.records = make(map[string][]*logspb.LogRecord)
+	b.size = 0
+}
+
+// Request builds the export request of the batch
+func (b *OtlpLogsBatch) Request() *collogspb.ExportLogsServiceRequest {
+	identities := make([]string, 0, len(b.records))
+	for identity := range b.records {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+
+	req := &collogspb.ExportLogsServiceRequest{}
+	for _, identity := range identities {
+		req.ResourceLogs = append(req.ResourceLogs, &logspb.ResourceLogs{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{otlpStringAttribute("service.name", identity)}},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: otlpScopeName},
+				LogRecords: b.records[identity],
+			}},
+		})
+	}
+	return req
+}
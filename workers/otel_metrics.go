@@ -0,0 +1,185 @@
+package workers
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// otlpStreamCounters are the aggregate counters of a stream identity,
+// the same as the ones exposed by the prometheus logger
+type otlpStreamCounters struct {
+	dnsMessages, queries, replies      int64
+	bytes, receivedBytes, sentBytes    int64
+	flagTC, flagAA, flagRA, flagAD     int64
+	malformed, fragmented, reassembled int64
+
+	qtypes, rcodes, operations, ipProtocol, ipVersion map[string]int64
+}
+
+func newOtlpStreamCounters() *otlpStreamCounters {
+	return &otlpStreamCounters{
+		qtypes:     make(map[string]int64),
+		rcodes:     make(map[string]int64),
+		operations: make(map[string]int64),
+		ipProtocol: make(map[string]int64),
+		ipVersion:  make(map[string]int64),
+	}
+}
+
+// OtlpMetricsAggregator computes cumulative counters per stream, exported as OTLP sums
+type OtlpMetricsAggregator struct {
+	prefix    string
+	startTime time.Time
+	streams   map[string]*otlpStreamCounters
+	sync.Mutex
+}
+
+func NewOtlpMetricsAggregator(prefix string) *OtlpMetricsAggregator {
+	return &OtlpMetricsAggregator{
+		prefix:    prefix,
+		startTime: time.Now(),
+		streams:   make(map[string]*otlpStreamCounters),
+	}
+}
+
+func (a *OtlpMetricsAggregator) Record(dm *dnsutils.DNSMessage) {
+	a.Lock()
+	defer a.Unlock()
+
+	c, exists := a.streams[dm.DNSTap.Identity]
+	if !exists {
+		c = newOtlpStreamCounters()
+		a.streams[dm.DNSTap.Identity] = c
+	}
+
+	c.dnsMessages++
+	c.bytes += int64(dm.DNS.Length)
+	c.ipVersion[dm.NetworkInfo.Family]++
+	c.ipProtocol[dm.NetworkInfo.Protocol]++
+	c.qtypes[dm.DNS.Qtype]++
+	c.operations[dm.DNSTap.Operation]++
+	if dm.DNS.Rcode != "-" {
+		c.rcodes[dm.DNS.Rcode]++
+	}
+
+	switch dm.DNS.Type {
+	case dnsutils.DNSQuery:
+		c.queries++
+		c.receivedBytes += int64(dm.DNS.Length)
+	case dnsutils.DNSReply:
+		c.replies++
+		c.sentBytes += int64(dm.DNS.Length)
+	}
+
+	if dm.DNS.Flags.TC {
+		c.flagTC++
+	}
+	if dm.DNS.Flags.AA {
+		c.flagAA++
+	}
+	if dm.DNS.Flags.RA {
+		c.flagRA++
+	}
+	if dm.DNS.Flags.AD {
+		c.flagAD++
+	}
+	if dm.DNS.MalformedPacket {
+		c.malformed++
+	}
+	if dm.NetworkInfo.IPDefragmented {
+		c.fragmented++
+	}
+	if dm.NetworkInfo.TCPReassembled {
+		c.reassembled++
+	}
+}
+
+func (a *OtlpMetricsAggregator) sum(name, description, unit string, points []*metricspb.NumberDataPoint) *metricspb.Metric {
+	return &metricspb.Metric{
+		Name:        a.prefix + "_" + name,
+		Description: description,
+		Unit:        unit,
+		Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			DataPoints:             points,
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            true,
+		}},
+	}
+}
+
+func (a *OtlpMetricsAggregator) point(value int64, now uint64, attributes ...*commonpb.KeyValue) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		StartTimeUnixNano: uint64(a.startTime.UnixNano()),
+		TimeUnixNano:      now,
+		Value:             &metricspb.NumberDataPoint_AsInt{AsInt: value},
+		Attributes:        attributes,
+	}
+}
+
+func (a *OtlpMetricsAggregator) pointsByKey(counters map[string]int64, label string, now uint64) []*metricspb.NumberDataPoint {
+	keys := make([]string, 0, len(counters))
+	for k := range counters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	points := make([]*metricspb.NumberDataPoint, 0, len(keys))
+	for _, k := range keys {
+		points = append(points, a.point(counters[k], now, otlpStringAttribute(label, k)))
+	}
+	return points
+}
+
+// Request builds the export request with the current value of the counters
+func (a *OtlpMetricsAggregator) Request(now time.Time) *colmetricspb.ExportMetricsServiceRequest {
+	a.Lock()
+	defer a.Unlock()
+
+	identities := make([]string, 0, len(a.streams))
+	for identity := range a.streams {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+
+	ts := uint64(now.UnixNano())
+	req := &colmetricspb.ExportMetricsServiceRequest{}
+	for _, identity := range identities {
+		c := a.streams[identity]
+		metrics := []*metricspb.Metric{
+			a.sum("dnsmessages", "Counter of DNS messages per stream", "1", []*metricspb.NumberDataPoint{a.point(c.dnsMessages, ts)}),
+			a.sum("queries", "Counter of DNS queries per stream", "1", []*metricspb.NumberDataPoint{a.point(c.queries, ts)}),
+			a.sum("replies", "Counter of DNS replies per stream", "1", []*metricspb.NumberDataPoint{a.point(c.replies, ts)}),
+			a.sum("qtypes", "Counter of queries per qtypes", "1", a.pointsByKey(c.qtypes, "query_type", ts)),
+			a.sum("rcodes", "Counter of replies per return codes", "1", a.pointsByKey(c.rcodes, "return_code", ts)),
+			a.sum("operations", "Counter of dns messages per operations", "1", a.pointsByKey(c.operations, "operation", ts)),
+			a.sum("ipprotocol", "Counter of packets per IP protocol", "1", a.pointsByKey(c.ipProtocol, "net_transport", ts)),
+			a.sum("ipversion", "Counter of packets per IP version", "1", a.pointsByKey(c.ipVersion, "net_family", ts)),
+			a.sum("flag_tc", "Number of packet with flag TC", "1", []*metricspb.NumberDataPoint{a.point(c.flagTC, ts)}),
+			a.sum("flag_aa", "Number of packet with flag AA", "1", []*metricspb.NumberDataPoint{a.point(c.flagAA, ts)}),
+			a.sum("flag_ra", "Number of packet with flag RA", "1", []*metricspb.NumberDataPoint{a.point(c.flagRA, ts)}),
+			a.sum("flag_ad", "Number of packet with flag AD", "1", []*metricspb.NumberDataPoint{a.point(c.flagAD, ts)}),
+			a.sum("malformed", "Number of malformed packets", "1", []*metricspb.NumberDataPoint{a.point(c.malformed, ts)}),
+			a.sum("fragmented", "Number of IP fragmented packets", "1", []*metricspb.NumberDataPoint{a.point(c.fragmented, ts)}),
+			a.sum("reassembled", "Number of TCP reassembled packets", "1", []*metricspb.NumberDataPoint{a.point(c.reassembled, ts)}),
+			a.sum("bytes", "The total bytes received and sent", "By", []*metricspb.NumberDataPoint{a.point(c.bytes, ts)}),
+			a.sum("received_bytes", "The total bytes received", "By", []*metricspb.NumberDataPoint{a.point(c.receivedBytes, ts)}),
+			a.sum("sent_bytes", "The total bytes sent", "By", []*metricspb.NumberDataPoint{a.point(c.sentBytes, ts)}),
+		}
+
+		req.ResourceMetrics = append(req.ResourceMetrics, &metricspb.ResourceMetrics{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{otlpStringAttribute("service.name", identity)}},
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Scope:   &commonpb.InstrumentationScope{Name: otlpScopeName},
+				Metrics: metrics,
+			}},
+		})
+	}
+	return req
+}
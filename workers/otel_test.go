@@ -1,11 +1,28 @@
 package workers
 
 import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-logger"
 	"github.com/stretchr/testify/assert"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestOpenTelemetry_InitTracerProvider(t *testing.T) {
@@ -17,8 +34,291 @@ func TestOpenTelemetry_InitTracerProvider(t *testing.T) {
 	client := NewOpenTelemetryClient(cfg, logger, "test-client")
 
 	// Initialize tracer provider
-	tracer := client.getTracer("test-service")
+	tracer, err := client.getTracer("test-service")
 
 	// Assert tracer is not nil
+	assert.NoError(t, err)
 	assert.NotNil(t, tracer, "Tracer should not be nil")
 }
+
+func TestOpenTelemetry_InitTracerProvider_HTTP(t *testing.T) {
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.OpenTelemetryClient.OtelEndpoint = "localhost:4318"
+	cfg.Loggers.OpenTelemetryClient.Protocol = pkgconfig.OtlpProtocolHTTP
+
+	client := NewOpenTelemetryClient(cfg, logger.New(false), "test-client")
+	tracer, err := client.getTracer("test-service")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, tracer, "Tracer should not be nil")
+}
+
+func TestOpenTelemetry_LogRecord(t *testing.T) {
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNSTap.TimeSec = 1704486841
+	dm.DNSTap.TimeNsec = 216166066
+	dm.OpenTelemetry = &dnsutils.LoggerOpenTelemetry{TraceID: "0102030405060708090a0b0c0d0e0f10"}
+
+	record, err := OtlpLogRecord(&dm, []byte("query dns.collector"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(1704486841216166066), record.TimeUnixNano)
+	assert.Equal(t, "query dns.collector", record.Body.GetStringValue())
+	assert.Len(t, record.TraceId, 16)
+
+	attributes := make(map[string]*commonpb.AnyValue)
+	for _, kv := range record.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "dns.collector", attributes["dns.qname"].GetStringValue())
+	assert.Equal(t, "A", attributes["dns.qtype"].GetStringValue())
+	assert.Equal(t, int64(0), attributes["dns.length"].GetIntValue())
+}
+
+func TestOpenTelemetry_MetricsAggregator(t *testing.T) {
+	agg := NewOtlpMetricsAggregator("dnscollector")
+
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNS.Length = 50
+	dm.DNS.Flags.AD = true
+	agg.Record(&dm)
+
+	dm.DNS.Type = dnsutils.DNSReply
+	dm.DNS.Rcode = dnsutils.DNSRcodeNXDomain
+	dm.DNS.Length = 100
+	agg.Record(&dm)
+
+	req := agg.Request(time.Now())
+	assert.Len(t, req.ResourceMetrics, 1)
+	assert.Equal(t, "collector", req.ResourceMetrics[0].Resource.Attributes[0].Value.GetStringValue())
+
+	values := make(map[string]int64)
+	for _, m := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		for _, p := range m.GetSum().DataPoints {
+			key := m.Name
+			for _, a := range p.Attributes {
+				key += "/" + a.Value.GetStringValue()
+			}
+			values[key] = p.GetAsInt()
+		}
+	}
+	assert.Equal(t, int64(2), values["dnscollector_dnsmessages"])
+	assert.Equal(t, int64(1), values["dnscollector_queries"])
+	assert.Equal(t, int64(1), values["dnscollector_replies"])
+	assert.Equal(t, int64(1), values["dnscollector_rcodes/NXDOMAIN"])
+	assert.Equal(t, int64(2), values["dnscollector_flag_ad"])
+	assert.Equal(t, int64(150), values["dnscollector_bytes"])
+	assert.Equal(t, int64(100), values["dnscollector_sent_bytes"])
+}
+
+func TestOpenTelemetry_ExportLogsHTTP(t *testing.T) {
+	received := make(chan *collogspb.ExportLogsServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != otlpPathLogs || r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Tenant") != "dns" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- req
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.OpenTelemetryClient.OtelEndpoint = strings.TrimPrefix(server.URL, "http://")
+	cfg.Loggers.OpenTelemetryClient.Protocol = pkgconfig.OtlpProtocolHTTP
+	cfg.Loggers.OpenTelemetryClient.TracesEnabled = false
+	cfg.Loggers.OpenTelemetryClient.LogsEnabled = true
+	cfg.Loggers.OpenTelemetryClient.BatchSize = 1
+	cfg.Loggers.OpenTelemetryClient.BearerToken = "secret"
+	cfg.Loggers.OpenTelemetryClient.Headers = map[string]string{"X-Tenant": "dns"}
+
+	g := NewOpenTelemetryClient(cfg, logger.New(false), "test")
+	go g.StartCollect()
+
+	g.GetInputChannel() <- dnsutils.GetFakeDNSMessage()
+
+	select {
+	case req := <-received:
+		assert.Len(t, req.ResourceLogs, 1)
+		records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+		assert.Len(t, records, 1)
+		assert.Contains(t, records[0].Body.GetStringValue(), "dns.collector")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no logs received")
+	}
+	g.Stop()
+}
+
+type fakeOtlpReceiver struct {
+	collogspb.UnimplementedLogsServiceServer
+	colmetricspb.UnimplementedMetricsServiceServer
+	metrics chan *colmetricspb.ExportMetricsServiceRequest
+	headers chan metadata.MD
+}
+
+func (r *fakeOtlpReceiver) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.headers <- md
+	r.metrics <- req
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func TestOpenTelemetry_ExportMetricsGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver := &fakeOtlpReceiver{
+		metrics: make(chan *colmetricspb.ExportMetricsServiceRequest, 1),
+		headers: make(chan metadata.MD, 1),
+	}
+	server := grpc.NewServer()
+	colmetricspb.RegisterMetricsServiceServer(server, receiver)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.OpenTelemetryClient.OtelEndpoint = listener.Addr().String()
+	cfg.Loggers.OpenTelemetryClient.MetricsEnabled = true
+	cfg.Loggers.OpenTelemetryClient.BasicAuthLogin = "admin"
+	cfg.Loggers.OpenTelemetryClient.BasicAuthPwd = "changeme"
+
+	g := NewOpenTelemetryClient(cfg, logger.New(false), "test")
+	dm := dnsutils.GetFakeDNSMessage()
+	g.metrics.Record(&dm)
+	g.FlushMetrics(0)
+
+	select {
+	case md := <-receiver.headers:
+		assert.Equal(t, []string{"Basic YWRtaW46Y2hhbmdlbWU="}, md.Get("authorization"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no metrics received")
+	}
+	req := <-receiver.metrics
+	assert.Len(t, req.ResourceMetrics, 1)
+}
+
+func TestOpenTelemetry_ExportRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.OpenTelemetryClient.OtelEndpoint = strings.TrimPrefix(server.URL, "http://")
+	cfg.Loggers.OpenTelemetryClient.Protocol = pkgconfig.OtlpProtocolHTTP
+	cfg.Loggers.OpenTelemetryClient.LogsEnabled = true
+	cfg.Loggers.OpenTelemetryClient.RetryInterval = 0
+
+	g := NewOpenTelemetryClient(cfg, logger.New(false), "test")
+	dm := dnsutils.GetFakeDNSMessage()
+	record, _ := OtlpLogRecord(&dm, []byte("test"))
+	g.logsBatch.Add("collector", record)
+	g.FlushLogs(3)
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, 0, g.logsBatch.Len())
+}
+
+func TestOpenTelemetry_ExportInBackground(t *testing.T) {
+	// the receiver is unavailable, each export is retried
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.OpenTelemetryClient.OtelEndpoint = strings.TrimPrefix(server.URL, "http://")
+	cfg.Loggers.OpenTelemetryClient.Protocol = pkgconfig.OtlpProtocolHTTP
+	cfg.Loggers.OpenTelemetryClient.TracesEnabled = false
+	cfg.Loggers.OpenTelemetryClient.LogsEnabled = true
+	cfg.Loggers.OpenTelemetryClient.BatchSize = 1
+	cfg.Loggers.OpenTelemetryClient.ExportQueueSize = 1
+	cfg.Loggers.OpenTelemetryClient.RetryInterval = 5
+
+	g := NewOpenTelemetryClient(cfg, logger.New(false), "test")
+	next := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	g.AddDefaultRoute(next)
+	go g.StartCollect()
+
+	// the messages are forwarded while the export is retried
+	for i := 0; i < 10; i++ {
+		g.GetInputChannel() <- dnsutils.GetFakeDNSMessage()
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case <-next.GetInputChannel():
+		case <-time.After(2 * time.Second):
+			t.Fatalf("message %d not forwarded, the export blocks the worker", i)
+		}
+	}
+
+	// the retries are interrupted on stop
+	start := time.Now()
+	g.Stop()
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestOpenTelemetry_IsRetryable(t *testing.T) {
+	assert.True(t, IsOtlpRetryable(&OtlpHTTPError{StatusCode: http.StatusServiceUnavailable}))
+	assert.True(t, IsOtlpRetryable(&OtlpHTTPError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, IsOtlpRetryable(&OtlpHTTPError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, IsOtlpRetryable(status.Error(codes.Unavailable, "unavailable")))
+	assert.False(t, IsOtlpRetryable(status.Error(codes.InvalidArgument, "invalid")))
+	assert.False(t, IsOtlpRetryable(ErrOtlpRejected))
+}
+
+func TestOpenTelemetry_Reload(t *testing.T) {
+	// the endpoint is changed on reload, the logs are exported to the new receiver
+	var oldCalls, newCalls atomic.Int32
+	oldServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		oldCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer oldServer.Close()
+	newServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		newCalls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer newServer.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.OpenTelemetryClient.OtelEndpoint = strings.TrimPrefix(oldServer.URL, "http://")
+	cfg.Loggers.OpenTelemetryClient.Protocol = pkgconfig.OtlpProtocolHTTP
+	cfg.Loggers.OpenTelemetryClient.TracesEnabled = false
+	cfg.Loggers.OpenTelemetryClient.LogsEnabled = true
+	cfg.Loggers.OpenTelemetryClient.BatchSize = 1
+
+	g := NewOpenTelemetryClient(cfg, logger.New(false), "test")
+	go g.StartCollect()
+
+	newCfg := pkgconfig.GetDefaultConfig()
+	newCfg.Loggers.OpenTelemetryClient.OtelEndpoint = strings.TrimPrefix(newServer.URL, "http://")
+	newCfg.Loggers.OpenTelemetryClient.Protocol = pkgconfig.OtlpProtocolHTTP
+	newCfg.Loggers.OpenTelemetryClient.TracesEnabled = false
+	newCfg.Loggers.OpenTelemetryClient.LogsEnabled = true
+	newCfg.Loggers.OpenTelemetryClient.BatchSize = 1
+	g.NewConfig() <- newCfg
+
+	// the exporter is replaced by the export goroutine before the next export
+	deadline := time.Now().Add(5 * time.Second)
+	for newCalls.Load() == 0 && time.Now().Before(deadline) {
+		g.GetInputChannel() <- dnsutils.GetFakeDNSMessage()
+		time.Sleep(100 * time.Millisecond)
+	}
+	g.Stop()
+
+	assert.Greater(t, newCalls.Load(), int32(0))
+}
@@ -0,0 +1,431 @@
+package workers
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-dnscollector/transformers"
+	"github.com/dmachard/go-logger"
+	"github.com/dmachard/go-netutils"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpExportRequest is an export request received by the servers,
+// the number of rejected log records is sent back once the request is processed
+type otlpExportRequest struct {
+	req      *collogspb.ExportLogsServiceRequest
+	rejected chan int64
+}
+
+type OtlpReceiver struct {
+	*GenericWorker
+	requests chan otlpExportRequest
+}
+
+func NewOtlpReceiver(next []Worker, config *pkgconfig.Config, logger *logger.Logger, name string) *OtlpReceiver {
+	bufSize := config.Global.Worker.ChannelBufferSize
+	if config.Collectors.OtlpReceiver.ChannelBufferSize > 0 {
+		bufSize = config.Collectors.OtlpReceiver.ChannelBufferSize
+	}
+	w := &OtlpReceiver{
+		GenericWorker: NewGenericWorker(config, logger, name, "otlp", bufSize, pkgconfig.DefaultMonitor),
+		requests:      make(chan otlpExportRequest),
+	}
+	w.SetDefaultRoutes(next)
+	w.ReadConfig()
+	return w
+}
+
+func (w *OtlpReceiver) ReadConfig() {
+	cfg := w.GetConfig().Collectors.OtlpReceiver
+	if !netutils.IsValidTLS(cfg.TLSMinVersion) {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] otlp - invalid tls min version")
+	}
+	if cfg.GRPCPort <= 0 && cfg.HTTPPort <= 0 {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] otlp - grpc-port or http-port is required")
+	}
+	if cfg.MaxRequestSize <= 0 {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "] otlp - max-request-size must be positive")
+	}
+}
+
+func (w *OtlpReceiver) tlsConfig() (*tls.Config, error) {
+	cfg := w.GetConfig().Collectors.OtlpReceiver
+	if !cfg.TLSSupport {
+		return nil, nil
+	}
+	cer, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cer},
+		MinVersion:   netutils.TLSVersion[cfg.TLSMinVersion],
+	}, nil
+}
+
+// otlpFlattenValue adds the value to the flat message, the arrays and maps
+// are expanded with the index or the key as suffix
+func otlpFlattenValue(flat map[string]interface{}, key string, value *commonpb.AnyValue) {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		flat[key] = v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		flat[key] = v.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		flat[key] = float64(v.IntValue)
+	case *commonpb.AnyValue_DoubleValue:
+		flat[key] = v.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		flat[key] = v.BytesValue
+	case *commonpb.AnyValue_ArrayValue:
+		for i, item := range v.ArrayValue.GetValues() {
+			otlpFlattenValue(flat, key+"."+strconv.Itoa(i), item)
+		}
+	case *commonpb.AnyValue_KvlistValue:
+		for _, kv := range v.KvlistValue.GetValues() {
+			otlpFlattenValue(flat, key+"."+kv.GetKey(), kv.GetValue())
+		}
+	}
+}
+
+// DecodeLogRecord converts a log record to a DNS message, the attributes are the flat-json keys
+// and the wire-format payload is decoded if provided
+func (w *OtlpReceiver) DecodeLogRecord(resource *resourcepb.Resource, record *logspb.LogRecord) (dnsutils.DNSMessage, error) {
+	cfg := w.GetConfig().Collectors.OtlpReceiver
+
+	// the attributes of the record take precedence over the resource ones
+	flat := make(map[string]interface{})
+	for _, kv := range resource.GetAttributes() {
+		otlpFlattenValue(flat, kv.GetKey(), kv.GetValue())
+	}
+	for _, kv := range record.GetAttributes() {
+		otlpFlattenValue(flat, kv.GetKey(), kv.GetValue())
+	}
+
+	// extract the wire-format payload, as bytes or base64 string
+	var payload []byte
+	if value, ok := flat[cfg.PayloadAttribute]; ok && len(cfg.PayloadAttribute) > 0 {
+		delete(flat, cfg.PayloadAttribute)
+		switch v := value.(type) {
+		case []byte:
+			payload = v
+		case string:
+			data, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return dnsutils.DNSMessage{}, errors.New("invalid base64 payload: " + err.Error())
+			}
+			payload = data
+		}
+	}
+
+	dm := dnsutils.DNSMessage{}
+	if err := dm.Unflatten(flat); err != nil {
+		return dm, err
+	}
+
+	// the service name is the identity of the stream by default
+	if _, ok := flat["dnstap.identity"]; !ok {
+		if serviceName, valid := flat["service.name"].(string); valid {
+			dm.DNSTap.Identity = serviceName
+		}
+	}
+
+	// use the time of the log record if the timestamp is not provided
+	if _, ok := flat["dnstap.timestamp-rfc3339ns"]; !ok {
+		ts := time.Now()
+		switch {
+		case record.GetTimeUnixNano() > 0:
+			ts = time.Unix(0, int64(record.GetTimeUnixNano()))
+		case record.GetObservedTimeUnixNano() > 0:
+			ts = time.Unix(0, int64(record.GetObservedTimeUnixNano()))
+		}
+		dm.DNSTap.TimeSec = int(ts.Unix())
+		dm.DNSTap.TimeNsec = ts.Nanosecond()
+		dm.DNSTap.Timestamp = ts.UnixNano()
+		dm.DNSTap.TimestampRFC3339 = ts.UTC().Format(time.RFC3339Nano)
+	}
+
+	if len(payload) == 0 {
+		return dm, nil
+	}
+	dm.DNS.Payload = payload
+	if dm.DNS.Length == 0 {
+		dm.DNS.Length = len(payload)
+	}
+
+	// decode payload if provided
+	if !cfg.DisableDNSParser {
+		dnsHeader, err := dnsutils.DecodeDNS(dm.DNS.Payload)
+		if err != nil {
+			dm.DNS.MalformedPacket = true
+			if w.GetConfig().Global.Trace.LogMalformed {
+				w.LogWarning("dns header parser stopped: %s", err)
+				w.LogWarning("dump dns payload: %v", dm.DNS.Payload)
+			}
+		}
+
+		// get number of questions
+		dm.DNS.QdCount = dnsHeader.Qdcount
+		dm.DNS.AnCount = dnsHeader.Ancount
+		dm.DNS.ArCount = dnsHeader.Arcount
+		dm.DNS.NsCount = dnsHeader.Nscount
+
+		// the direction is given by the header without operation
+		if _, ok := flat["dnstap.operation"]; !ok && err == nil {
+			if dnsHeader.Qr == 1 {
+				dm.DNS.Type = dnsutils.DNSReply
+			} else {
+				dm.DNS.Type = dnsutils.DNSQuery
+			}
+		}
+
+		if err = dnsutils.DecodePayload(&dm, &dnsHeader, w.GetConfig()); err != nil {
+			dm.DNS.MalformedPacket = true
+			if w.GetConfig().Global.Trace.LogMalformed {
+				w.LogWarning("dns payload parser stopped: %s", err)
+				w.LogWarning("dump dns payload: %v", dm.DNS.Payload)
+			}
+		}
+	}
+	return dm, nil
+}
+
+// Export sends the request to the main loop and waits for the number of rejected log records
+func (w *OtlpReceiver) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (int64, error) {
+	export := otlpExportRequest{req: req, rejected: make(chan int64, 1)}
+	select {
+	case w.requests <- export:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	select {
+	case rejected := <-export.rejected:
+		return rejected, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func otlpLogsResponse(rejected int64) *collogspb.ExportLogsServiceResponse {
+	resp := &collogspb.ExportLogsServiceResponse{}
+	if rejected > 0 {
+		resp.PartialSuccess = &collogspb.ExportLogsPartialSuccess{
+			RejectedLogRecords: rejected,
+			ErrorMessage:       "unable to decode the log records to dns messages",
+		}
+	}
+	return resp
+}
+
+// otlpLogsService implements the OTLP/gRPC logs service
+type otlpLogsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	receiver *OtlpReceiver
+}
+
+func (s *otlpLogsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	rejected, err := s.receiver.Export(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return otlpLogsResponse(rejected), nil
+}
+
+// HandleHTTPLogs implements the OTLP/HTTP logs endpoint, with protobuf or json encoding
+func (w *OtlpReceiver) HandleHTTPLogs(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(rw, r.Body, int64(w.GetConfig().Collectors.OtlpReceiver.MaxRequestSize))
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = io.LimitReader(gz, int64(w.GetConfig().Collectors.OtlpReceiver.MaxRequestSize))
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(rw, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the parameters of the media type are ignored, like the charset
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json"
+	req := &collogspb.ExportLogsServiceRequest{}
+	if isJSON {
+		err = protojson.Unmarshal(data, req)
+	} else {
+		err = proto.Unmarshal(data, req)
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rejected, err := w.Export(r.Context(), req)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	var reply []byte
+	if isJSON {
+		reply, err = protojson.Marshal(otlpLogsResponse(rejected))
+		rw.Header().Set("Content-Type", "application/json")
+	} else {
+		reply, err = proto.Marshal(otlpLogsResponse(rejected))
+		rw.Header().Set("Content-Type", "application/x-protobuf")
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(reply)
+}
+
+func (w *OtlpReceiver) StartCollect() {
+	w.LogInfo("starting data collection")
+	defer w.CollectDone()
+
+	cfg := w.GetConfig().Collectors.OtlpReceiver
+	tlsConfig, err := w.tlsConfig()
+	if err != nil {
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] otlp - invalid tls config: ", err)
+	}
+
+	// start the grpc server
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort > 0 {
+		listener, err := net.Listen(netutils.SocketTCP, net.JoinHostPort(cfg.ListenIP, strconv.Itoa(cfg.GRPCPort)))
+		if err != nil {
+			w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] otlp - grpc listening failed: ", err)
+		}
+		opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(cfg.MaxRequestSize)}
+		if tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcServer = grpc.NewServer(opts...)
+		collogspb.RegisterLogsServiceServer(grpcServer, &otlpLogsService{receiver: w})
+		w.LogInfo("otlp/grpc listening on %s", listener.Addr())
+		go grpcServer.Serve(listener)
+	}
+
+	// start the http server
+	var httpServer *http.Server
+	if cfg.HTTPPort > 0 {
+		listener, err := net.Listen(netutils.SocketTCP, net.JoinHostPort(cfg.ListenIP, strconv.Itoa(cfg.HTTPPort)))
+		if err != nil {
+			w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] otlp - http listening failed: ", err)
+		}
+		if tlsConfig != nil {
+			listener = tls.NewListener(listener, tlsConfig)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc(otlpPathLogs, w.HandleHTTPLogs)
+		httpServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		w.LogInfo("otlp/http listening on %s", listener.Addr())
+		go httpServer.Serve(listener)
+	}
+
+	// prepare next channels
+	defaultRoutes, defaultNames := GetRoutes(w.GetDefaultRoutes())
+	droppedRoutes, droppedNames := GetRoutes(w.GetDroppedRoutes())
+
+	// prepare enabled transformers
+	transforms := transformers.NewTransforms(&w.GetConfig().IngoingTransformers, w.GetLogger(), w.GetName(), defaultRoutes, 0)
+
+	for {
+		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+			transforms.SetNextWorkers(defaultRoutes)
+
+		case <-w.OnStop():
+			w.LogInfo("stop to listen...")
+			transforms.Reset()
+			if grpcServer != nil {
+				grpcServer.Stop()
+			}
+			if httpServer != nil {
+				httpServer.Close()
+			}
+			return
+
+		// new config provided? the listeners are not restarted
+		case cfg := <-w.NewConfig():
+			w.SetConfig(cfg)
+			w.ReadConfig()
+			transforms.ReloadConfig(&cfg.IngoingTransformers)
+
+		case export := <-w.requests:
+			var rejected int64
+			for _, rl := range export.req.GetResourceLogs() {
+				for _, sl := range rl.GetScopeLogs() {
+					for _, record := range sl.GetLogRecords() {
+						// count global messages
+						w.CountIngressTraffic()
+
+						dm, err := w.DecodeLogRecord(rl.GetResource(), record)
+						if err != nil {
+							w.LogError("unable to decode log record: %s", err)
+							rejected++
+							continue
+						}
+
+						// count output packets
+						w.CountEgressTraffic()
+
+						// apply all enabled transformers
+						transformResult, err := transforms.ProcessMessage(&dm)
+						if err != nil {
+							w.LogError(err.Error())
+						}
+						if transformResult == transformers.ReturnDrop {
+							w.SendDroppedTo(droppedRoutes, droppedNames, dm)
+							continue
+						}
+
+						// dispatch dns messages to connected loggers
+						w.SendForwardedTo(defaultRoutes, defaultNames, dm)
+					}
+				}
+			}
+			export.rejected <- rejected
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package workers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func Test_OtlpReceiver_DecodeAttributes(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	c := NewOtlpReceiver(nil, config, logger.New(false), "test")
+
+	// log record produced by the opentelemetry logger
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNSTap.TimestampRFC3339 = "2024-01-02T03:04:05.123456789Z"
+	record, err := OtlpLogRecord(&dm, []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dmOut, err := c.DecodeLogRecord(&resourcepb.Resource{}, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dmOut.DNS.Qname != dm.DNS.Qname || dmOut.DNS.Qtype != dm.DNS.Qtype {
+		t.Errorf("invalid question in dns message: %s %s", dmOut.DNS.Qname, dmOut.DNS.Qtype)
+	}
+	if dmOut.NetworkInfo.QueryIP != dm.NetworkInfo.QueryIP {
+		t.Errorf("invalid query ip in dns message: %s", dmOut.NetworkInfo.QueryIP)
+	}
+	if dmOut.DNSTap.Identity != "collector" {
+		t.Errorf("invalid identity: %s", dmOut.DNSTap.Identity)
+	}
+	if dmOut.DNSTap.TimeNsec != 123456789 {
+		t.Errorf("invalid timestamp: %d", dmOut.DNSTap.TimeNsec)
+	}
+}
+
+func Test_OtlpReceiver_DecodePayload(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	c := NewOtlpReceiver(nil, config, logger.New(false), "test")
+
+	dm := dnsutils.GetFakeDNSMessageWithPayload()
+	resource := &resourcepb.Resource{Attributes: []*commonpb.KeyValue{otlpStringAttribute("service.name", "resolver1")}}
+	record := &logspb.LogRecord{
+		TimeUnixNano: 1704164645000000000,
+		Attributes: []*commonpb.KeyValue{
+			otlpStringAttribute("network.query-ip", "10.0.0.1"),
+			otlpStringAttribute("dns.payload", base64.StdEncoding.EncodeToString(dm.DNS.Payload)),
+		},
+	}
+
+	dmOut, err := c.DecodeLogRecord(resource, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dmOut.DNS.Qname != pkgconfig.ExpectedQname {
+		t.Errorf("qname not decoded from the payload: %s", dmOut.DNS.Qname)
+	}
+	if dmOut.DNS.Type != dnsutils.DNSQuery || dmOut.DNS.QdCount != 1 {
+		t.Errorf("invalid dns header: %s %d", dmOut.DNS.Type, dmOut.DNS.QdCount)
+	}
+	if dmOut.DNS.Length != len(dm.DNS.Payload) {
+		t.Errorf("invalid length: %d", dmOut.DNS.Length)
+	}
+	if dmOut.NetworkInfo.QueryIP != "10.0.0.1" || dmOut.DNSTap.Identity != "resolver1" {
+		t.Errorf("invalid attributes: %s %s", dmOut.NetworkInfo.QueryIP, dmOut.DNSTap.Identity)
+	}
+	if dmOut.DNSTap.TimeSec != 1704164645 {
+		t.Errorf("invalid timestamp: %d", dmOut.DNSTap.TimeSec)
+	}
+}
+
+func Test_OtlpReceiver_Export(t *testing.T) {
+	testcases := []struct {
+		protocol string
+		port     int
+	}{
+		{protocol: pkgconfig.OtlpProtocolGRPC, port: 14317},
+		{protocol: pkgconfig.OtlpProtocolHTTP, port: 14318},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.protocol, func(t *testing.T) {
+			// simulate next workers
+			kept := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+
+			// start the collector
+			config := pkgconfig.GetDefaultConfig()
+			config.Collectors.OtlpReceiver.ListenIP = "127.0.0.1"
+			config.Collectors.OtlpReceiver.GRPCPort = 14317
+			config.Collectors.OtlpReceiver.HTTPPort = 14318
+			c := NewOtlpReceiver([]Worker{kept}, config, logger.New(false), "test")
+			go c.StartCollect()
+			defer c.Stop()
+
+			// send logs with the exporter of the opentelemetry logger
+			exporterConfig := pkgconfig.GetDefaultConfig()
+			exporterConfig.Loggers.OpenTelemetryClient.OtelEndpoint = "127.0.0.1:" + strconv.Itoa(tc.port)
+			exporterConfig.Loggers.OpenTelemetryClient.Protocol = tc.protocol
+			exporter, err := NewOtlpExporter(exporterConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer exporter.Close()
+
+			dm := dnsutils.GetFakeDNSMessage()
+			record, _ := OtlpLogRecord(&dm, []byte("test"))
+			invalid := &logspb.LogRecord{Attributes: []*commonpb.KeyValue{otlpStringAttribute("dns.id", "invalid")}}
+			batch := NewOtlpLogsBatch()
+			batch.Add("collector", record)
+			batch.Add("collector", invalid)
+
+			// wait for the servers
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				err = exporter.ExportLogs(context.Background(), batch.Request())
+				if err == nil || errors.Is(err, ErrOtlpRejected) || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			// the invalid record is rejected
+			if !errors.Is(err, ErrOtlpRejected) {
+				t.Fatalf("partial success expected: %v", err)
+			}
+
+			dmOut := <-kept.GetInputChannel()
+			if dmOut.DNS.Qname != dm.DNS.Qname {
+				t.Errorf("invalid qname in dns message: %s", dmOut.DNS.Qname)
+			}
+			if len(kept.GetInputChannel()) != 0 {
+				t.Errorf("the invalid record must not be forwarded")
+			}
+		})
+	}
+}
+
+func Test_OtlpReceiver_HTTPContentType(t *testing.T) {
+	// simulate next workers
+	kept := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+
+	// start the collector
+	config := pkgconfig.GetDefaultConfig()
+	config.Collectors.OtlpReceiver.ListenIP = "127.0.0.1"
+	config.Collectors.OtlpReceiver.GRPCPort = 0
+	config.Collectors.OtlpReceiver.HTTPPort = 14319
+	c := NewOtlpReceiver([]Worker{kept}, config, logger.New(false), "test")
+	go c.StartCollect()
+	defer c.Stop()
+
+	dm := dnsutils.GetFakeDNSMessage()
+	record, _ := OtlpLogRecord(&dm, []byte("test"))
+	batch := NewOtlpLogsBatch()
+	batch.Add("collector", record)
+	data, err := protojson.Marshal(batch.Request())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the json encoding is detected with the parameters of the media type
+	req := httptest.NewRequest(http.MethodPost, otlpPathLogs, strings.NewReader(string(data)))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rw := httptest.NewRecorder()
+	c.HandleHTTPLogs(rw, req)
+
+	if rw.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rw.Code, rw.Body.String())
+	}
+	if rw.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("json response expected: %s", rw.Header().Get("Content-Type"))
+	}
+	dmOut := <-kept.GetInputChannel()
+	if dmOut.DNS.Qname != dm.DNS.Qname {
+		t.Errorf("invalid qname in dns message: %s", dmOut.DNS.Qname)
+	}
+}
@@ -64,8 +64,7 @@ func (w *PdnsServer) HandleConn(conn net.Conn, connID uint64, forceClose chan bo
 	}
 	pdnsProcessor := NewPdnsProcessor(int(connID), peerName, w.GetConfig(), w.GetLogger(), w.GetName(), bufSize)
 	pdnsProcessor.SetMetrics(w.metrics)
-	pdnsProcessor.SetDefaultRoutes(w.GetDefaultRoutes())
-	pdnsProcessor.SetDefaultDropped(w.GetDroppedRoutes())
+	pdnsProcessor.FollowRoutes(w.GenericWorker)
 	go pdnsProcessor.StartCollect()
 
 	r := bufio.NewReader(conn)
@@ -235,6 +234,11 @@ func (w *PdnsProcessor) StartCollect() {
 	// read incoming dns message
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+			transforms.SetNextWorkers(defaultRoutes)
+
 		case cfg := <-w.NewConfig():
 			w.SetConfig(cfg)
 			transforms.ReloadConfig(&cfg.IngoingTransformers)
@@ -0,0 +1,308 @@
+package workers
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-dnscollector/transformers"
+	"github.com/dmachard/go-logger"
+	"github.com/dmachard/go-netutils"
+	powerdns_protobuf "github.com/dmachard/go-powerdns-protobuf"
+)
+
+var ErrPdnsPayloadTooLarge = errors.New("protobuf payload too large")
+
+// WritePdnsFrame writes the protobuf payload with the 2 bytes length prefix expected by the PowerDNS consumers
+func WritePdnsFrame(w *bufio.Writer, data []byte) error {
+	if len(data) > powerdns_protobuf.DATA_FRAME_LENGTH_MAX {
+		return ErrPdnsPayloadTooLarge
+	}
+	if err := binary.Write(w, binary.BigEndian, uint16(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+type PdnsSender struct {
+	*GenericWorker
+	stopConnect                        chan bool
+	transport                          string
+	transportConn                      net.Conn
+	transportWriter                    *bufio.Writer
+	transportReady, transportReconnect chan bool
+	writerReady                        bool
+}
+
+func NewPdnsSender(config *pkgconfig.Config, logger *logger.Logger, name string) *PdnsSender {
+	bufSize := config.Global.Worker.ChannelBufferSize
+	if config.Loggers.PowerDNSClient.ChannelBufferSize > 0 {
+		bufSize = config.Loggers.PowerDNSClient.ChannelBufferSize
+	}
+	w := &PdnsSender{GenericWorker: NewGenericWorker(config, logger, name, "powerdns", bufSize, pkgconfig.DefaultMonitor)}
+	w.transportReady = make(chan bool)
+	w.transportReconnect = make(chan bool)
+	w.stopConnect = make(chan bool)
+	w.ReadConfig()
+	return w
+}
+
+func (w *PdnsSender) ReadConfig() {
+	w.transport = w.GetConfig().Loggers.PowerDNSClient.Transport
+	if w.transport != netutils.SocketTCP && w.transport != netutils.SocketTLS {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "invalid transport")
+	}
+
+	// get hostname or global one
+	if w.GetConfig().Loggers.PowerDNSClient.ServerID == "" {
+		w.GetConfig().Loggers.PowerDNSClient.ServerID = w.GetConfig().GetServerIdentity()
+	}
+
+	if !netutils.IsValidTLS(w.GetConfig().Loggers.PowerDNSClient.TLSMinVersion) {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "invalid tls min version")
+	}
+}
+
+func (w *PdnsSender) Disconnect() {
+	close(w.stopConnect)
+	if w.transportConn != nil {
+		w.LogInfo("closing tcp connection")
+		w.transportConn.Close()
+		w.LogInfo("closed")
+	}
+}
+
+func (w *PdnsSender) ConnectToRemote() {
+	for {
+		if w.transportConn != nil {
+			w.transportConn.Close()
+			w.transportConn = nil
+		}
+
+		address := net.JoinHostPort(
+			w.GetConfig().Loggers.PowerDNSClient.RemoteAddress,
+			strconv.Itoa(w.GetConfig().Loggers.PowerDNSClient.RemotePort),
+		)
+		connTimeout := time.Duration(w.GetConfig().Loggers.PowerDNSClient.ConnectTimeout) * time.Second
+
+		// make the connection
+		var conn net.Conn
+		var err error
+
+		w.LogInfo("connecting to %s://%s", w.transport, address)
+		switch w.transport {
+		case netutils.SocketTCP:
+			conn, err = net.DialTimeout(w.transport, address, connTimeout)
+
+		case netutils.SocketTLS:
+			var tlsConfig *tls.Config
+
+			tlsOptions := netutils.TLSOptions{
+				InsecureSkipVerify: w.GetConfig().Loggers.PowerDNSClient.TLSInsecure, MinVersion: w.GetConfig().Loggers.PowerDNSClient.TLSMinVersion,
+				CAFile: w.GetConfig().Loggers.PowerDNSClient.CAFile, CertFile: w.GetConfig().Loggers.PowerDNSClient.CertFile, KeyFile: w.GetConfig().Loggers.PowerDNSClient.KeyFile,
+			}
+
+			tlsConfig, err = netutils.TLSClientConfig(tlsOptions)
+			if err == nil {
+				dialer := &net.Dialer{Timeout: connTimeout}
+				conn, err = tls.DialWithDialer(dialer, netutils.SocketTCP, address, tlsConfig)
+			}
+		}
+
+		// something is wrong during connection ?
+		if err != nil {
+			w.LogError("%s", err)
+			w.LogInfo("retry to connect in %d seconds", w.GetConfig().Loggers.PowerDNSClient.RetryInterval)
+			select {
+			case <-w.stopConnect:
+				return
+			case <-time.After(time.Duration(w.GetConfig().Loggers.PowerDNSClient.RetryInterval) * time.Second):
+				continue
+			}
+		}
+
+		w.transportConn = conn
+
+		// block until the writer is ready
+		select {
+		case w.transportReady <- true:
+		case <-w.stopConnect:
+			conn.Close()
+			return
+		}
+
+		// block until an error occurred, need to reconnect
+		select {
+		case w.transportReconnect <- true:
+		case <-w.stopConnect:
+			return
+		}
+	}
+}
+
+func (w *PdnsSender) FlushBuffer(buf *[]dnsutils.DNSMessage) {
+	for _, dm := range *buf {
+		// update identity ?
+		if w.GetConfig().Loggers.PowerDNSClient.OverwriteIdentity {
+			dm.DNSTap.Identity = w.GetConfig().Loggers.PowerDNSClient.ServerID
+		}
+
+		// encode dns message to powerdns protobuf binary
+		data, err := dm.ToPowerDNS()
+		if err != nil {
+			w.LogError("failed to encode to PowerDNS protobuf: %s", err)
+			continue
+		}
+
+		if err := WritePdnsFrame(w.transportWriter, data); err != nil {
+			if errors.Is(err, ErrPdnsPayloadTooLarge) {
+				w.LogError("%s", err)
+				continue
+			}
+			w.LogError("send frame error %s", err)
+			w.writerReady = false
+			<-w.transportReconnect
+			break
+		}
+	}
+
+	if w.writerReady {
+		if err := w.transportWriter.Flush(); err != nil {
+			w.LogError("send frame error %s", err)
+			w.writerReady = false
+			<-w.transportReconnect
+		}
+	}
+
+	// reset buffer
+	*buf = nil
+}
+
+func (w *PdnsSender) StartCollect() {
+	w.LogInfo("starting data collection")
+	defer w.CollectDone()
+
+	// prepare next channels
+	defaultRoutes, defaultNames := GetRoutes(w.GetDefaultRoutes())
+	droppedRoutes, droppedNames := GetRoutes(w.GetDroppedRoutes())
+
+	// prepare transforms
+	subprocessors := transformers.NewTransforms(&w.GetConfig().OutgoingTransformers, w.GetLogger(), w.GetName(), w.GetOutputChannelAsList(), 0)
+
+	// goroutine to process transformed dns messages
+	go w.StartLogging()
+
+	// init remote conn
+	go w.ConnectToRemote()
+
+	// loop to process incoming messages
+	for {
+		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
+		case <-w.OnStop():
+			w.StopLogger()
+			subprocessors.Reset()
+			return
+
+		// new config provided?
+		case cfg := <-w.NewConfig():
+			w.SetConfig(cfg)
+			w.ReadConfig()
+			subprocessors.ReloadConfig(&cfg.OutgoingTransformers)
+
+		case dm, opened := <-w.GetInputChannel():
+			if !opened {
+				w.LogInfo("input channel closed!")
+				return
+			}
+			// count global messages
+			w.CountIngressTraffic()
+
+			// apply transforms, init dns message with additional parts if necessary
+			transformResult, err := subprocessors.ProcessMessage(&dm)
+			if err != nil {
+				w.LogError(err.Error())
+			}
+			if transformResult == transformers.ReturnDrop {
+				w.SendDroppedTo(droppedRoutes, droppedNames, dm)
+				continue
+			}
+
+			// send to output channel
+			w.CountEgressTraffic()
+			w.GetOutputChannel() <- dm
+
+			// send to next ?
+			w.SendForwardedTo(defaultRoutes, defaultNames, dm)
+		}
+	}
+}
+
+func (w *PdnsSender) StartLogging() {
+	w.LogInfo("logging has started")
+	defer w.LoggingDone()
+
+	// init buffer
+	bufferDm := []dnsutils.DNSMessage{}
+
+	// init flush timer for buffer
+	flushInterval := time.Duration(w.GetConfig().Loggers.PowerDNSClient.FlushInterval) * time.Second
+	flushTimer := time.NewTimer(flushInterval)
+
+	w.LogInfo("ready to process")
+	for {
+		select {
+		case <-w.OnLoggerStopped():
+			// closing remote connection if exist
+			w.Disconnect()
+			return
+
+		// init the writer
+		case <-w.transportReady:
+			w.LogInfo("transport connected with success")
+			w.transportWriter = bufio.NewWriter(w.transportConn)
+			w.writerReady = true
+
+		// incoming dns message to process
+		case dm, opened := <-w.GetOutputChannel():
+			if !opened {
+				w.LogInfo("output channel closed!")
+				return
+			}
+
+			// drop dns message if the connection is not ready to avoid memory leak or
+			// to block the channel
+			if !w.writerReady {
+				continue
+			}
+
+			// append dns message to buffer
+			bufferDm = append(bufferDm, dm)
+
+			// buffer is full ?
+			if len(bufferDm) >= w.GetConfig().Loggers.PowerDNSClient.BufferSize {
+				w.FlushBuffer(&bufferDm)
+			}
+
+		// flush the buffer
+		case <-flushTimer.C:
+			// force to flush the buffer
+			if len(bufferDm) > 0 {
+				w.FlushBuffer(&bufferDm)
+			}
+
+			// restart timer
+			flushTimer.Reset(flushInterval)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package workers
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+	"github.com/dmachard/go-netutils"
+	powerdns_protobuf "github.com/dmachard/go-powerdns-protobuf"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_PowerDNSClient(t *testing.T) {
+	// fake powerdns receiver
+	fakeRcvr, err := net.Listen(netutils.SocketTCP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fakeRcvr.Close()
+
+	// init logger
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.PowerDNSClient.RemotePort = fakeRcvr.Addr().(*net.TCPAddr).Port
+	cfg.Loggers.PowerDNSClient.FlushInterval = 1
+	cfg.Loggers.PowerDNSClient.BufferSize = 0
+	cfg.Loggers.PowerDNSClient.OverwriteIdentity = true
+	cfg.Loggers.PowerDNSClient.ServerID = "pdns-logger"
+
+	g := NewPdnsSender(cfg, logger.New(false), "test")
+
+	// start the logger
+	go g.StartCollect()
+
+	// accept conn from logger
+	conn, err := fakeRcvr.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// wait the writer to be ready
+	time.Sleep(time.Second)
+
+	// send fake dns message to logger
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.PowerDNS = &dnsutils.CollectorPowerDNS{Tags: []string{"tag1"}}
+	g.GetInputChannel() <- dm
+
+	// receive the length-prefixed protobuf on server side
+	pbs := powerdns_protobuf.NewProtobufStream(bufio.NewReader(conn), conn, 5*time.Second)
+	payload, err := pbs.RecvPayload(true)
+	if err != nil {
+		t.Fatalf("error to receive payload: %s", err)
+	}
+
+	pbdm := &powerdns_protobuf.PBDNSMessage{}
+	if err := proto.Unmarshal(payload.Data(), pbdm); err != nil {
+		t.Fatalf("error to decode powerdns protobuf: %s", err)
+	}
+	if pbdm.GetQuestion().GetQName() != "dns.collector." {
+		t.Errorf("invalid qname: %s", pbdm.GetQuestion().GetQName())
+	}
+	if string(pbdm.GetServerIdentity()) != "pdns-logger" {
+		t.Errorf("identity not overwritten: %s", pbdm.GetServerIdentity())
+	}
+	if len(pbdm.GetResponse().GetTags()) != 1 {
+		t.Errorf("invalid tags: %v", pbdm.GetResponse().GetTags())
+	}
+}
+
+func Test_PowerDNSClient_FrameTooLarge(t *testing.T) {
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+	defer conn2.Close()
+
+	if err := WritePdnsFrame(bufio.NewWriter(conn1), make([]byte, powerdns_protobuf.DATA_FRAME_LENGTH_MAX+1)); err != ErrPdnsPayloadTooLarge {
+		t.Errorf("payload too large error expected, got %v", err)
+	}
+}
+
+func Test_PowerDNSClient_StopWhileReconnecting(t *testing.T) {
+	// reserve a port without receiver
+	listener, err := net.Listen(netutils.SocketTCP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	listener.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.PowerDNSClient.RemoteAddress = "127.0.0.1"
+	cfg.Loggers.PowerDNSClient.RemotePort = addr.Port
+	cfg.Loggers.PowerDNSClient.ConnectTimeout = 1
+	cfg.Loggers.PowerDNSClient.RetryInterval = 1
+
+	g := NewPdnsSender(cfg, logger.New(false), "test")
+	go g.StartCollect()
+	time.Sleep(500 * time.Millisecond)
+
+	stopped := make(chan bool)
+	go func() {
+		g.Stop()
+		stopped <- true
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the logger is not stopped")
+	}
+
+	// the receiver is back, the stopped logger must not connect
+	fakeRcvr, err := net.Listen(netutils.SocketTCP, addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fakeRcvr.Close()
+	fakeRcvr.(*net.TCPListener).SetDeadline(time.Now().Add(3 * time.Second))
+	if conn, err := fakeRcvr.Accept(); err == nil {
+		conn.Close()
+		t.Error("the logger is connected after stop")
+	}
+}
@@ -36,6 +36,7 @@ var catalogueSelectors map[string]func(*dnsutils.DNSMessage) string = map[string
 	"stream_id":     GetStreamID,
 	"resolver":      GetResolverIP,
 	"stream_global": GetStreamGlobal,
+	"edns_nsid":     GetEdnsNSID,
 }
 
 /*
@@ -140,6 +141,13 @@ func GetResolverIP(dm *dnsutils.DNSMessage) string {
 	return dm.NetworkInfo.ResponseIP
 }
 
+func GetEdnsNSID(dm *dnsutils.DNSMessage) string {
+	if len(dm.EDNS.NSID) == 0 {
+		return "-"
+	}
+	return dm.EDNS.NSID
+}
+
 type Prometheus struct {
 	*GenericWorker
 	doneAPI      chan bool
@@ -179,6 +187,10 @@ type Prometheus struct {
 	// by default in configuration
 	histogramQueriesLength, histogramRepliesLength *prometheus.HistogramVec
 	histogramQnamesLength, histogramLatencies      *prometheus.HistogramVec
+
+	// User-defined metrics, declared in the custom-metrics stanza
+	customMetrics         []*PromCustomMetric
+	counterCustomOverflow *prometheus.CounterVec
 }
 
 func newPrometheusCounterSet(w *Prometheus, labels prometheus.Labels) *PrometheusCountersSet {
@@ -1034,6 +1046,28 @@ func (w *Prometheus) InitProm() {
 		w.catalogueLabels,
 	)
 	w.promRegistry.MustRegister(w.histogramLatencies)
+
+	// User-defined counters and histograms
+	if len(w.GetConfig().Loggers.Prometheus.CustomMetrics) > 0 {
+		w.counterCustomOverflow = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_custom_metrics_overflow_total", promPrefix),
+				Help: "Number of observations recorded in the overflow series due to the cardinality limit",
+			},
+			[]string{"metric"},
+		)
+		w.promRegistry.MustRegister(w.counterCustomOverflow)
+	}
+	for _, cfg := range w.GetConfig().Loggers.Prometheus.CustomMetrics {
+		metric, err := NewPromCustomMetric(cfg, promPrefix, w.GetConfig().Loggers.Prometheus.CustomMetricsCardinality, w.counterCustomOverflow)
+		if err != nil {
+			w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] prometheus - ", err)
+		}
+		if err := w.promRegistry.Register(metric.Collector()); err != nil {
+			w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] prometheus - unable to register custom metric: ", err)
+		}
+		w.customMetrics = append(w.customMetrics, metric)
+	}
 }
 
 func (w *Prometheus) ReadConfig() {
@@ -1056,6 +1090,11 @@ func (w *Prometheus) Record(dm dnsutils.DNSMessage) {
 		counterSet.Record(dm)
 	}
 
+	// update the user-defined metrics
+	for _, metric := range w.customMetrics {
+		metric.Record(&dm)
+	}
+
 }
 
 func (w *Prometheus) ComputeEventsPerSecond() {
@@ -1147,6 +1186,10 @@ func (w *Prometheus) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
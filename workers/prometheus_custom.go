@@ -0,0 +1,206 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-dnscollector/telemetry"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// label value used when the json path is missing in the dns message
+// or when the cardinality limit of the metric is reached
+const (
+	promCustomLabelEmpty    = "-"
+	promCustomLabelOverflow = "_other_"
+)
+
+// PromCustomMetric is a counter or histogram declared in the config,
+// updated for each dns message matching the conditions
+type PromCustomMetric struct {
+	name           string
+	route          ConditionalRoute
+	labelPaths     []string
+	valuePath      string
+	maxCardinality int
+
+	counter   *prometheus.CounterVec
+	histogram *prometheus.HistogramVec
+	overflow  prometheus.Counter
+
+	sync.Mutex
+	series map[string]bool
+}
+
+func NewPromCustomMetric(cfg pkgconfig.ConfigPrometheusMetric, promPrefix string, defaultCardinality int, overflow *prometheus.CounterVec) (*PromCustomMetric, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("custom metric without name")
+	}
+
+	m := &PromCustomMetric{
+		name:           fmt.Sprintf("%s_%s", promPrefix, telemetry.SanitizeMetricName(cfg.Name)),
+		labelPaths:     cfg.Labels,
+		valuePath:      cfg.Value,
+		maxCardinality: cfg.MaxCardinality,
+		series:         make(map[string]bool),
+	}
+	if m.maxCardinality <= 0 {
+		m.maxCardinality = defaultCardinality
+	}
+
+	route, err := NewConditionalRoute(cfg.Matching.Include, cfg.Matching.Exclude, nil)
+	if err != nil {
+		return nil, fmt.Errorf("custom metric %s: %w", cfg.Name, err)
+	}
+	m.route = route
+
+	// label names are derived from the json paths, geoip.country-isocode => geoip_country_isocode
+	labelNames := make([]string, 0, len(cfg.Labels))
+	for _, path := range cfg.Labels {
+		labelNames = append(labelNames, telemetry.SanitizeMetricName(path))
+	}
+
+	help := cfg.Help
+	if help == "" {
+		help = fmt.Sprintf("User-defined %s %s", cfg.Type, cfg.Name)
+	}
+
+	switch cfg.Type {
+	case pkgconfig.PromMetricCounter:
+		m.counter = prometheus.NewCounterVec(prometheus.CounterOpts{Name: m.name, Help: help}, labelNames)
+	case pkgconfig.PromMetricHistogram:
+		if cfg.Value == "" {
+			return nil, fmt.Errorf("custom metric %s: value is required for histogram", cfg.Name)
+		}
+		buckets := cfg.Buckets
+		if len(buckets) == 0 {
+			buckets = prometheus.DefBuckets
+		}
+		m.histogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: m.name, Help: help, Buckets: buckets}, labelNames)
+	default:
+		return nil, fmt.Errorf("custom metric %s: invalid type %q, counter or histogram expected", cfg.Name, cfg.Type)
+	}
+
+	m.overflow = overflow.WithLabelValues(m.name)
+	return m, nil
+}
+
+// Collector returns the vector to register
+func (m *PromCustomMetric) Collector() prometheus.Collector {
+	if m.histogram != nil {
+		return m.histogram
+	}
+	return m.counter
+}
+
+func (m *PromCustomMetric) Record(dm *dnsutils.DNSMessage) {
+	if matched, err := m.route.Match(dm); err != nil || !matched {
+		return
+	}
+
+	dmValue := reflect.ValueOf(dm).Elem()
+
+	// histogram without value are ignored
+	var observed float64
+	if m.histogram != nil {
+		value, found := dnsutils.GetFieldByJSONTag(dmValue, m.valuePath)
+		if !found {
+			return
+		}
+		if observed, found = promCustomObservedValue(value); !found {
+			return
+		}
+	}
+
+	labelValues := make([]string, len(m.labelPaths))
+	for i, path := range m.labelPaths {
+		labelValues[i] = promCustomLabelValue(dmValue, path)
+	}
+	labelValues = m.limitCardinality(labelValues)
+
+	if m.histogram != nil {
+		m.histogram.WithLabelValues(labelValues...).Observe(observed)
+	} else {
+		m.counter.WithLabelValues(labelValues...).Inc()
+	}
+}
+
+// limitCardinality replaces the label values by the overflow value
+// when the number of series reaches the maximum of the metric
+func (m *PromCustomMetric) limitCardinality(labelValues []string) []string {
+	if len(labelValues) == 0 {
+		return labelValues
+	}
+
+	key := strings.Join(labelValues, "\x00")
+
+	m.Lock()
+	defer m.Unlock()
+	if m.series[key] {
+		return labelValues
+	}
+	if len(m.series) < m.maxCardinality {
+		m.series[key] = true
+		return labelValues
+	}
+
+	m.overflow.Inc()
+	for i := range labelValues {
+		labelValues[i] = promCustomLabelOverflow
+	}
+	return labelValues
+}
+
+// promCustomObservedValue converts any numeric field to the value of the histogram
+func promCustomObservedValue(value reflect.Value) (float64, bool) {
+	switch {
+	case value.CanInt():
+		return float64(value.Int()), true
+	case value.CanUint():
+		return float64(value.Uint()), true
+	case value.CanFloat():
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+// promCustomLabelValue converts the field to a label value, lists are joined with a comma
+func promCustomLabelValue(dmValue reflect.Value, path string) string {
+	value, found := dnsutils.GetFieldByJSONTag(dmValue, path)
+	if !found {
+		return promCustomLabelEmpty
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		if value.String() == "" {
+			return promCustomLabelEmpty
+		}
+		return strings.ToValidUTF8(value.String(), "�")
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		if value.Len() == 0 {
+			return promCustomLabelEmpty
+		}
+		items := make([]string, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			items = append(items, fmt.Sprintf("%v", value.Index(i).Interface()))
+		}
+		return strings.ToValidUTF8(strings.Join(items, ","), "�")
+	default:
+		return fmt.Sprintf("%v", value.Interface())
+	}
+}
@@ -0,0 +1,178 @@
+package workers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+	"gopkg.in/yaml.v3"
+)
+
+func loadCustomMetrics(t *testing.T, config *pkgconfig.Config, content string) {
+	if err := yaml.Unmarshal([]byte(content), &config.Loggers.Prometheus.CustomMetrics); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrometheus_CustomCounter(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	loadCustomMetrics(t, config, `
+- name: nxdomain_by_country
+  type: counter
+  matching:
+    include:
+      dns.rcode: "NXDOMAIN"
+  labels: [ geoip.country-isocode, dns.flags.ad ]
+`)
+	g := NewPrometheus(config, logger.New(false), "test")
+
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.Geo = &dnsutils.TransformDNSGeo{CountryIsoCode: "FR"}
+	dm.DNS.Rcode = "NXDOMAIN"
+	g.Record(dm)
+	g.Record(dm)
+
+	// not matching
+	dm.DNS.Rcode = "NOERROR"
+	g.Record(dm)
+
+	// geoip not available
+	dmNoGeo := dnsutils.GetFakeDNSMessage()
+	dmNoGeo.DNS.Rcode = "NXDOMAIN"
+	g.Record(dmNoGeo)
+
+	mf := getMetrics(g, t)
+	if !ensureMetricValue(t, mf, "dnscollector_nxdomain_by_country", map[string]string{"geoip_country_isocode": "FR", "dns_flags_ad": "false"}, 2) {
+		t.Errorf("Cannot validate dnscollector_nxdomain_by_country!")
+	}
+	if !ensureMetricValue(t, mf, "dnscollector_nxdomain_by_country", map[string]string{"geoip_country_isocode": "-", "dns_flags_ad": "false"}, 1) {
+		t.Errorf("Cannot validate dnscollector_nxdomain_by_country without geoip!")
+	}
+}
+
+func TestPrometheus_CustomHistogram(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	loadCustomMetrics(t, config, `
+- name: tagged_latency
+  type: histogram
+  labels: [ atags.tags ]
+  value: dnstap.latency
+  buckets: [ 0.01, 0.1, 1 ]
+`)
+	g := NewPrometheus(config, logger.New(false), "test")
+
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.ATags = &dnsutils.TransformATags{Tags: []string{"tag1", "tag2"}}
+	dm.DNSTap.Latency = 0.05
+	g.Record(dm)
+
+	mf := getMetrics(g, t)
+	if !ensureMetricValue(t, mf, "dnscollector_tagged_latency", map[string]string{"atags_tags": "tag1,tag2"}, 1) {
+		t.Errorf("Cannot validate dnscollector_tagged_latency!")
+	}
+}
+
+func TestPrometheus_CustomCardinality(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	loadCustomMetrics(t, config, `
+- name: queries_by_qname
+  type: counter
+  labels: [ dns.qname ]
+  max-cardinality: 2
+`)
+	g := NewPrometheus(config, logger.New(false), "test")
+
+	for _, qname := range []string{"a.com", "b.com", "c.com", "d.com", "a.com"} {
+		dm := dnsutils.GetFakeDNSMessage()
+		dm.DNS.Qname = qname
+		g.Record(dm)
+	}
+
+	mf := getMetrics(g, t)
+	if !ensureMetricValue(t, mf, "dnscollector_queries_by_qname", map[string]string{"dns_qname": "a.com"}, 2) {
+		t.Errorf("Cannot validate dnscollector_queries_by_qname!")
+	}
+	if !ensureMetricValue(t, mf, "dnscollector_queries_by_qname", map[string]string{"dns_qname": "_other_"}, 2) {
+		t.Errorf("Cannot validate overflow series of dnscollector_queries_by_qname!")
+	}
+	if !ensureMetricValue(t, mf, "dnscollector_custom_metrics_overflow_total", map[string]string{"metric": "dnscollector_queries_by_qname"}, 2) {
+		t.Errorf("Cannot validate dnscollector_custom_metrics_overflow_total!")
+	}
+	if len(mf["dnscollector_queries_by_qname"].Metric) != 3 {
+		t.Errorf("3 series expected, got %d", len(mf["dnscollector_queries_by_qname"].Metric))
+	}
+}
+
+func TestPrometheus_CustomInvalidConfig(t *testing.T) {
+	testcases := []struct {
+		name string
+		cfg  pkgconfig.ConfigPrometheusMetric
+	}{
+		{name: "missing_name", cfg: pkgconfig.ConfigPrometheusMetric{Type: pkgconfig.PromMetricCounter}},
+		{name: "invalid_type", cfg: pkgconfig.ConfigPrometheusMetric{Name: "test", Type: "gauge"}},
+		{name: "histogram_without_value", cfg: pkgconfig.ConfigPrometheusMetric{Name: "test", Type: pkgconfig.PromMetricHistogram}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewPromCustomMetric(tc.cfg, "dnscollector", 10, nil); err == nil {
+				t.Errorf("error expected")
+			}
+		})
+	}
+}
+
+func TestPrometheus_CustomObservedValue(t *testing.T) {
+	testcases := []struct {
+		value    interface{}
+		expected float64
+		valid    bool
+	}{
+		{value: 10, expected: 10, valid: true},
+		{value: int32(-5), expected: -5, valid: true},
+		{value: uint16(53), expected: 53, valid: true},
+		{value: uint64(1024), expected: 1024, valid: true},
+		{value: float32(0.5), expected: 0.5, valid: true},
+		{value: 0.25, expected: 0.25, valid: true},
+		{value: "10", valid: false},
+		{value: true, valid: false},
+	}
+
+	for _, tc := range testcases {
+		observed, valid := promCustomObservedValue(reflect.ValueOf(tc.value))
+		if valid != tc.valid || observed != tc.expected {
+			t.Errorf("%T(%v): expected %v %v, got %v %v", tc.value, tc.value, tc.expected, tc.valid, observed, valid)
+		}
+	}
+}
+
+func TestPrometheus_CustomMatchSource(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	loadCustomMetrics(t, config, `
+- name: kept_domains
+  type: counter
+  matching:
+    include:
+      dns.qname:
+        match-source: "file://../tests/testsdata/filtering_keep_domains_regex.txt"
+        source-kind: "regexp_list"
+  labels: [ dns.qname ]
+`)
+	g := NewPrometheus(config, logger.New(false), "test")
+
+	for _, qname := range []string{"mail.google.com", "dns.collector", "mail.google.com"} {
+		dm := dnsutils.GetFakeDNSMessage()
+		dm.DNS.Qname = qname
+		g.Record(dm)
+	}
+
+	mf := getMetrics(g, t)
+	if !ensureMetricValue(t, mf, "dnscollector_kept_domains", map[string]string{"dns_qname": "mail.google.com"}, 2) {
+		t.Errorf("Cannot validate dnscollector_kept_domains!")
+	}
+	if len(mf["dnscollector_kept_domains"].Metric) != 1 {
+		t.Errorf("1 series expected, got %d", len(mf["dnscollector_kept_domains"].Metric))
+	}
+}
@@ -70,6 +70,23 @@ func TestPrometheus_GetMetrics(t *testing.T) {
 	t.Run("TwoLabelsStreamIDResolver", getMetricsTestCase(config, map[string]string{"resolver": "4.3.2.1", "stream_id": "collector"}))
 }
 
+func TestPrometheus_EdnsLabels(t *testing.T) {
+	dm := dnsutils.GetFakeDNSMessage()
+	if GetEdnsNSID(&dm) != "-" {
+		t.Errorf("invalid labels for message without edns options")
+	}
+
+	dm.EDNS.NSID = "ns1.fra"
+	if label := catalogueSelectors["edns_nsid"](&dm); label != "ns1.fra" {
+		t.Errorf("invalid edns_nsid label: %s", label)
+	}
+
+	// the client cookie is unique per client, it's not available as label
+	if _, ok := catalogueSelectors["edns_client_cookie"]; ok {
+		t.Errorf("unexpected edns_client_cookie label")
+	}
+}
+
 // This helper generates a set of DNS packets for logger to count
 // It then collects Prometheus metrics to verify they exist and have expected labels/values
 // func getMetricsHelper(config *pkgconfig.Config, labels map[string]string, t *testing.T) {
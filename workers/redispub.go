@@ -2,14 +2,12 @@ package workers
 
 import (
 	"bufio"
-	"bytes"
 	"crypto/tls"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
@@ -21,8 +19,8 @@ import (
 
 type RedisPub struct {
 	*GenericWorker
-	stopRead, doneRead                 chan bool
-	textFormat                         []string
+	stopConnect                        chan bool
+	encoder                            dnsutils.Encoder
 	transport                          string
 	transportWriter                    *bufio.Writer
 	transportConn                      net.Conn
@@ -36,8 +34,7 @@ func NewRedisPub(config *pkgconfig.Config, logger *logger.Logger, name string) *
 		bufSize = config.Loggers.RedisPub.ChannelBufferSize
 	}
 	w := &RedisPub{GenericWorker: NewGenericWorker(config, logger, name, "redispub", bufSize, pkgconfig.DefaultMonitor)}
-	w.stopRead = make(chan bool)
-	w.doneRead = make(chan bool)
+	w.stopConnect = make(chan bool)
 	w.transportReady = make(chan bool)
 	w.transportReconnect = make(chan bool)
 	w.ReadConfig()
@@ -57,42 +54,30 @@ func (w *RedisPub) ReadConfig() {
 	}
 	// end
 
-	if len(w.GetConfig().Loggers.RedisPub.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.RedisPub.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
-	}
+	w.encoder = w.NewEncoder(w.GetConfig().Loggers.RedisPub.Mode, w.GetEncoderOptions(w.GetConfig().Loggers.RedisPub.TextFormat, ""))
 }
 
 func (w *RedisPub) Disconnect() {
+	close(w.stopConnect)
 	if w.transportConn != nil {
 		w.LogInfo("closing redispub connection")
 		w.transportConn.Close()
 	}
 }
 
-func (w *RedisPub) ReadFromConnection() {
+// ReadFromConnection discards the data sent by the remote, until the connection is closed
+func (w *RedisPub) ReadFromConnection(conn net.Conn) {
 	buffer := make([]byte, 4096)
-
-	go func() {
-		for {
-			_, err := w.transportConn.Read(buffer)
-			if err != nil {
-				if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
-					w.LogInfo("read from connection terminated")
-					break
-				}
-				w.LogError("Error on reading: %s", err.Error())
+	for {
+		_, err := conn.Read(buffer)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				w.LogInfo("read from connection terminated")
+				return
 			}
-			// We just discard the data
+			w.LogError("Error on reading: %s", err.Error())
 		}
-	}()
-
-	// block goroutine until receive true event in stopRead channel
-	<-w.stopRead
-	w.doneRead <- true
-
-	w.LogInfo("read goroutine terminated")
+	}
 }
 
 func (w *RedisPub) ConnectToRemote() {
@@ -148,57 +133,56 @@ func (w *RedisPub) ConnectToRemote() {
 		if err != nil {
 			w.LogError("%s", err)
 			w.LogInfo("retry to connect in %d seconds", w.GetConfig().Loggers.RedisPub.RetryInterval)
-			time.Sleep(time.Duration(w.GetConfig().Loggers.RedisPub.RetryInterval) * time.Second)
-			continue
+			select {
+			case <-w.stopConnect:
+				return
+			case <-time.After(time.Duration(w.GetConfig().Loggers.RedisPub.RetryInterval) * time.Second):
+				continue
+			}
 		}
 
 		w.transportConn = conn
 
 		// block until framestream is ready
-		w.transportReady <- true
+		select {
+		case w.transportReady <- true:
+		case <-w.stopConnect:
+			conn.Close()
+			return
+		}
 
 		// block until an error occurred, need to reconnect
-		w.transportReconnect <- true
+		select {
+		case w.transportReconnect <- true:
+		case <-w.stopConnect:
+			return
+		}
 	}
 }
 
 func (w *RedisPub) FlushBuffer(buf *[]dnsutils.DNSMessage) {
-	// create escaping buffer
-	escapeBuffer := new(bytes.Buffer)
-	// create a new encoder that writes to the buffer
-	encoder := json.NewEncoder(escapeBuffer)
+	channel := w.GetConfig().Loggers.RedisPub.RedisChannel
 
 	for _, dm := range *buf {
-		escapeBuffer.Reset()
-
-		cmd := "PUBLISH " + strconv.Quote(w.GetConfig().Loggers.RedisPub.RedisChannel) + " "
-		w.transportWriter.WriteString(cmd)
-
-		if w.GetConfig().Loggers.RedisPub.Mode == pkgconfig.ModeText {
-			w.transportWriter.WriteString(strconv.Quote(dm.String(w.textFormat, w.GetConfig().Global.TextFormatDelimiter, w.GetConfig().Global.TextFormatBoundary)))
-			w.transportWriter.WriteString(w.GetConfig().Loggers.RedisPub.PayloadDelimiter)
-		}
-
-		if w.GetConfig().Loggers.RedisPub.Mode == pkgconfig.ModeJSON {
-			encoder.Encode(dm)
-			w.transportWriter.WriteString(strconv.Quote(escapeBuffer.String()))
-			w.transportWriter.WriteString(w.GetConfig().Loggers.RedisPub.PayloadDelimiter)
+		data, err := w.encoder.Encode(&dm)
+		if err != nil {
+			w.LogError("encoding DNS message failed: %s", err)
+			continue
 		}
 
-		if w.GetConfig().Loggers.RedisPub.Mode == pkgconfig.ModeFlatJSON {
-			flat, err := dm.Flatten()
-			if err != nil {
-				w.LogError("flattening DNS message failed: %e", err)
-				continue
-			}
-			encoder.Encode(flat)
-			w.transportWriter.WriteString(strconv.Quote(escapeBuffer.String()))
+		if w.encoder.IsBinary() {
+			// binary payload, use the multi bulk request of the redis protocol
+			fmt.Fprintf(w.transportWriter, "*3\r\n$7\r\nPUBLISH\r\n$%d\r\n%s\r\n$%d\r\n", len(channel), channel, len(data))
+			w.transportWriter.Write(data)
+			w.transportWriter.WriteString("\r\n")
+		} else {
+			w.transportWriter.WriteString("PUBLISH " + strconv.Quote(channel) + " ")
+			w.transportWriter.WriteString(strconv.Quote(string(data)))
 			w.transportWriter.WriteString(w.GetConfig().Loggers.RedisPub.PayloadDelimiter)
 		}
 
 		// flush the transport buffer
-		err := w.transportWriter.Flush()
-		if err != nil {
+		if err := w.transportWriter.Flush(); err != nil {
 			w.LogError("send frame error", err.Error())
 			w.writerReady = false
 			<-w.transportReconnect
@@ -227,13 +211,13 @@ func (w *RedisPub) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
-
-			w.stopRead <- true
-			<-w.doneRead
-
 			return
 
 			// new config provided?
@@ -296,7 +280,7 @@ func (w *RedisPub) StartLogging() {
 			w.transportWriter = bufio.NewWriter(w.transportConn)
 			w.writerReady = true
 			// read from the connection until we stop
-			go w.ReadFromConnection()
+			go w.ReadFromConnection(w.transportConn)
 
 			// incoming dns message to process
 		case dm, opened := <-w.GetOutputChannel():
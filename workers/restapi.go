@@ -3,41 +3,22 @@ package workers
 import (
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"net"
 	"net/http"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-dnscollector/transformers"
 	"github.com/dmachard/go-logger"
 	"github.com/dmachard/go-netutils"
-	"github.com/dmachard/go-topmap"
+	lru "github.com/hashicorp/golang-lru/v2"
 )
 
-type HitsRecord struct {
-	TotalHits int            `json:"total-hits"`
-	Hits      map[string]int `json:"hits"`
-}
-
-type SearchBy struct {
-	Clients map[string]*HitsRecord
-	Domains map[string]*HitsRecord
-}
-
-type HitsStream struct {
-	Streams map[string]SearchBy
-}
-
-type HitsUniq struct {
-	Clients        map[string]int
-	Domains        map[string]int
-	NxDomains      map[string]int
-	SfDomains      map[string]int
-	PublicSuffixes map[string]int
-	Suspicious     map[string]*dnsutils.TransformSuspicious
-}
+var ErrInvalidStatsWindow = errors.New("invalid window")
 
 type KeyHit struct {
 	Key string `json:"key"`
@@ -50,16 +31,14 @@ type RestAPI struct {
 	httpserver net.Listener
 	httpmux    *http.ServeMux
 
-	HitsStream HitsStream
-	HitsUniq   HitsUniq
+	Streams    map[string]int `json:"streams"`
+	Stats      map[string]*StreamHits
+	Suspicious *lru.Cache[string, *dnsutils.TransformSuspicious]
+	Messages   *MessagesRing
 
-	Streams map[string]int `json:"streams"`
+	StreamClients *StreamClients
 
-	TopQnames      *topmap.TopMap
-	TopClients     *topmap.TopMap
-	TopTLDs        *topmap.TopMap
-	TopNonExistent *topmap.TopMap
-	TopServFail    *topmap.TopMap
+	now func() time.Time
 
 	sync.RWMutex
 }
@@ -70,30 +49,38 @@ func NewRestAPI(config *pkgconfig.Config, logger *logger.Logger, name string) *R
 		bufSize = config.Loggers.RestAPI.ChannelBufferSize
 	}
 	w := &RestAPI{GenericWorker: NewGenericWorker(config, logger, name, "restapi", bufSize, pkgconfig.DefaultMonitor)}
-	w.HitsStream = HitsStream{
-		Streams: make(map[string]SearchBy),
-	}
-	w.HitsUniq = HitsUniq{
-		Clients:        make(map[string]int),
-		Domains:        make(map[string]int),
-		NxDomains:      make(map[string]int),
-		SfDomains:      make(map[string]int),
-		PublicSuffixes: make(map[string]int),
-		Suspicious:     make(map[string]*dnsutils.TransformSuspicious),
-	}
-	w.Streams = make(map[string]int)
-	w.TopQnames = topmap.NewTopMap(config.Loggers.RestAPI.TopN)
-	w.TopClients = topmap.NewTopMap(config.Loggers.RestAPI.TopN)
-	w.TopTLDs = topmap.NewTopMap(config.Loggers.RestAPI.TopN)
-	w.TopNonExistent = topmap.NewTopMap(config.Loggers.RestAPI.TopN)
-	w.TopServFail = topmap.NewTopMap(config.Loggers.RestAPI.TopN)
+	w.now = time.Now
+	w.StreamClients = NewStreamClients()
+	w.ReadConfig()
+	w.resetStats()
 	return w
 }
 
+func (w *RestAPI) resetStats() {
+	w.Streams = make(map[string]int)
+	w.Stats = make(map[string]*StreamHits)
+	w.Suspicious, _ = lru.New[string, *dnsutils.TransformSuspicious](w.maxCardinality())
+	w.Messages = NewMessagesRing(w.GetConfig().Loggers.RestAPI.RingBufferSize)
+}
+
+func (w *RestAPI) maxCardinality() int {
+	if w.GetConfig().Loggers.RestAPI.MaxCardinality > 0 {
+		return w.GetConfig().Loggers.RestAPI.MaxCardinality
+	}
+	return pkgconfig.DefaultMaxCardinality
+}
+
 func (w *RestAPI) ReadConfig() {
 	if !netutils.IsValidTLS(w.GetConfig().Loggers.RestAPI.TLSMinVersion) {
 		w.LogFatal(pkgconfig.PrefixLogWorker + "[" + w.GetName() + "]restapi - invalid tls min version")
 	}
+
+	// resize the ring buffer of messages if needed
+	w.Lock()
+	if w.Messages != nil && w.Messages.Size() != w.GetConfig().Loggers.RestAPI.RingBufferSize {
+		w.Messages = NewMessagesRing(w.GetConfig().Loggers.RestAPI.RingBufferSize)
+	}
+	w.Unlock()
 }
 
 func (w *RestAPI) BasicAuth(httpWriter http.ResponseWriter, r *http.Request) bool {
@@ -106,9 +93,49 @@ func (w *RestAPI) BasicAuth(httpWriter http.ResponseWriter, r *http.Request) boo
 		(password == w.GetConfig().Loggers.RestAPI.BasicAuthPwd)
 }
 
+// GetHits sums the hits of the table selected for the window and the stream
+// provided in the query parameters, all hits of all streams by default
+func (w *RestAPI) GetHits(r *http.Request, table func(*hitsTables) *HitsCounter) (map[string]int, error) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = StatsWindowAll
+	}
+	if !IsValidStatsWindow(window) {
+		return nil, ErrInvalidStatsWindow
+	}
+	stream := r.URL.Query().Get("stream")
+
+	hits := make(map[string]int)
+	now := w.now()
+	for identity, stats := range w.Stats {
+		if stream != "" && stream != identity {
+			continue
+		}
+		stats.Hits(window, table, hits, now)
+	}
+	return hits, nil
+}
+
+// writeHits encodes the hits as an array, sorted and limited to the top N if required
+func (w *RestAPI) writeHits(httpWriter http.ResponseWriter, r *http.Request, table func(*hitsTables) *HitsCounter, top bool) {
+	hits, err := w.GetHits(r, table)
+	if err != nil {
+		http.Error(httpWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var dataArray []KeyHit
+	if top {
+		dataArray = SortHits(hits, w.GetConfig().Loggers.RestAPI.TopN)
+	} else {
+		dataArray = SortHits(hits, 0)
+	}
+	json.NewEncoder(httpWriter).Encode(dataArray)
+}
+
 func (w *RestAPI) DeleteResetHandler(httpWriter http.ResponseWriter, r *http.Request) {
-	w.RLock()
-	defer w.RUnlock()
+	w.Lock()
+	defer w.Unlock()
 
 	if !w.BasicAuth(httpWriter, r) {
 		http.Error(httpWriter, "Not authorized", http.StatusUnauthorized)
@@ -117,23 +144,7 @@ func (w *RestAPI) DeleteResetHandler(httpWriter http.ResponseWriter, r *http.Req
 
 	switch r.Method {
 	case http.MethodDelete:
-
-		w.HitsUniq.Clients = make(map[string]int)
-		w.HitsUniq.Domains = make(map[string]int)
-		w.HitsUniq.NxDomains = make(map[string]int)
-		w.HitsUniq.SfDomains = make(map[string]int)
-		w.HitsUniq.PublicSuffixes = make(map[string]int)
-		w.HitsUniq.Suspicious = make(map[string]*dnsutils.TransformSuspicious)
-
-		w.Streams = make(map[string]int)
-
-		w.TopQnames = topmap.NewTopMap(w.GetConfig().Loggers.RestAPI.TopN)
-		w.TopClients = topmap.NewTopMap(w.GetConfig().Loggers.RestAPI.TopN)
-		w.TopTLDs = topmap.NewTopMap(w.GetConfig().Loggers.RestAPI.TopN)
-		w.TopNonExistent = topmap.NewTopMap(w.GetConfig().Loggers.RestAPI.TopN)
-		w.TopServFail = topmap.NewTopMap(w.GetConfig().Loggers.RestAPI.TopN)
-
-		w.HitsStream.Streams = make(map[string]SearchBy)
+		w.resetStats()
 
 		httpWriter.Header().Set("Content-Type", "application/text")
 		httpWriter.Write([]byte("OK"))
@@ -155,7 +166,7 @@ func (w *RestAPI) GetTopTLDsHandler(httpWriter http.ResponseWriter, r *http.Requ
 
 	switch r.Method {
 	case http.MethodGet:
-		json.NewEncoder(httpWriter).Encode(w.TopTLDs.Get())
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.tlds }, true)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -174,7 +185,7 @@ func (w *RestAPI) GetTopClientsHandler(httpWriter http.ResponseWriter, r *http.R
 
 	switch r.Method {
 	case http.MethodGet:
-		json.NewEncoder(httpWriter).Encode(w.TopClients.Get())
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.clients }, true)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -193,7 +204,7 @@ func (w *RestAPI) GetTopDomainsHandler(httpWriter http.ResponseWriter, r *http.R
 
 	switch r.Method {
 	case http.MethodGet:
-		json.NewEncoder(httpWriter).Encode(w.TopQnames.Get())
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.domains }, true)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -212,7 +223,7 @@ func (w *RestAPI) GetTopNxDomainsHandler(httpWriter http.ResponseWriter, r *http
 
 	switch r.Method {
 	case http.MethodGet:
-		json.NewEncoder(httpWriter).Encode(w.TopNonExistent.Get())
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.nxDomains }, true)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -231,7 +242,7 @@ func (w *RestAPI) GetTopSfDomainsHandler(httpWriter http.ResponseWriter, r *http
 
 	switch r.Method {
 	case http.MethodGet:
-		json.NewEncoder(httpWriter).Encode(w.TopServFail.Get())
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.sfDomains }, true)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -250,14 +261,7 @@ func (w *RestAPI) GetTLDsHandler(httpWriter http.ResponseWriter, r *http.Request
 
 	switch r.Method {
 	case http.MethodGet:
-		// return as array
-		dataArray := []KeyHit{}
-		for tld, hit := range w.HitsUniq.PublicSuffixes {
-			dataArray = append(dataArray, KeyHit{Key: tld, Hit: hit})
-		}
-
-		// encode
-		json.NewEncoder(httpWriter).Encode(dataArray)
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.tlds }, false)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -276,13 +280,7 @@ func (w *RestAPI) GetClientsHandler(httpWriter http.ResponseWriter, r *http.Requ
 
 	switch r.Method {
 	case http.MethodGet:
-		// return as array
-		dataArray := []KeyHit{}
-		for address, hit := range w.HitsUniq.Clients {
-			dataArray = append(dataArray, KeyHit{Key: address, Hit: hit})
-		}
-		// encode
-		json.NewEncoder(httpWriter).Encode(dataArray)
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.clients }, false)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -301,14 +299,7 @@ func (w *RestAPI) GetDomainsHandler(httpWriter http.ResponseWriter, r *http.Requ
 
 	switch r.Method {
 	case http.MethodGet:
-		// return as array
-		dataArray := []KeyHit{}
-		for domain, hit := range w.HitsUniq.Domains {
-			dataArray = append(dataArray, KeyHit{Key: domain, Hit: hit})
-		}
-
-		// encode
-		json.NewEncoder(httpWriter).Encode(dataArray)
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.domains }, false)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -327,15 +318,7 @@ func (w *RestAPI) GetNxDomainsHandler(httpWriter http.ResponseWriter, r *http.Re
 
 	switch r.Method {
 	case http.MethodGet:
-		// convert to array
-		dataArray := []KeyHit{}
-		for domain, hit := range w.HitsUniq.NxDomains {
-			dataArray = append(dataArray, KeyHit{Key: domain, Hit: hit})
-		}
-
-		// encode
-		json.NewEncoder(httpWriter).Encode(dataArray)
-
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.nxDomains }, false)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -354,14 +337,7 @@ func (w *RestAPI) GetSfDomainsHandler(httpWriter http.ResponseWriter, r *http.Re
 
 	switch r.Method {
 	case http.MethodGet:
-		// return as array
-		dataArray := []KeyHit{}
-		for domain, hit := range w.HitsUniq.SfDomains {
-			dataArray = append(dataArray, KeyHit{Key: domain, Hit: hit})
-		}
-
-		// encode
-		json.NewEncoder(httpWriter).Encode(dataArray)
+		w.writeHits(httpWriter, r, func(t *hitsTables) *HitsCounter { return t.sfDomains }, false)
 	default:
 		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -382,9 +358,13 @@ func (w *RestAPI) GetSuspiciousHandler(httpWriter http.ResponseWriter, r *http.R
 	case http.MethodGet:
 		// return as array
 		dataArray := []*dnsutils.TransformSuspicious{}
-		for domain, suspicious := range w.HitsUniq.Suspicious {
-			suspicious.Domain = domain
-			dataArray = append(dataArray, suspicious)
+		for _, domain := range w.Suspicious.Keys() {
+			if suspicious, ok := w.Suspicious.Peek(domain); ok {
+				// copy the entry, it's shared with the writer
+				item := *suspicious
+				item.Domain = domain
+				dataArray = append(dataArray, &item)
+			}
 		}
 
 		// encode
@@ -409,30 +389,32 @@ func (w *RestAPI) GetSearchHandler(httpWriter http.ResponseWriter, r *http.Reque
 		filter := r.URL.Query()["filter"]
 		if len(filter) == 0 {
 			http.Error(httpWriter, "Arguments are missing", http.StatusBadRequest)
+			return
 		}
 
-		dataArray := []KeyHit{}
+		pairs, err := w.GetHits(r, func(t *hitsTables) *HitsCounter { return t.search })
+		if err != nil {
+			http.Error(httpWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		// search by IP
-		for _, search := range w.HitsStream.Streams {
-			userHits, clientExists := search.Clients[filter[0]]
-			if clientExists {
-				for domain, hit := range userHits.Hits {
-					dataArray = append(dataArray, KeyHit{Key: domain, Hit: hit})
-				}
+		byClient := make(map[string]int)
+		byDomain := make(map[string]int)
+		for key, hit := range pairs {
+			client, domain := splitSearchKey(key)
+			if client == filter[0] {
+				byClient[domain] += hit
+			}
+			if domain == filter[0] {
+				byDomain[client] += hit
 			}
 		}
 
 		// search by domain
+		dataArray := SortHits(byClient, 0)
 		if len(dataArray) == 0 {
-			for _, search := range w.HitsStream.Streams {
-				domainHists, domainExists := search.Domains[filter[0]]
-				if domainExists {
-					for addr, hit := range domainHists.Hits {
-						dataArray = append(dataArray, KeyHit{Key: addr, Hit: hit})
-					}
-				}
-			}
+			dataArray = SortHits(byDomain, 0)
 		}
 
 		// encode to json
@@ -473,109 +455,30 @@ func (w *RestAPI) RecordDNSMessage(dm dnsutils.DNSMessage) {
 	w.Lock()
 	defer w.Unlock()
 
-	if _, exists := w.Streams[dm.DNSTap.Identity]; !exists {
-		w.Streams[dm.DNSTap.Identity] = 1
-	} else {
-		w.Streams[dm.DNSTap.Identity] += 1
-	}
-
 	// record suspicious domains only is enabled
 	if dm.Suspicious != nil {
 		if dm.Suspicious.Score > 0.0 {
-			if _, exists := w.HitsUniq.Suspicious[dm.DNS.Qname]; !exists {
-				w.HitsUniq.Suspicious[dm.DNS.Qname] = dm.Suspicious
-			}
-		}
-	}
-
-	// uniq record for tld
-	// record public suffix only if enabled
-	if dm.PublicSuffix != nil {
-		if dm.PublicSuffix.QnamePublicSuffix != "-" {
-			if _, ok := w.HitsUniq.PublicSuffixes[dm.PublicSuffix.QnamePublicSuffix]; !ok {
-				w.HitsUniq.PublicSuffixes[dm.PublicSuffix.QnamePublicSuffix] = 1
-			} else {
-				w.HitsUniq.PublicSuffixes[dm.PublicSuffix.QnamePublicSuffix]++
-			}
+			w.Suspicious.ContainsOrAdd(dm.DNS.Qname, dm.Suspicious)
 		}
 	}
 
-	// uniq record for domains
-	if _, exists := w.HitsUniq.Domains[dm.DNS.Qname]; !exists {
-		w.HitsUniq.Domains[dm.DNS.Qname] = 1
-	} else {
-		w.HitsUniq.Domains[dm.DNS.Qname] += 1
-	}
-
-	if dm.DNS.Rcode == dnsutils.DNSRcodeNXDomain {
-		if _, exists := w.HitsUniq.NxDomains[dm.DNS.Qname]; !exists {
-			w.HitsUniq.NxDomains[dm.DNS.Qname] = 1
-		} else {
-			w.HitsUniq.NxDomains[dm.DNS.Qname] += 1
-		}
-	}
-
-	if dm.DNS.Rcode == dnsutils.DNSRcodeServFail {
-		if _, exists := w.HitsUniq.SfDomains[dm.DNS.Qname]; !exists {
-			w.HitsUniq.SfDomains[dm.DNS.Qname] = 1
-		} else {
-			w.HitsUniq.SfDomains[dm.DNS.Qname] += 1
+	// record hits per stream in all windows, the new streams are ignored
+	// when the max number of streams is reached
+	stats, exists := w.Stats[dm.DNSTap.Identity]
+	if !exists {
+		maxStreams := w.GetConfig().Loggers.RestAPI.MaxStreams
+		if maxStreams > 0 && len(w.Stats) >= maxStreams {
+			w.Messages.Push(dm)
+			return
 		}
+		stats = NewStreamHits(w.maxCardinality())
+		w.Stats[dm.DNSTap.Identity] = stats
 	}
+	w.Streams[dm.DNSTap.Identity]++
+	stats.Record(&dm, w.now())
 
-	// uniq record for queries
-	if _, exists := w.HitsUniq.Clients[dm.NetworkInfo.QueryIP]; !exists {
-		w.HitsUniq.Clients[dm.NetworkInfo.QueryIP] = 1
-	} else {
-		w.HitsUniq.Clients[dm.NetworkInfo.QueryIP] += 1
-	}
-
-	// uniq top qnames and clients
-	w.TopQnames.Record(dm.DNS.Qname, w.HitsUniq.Domains[dm.DNS.Qname])
-	w.TopClients.Record(dm.NetworkInfo.QueryIP, w.HitsUniq.Clients[dm.NetworkInfo.QueryIP])
-	if dm.PublicSuffix != nil {
-		w.TopTLDs.Record(dm.PublicSuffix.QnamePublicSuffix, w.HitsUniq.PublicSuffixes[dm.PublicSuffix.QnamePublicSuffix])
-	}
-	if dm.DNS.Rcode == dnsutils.DNSRcodeNXDomain {
-		w.TopNonExistent.Record(dm.DNS.Qname, w.HitsUniq.NxDomains[dm.DNS.Qname])
-	}
-	if dm.DNS.Rcode == dnsutils.DNSRcodeServFail {
-		w.TopServFail.Record(dm.DNS.Qname, w.HitsUniq.SfDomains[dm.DNS.Qname])
-	}
-
-	// record dns message per client source ip and domain
-	if _, exists := w.HitsStream.Streams[dm.DNSTap.Identity]; !exists {
-		w.HitsStream.Streams[dm.DNSTap.Identity] = SearchBy{Clients: make(map[string]*HitsRecord),
-			Domains: make(map[string]*HitsRecord)}
-	}
-
-	// continue with the query IP
-	if _, exists := w.HitsStream.Streams[dm.DNSTap.Identity].Clients[dm.NetworkInfo.QueryIP]; !exists {
-		w.HitsStream.Streams[dm.DNSTap.Identity].Clients[dm.NetworkInfo.QueryIP] = &HitsRecord{Hits: make(map[string]int), TotalHits: 1}
-	} else {
-		w.HitsStream.Streams[dm.DNSTap.Identity].Clients[dm.NetworkInfo.QueryIP].TotalHits += 1
-	}
-
-	// continue with Qname
-	if _, exists := w.HitsStream.Streams[dm.DNSTap.Identity].Clients[dm.NetworkInfo.QueryIP].Hits[dm.DNS.Qname]; !exists {
-		w.HitsStream.Streams[dm.DNSTap.Identity].Clients[dm.NetworkInfo.QueryIP].Hits[dm.DNS.Qname] = 1
-	} else {
-		w.HitsStream.Streams[dm.DNSTap.Identity].Clients[dm.NetworkInfo.QueryIP].Hits[dm.DNS.Qname] += 1
-	}
-
-	// domain doesn't exists in domains map?
-	if _, exists := w.HitsStream.Streams[dm.DNSTap.Identity].Domains[dm.DNS.Qname]; !exists {
-		w.HitsStream.Streams[dm.DNSTap.Identity].Domains[dm.DNS.Qname] = &HitsRecord{Hits: make(map[string]int), TotalHits: 1}
-	} else {
-		w.HitsStream.Streams[dm.DNSTap.Identity].Domains[dm.DNS.Qname].TotalHits += 1
-	}
-
-	// domain doesn't exists in domains map?
-	if _, exists := w.HitsStream.Streams[dm.DNSTap.Identity].Domains[dm.DNS.Qname].Hits[dm.NetworkInfo.QueryIP]; !exists {
-		w.HitsStream.Streams[dm.DNSTap.Identity].Domains[dm.DNS.Qname].Hits[dm.NetworkInfo.QueryIP] = 1
-	} else {
-		w.HitsStream.Streams[dm.DNSTap.Identity].Domains[dm.DNS.Qname].Hits[dm.NetworkInfo.QueryIP] += 1
-	}
+	// keep the message in the ring buffer
+	w.Messages.Push(dm)
 }
 
 func (w *RestAPI) ListenAndServe() {
@@ -594,6 +497,8 @@ func (w *RestAPI) ListenAndServe() {
 	mux.HandleFunc("/domains/servfail/top", w.GetTopSfDomainsHandler)
 	mux.HandleFunc("/suspicious", w.GetSuspiciousHandler)
 	mux.HandleFunc("/search", w.GetSearchHandler)
+	mux.HandleFunc("/messages", w.GetMessagesHandler)
+	mux.HandleFunc("/stream", w.GetStreamHandler)
 	mux.HandleFunc("/reset", w.DeleteResetHandler)
 
 	var err error
@@ -660,10 +565,15 @@ func (w *RestAPI) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
 
+			w.StreamClients.RemoveAll()
 			w.httpserver.Close()
 			<-w.doneAPI
 
@@ -719,6 +629,9 @@ func (w *RestAPI) StartLogging() {
 			}
 			// record the dnstap message
 			w.RecordDNSMessage(dm)
+
+			// and send it to the clients of the live stream
+			w.BroadcastDNSMessage(dm)
 		}
 	}
 }
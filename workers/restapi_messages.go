@@ -0,0 +1,268 @@
+package workers
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+)
+
+const DefaultMessagesLimit = 100
+
+var (
+	ErrInvalidMessagesFilter = errors.New("invalid filter")
+	ErrInvalidMessagesFormat = errors.New("invalid format")
+	ErrInvalidMessagesPaging = errors.New("invalid paging")
+)
+
+// MessagesRing keeps the last DNS messages, the oldest one is overwritten when the ring is full
+type MessagesRing struct {
+	messages []dnsutils.DNSMessage
+	next     int
+	full     bool
+}
+
+func NewMessagesRing(size int) *MessagesRing {
+	return &MessagesRing{messages: make([]dnsutils.DNSMessage, size)}
+}
+
+func (r *MessagesRing) Size() int {
+	return len(r.messages)
+}
+
+func (r *MessagesRing) Len() int {
+	if r.full {
+		return len(r.messages)
+	}
+	return r.next
+}
+
+func (r *MessagesRing) Push(dm dnsutils.DNSMessage) {
+	if len(r.messages) == 0 {
+		return
+	}
+	r.messages[r.next] = dm
+	r.next = (r.next + 1) % len(r.messages)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Walk calls the function on each message from the newest to the oldest one,
+// until the function returns false
+func (r *MessagesRing) Walk(fn func(dm *dnsutils.DNSMessage) bool) {
+	for i := 1; i <= r.Len(); i++ {
+		idx := (r.next - i + len(r.messages)) % len(r.messages)
+		if !fn(&r.messages[idx]) {
+			return
+		}
+	}
+}
+
+// Messages returns a copy of the messages from the newest to the oldest one
+func (r *MessagesRing) Messages() []dnsutils.DNSMessage {
+	messages := make([]dnsutils.DNSMessage, 0, r.Len())
+	r.Walk(func(dm *dnsutils.DNSMessage) bool {
+		messages = append(messages, *dm)
+		return true
+	})
+	return messages
+}
+
+// MessagesFilter selects the messages of the ring buffer according to the query parameters
+type MessagesFilter struct {
+	Matching map[string]interface{}
+	Network  *net.IPNet
+	From     time.Time
+	To       time.Time
+}
+
+// NewMessagesFilter converts the query parameters to a matching with the dnsutils operators:
+// qname (regex), identity, rcode and qtype (exact), client (address or CIDR), from and to (RFC3339)
+func NewMessagesFilter(r *http.Request) (*MessagesFilter, error) {
+	filter := &MessagesFilter{Matching: make(map[string]interface{})}
+	query := r.URL.Query()
+
+	if qname := query.Get("qname"); qname != "" {
+		if _, err := regexp.Compile(qname); err != nil {
+			return nil, ErrInvalidMessagesFilter
+		}
+		filter.Matching["dns.qname"] = qname
+	}
+
+	exactFields := map[string]string{"identity": "dnstap.identity", "rcode": "dns.rcode", "qtype": "dns.qtype"}
+	for param, field := range exactFields {
+		if value := query.Get(param); value != "" {
+			filter.Matching[field] = "^" + regexp.QuoteMeta(value) + "$"
+		}
+	}
+
+	if client := query.Get("client"); client != "" {
+		_, network, err := net.ParseCIDR(client)
+		if err != nil {
+			ip := net.ParseIP(client)
+			if ip == nil {
+				return nil, ErrInvalidMessagesFilter
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+		filter.Network = network
+	}
+
+	for param, value := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if query.Get(param) == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, query.Get(param))
+		if err != nil {
+			return nil, ErrInvalidMessagesFilter
+		}
+		*value = t
+	}
+	return filter, nil
+}
+
+func (f *MessagesFilter) Match(dm *dnsutils.DNSMessage) bool {
+	if f.Network != nil {
+		ip := net.ParseIP(dm.NetworkInfo.QueryIP)
+		if ip == nil || !f.Network.Contains(ip) {
+			return false
+		}
+	}
+
+	if !f.From.IsZero() || !f.To.IsZero() {
+		ts := time.Unix(int64(dm.DNSTap.TimeSec), int64(dm.DNSTap.TimeNsec))
+		if !f.From.IsZero() && ts.Before(f.From) {
+			return false
+		}
+		if !f.To.IsZero() && ts.After(f.To) {
+			return false
+		}
+	}
+
+	if len(f.Matching) > 0 {
+		err, matched := dm.Matching(f.Matching)
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// getPaging reads the limit and offset query parameters
+func getPaging(r *http.Request) (int, int, error) {
+	limit, offset := DefaultMessagesLimit, 0
+	var err error
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			return 0, 0, ErrInvalidMessagesPaging
+		}
+	}
+	if value := r.URL.Query().Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return 0, 0, ErrInvalidMessagesPaging
+		}
+	}
+	return limit, offset, nil
+}
+
+// SearchMessages returns the matching messages from the newest to the oldest one,
+// according to the paging, and the total of messages matching the filter.
+// The ring is copied under the lock, the filter is applied after its release.
+func (w *RestAPI) SearchMessages(filter *MessagesFilter, limit, offset int) ([]dnsutils.DNSMessage, int) {
+	w.RLock()
+	ring := w.Messages.Messages()
+	w.RUnlock()
+
+	messages := []dnsutils.DNSMessage{}
+	total := 0
+	for i := range ring {
+		if !filter.Match(&ring[i]) {
+			continue
+		}
+		if total >= offset && len(messages) < limit {
+			messages = append(messages, ring[i])
+		}
+		total++
+	}
+	return messages, total
+}
+
+func (w *RestAPI) GetMessagesHandler(httpWriter http.ResponseWriter, r *http.Request) {
+	if !w.BasicAuth(httpWriter, r) {
+		http.Error(httpWriter, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		filter, err := NewMessagesFilter(r)
+		if err != nil {
+			http.Error(httpWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit, offset, err := getPaging(r)
+		if err != nil {
+			http.Error(httpWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		encoder, err := w.NewFormatEncoder(format)
+		if err == nil && encoder.IsBinary() {
+			err = ErrInvalidMessagesFormat
+		}
+		if err != nil {
+			http.Error(httpWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		messages, total := w.SearchMessages(filter, limit, offset)
+		httpWriter.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+		// json formats are returned as array, the others with one message per line
+		switch format {
+		case "", pkgconfig.ModeJSON:
+			httpWriter.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(httpWriter).Encode(messages)
+
+		case pkgconfig.ModeFlatJSON:
+			dataArray := []map[string]interface{}{}
+			for i := range messages {
+				flat, err := messages[i].Flatten()
+				if err != nil {
+					w.LogError("flattening DNS message failed: %e", err)
+					continue
+				}
+				dataArray = append(dataArray, flat)
+			}
+			httpWriter.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(httpWriter).Encode(dataArray)
+
+		default:
+			httpWriter.Header().Set("Content-Type", "text/plain")
+			for i := range messages {
+				data, err := encoder.Encode(&messages[i])
+				if err != nil {
+					w.LogError("encoding DNS message failed: %s", err)
+					continue
+				}
+				httpWriter.Write(data)
+				httpWriter.Write([]byte("\n"))
+			}
+		}
+
+	default:
+		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
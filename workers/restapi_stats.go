@@ -0,0 +1,260 @@
+package workers
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	StatsWindowAll = "all"
+	// separator between the client and the domain in the keys of the search table
+	searchKeySeparator = "\x00"
+)
+
+// StatsWindows defines the sliding windows available in the REST API, the hits
+// are counted in buckets and the oldest bucket is reset when the window slides.
+var StatsWindows = map[string]struct {
+	BucketSize time.Duration
+	Buckets    int
+}{
+	"1m": {BucketSize: 10 * time.Second, Buckets: 6},
+	"5m": {BucketSize: time.Minute, Buckets: 5},
+	"1h": {BucketSize: 10 * time.Minute, Buckets: 6},
+}
+
+func IsValidStatsWindow(window string) bool {
+	if window == StatsWindowAll {
+		return true
+	}
+	_, ok := StatsWindows[window]
+	return ok
+}
+
+// statsPendingPeriod is the period of the pending bucket of a stream, the bucket
+// sizes of the windows must be a multiple of it
+const statsPendingPeriod = 10 * time.Second
+
+// HitsCounter counts the hits per key, the least recently seen keys are
+// evicted when the max cardinality is reached
+type HitsCounter struct {
+	cache *lru.Cache[string, int]
+}
+
+func NewHitsCounter(maxCardinality int) *HitsCounter {
+	cache, _ := lru.New[string, int](maxCardinality)
+	return &HitsCounter{cache: cache}
+}
+
+func (c *HitsCounter) Inc(key string) {
+	c.Add(key, 1)
+}
+
+func (c *HitsCounter) Add(key string, n int) {
+	hits, _ := c.cache.Get(key)
+	c.cache.Add(key, hits+n)
+}
+
+// AddTo sums the hits of the counter in the map provided
+func (c *HitsCounter) AddTo(hits map[string]int) {
+	for _, key := range c.cache.Keys() {
+		if v, ok := c.cache.Peek(key); ok {
+			hits[key] += v
+		}
+	}
+}
+
+// Merge adds the hits of the other counter, from the least to the most recently seen key
+func (c *HitsCounter) Merge(other *HitsCounter) {
+	for _, key := range other.cache.Keys() {
+		if v, ok := other.cache.Peek(key); ok {
+			c.Add(key, v)
+		}
+	}
+}
+
+func (c *HitsCounter) Reset() {
+	c.cache.Purge()
+}
+
+// hitsTables is the set of counters of a bucket
+type hitsTables struct {
+	clients, domains, nxDomains, sfDomains, tlds, search *HitsCounter
+}
+
+func newHitsTables(maxCardinality int) *hitsTables {
+	return &hitsTables{
+		clients:   NewHitsCounter(maxCardinality),
+		domains:   NewHitsCounter(maxCardinality),
+		nxDomains: NewHitsCounter(maxCardinality),
+		sfDomains: NewHitsCounter(maxCardinality),
+		tlds:      NewHitsCounter(maxCardinality),
+		search:    NewHitsCounter(maxCardinality),
+	}
+}
+
+func (t *hitsTables) counters() []*HitsCounter {
+	return []*HitsCounter{t.clients, t.domains, t.nxDomains, t.sfDomains, t.tlds, t.search}
+}
+
+func (t *hitsTables) record(dm *dnsutils.DNSMessage) {
+	t.clients.Inc(dm.NetworkInfo.QueryIP)
+	t.domains.Inc(dm.DNS.Qname)
+	t.search.Inc(dm.NetworkInfo.QueryIP + searchKeySeparator + dm.DNS.Qname)
+
+	switch dm.DNS.Rcode {
+	case dnsutils.DNSRcodeNXDomain:
+		t.nxDomains.Inc(dm.DNS.Qname)
+	case dnsutils.DNSRcodeServFail:
+		t.sfDomains.Inc(dm.DNS.Qname)
+	}
+
+	if dm.PublicSuffix != nil && dm.PublicSuffix.QnamePublicSuffix != "-" {
+		t.tlds.Inc(dm.PublicSuffix.QnamePublicSuffix)
+	}
+}
+
+func (t *hitsTables) merge(other *hitsTables) {
+	dst, src := t.counters(), other.counters()
+	for i := range dst {
+		dst[i].Merge(src[i])
+	}
+}
+
+func (t *hitsTables) reset() {
+	for _, c := range t.counters() {
+		c.Reset()
+	}
+}
+
+// StatsWindow counts the hits over a sliding window, a window without bucket size
+// counts all the hits since the start or the last reset.
+// The max cardinality is shared between the buckets of the window.
+type StatsWindow struct {
+	bucketSize time.Duration
+	buckets    []*hitsTables
+	starts     []time.Time
+}
+
+func NewStatsWindow(bucketSize time.Duration, buckets int, maxCardinality int) *StatsWindow {
+	sw := &StatsWindow{bucketSize: bucketSize}
+	bucketCardinality := max(maxCardinality/buckets, 1)
+	for i := 0; i < buckets; i++ {
+		sw.buckets = append(sw.buckets, newHitsTables(bucketCardinality))
+		sw.starts = append(sw.starts, time.Time{})
+	}
+	return sw
+}
+
+func (sw *StatsWindow) bucketIndex(now time.Time) (int, time.Time) {
+	if sw.bucketSize == 0 {
+		return 0, time.Time{}
+	}
+	start := now.Truncate(sw.bucketSize)
+	return int(start.UnixNano()/int64(sw.bucketSize)) % len(sw.buckets), start
+}
+
+// Add sums the hits of the tables in the bucket of the period started at the time provided
+func (sw *StatsWindow) Add(tables *hitsTables, at time.Time) {
+	idx, start := sw.bucketIndex(at)
+	// the bucket is reused, reset the hits of the previous period
+	if !sw.starts[idx].Equal(start) {
+		sw.buckets[idx].reset()
+		sw.starts[idx] = start
+	}
+	sw.buckets[idx].merge(tables)
+}
+
+// contains returns true if the period started at the time provided is in the window
+func (sw *StatsWindow) contains(start time.Time, now time.Time) bool {
+	if sw.bucketSize == 0 {
+		return true
+	}
+	return start.After(now.Add(-sw.bucketSize * time.Duration(len(sw.buckets))))
+}
+
+// Hits returns the hits of the table selected for the buckets in the window
+func (sw *StatsWindow) Hits(table func(*hitsTables) *HitsCounter, hits map[string]int, now time.Time) {
+	for i, bucket := range sw.buckets {
+		if !sw.contains(sw.starts[i], now) {
+			continue
+		}
+		table(bucket).AddTo(hits)
+	}
+}
+
+// StreamHits holds the windows of a stream, the messages are counted in a pending
+// bucket which is added to all windows when its period is over
+type StreamHits struct {
+	pending      *hitsTables
+	pendingStart time.Time
+	windows      map[string]*StatsWindow
+}
+
+func NewStreamHits(maxCardinality int) *StreamHits {
+	s := &StreamHits{pending: newHitsTables(maxCardinality), windows: make(map[string]*StatsWindow)}
+	s.windows[StatsWindowAll] = NewStatsWindow(0, 1, maxCardinality)
+	for name, window := range StatsWindows {
+		s.windows[name] = NewStatsWindow(window.BucketSize, window.Buckets, maxCardinality)
+	}
+	return s
+}
+
+func (s *StreamHits) Record(dm *dnsutils.DNSMessage, now time.Time) {
+	start := now.Truncate(statsPendingPeriod)
+	if !s.pendingStart.Equal(start) {
+		s.flush()
+		s.pendingStart = start
+	}
+	s.pending.record(dm)
+}
+
+// flush adds the pending hits to the windows
+func (s *StreamHits) flush() {
+	if s.pendingStart.IsZero() {
+		return
+	}
+	for _, window := range s.windows {
+		window.Add(s.pending, s.pendingStart)
+	}
+	s.pending.reset()
+}
+
+// Hits returns the hits of the table selected in the window, the pending hits included
+func (s *StreamHits) Hits(window string, table func(*hitsTables) *HitsCounter, hits map[string]int, now time.Time) {
+	sw := s.windows[window]
+	sw.Hits(table, hits, now)
+	if !s.pendingStart.IsZero() && sw.contains(s.pendingStart, now) {
+		table(s.pending).AddTo(hits)
+	}
+}
+
+// SortHits converts the hits to a list sorted by hits, limited to n items if n > 0
+func SortHits(hits map[string]int, n int) []KeyHit {
+	dataArray := []KeyHit{}
+	for key, hit := range hits {
+		dataArray = append(dataArray, KeyHit{Key: key, Hit: hit})
+	}
+	sort.Slice(dataArray, func(i, j int) bool {
+		if dataArray[i].Hit == dataArray[j].Hit {
+			return dataArray[i].Key < dataArray[j].Key
+		}
+		return dataArray[i].Hit > dataArray[j].Hit
+	})
+	if n > 0 && len(dataArray) > n {
+		dataArray = dataArray[:n]
+	}
+	return dataArray
+}
+
+// splitSearchKey returns the client and the domain of a key of the search table
+func splitSearchKey(key string) (string, string) {
+	parts := strings.SplitN(key, searchKeySeparator, 2)
+	if len(parts) != 2 {
+		return key, ""
+	}
+	return parts[0], parts[1]
+}
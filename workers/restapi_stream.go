@@ -0,0 +1,239 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/gorilla/websocket"
+)
+
+var ErrTooManyStreamClients = errors.New("too many clients")
+
+// StreamClient is a client of the live stream, with its own filter and format.
+// The client is dropped when its buffer is full to never block the pipeline.
+type StreamClient struct {
+	name     string
+	filter   *MessagesFilter
+	encoder  dnsutils.Encoder
+	messages chan dnsutils.DNSMessage
+	dropped  chan struct{}
+}
+
+// StreamClients is the list of clients connected to the live stream
+type StreamClients struct {
+	clients map[*StreamClient]struct{}
+	sync.Mutex
+}
+
+func NewStreamClients() *StreamClients {
+	return &StreamClients{clients: make(map[*StreamClient]struct{})}
+}
+
+func (c *StreamClients) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.clients)
+}
+
+func (c *StreamClients) Add(client *StreamClient, maxClients int) error {
+	c.Lock()
+	defer c.Unlock()
+	if maxClients > 0 && len(c.clients) >= maxClients {
+		return ErrTooManyStreamClients
+	}
+	c.clients[client] = struct{}{}
+	return nil
+}
+
+// Remove deletes the client and notifies it, returns false if the client is already removed
+func (c *StreamClients) Remove(client *StreamClient) bool {
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.clients[client]; !ok {
+		return false
+	}
+	delete(c.clients, client)
+	close(client.dropped)
+	return true
+}
+
+func (c *StreamClients) RemoveAll() {
+	c.Lock()
+	defer c.Unlock()
+	for client := range c.clients {
+		delete(c.clients, client)
+		close(client.dropped)
+	}
+}
+
+// Broadcast sends the message to the clients matching it and returns the slow ones
+func (c *StreamClients) Broadcast(dm *dnsutils.DNSMessage) []*StreamClient {
+	c.Lock()
+	defer c.Unlock()
+
+	slowClients := []*StreamClient{}
+	for client := range c.clients {
+		if !client.filter.Match(dm) {
+			continue
+		}
+		select {
+		case client.messages <- *dm:
+		default:
+			slowClients = append(slowClients, client)
+		}
+	}
+	return slowClients
+}
+
+// BroadcastDNSMessage sends the message to the clients of the live stream, the slow clients are dropped
+func (w *RestAPI) BroadcastDNSMessage(dm dnsutils.DNSMessage) {
+	for _, client := range w.StreamClients.Broadcast(&dm) {
+		if w.StreamClients.Remove(client) {
+			w.LogWarning("stream client %s too slow, disconnected", client.name)
+			w.WorkerIsBusy(client.name)
+		}
+	}
+}
+
+// NewFormatEncoder returns the encoder of the format requested by the client, json by default
+func (w *RestAPI) NewFormatEncoder(format string) (dnsutils.Encoder, error) {
+	if format == "" {
+		format = pkgconfig.ModeJSON
+	}
+	encoder, err := dnsutils.NewEncoder(format, w.GetEncoderOptions(w.GetConfig().Loggers.RestAPI.TextFormat, ""))
+	if err != nil {
+		return nil, ErrInvalidMessagesFormat
+	}
+	return encoder, nil
+}
+
+// GetStreamHandler streams the DNS messages with server-sent events,
+// or with websocket if the client asks for an upgrade of the connection
+func (w *RestAPI) GetStreamHandler(httpWriter http.ResponseWriter, r *http.Request) {
+	if !w.BasicAuth(httpWriter, r) {
+		http.Error(httpWriter, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(httpWriter, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := NewMessagesFilter(r)
+	if err != nil {
+		http.Error(httpWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	encoder, err := w.NewFormatEncoder(r.URL.Query().Get("format"))
+	// binary formats are only supported with websocket
+	if err == nil && encoder.IsBinary() && !websocket.IsWebSocketUpgrade(r) {
+		err = ErrInvalidMessagesFormat
+	}
+	if err != nil {
+		http.Error(httpWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client := &StreamClient{
+		name:     fmt.Sprintf("stream-client-%s", r.RemoteAddr),
+		filter:   filter,
+		encoder:  encoder,
+		messages: make(chan dnsutils.DNSMessage, w.GetConfig().Loggers.RestAPI.StreamBufferSize),
+		dropped:  make(chan struct{}),
+	}
+	if err := w.StreamClients.Add(client, w.GetConfig().Loggers.RestAPI.StreamMaxClients); err != nil {
+		http.Error(httpWriter, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer w.StreamClients.Remove(client)
+
+	w.LogInfo("stream client %s connected", client.name)
+	if websocket.IsWebSocketUpgrade(r) {
+		w.streamWebSocket(httpWriter, r, client)
+	} else {
+		w.streamEvents(httpWriter, r, client)
+	}
+	w.LogInfo("stream client %s disconnected", client.name)
+}
+
+func (w *RestAPI) streamEvents(httpWriter http.ResponseWriter, r *http.Request, client *StreamClient) {
+	flusher, ok := httpWriter.(http.Flusher)
+	if !ok {
+		http.Error(httpWriter, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	httpWriter.Header().Set("Content-Type", "text/event-stream")
+	httpWriter.Header().Set("Cache-Control", "no-cache")
+	httpWriter.Header().Set("Connection", "keep-alive")
+	httpWriter.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client.dropped:
+			return
+		case dm := <-client.messages:
+			data, err := client.encoder.Encode(&dm)
+			if err != nil {
+				w.LogError("stream client %s - encoding failed: %s", client.name, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(httpWriter, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (w *RestAPI) streamWebSocket(httpWriter http.ResponseWriter, r *http.Request, client *StreamClient) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(httpWriter, r, nil)
+	if err != nil {
+		w.LogError("stream client %s - websocket upgrade failed: %s", client.name, err)
+		return
+	}
+	defer conn.Close()
+
+	// read the control messages and detect the disconnection of the client
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-client.dropped:
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "disconnected"))
+			return
+		case dm := <-client.messages:
+			data, err := client.encoder.Encode(&dm)
+			if err != nil {
+				w.LogError("stream client %s - encoding failed: %s", client.name, err)
+				continue
+			}
+			messageType := websocket.TextMessage
+			if client.encoder.IsBinary() {
+				messageType = websocket.BinaryMessage
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -1,15 +1,18 @@
 package workers
 
 import (
+	"bufio"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-logger"
+	"github.com/gorilla/websocket"
 )
 
 func TestRestAPI_BadBasicAuth(t *testing.T) {
@@ -312,3 +315,435 @@ func TestRestAPI_GetMethod(t *testing.T) {
 		})
 	}
 }
+
+func TestRestAPI_WindowAndStream(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	g := NewRestAPI(config, logger.New(false), "test")
+
+	// simulate the clock
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+
+	// one old message on the first stream, 10 minutes ago
+	dm := dnsutils.GetFakeDNSMessage()
+	dm.DNSTap.Identity = "stream1"
+	now = now.Add(-10 * time.Minute)
+	g.RecordDNSMessage(dm)
+
+	// two recent messages on the second stream
+	now = now.Add(10 * time.Minute)
+	dm.DNSTap.Identity = "stream2"
+	dm.NetworkInfo.QueryIP = "5.6.7.8"
+	g.RecordDNSMessage(dm)
+	g.RecordDNSMessage(dm)
+
+	tt := []struct {
+		name       string
+		uri        string
+		handler    func(w http.ResponseWriter, r *http.Request)
+		want       string
+		statusCode int
+	}{
+		{
+			name:       "top_all",
+			uri:        "/domains/top",
+			handler:    g.GetTopDomainsHandler,
+			want:       `^\[\{"key":"dns.collector","hit":3\}\]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "top_1m",
+			uri:        "/domains/top?window=1m",
+			handler:    g.GetTopDomainsHandler,
+			want:       `^\[\{"key":"dns.collector","hit":2\}\]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "top_1h",
+			uri:        "/clients/top?window=1h",
+			handler:    g.GetTopClientsHandler,
+			want:       `^\[\{"key":"5.6.7.8","hit":2\},\{"key":"1.2.3.4","hit":1\}\]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "top_stream",
+			uri:        "/clients/top?stream=stream1",
+			handler:    g.GetTopClientsHandler,
+			want:       `^\[\{"key":"1.2.3.4","hit":1\}\]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "top_stream_window",
+			uri:        "/clients/top?stream=stream1&window=5m",
+			handler:    g.GetTopClientsHandler,
+			want:       `^\[\]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "search_window",
+			uri:        "/search?filter=dns.collector&window=5m",
+			handler:    g.GetSearchHandler,
+			want:       `^\[\{"key":"5.6.7.8","hit":2\}\]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "search_stream",
+			uri:        "/search?filter=dns.collector&stream=stream1",
+			handler:    g.GetSearchHandler,
+			want:       `^\[\{"key":"1.2.3.4","hit":1\}\]`,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "invalid_window",
+			uri:        "/domains/top?window=2d",
+			handler:    g.GetTopDomainsHandler,
+			want:       `invalid window`,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tc.uri, strings.NewReader(""))
+			request.SetBasicAuth(config.Loggers.RestAPI.BasicAuthLogin, config.Loggers.RestAPI.BasicAuthPwd)
+			responseRecorder := httptest.NewRecorder()
+
+			tc.handler(responseRecorder, request)
+
+			if responseRecorder.Code != tc.statusCode {
+				t.Errorf("Want status '%d', got '%d'", tc.statusCode, responseRecorder.Code)
+			}
+			if !regexp.MustCompile(tc.want).MatchString(responseRecorder.Body.String()) {
+				t.Errorf("Want response '%s', got '%s'", tc.want, responseRecorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestRestAPI_MaxCardinality(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	config.Loggers.RestAPI.MaxCardinality = 2
+	g := NewRestAPI(config, logger.New(false), "test")
+
+	dm := dnsutils.GetFakeDNSMessage()
+	for _, ip := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		dm.NetworkInfo.QueryIP = ip
+		g.RecordDNSMessage(dm)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/clients", strings.NewReader(""))
+	request.SetBasicAuth(config.Loggers.RestAPI.BasicAuthLogin, config.Loggers.RestAPI.BasicAuthPwd)
+	responseRecorder := httptest.NewRecorder()
+	g.GetClientsHandler(responseRecorder, request)
+
+	// the least recently seen client is evicted
+	want := `[{"key":"2.2.2.2","hit":1},{"key":"3.3.3.3","hit":1}]`
+	if strings.TrimSpace(responseRecorder.Body.String()) != want {
+		t.Errorf("Want response '%s', got '%s'", want, responseRecorder.Body.String())
+	}
+}
+
+func TestRestAPI_MaxStreams(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	config.Loggers.RestAPI.MaxStreams = 1
+	g := NewRestAPI(config, logger.New(false), "test")
+
+	dm := dnsutils.GetFakeDNSMessage()
+	for _, identity := range []string{"stream1", "stream2", "stream1"} {
+		dm.DNSTap.Identity = identity
+		g.RecordDNSMessage(dm)
+	}
+
+	// the second stream is not counted
+	if len(g.Stats) != 1 || g.Streams["stream1"] != 2 {
+		t.Errorf("unexpected streams: %v", g.Streams)
+	}
+	if g.Messages.Len() != 3 {
+		t.Errorf("want 3 messages in the ring, got %d", g.Messages.Len())
+	}
+}
+
+func TestRestAPI_StatsRotation(t *testing.T) {
+	s := NewStreamHits(pkgconfig.DefaultMaxCardinality)
+	table := func(t *hitsTables) *HitsCounter { return t.domains }
+	dm := dnsutils.GetFakeDNSMessage()
+
+	// one message per period of the pending bucket during two minutes
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		s.Record(&dm, now)
+		now = now.Add(statsPendingPeriod)
+	}
+
+	for window, want := range map[string]int{StatsWindowAll: 12, "1m": 5, "5m": 12, "1h": 12} {
+		hits := make(map[string]int)
+		s.Hits(window, table, hits, now)
+		if hits[dm.DNS.Qname] != want {
+			t.Errorf("window %s: want %d hits, got %d", window, want, hits[dm.DNS.Qname])
+		}
+	}
+}
+
+func TestRestAPI_MessagesRing(t *testing.T) {
+	ring := NewMessagesRing(2)
+	for _, qname := range []string{"a.com", "b.com", "c.com"} {
+		dm := dnsutils.GetFakeDNSMessage()
+		dm.DNS.Qname = qname
+		ring.Push(dm)
+	}
+
+	qnames := []string{}
+	ring.Walk(func(dm *dnsutils.DNSMessage) bool {
+		qnames = append(qnames, dm.DNS.Qname)
+		return true
+	})
+	if strings.Join(qnames, ",") != "c.com,b.com" {
+		t.Errorf("unexpected messages in ring: %v", qnames)
+	}
+
+	// the copy is not modified by the next messages
+	messages := ring.Messages()
+	ring.Push(dnsutils.GetFakeDNSMessage())
+	if len(messages) != 2 || messages[0].DNS.Qname != "c.com" || messages[1].DNS.Qname != "b.com" {
+		t.Errorf("unexpected copy of the ring: %v", messages)
+	}
+}
+
+func TestRestAPI_GetMessages(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	config.Loggers.RestAPI.TextFormat = "identity qname rcode"
+	g := NewRestAPI(config, logger.New(false), "test")
+
+	messages := []struct {
+		identity, ip, qname, rcode, qtype string
+		timeSec                           int
+	}{
+		{"ns1", "10.0.0.1", "www.google.com", "NOERROR", "A", 1704103200},
+		{"ns1", "10.0.0.2", "mail.google.com", "NXDOMAIN", "AAAA", 1704103260},
+		{"ns2", "192.168.1.1", "dns.collector", "NOERROR", "A", 1704103320},
+	}
+	for _, m := range messages {
+		dm := dnsutils.GetFakeDNSMessage()
+		dm.DNSTap.Identity = m.identity
+		dm.NetworkInfo.QueryIP = m.ip
+		dm.DNS.Qname = m.qname
+		dm.DNS.Rcode = m.rcode
+		dm.DNS.Qtype = m.qtype
+		dm.DNSTap.TimeSec = m.timeSec
+		g.RecordDNSMessage(dm)
+	}
+
+	tt := []struct {
+		name       string
+		uri        string
+		want       string
+		total      string
+		statusCode int
+	}{
+		{
+			name:       "all",
+			uri:        "/messages?format=text",
+			want:       "^ns2 dns.collector NOERROR\nns1 mail.google.com NXDOMAIN\nns1 www.google.com NOERROR\n$",
+			total:      "3",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "qname_regex",
+			uri:        "/messages?format=text&qname=.*google.com$",
+			want:       "^ns1 mail.google.com NXDOMAIN\nns1 www.google.com NOERROR\n$",
+			total:      "2",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "client_cidr_and_rcode",
+			uri:        "/messages?format=text&client=10.0.0.0/8&rcode=NOERROR",
+			want:       "^ns1 www.google.com NOERROR\n$",
+			total:      "1",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "identity_qtype",
+			uri:        "/messages?format=text&identity=ns1&qtype=AAAA",
+			want:       "^ns1 mail.google.com NXDOMAIN\n$",
+			total:      "1",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "time_range",
+			uri:        "/messages?format=text&from=2024-01-01T10:00:30Z&to=2024-01-01T10:01:30Z",
+			want:       "^ns1 mail.google.com NXDOMAIN\n$",
+			total:      "1",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "paging",
+			uri:        "/messages?format=text&limit=1&offset=1",
+			want:       "^ns1 mail.google.com NXDOMAIN\n$",
+			total:      "3",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "json",
+			uri:        "/messages?client=192.168.1.1",
+			want:       `^\[\{"network":\{.*"query-ip":"192.168.1.1".*\}\]`,
+			total:      "1",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "flat_json",
+			uri:        "/messages?format=flat-json&client=192.168.1.1",
+			want:       `^\[\{.*"dns.qname":"dns.collector".*\}\]`,
+			total:      "1",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "invalid_client",
+			uri:        "/messages?client=invalid",
+			want:       "invalid filter",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid_regex",
+			uri:        "/messages?qname=(",
+			want:       "invalid filter",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid_format",
+			uri:        "/messages?format=xml",
+			want:       "invalid format",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid_paging",
+			uri:        "/messages?limit=-1",
+			want:       "invalid paging",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tc.uri, strings.NewReader(""))
+			request.SetBasicAuth(config.Loggers.RestAPI.BasicAuthLogin, config.Loggers.RestAPI.BasicAuthPwd)
+			responseRecorder := httptest.NewRecorder()
+
+			g.GetMessagesHandler(responseRecorder, request)
+
+			if responseRecorder.Code != tc.statusCode {
+				t.Errorf("Want status '%d', got '%d'", tc.statusCode, responseRecorder.Code)
+			}
+			if !regexp.MustCompile(tc.want).MatchString(responseRecorder.Body.String()) {
+				t.Errorf("Want response '%s', got '%s'", tc.want, responseRecorder.Body.String())
+			}
+			if tc.total != "" && responseRecorder.Header().Get("X-Total-Count") != tc.total {
+				t.Errorf("Want total '%s', got '%s'", tc.total, responseRecorder.Header().Get("X-Total-Count"))
+			}
+		})
+	}
+}
+
+func TestRestAPI_StreamEvents(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	config.Loggers.RestAPI.TextFormat = "qname rcode"
+	g := NewRestAPI(config, logger.New(false), "test")
+
+	server := httptest.NewServer(http.HandlerFunc(g.GetStreamHandler))
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/stream?format=text&rcode=NXDOMAIN", nil)
+	request.SetBasicAuth(config.Loggers.RestAPI.BasicAuthLogin, config.Loggers.RestAPI.BasicAuthPwd)
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type: %s", resp.Header.Get("Content-Type"))
+	}
+
+	// the first message is filtered, only the second one is streamed
+	dm := dnsutils.GetFakeDNSMessage()
+	g.BroadcastDNSMessage(dm)
+	dm.DNS.Rcode = "NXDOMAIN"
+	g.BroadcastDNSMessage(dm)
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "data: dns.collector NXDOMAIN\n" {
+		t.Errorf("unexpected event: %q", line)
+	}
+}
+
+func TestRestAPI_StreamWebSocket(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	g := NewRestAPI(config, logger.New(false), "test")
+
+	server := httptest.NewServer(http.HandlerFunc(g.GetStreamHandler))
+	defer server.Close()
+
+	header := http.Header{}
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.SetBasicAuth(config.Loggers.RestAPI.BasicAuthLogin, config.Loggers.RestAPI.BasicAuthPwd)
+	header.Set("Authorization", request.Header.Get("Authorization"))
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/stream?format=flat-json&client=1.2.3.4"
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// wait for the registration of the client
+	for g.StreamClients.Len() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	g.BroadcastDNSMessage(dnsutils.GetFakeDNSMessage())
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`"network.query-ip":"1.2.3.4"`).Match(data) {
+		t.Errorf("unexpected message: %s", data)
+	}
+}
+
+func TestRestAPI_StreamSlowClient(t *testing.T) {
+	config := pkgconfig.GetDefaultConfig()
+	g := NewRestAPI(config, logger.New(false), "test")
+
+	filter, _ := NewMessagesFilter(httptest.NewRequest(http.MethodGet, "/stream", nil))
+	client := &StreamClient{
+		name:     "slow",
+		filter:   filter,
+		messages: make(chan dnsutils.DNSMessage, 1),
+		dropped:  make(chan struct{}),
+	}
+	if err := g.StreamClients.Add(client, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// max clients reached
+	if err := g.StreamClients.Add(&StreamClient{}, 1); err != ErrTooManyStreamClients {
+		t.Errorf("want too many clients error, got %v", err)
+	}
+
+	// the buffer of the client is full on the second message
+	g.BroadcastDNSMessage(dnsutils.GetFakeDNSMessage())
+	g.BroadcastDNSMessage(dnsutils.GetFakeDNSMessage())
+
+	select {
+	case <-client.dropped:
+	default:
+		t.Errorf("slow client not dropped")
+	}
+	if g.StreamClients.Len() != 0 {
+		t.Errorf("slow client still registered")
+	}
+}
@@ -10,12 +10,12 @@ import (
 	"net/url"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/grafana/dskit/backoff"
 
+	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-dnscollector/transformers"
 	"github.com/dmachard/go-logger"
@@ -27,8 +27,8 @@ import (
 type ScalyrClient struct {
 	*GenericWorker
 
-	mode       string
-	textFormat []string
+	mode    string
+	encoder dnsutils.Encoder
 
 	session string // Session ID, used by scalyr, see API docs
 
@@ -72,16 +72,16 @@ func (w *ScalyrClient) ReadConfig() {
 		w.mode = w.GetConfig().Loggers.ScalyrClient.Mode
 	}
 
-	if len(w.GetConfig().Loggers.ScalyrClient.Parser) == 0 && (w.mode == pkgconfig.ModeText || w.mode == pkgconfig.ModeJSON) {
-		w.LogFatal(fmt.Sprintf("No Scalyr parser configured for Scalyr Client in %s mode", w.mode))
+	// json and flat-json are sent as attributes, the other modes as message
+	w.encoder = w.NewEncoder(w.mode, w.GetEncoderOptions(w.GetConfig().Loggers.ScalyrClient.TextFormat, ""))
+	if w.encoder.IsBinary() {
+		w.LogFatal(fmt.Sprintf("Binary mode %s not supported by Scalyr Client", w.mode))
 	}
-	w.parser = w.GetConfig().Loggers.ScalyrClient.Parser
 
-	if len(w.GetConfig().Loggers.ScalyrClient.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.ScalyrClient.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
+	if len(w.GetConfig().Loggers.ScalyrClient.Parser) == 0 && w.mode != pkgconfig.ModeFlatJSON {
+		w.LogFatal(fmt.Sprintf("No Scalyr parser configured for Scalyr Client in %s mode", w.mode))
 	}
+	w.parser = w.GetConfig().Loggers.ScalyrClient.Parser
 
 	if host := w.GetConfig().Loggers.ScalyrClient.ServerURL; host != "" {
 		w.endpoint = makeEndpoint(host)
@@ -142,6 +142,10 @@ func (w *ScalyrClient) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -230,10 +234,6 @@ func (w *ScalyrClient) StartLogging() {
 			}
 
 			switch w.mode {
-			case pkgconfig.ModeText:
-				attrs["message"] = string(dm.Bytes(w.textFormat,
-					w.GetConfig().Global.TextFormatDelimiter,
-					w.GetConfig().Global.TextFormatBoundary))
 			case pkgconfig.ModeJSON:
 				attrs["message"] = dm
 			case pkgconfig.ModeFlatJSON:
@@ -248,6 +248,13 @@ func (w *ScalyrClient) StartLogging() {
 						attrs[k] = v
 					}
 				}
+			default:
+				message, err := w.encoder.Encode(&dm)
+				if err != nil {
+					w.LogError("unable to encode: %s", err)
+					break
+				}
+				attrs["message"] = string(message)
 			}
 			events = append(events, event{
 				TS:    strconv.FormatInt(time.Unix(int64(dm.DNSTap.TimeSec), int64(dm.DNSTap.TimeNsec)).UnixNano(), 10),
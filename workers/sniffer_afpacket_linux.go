@@ -8,6 +8,7 @@ import (
 	"errors"
 	"net"
 	"os"
+	"sort"
 	"syscall"
 	"time"
 
@@ -66,12 +67,31 @@ func (w *AfpacketSniffer) Listen() error {
 		return err
 	}
 
+	// all ports to capture, dns and encrypted sessions
+	dnsPorts, sessionPorts := w.GetPorts()
+	filterPorts := append([]int{}, dnsPorts...)
+	for port := range sessionPorts {
+		if !containsPort(dnsPorts, port) {
+			filterPorts = append(filterPorts, port)
+		}
+	}
+	sort.Ints(filterPorts)
+	if len(filterPorts) == 0 {
+		return errors.New("no port to capture")
+	}
+
 	var filter []bpf.Instruction
 	isEthernet := !w.GetConfig().Collectors.AfpacketLiveCapture.RawIPSupport
-	filter, err = netutils.GetBpfDnsFilterPort(w.GetConfig().Collectors.AfpacketLiveCapture.Port, isEthernet)
-
-	if w.GetConfig().Collectors.AfpacketLiveCapture.GreSupport {
-		filter, err = netutils.GetBpfGreDnsFilterPort(w.GetConfig().Collectors.AfpacketLiveCapture.Port)
+	switch {
+	case w.GetConfig().Collectors.AfpacketLiveCapture.GreSupport:
+		if len(filterPorts) > 1 {
+			return errors.New("gre support is limited to one port")
+		}
+		filter, err = netutils.GetBpfGreDnsFilterPort(filterPorts[0])
+	case len(filterPorts) == 1:
+		filter, err = netutils.GetBpfDnsFilterPort(filterPorts[0], isEthernet)
+	default:
+		filter, err = GetBpfDNSFilterPorts(filterPorts, isEthernet)
 	}
 	if err != nil {
 		return err
@@ -81,12 +101,45 @@ func (w *AfpacketSniffer) Listen() error {
 		return err
 	}
 
-	w.LogInfo("BPF filter applied")
+	w.LogInfo("BPF filter applied on ports %v", filterPorts)
 
 	w.fd = fd
 	return nil
 }
 
+// GetPorts returns the ports of the plain DNS traffic and the ports of the encrypted
+// sessions with the protocol (DOT, DOH)
+func (w *AfpacketSniffer) GetPorts() ([]int, map[int]string) {
+	cfg := w.GetConfig().Collectors.AfpacketLiveCapture
+
+	var dnsPorts []int
+	for _, port := range append([]int{cfg.Port}, cfg.Ports...) {
+		if port > 0 && !containsPort(dnsPorts, port) {
+			dnsPorts = append(dnsPorts, port)
+		}
+	}
+
+	sessionPorts := make(map[int]string)
+	if cfg.EncryptedSupport {
+		for _, port := range cfg.DoTPorts {
+			sessionPorts[port] = dnsutils.ProtoDoT
+		}
+		for _, port := range cfg.DoHPorts {
+			sessionPorts[port] = dnsutils.ProtoDoH
+		}
+	}
+	return dnsPorts, sessionPorts
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
 func (w *AfpacketSniffer) StartCollect() {
 	w.LogInfo("starting data collection")
 	defer w.CollectDone()
@@ -103,8 +156,7 @@ func (w *AfpacketSniffer) StartCollect() {
 		bufSize = w.GetConfig().Collectors.AfpacketLiveCapture.ChannelBufferSize
 	}
 	dnsProcessor := NewDNSProcessor(w.GetConfig(), w.GetLogger(), w.GetName(), bufSize)
-	dnsProcessor.SetDefaultRoutes(w.GetDefaultRoutes())
-	dnsProcessor.SetDefaultDropped(w.GetDroppedRoutes())
+	dnsProcessor.FollowRoutes(w.GenericWorker)
 	go dnsProcessor.StartCollect()
 
 	dnsChan := make(chan netutils.DNSPacket)
@@ -112,6 +164,12 @@ func (w *AfpacketSniffer) StartCollect() {
 	tcpChan := make(chan gopacket.Packet)
 	fragIP4Chan := make(chan gopacket.Packet)
 	fragIP6Chan := make(chan gopacket.Packet)
+	sessionChan := make(chan gopacket.Packet)
+	sessionEvents := make(chan dnsutils.DNSMessage)
+
+	dnsPorts, sessionPorts := w.GetPorts()
+	sessionTimeout := time.Duration(w.GetConfig().Collectors.AfpacketLiveCapture.SessionTimeout) * time.Second
+	sessions := NewSessionTracker(sessionPorts, sessionTimeout)
 
 	var netDecoder netutils.PacketDecoder
 	if w.GetConfig().Collectors.AfpacketLiveCapture.RawIPSupport {
@@ -120,11 +178,49 @@ func (w *AfpacketSniffer) StartCollect() {
 		netDecoder = &netutils.NetDecoder{}
 	}
 
-	// defrag ipv4
-	go netutils.IPDefragger(fragIP4Chan, udpChan, tcpChan, w.GetConfig().Collectors.AfpacketLiveCapture.Port)
+	ctx, cancel := context.WithCancel(context.Background())
 
-	// defrag ipv6
-	go netutils.IPDefragger(fragIP6Chan, udpChan, tcpChan, w.GetConfig().Collectors.AfpacketLiveCapture.Port)
+	// dispatch the packets according to the transport and the ports
+	dispatch := func(packet gopacket.Packet) {
+		var output chan gopacket.Packet
+		switch transport := packet.TransportLayer().(type) {
+		case *layers.UDP:
+			if containsPort(dnsPorts, int(transport.SrcPort)) || containsPort(dnsPorts, int(transport.DstPort)) {
+				output = udpChan
+			}
+		case *layers.TCP:
+			switch {
+			case containsPort(dnsPorts, int(transport.SrcPort)) || containsPort(dnsPorts, int(transport.DstPort)):
+				output = tcpChan
+			case sessions.IsSessionPort(transport):
+				output = sessionChan
+			}
+		}
+		if output == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+		case output <- packet:
+		}
+	}
+
+	// defrag ipv4 and ipv6
+	for _, fragChan := range []chan gopacket.Packet{fragIP4Chan, fragIP6Chan} {
+		go func(fragChan chan gopacket.Packet) {
+			defragger := netutils.NewIPDefragmenter()
+			for fragment := range fragChan {
+				reassembled, err := defragger.DefragIP(fragment)
+				if err != nil {
+					break
+				}
+				if reassembled == nil || reassembled.TransportLayer() == nil {
+					continue
+				}
+				dispatch(reassembled)
+			}
+		}(fragChan)
+	}
 
 	// tcp assembly
 	go netutils.TCPAssembler(tcpChan, dnsChan, 0)
@@ -132,7 +228,35 @@ func (w *AfpacketSniffer) StartCollect() {
 	// udp processor
 	go netutils.UDPProcessor(udpChan, dnsChan, 0)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// encrypted sessions
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		var events []dnsutils.DNSMessage
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case packet := <-sessionChan:
+				if dm, closed := sessions.Process(packet); closed {
+					events = append(events, dm)
+				}
+			case now := <-ticker.C:
+				events = append(events, sessions.Expire(now)...)
+			}
+
+			for _, dm := range events {
+				select {
+				case <-ctx.Done():
+					return
+				case sessionEvents <- dm:
+				}
+			}
+			events = events[:0]
+		}
+	}()
+
 	done := make(chan struct{})
 	go func(ctx context.Context) {
 		defer func() {
@@ -242,21 +366,7 @@ func (w *AfpacketSniffer) StartCollect() {
 				}
 
 				// tcp or udp packets ?
-				if packet.TransportLayer().LayerType() == layers.LayerTypeUDP {
-					select {
-					case <-ctx.Done():
-						return
-					case udpChan <- packet:
-					}
-				}
-
-				if packet.TransportLayer().LayerType() == layers.LayerTypeTCP {
-					select {
-					case <-ctx.Done():
-						return
-					case tcpChan <- packet:
-					}
-				}
+				dispatch(packet)
 			}
 		}
 
@@ -303,6 +413,11 @@ func (w *AfpacketSniffer) StartCollect() {
 
 			// send DNS message to DNS processor
 			dnsProcessor.GetInputChannel() <- dm
+
+		// encrypted session closed ?
+		case dmSession := <-sessionEvents:
+			dmSession.DNSTap.Identity = w.GetConfig().GetServerIdentity()
+			dnsProcessor.GetInputChannel() <- dmSession
 		}
 	}
 }
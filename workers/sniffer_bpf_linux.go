@@ -0,0 +1,100 @@
+//go:build linux
+
+package workers
+
+import (
+	"errors"
+
+	"github.com/dmachard/go-netutils"
+	"golang.org/x/net/bpf"
+)
+
+const (
+	ethLen  = uint32(14)
+	ipv6Len = uint32(40)
+
+	// jumps in BPF are limited to 255 instructions
+	maxBpfPorts = 32
+)
+
+// matchBpfPorts accepts the packet if the source or destination port
+// is in the list, register X must point to the transport layer
+func matchBpfPorts(lr *netutils.LabelResolver, ports []int) {
+	lr.Add(bpf.LoadIndirect{Off: 0, Size: 2}) // A = pkt[X:X+2] = source port
+	for _, port := range ports {
+		lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(port)}, "accept_packet", "")
+	}
+	lr.Add(bpf.LoadIndirect{Off: 2, Size: 2}) // A = pkt[X+2:X+4] = destination port
+	for i, port := range ports {
+		onFalse := ""
+		if i == len(ports)-1 {
+			onFalse = "ignore_packet"
+		}
+		lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(port)}, "accept_packet", onFalse)
+	}
+}
+
+// GetBpfDNSFilterPorts returns a BPF filter accepting the UDP and TCP packets
+// on one of the ports provided, and the IP fragments
+func GetBpfDNSFilterPorts(ports []int, withEthernet bool) ([]bpf.Instruction, error) {
+	if len(ports) == 0 {
+		return nil, errors.New("no port to filter")
+	}
+	if len(ports) > maxBpfPorts {
+		return nil, errors.New("too many ports to filter")
+	}
+
+	lr := &netutils.LabelResolver{LabelMap: make(map[string]int)}
+
+	// IPv4, IPv6 protocol condition
+	if withEthernet {
+		lr.Add(bpf.LoadConstant{Dst: bpf.RegX, Val: ethLen})                                  // X = 14
+		lr.Add(bpf.LoadAbsolute{Off: 12, Size: 2})                                            // A = eth.type
+		lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x0800}, "read_ipv4", "")              // A == IPv4 ?
+		lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x86dd}, "read_ipv6", "ignore_packet") // A == IPv6 ?
+	} else {
+		lr.Add(bpf.LoadConstant{Dst: bpf.RegX, Val: 0})                                  // X = 0
+		lr.Add(bpf.LoadAbsolute{Off: 0, Size: 1})                                        // A = ip version and ihl
+		lr.Add(bpf.ALUOpConstant{Op: bpf.ALUOpShiftRight, Val: 4})                       // A = ip version
+		lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 4}, "read_ipv4", "")              // IPv4 ?
+		lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 6}, "read_ipv6", "ignore_packet") // IPv6 ?
+	}
+
+	// IPv4 layer
+	lr.Label("read_ipv4")
+	lr.Add(bpf.LoadIndirect{Off: 6, Size: 2})                                                    // A = flags and fragment offset
+	lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpBitsSet, Val: 0x1fff}, "accept_packet", "")               // fragment ?
+	lr.Add(bpf.LoadIndirect{Off: 9, Size: 1})                                                    // A = ip.proto
+	lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x11}, "read_ipv4_transport", "")             // UDP ?
+	lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x6}, "read_ipv4_transport", "ignore_packet") // TCP ?
+
+	lr.Label("read_ipv4_transport")
+	lr.Add(bpf.LoadIndirect{Off: 0, Size: 1})              // A = version and ihl
+	lr.Add(bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: 0x0F}) // A = ihl
+	lr.Add(bpf.ALUOpConstant{Op: bpf.ALUOpMul, Val: 4})    // A = header length in bytes
+	lr.Add(bpf.ALUOpX{Op: bpf.ALUOpAdd})                   // A = A + X
+	lr.Add(bpf.TAX{})                                      // X = A
+	matchBpfPorts(lr, ports)
+
+	// IPv6 layer
+	lr.Label("read_ipv6")
+	lr.Add(bpf.LoadIndirect{Off: 6, Size: 1})                                                    // A = next header
+	lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x2c}, "accept_packet", "")                   // fragment ?
+	lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x11}, "read_ipv6_transport", "")             // UDP ?
+	lr.JumpIf(bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x6}, "read_ipv6_transport", "ignore_packet") // TCP ?
+
+	lr.Label("read_ipv6_transport")
+	lr.Add(bpf.TXA{})                                         // A = X
+	lr.Add(bpf.ALUOpConstant{Op: bpf.ALUOpAdd, Val: ipv6Len}) // A = A + 40
+	lr.Add(bpf.TAX{})                                         // X = A
+	matchBpfPorts(lr, ports)
+
+	// keep the packet and send up to 65k of the packet to userspace
+	lr.Label("accept_packet")
+	lr.Add(bpf.RetConstant{Val: 0xFFFF})
+
+	lr.Label("ignore_packet")
+	lr.Add(bpf.RetConstant{Val: 0})
+
+	return lr.ResolveJumps()
+}
@@ -0,0 +1,124 @@
+//go:build linux
+
+package workers
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/bpf"
+)
+
+func getSerializedPacket(t *testing.T, withEthernet, ipv6, udp bool, srcPort, dstPort int) []byte {
+	var serialized []gopacket.SerializableLayer
+	var network gopacket.NetworkLayer
+
+	if ipv6 {
+		ip := &layers.IPv6{Version: 6, HopLimit: 64, SrcIP: net.ParseIP("2001:db8::1"), DstIP: net.ParseIP("2001:db8::2")}
+		ip.NextHeader = layers.IPProtocolTCP
+		if udp {
+			ip.NextHeader = layers.IPProtocolUDP
+		}
+		network = ip
+	} else {
+		ip := &layers.IPv4{Version: 4, TTL: 64, SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.2")}
+		ip.Protocol = layers.IPProtocolTCP
+		if udp {
+			ip.Protocol = layers.IPProtocolUDP
+		}
+		network = ip
+	}
+
+	if withEthernet {
+		eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 0, 0, 0, 0, 1}, DstMAC: net.HardwareAddr{0, 0, 0, 0, 0, 2}}
+		eth.EthernetType = layers.EthernetTypeIPv4
+		if ipv6 {
+			eth.EthernetType = layers.EthernetTypeIPv6
+		}
+		serialized = append(serialized, eth)
+	}
+	serialized = append(serialized, network.(gopacket.SerializableLayer))
+
+	if udp {
+		transport := &layers.UDP{SrcPort: layers.UDPPort(srcPort), DstPort: layers.UDPPort(dstPort)}
+		transport.SetNetworkLayerForChecksum(network)
+		serialized = append(serialized, transport)
+	} else {
+		transport := &layers.TCP{SrcPort: layers.TCPPort(srcPort), DstPort: layers.TCPPort(dstPort), Window: 1024}
+		transport.SetNetworkLayerForChecksum(network)
+		serialized = append(serialized, transport)
+	}
+	serialized = append(serialized, gopacket.Payload([]byte{0, 1, 2, 3}))
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, serialized...); err != nil {
+		t.Fatalf("unable to serialize packet: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetBpfDNSFilterPorts(t *testing.T) {
+	ports := []int{53, 853, 5353}
+
+	testcases := []struct {
+		name     string
+		ipv6     bool
+		udp      bool
+		srcPort  int
+		dstPort  int
+		accepted bool
+	}{
+		{name: "ipv4_udp_dst", udp: true, srcPort: 40000, dstPort: 53, accepted: true},
+		{name: "ipv4_udp_src", udp: true, srcPort: 5353, dstPort: 40000, accepted: true},
+		{name: "ipv4_tcp_dot", srcPort: 40000, dstPort: 853, accepted: true},
+		{name: "ipv4_tcp_other", srcPort: 40000, dstPort: 443, accepted: false},
+		{name: "ipv6_udp_dst", ipv6: true, udp: true, srcPort: 40000, dstPort: 5353, accepted: true},
+		{name: "ipv6_tcp_src", ipv6: true, srcPort: 853, dstPort: 40000, accepted: true},
+		{name: "ipv6_udp_other", ipv6: true, udp: true, srcPort: 40000, dstPort: 123, accepted: false},
+	}
+
+	for _, withEthernet := range []bool{true, false} {
+		filter, err := GetBpfDNSFilterPorts(ports, withEthernet)
+		if err != nil {
+			t.Fatalf("unable to build filter: %s", err)
+		}
+		vm, err := bpf.NewVM(filter)
+		if err != nil {
+			t.Fatalf("invalid filter: %s", err)
+		}
+
+		for _, tc := range testcases {
+			name := tc.name
+			if !withEthernet {
+				name += "_rawip"
+			}
+			t.Run(name, func(t *testing.T) {
+				pkt := getSerializedPacket(t, withEthernet, tc.ipv6, tc.udp, tc.srcPort, tc.dstPort)
+				n, err := vm.Run(pkt)
+				if err != nil {
+					t.Fatalf("filter error: %s", err)
+				}
+				if (n > 0) != tc.accepted {
+					t.Errorf("packet accepted=%v, expected=%v", n > 0, tc.accepted)
+				}
+			})
+		}
+	}
+}
+
+func TestGetBpfDNSFilterPorts_Invalid(t *testing.T) {
+	if _, err := GetBpfDNSFilterPorts([]int{}, true); err == nil {
+		t.Errorf("error expected without port")
+	}
+
+	ports := make([]int, maxBpfPorts+1)
+	for i := range ports {
+		ports[i] = 1000 + i
+	}
+	if _, err := GetBpfDNSFilterPorts(ports, true); err == nil {
+		t.Errorf("error expected with too many ports")
+	}
+}
@@ -0,0 +1,295 @@
+package workers
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	// maximum number of encrypted sessions followed at the same time
+	maxTrackedSessions = 65536
+	// maximum bytes buffered to decode the TLS ClientHello
+	maxClientHelloSize = 16384 + 5
+)
+
+type sessionKey struct {
+	net, transport gopacket.Flow
+}
+
+type tlsSession struct {
+	protocol             string
+	family               string
+	ipFlow, portFlow     gopacket.Flow
+	start, last          time.Time
+	bytesSent, bytesRecv int
+	hello                []byte
+	helloDone            bool
+	sni                  string
+	alpn                 []string
+	finClient, finServer bool
+}
+
+// SessionTracker follows the encrypted DNS sessions (DoT, DoH) seen on the wire.
+// The payload can't be decoded, so one metadata event is produced per session
+// with the SNI, ALPN, byte counts and duration.
+type SessionTracker struct {
+	protocols map[int]string
+	timeout   time.Duration
+	sessions  map[sessionKey]*tlsSession
+}
+
+// NewSessionTracker creates a tracker, protocols maps the server ports to the protocol (DOT, DOH)
+func NewSessionTracker(protocols map[int]string, timeout time.Duration) *SessionTracker {
+	return &SessionTracker{
+		protocols: protocols,
+		timeout:   timeout,
+		sessions:  make(map[sessionKey]*tlsSession),
+	}
+}
+
+// IsSessionPort returns true if the TCP segment belongs to an encrypted session
+func (t *SessionTracker) IsSessionPort(tcp *layers.TCP) bool {
+	_, dst := t.protocols[int(tcp.DstPort)]
+	_, src := t.protocols[int(tcp.SrcPort)]
+	return dst || src
+}
+
+// Sessions returns the number of sessions in progress
+func (t *SessionTracker) Sessions() int {
+	return len(t.sessions)
+}
+
+// Process updates the session of the packet, a DNS message is returned when the session is closed
+func (t *SessionTracker) Process(packet gopacket.Packet) (dnsutils.DNSMessage, bool) {
+	tcp, ok := packet.TransportLayer().(*layers.TCP)
+	if !ok || packet.NetworkLayer() == nil {
+		return dnsutils.DNSMessage{}, false
+	}
+
+	netFlow := packet.NetworkLayer().NetworkFlow()
+	tcpFlow := tcp.TransportFlow()
+
+	// the client is the peer talking to the server port
+	fromClient := true
+	protocol, isServer := t.protocols[int(tcp.DstPort)]
+	if !isServer {
+		protocol, isServer = t.protocols[int(tcp.SrcPort)]
+		if !isServer {
+			return dnsutils.DNSMessage{}, false
+		}
+		fromClient = false
+		netFlow, tcpFlow = netFlow.Reverse(), tcpFlow.Reverse()
+	}
+
+	key := sessionKey{net: netFlow, transport: tcpFlow}
+	timestamp := packet.Metadata().Timestamp
+	session, exists := t.sessions[key]
+	if !exists {
+		// nothing to report for a session already closed
+		if tcp.RST || tcp.FIN || len(t.sessions) >= maxTrackedSessions {
+			return dnsutils.DNSMessage{}, false
+		}
+		session = &tlsSession{
+			protocol: protocol,
+			family:   netFlow.EndpointType().String(),
+			ipFlow:   netFlow,
+			portFlow: tcpFlow,
+			start:    timestamp,
+		}
+		t.sessions[key] = session
+	}
+	session.last = timestamp
+
+	payloadLen := len(tcp.Payload)
+	if fromClient {
+		session.bytesSent += payloadLen
+		if payloadLen > 0 && !session.helloDone {
+			session.readClientHello(tcp.Payload)
+		}
+		if tcp.FIN {
+			session.finClient = true
+		}
+	} else {
+		session.bytesRecv += payloadLen
+		if tcp.FIN {
+			session.finServer = true
+		}
+	}
+
+	switch {
+	case tcp.RST:
+		delete(t.sessions, key)
+		return session.toDNSMessage(dnsutils.SessionClosingRst), true
+	case session.finClient && session.finServer:
+		delete(t.sessions, key)
+		return session.toDNSMessage(dnsutils.SessionClosingFin), true
+	}
+	return dnsutils.DNSMessage{}, false
+}
+
+// Expire returns the sessions without activity since the timeout
+func (t *SessionTracker) Expire(now time.Time) []dnsutils.DNSMessage {
+	var expired []dnsutils.DNSMessage
+	for key, session := range t.sessions {
+		if now.Sub(session.last) < t.timeout {
+			continue
+		}
+		delete(t.sessions, key)
+		expired = append(expired, session.toDNSMessage(dnsutils.SessionClosingTimeout))
+	}
+	return expired
+}
+
+func (s *tlsSession) readClientHello(payload []byte) {
+	s.hello = append(s.hello, payload...)
+
+	// not a TLS handshake
+	if s.hello[0] != 0x16 {
+		s.helloDone = true
+		s.hello = nil
+		return
+	}
+
+	// wait the full record
+	if len(s.hello) < 5 {
+		return
+	}
+	recordLen := int(binary.BigEndian.Uint16(s.hello[3:5]))
+	if len(s.hello) < 5+recordLen && len(s.hello) < maxClientHelloSize {
+		return
+	}
+
+	end := 5 + recordLen
+	if end > len(s.hello) {
+		end = len(s.hello)
+	}
+	s.sni, s.alpn = ParseClientHello(s.hello[5:end])
+	s.helloDone = true
+	s.hello = nil
+}
+
+func (s *tlsSession) toDNSMessage(closing string) dnsutils.DNSMessage {
+	dm := dnsutils.DNSMessage{}
+	dm.Init()
+
+	dm.NetworkInfo.Family = s.family
+	dm.NetworkInfo.Protocol = s.protocol
+	dm.NetworkInfo.QueryIP = s.ipFlow.Src().String()
+	dm.NetworkInfo.ResponseIP = s.ipFlow.Dst().String()
+	dm.NetworkInfo.QueryPort = s.portFlow.Src().String()
+	dm.NetworkInfo.ResponsePort = s.portFlow.Dst().String()
+
+	dm.DNS.Type = dnsutils.DNSSession
+	dm.DNSTap.Operation = dnsutils.DNSTapClientSession
+
+	timestamp := s.start.UnixNano()
+	seconds := timestamp / int64(time.Second)
+	dm.DNSTap.TimeSec = int(seconds)
+	dm.DNSTap.TimeNsec = int(timestamp - seconds*int64(time.Second))
+
+	dm.Session = &dnsutils.CollectorSession{
+		SNI:           s.sni,
+		ALPN:          s.alpn,
+		BytesSent:     s.bytesSent,
+		BytesReceived: s.bytesRecv,
+		Duration:      s.last.Sub(s.start).Seconds(),
+		Closing:       closing,
+	}
+	return dm
+}
+
+// ParseClientHello extracts the server name and the ALPN protocols
+// from the handshake message of a TLS ClientHello record
+func ParseClientHello(data []byte) (sni string, alpn []string) {
+	// handshake type (1) + length (3)
+	if len(data) < 4 || data[0] != 0x01 {
+		return
+	}
+	msg := data[4:]
+
+	// client version (2) + random (32)
+	offset := 34
+	// session id
+	if offset+1 > len(msg) {
+		return
+	}
+	offset += 1 + int(msg[offset])
+	// cipher suites
+	if offset+2 > len(msg) {
+		return
+	}
+	offset += 2 + int(binary.BigEndian.Uint16(msg[offset:]))
+	// compression methods
+	if offset+1 > len(msg) {
+		return
+	}
+	offset += 1 + int(msg[offset])
+	// extensions
+	if offset+2 > len(msg) {
+		return
+	}
+	extEnd := offset + 2 + int(binary.BigEndian.Uint16(msg[offset:]))
+	if extEnd > len(msg) {
+		extEnd = len(msg)
+	}
+	offset += 2
+
+	for offset+4 <= extEnd {
+		extType := binary.BigEndian.Uint16(msg[offset:])
+		extLen := int(binary.BigEndian.Uint16(msg[offset+2:]))
+		offset += 4
+		if offset+extLen > extEnd {
+			return
+		}
+		ext := msg[offset : offset+extLen]
+		offset += extLen
+
+		switch extType {
+		case 0x0000: // server_name
+			sni = parseServerName(ext)
+		case 0x0010: // application_layer_protocol_negotiation
+			alpn = parseALPN(ext)
+		}
+	}
+	return
+}
+
+func parseServerName(ext []byte) string {
+	if len(ext) < 2 {
+		return ""
+	}
+	list := ext[2:]
+	for len(list) >= 3 {
+		nameType := list[0]
+		nameLen := int(binary.BigEndian.Uint16(list[1:]))
+		if 3+nameLen > len(list) {
+			return ""
+		}
+		if nameType == 0 {
+			return string(list[3 : 3+nameLen])
+		}
+		list = list[3+nameLen:]
+	}
+	return ""
+}
+
+func parseALPN(ext []byte) []string {
+	if len(ext) < 2 {
+		return nil
+	}
+	var protocols []string
+	list := ext[2:]
+	for len(list) >= 1 {
+		protoLen := int(list[0])
+		if 1+protoLen > len(list) {
+			break
+		}
+		protocols = append(protocols, string(list[1:1+protoLen]))
+		list = list[1+protoLen:]
+	}
+	return protocols
+}
@@ -0,0 +1,184 @@
+package workers
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// getClientHello returns the first record sent by a TLS client
+func getClientHello(t *testing.T, serverName string, alpn []string) []byte {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		tlsClient := tls.Client(client, &tls.Config{ServerName: serverName, NextProtos: alpn})
+		tlsClient.Handshake()
+	}()
+
+	buf := make([]byte, 4096)
+	server.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatalf("unable to read client hello: %s", err)
+	}
+	client.Close()
+	return buf[:n]
+}
+
+func getTCPPacket(t *testing.T, src, dst string, srcPort, dstPort int, flags string, payload []byte, ts time.Time) gopacket.Packet {
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: net.ParseIP(src), DstIP: net.ParseIP(dst)}
+	tcp := &layers.TCP{SrcPort: layers.TCPPort(srcPort), DstPort: layers.TCPPort(dstPort), Window: 1024}
+	for _, f := range flags {
+		switch f {
+		case 'S':
+			tcp.SYN = true
+		case 'A':
+			tcp.ACK = true
+		case 'F':
+			tcp.FIN = true
+		case 'R':
+			tcp.RST = true
+		}
+	}
+	tcp.SetNetworkLayerForChecksum(ip)
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, ip, tcp, gopacket.Payload(payload)); err != nil {
+		t.Fatalf("unable to serialize packet: %s", err)
+	}
+
+	packet := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+	packet.Metadata().Timestamp = ts
+	return packet
+}
+
+func TestSessionTracker_DoT(t *testing.T) {
+	tracker := NewSessionTracker(map[int]string{853: dnsutils.ProtoDoT}, time.Minute)
+	hello := getClientHello(t, "dns.google", []string{"dot"})
+
+	start := time.Unix(1700000000, 0)
+	packets := []gopacket.Packet{
+		getTCPPacket(t, "10.0.0.1", "8.8.8.8", 40000, 853, "S", nil, start),
+		getTCPPacket(t, "8.8.8.8", "10.0.0.1", 853, 40000, "SA", nil, start.Add(10*time.Millisecond)),
+		getTCPPacket(t, "10.0.0.1", "8.8.8.8", 40000, 853, "A", hello, start.Add(20*time.Millisecond)),
+		getTCPPacket(t, "8.8.8.8", "10.0.0.1", 853, 40000, "A", make([]byte, 1000), start.Add(30*time.Millisecond)),
+		getTCPPacket(t, "10.0.0.1", "8.8.8.8", 40000, 853, "FA", nil, start.Add(time.Second)),
+	}
+	for _, packet := range packets {
+		if _, closed := tracker.Process(packet); closed {
+			t.Fatalf("session closed too early")
+		}
+	}
+	if tracker.Sessions() != 1 {
+		t.Fatalf("expected one session, got %d", tracker.Sessions())
+	}
+
+	dm, closed := tracker.Process(getTCPPacket(t, "8.8.8.8", "10.0.0.1", 853, 40000, "FA", nil, start.Add(2*time.Second)))
+	if !closed {
+		t.Fatalf("session should be closed")
+	}
+	if tracker.Sessions() != 0 {
+		t.Errorf("session not removed")
+	}
+
+	if dm.NetworkInfo.Protocol != dnsutils.ProtoDoT || dm.DNSTap.Operation != dnsutils.DNSTapClientSession {
+		t.Errorf("invalid protocol or operation: %s %s", dm.NetworkInfo.Protocol, dm.DNSTap.Operation)
+	}
+	if dm.NetworkInfo.QueryIP != "10.0.0.1" || dm.NetworkInfo.QueryPort != "40000" ||
+		dm.NetworkInfo.ResponseIP != "8.8.8.8" || dm.NetworkInfo.ResponsePort != "853" {
+		t.Errorf("invalid network info: %+v", dm.NetworkInfo)
+	}
+	if dm.Session.SNI != "dns.google" {
+		t.Errorf("invalid sni: %s", dm.Session.SNI)
+	}
+	if len(dm.Session.ALPN) != 1 || dm.Session.ALPN[0] != "dot" {
+		t.Errorf("invalid alpn: %v", dm.Session.ALPN)
+	}
+	if dm.Session.BytesSent != len(hello) || dm.Session.BytesReceived != 1000 {
+		t.Errorf("invalid byte counts: %d/%d", dm.Session.BytesSent, dm.Session.BytesReceived)
+	}
+	if dm.Session.Duration != 2 || dm.Session.Closing != dnsutils.SessionClosingFin {
+		t.Errorf("invalid duration or closing: %f %s", dm.Session.Duration, dm.Session.Closing)
+	}
+	if dm.DNSTap.TimeSec != 1700000000 {
+		t.Errorf("invalid start time: %d", dm.DNSTap.TimeSec)
+	}
+}
+
+func TestSessionTracker_Reset(t *testing.T) {
+	tracker := NewSessionTracker(map[int]string{443: dnsutils.ProtoDoH}, time.Minute)
+
+	start := time.Unix(1700000000, 0)
+	tracker.Process(getTCPPacket(t, "10.0.0.1", "1.1.1.1", 40000, 443, "S", nil, start))
+	dm, closed := tracker.Process(getTCPPacket(t, "1.1.1.1", "10.0.0.1", 443, 40000, "R", nil, start.Add(time.Second)))
+	if !closed {
+		t.Fatalf("session should be closed")
+	}
+	if dm.NetworkInfo.Protocol != dnsutils.ProtoDoH || dm.Session.Closing != dnsutils.SessionClosingRst {
+		t.Errorf("invalid protocol or closing: %s %s", dm.NetworkInfo.Protocol, dm.Session.Closing)
+	}
+	if dm.Session.SNI != "" {
+		t.Errorf("no sni expected, got %s", dm.Session.SNI)
+	}
+}
+
+func TestSessionTracker_Expire(t *testing.T) {
+	tracker := NewSessionTracker(map[int]string{853: dnsutils.ProtoDoT}, 10*time.Second)
+
+	start := time.Unix(1700000000, 0)
+	tracker.Process(getTCPPacket(t, "10.0.0.1", "8.8.8.8", 40000, 853, "S", nil, start))
+
+	if expired := tracker.Expire(start.Add(5 * time.Second)); len(expired) != 0 {
+		t.Errorf("session expired too early")
+	}
+	expired := tracker.Expire(start.Add(10 * time.Second))
+	if len(expired) != 1 {
+		t.Fatalf("expected one expired session, got %d", len(expired))
+	}
+	if expired[0].Session.Closing != dnsutils.SessionClosingTimeout {
+		t.Errorf("invalid closing: %s", expired[0].Session.Closing)
+	}
+}
+
+func TestSessionTracker_IgnorePorts(t *testing.T) {
+	tracker := NewSessionTracker(map[int]string{853: dnsutils.ProtoDoT}, time.Minute)
+
+	if _, closed := tracker.Process(getTCPPacket(t, "10.0.0.1", "8.8.8.8", 40000, 80, "R", nil, time.Now())); closed {
+		t.Errorf("unexpected session")
+	}
+	if tracker.Sessions() != 0 {
+		t.Errorf("no session expected")
+	}
+}
+
+func TestParseClientHello_Invalid(t *testing.T) {
+	hello := getClientHello(t, "dns.google", []string{"h2"})
+
+	testcases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: []byte{}},
+		{name: "not_client_hello", data: []byte{0x02, 0x00, 0x00, 0x00}},
+		{name: "truncated_header", data: hello[5:40]},
+		{name: "truncated_extensions", data: hello[5 : len(hello)-20]},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// must not panic
+			ParseClientHello(tc.data)
+		})
+	}
+
+	sni, alpn := ParseClientHello(hello[5:])
+	if sni != "dns.google" || len(alpn) != 1 || alpn[0] != "h2" {
+		t.Errorf("invalid result: %s %v", sni, alpn)
+	}
+}
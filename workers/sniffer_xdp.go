@@ -44,8 +44,7 @@ func (w *XDPSniffer) StartCollect() {
 		bufSize = w.GetConfig().Collectors.XdpLiveCapture.ChannelBufferSize
 	}
 	dnsProcessor := NewDNSProcessor(w.GetConfig(), w.GetLogger(), w.GetName(), bufSize)
-	dnsProcessor.SetDefaultRoutes(w.GetDefaultRoutes())
-	dnsProcessor.SetDefaultDropped(w.GetDroppedRoutes())
+	dnsProcessor.FollowRoutes(w.GenericWorker)
 	go dnsProcessor.StartCollect()
 
 	// get network interface by name
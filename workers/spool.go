@@ -0,0 +1,390 @@
+package workers
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+)
+
+const spoolSuffix = ".spool"
+
+var ErrSpoolCorrupted = errors.New("spool record corrupted")
+
+// spoolSegment describes one segment file on disk
+type spoolSegment struct {
+	seq     uint64
+	path    string
+	size    int64
+	records int
+}
+
+// DiskSpool is an append-only queue of opaque records stored in segment files.
+// Each record is written as: length (4 bytes) | crc32 (4 bytes) | payload
+type DiskSpool struct {
+	sync.Mutex
+	config   pkgconfig.ConfigSpool
+	prefix   string
+	segments []*spoolSegment
+	current  *os.File
+	writer   *bufio.Writer
+	lastSync time.Time
+	size     int64
+	records  int
+}
+
+// NewDiskSpool opens the spool of the worker, existing segments are kept
+// so the records written before a restart are replayed.
+func NewDiskSpool(config pkgconfig.ConfigSpool, name string) (*DiskSpool, error) {
+	switch config.FsyncPolicy {
+	case pkgconfig.SpoolFsyncAlways, pkgconfig.SpoolFsyncInterval, pkgconfig.SpoolFsyncNever:
+	default:
+		return nil, fmt.Errorf("invalid fsync policy: %s", config.FsyncPolicy)
+	}
+	if config.SegmentSize <= 0 || config.MaxSize < config.SegmentSize {
+		return nil, fmt.Errorf("invalid sizes segment-size=%d max-size=%d", config.SegmentSize, config.MaxSize)
+	}
+	if err := os.MkdirAll(config.Directory, 0o750); err != nil {
+		return nil, fmt.Errorf("unable to create spool directory: %w", err)
+	}
+
+	s := &DiskSpool{config: config, prefix: name + "-", lastSync: time.Now()}
+	if err := s.loadSegments(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *DiskSpool) loadSegments() error {
+	entries, err := os.ReadDir(s.config.Directory)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, s.prefix) || !strings.HasSuffix(name, spoolSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, s.prefix), spoolSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		seg := &spoolSegment{seq: seq, path: filepath.Join(s.config.Directory, name)}
+		if err := seg.scan(); err != nil {
+			return err
+		}
+		if seg.records == 0 {
+			os.Remove(seg.path)
+			continue
+		}
+		s.segments = append(s.segments, seg)
+		s.size += seg.size
+		s.records += seg.records
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+	return nil
+}
+
+// scan counts the valid records of the segment, a partial record at the end
+// of the file (crash during a write) is truncated
+func (seg *spoolSegment) scan() error {
+	f, err := os.OpenFile(seg.path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		payload, err := readSpoolRecord(r)
+		if err != nil {
+			break
+		}
+		offset += int64(8 + len(payload))
+		seg.records++
+	}
+	seg.size = offset
+	return f.Truncate(offset)
+}
+
+func readSpoolRecord(r io.Reader) ([]byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, ErrSpoolCorrupted
+	}
+	return payload, nil
+}
+
+func (s *DiskSpool) nextSeq() uint64 {
+	if len(s.segments) == 0 {
+		return 1
+	}
+	return s.segments[len(s.segments)-1].seq + 1
+}
+
+func (s *DiskSpool) openSegment() error {
+	seq := s.nextSeq()
+	path := filepath.Join(s.config.Directory, fmt.Sprintf("%s%020d%s", s.prefix, seq, spoolSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	s.current = f
+	s.writer = bufio.NewWriter(f)
+	s.segments = append(s.segments, &spoolSegment{seq: seq, path: path})
+	return nil
+}
+
+// sealSegment flushes and closes the segment being written
+func (s *DiskSpool) sealSegment() error {
+	if s.current == nil {
+		return nil
+	}
+	err := s.writer.Flush()
+	if s.config.FsyncPolicy != pkgconfig.SpoolFsyncNever {
+		s.current.Sync()
+	}
+	s.current.Close()
+	s.current, s.writer = nil, nil
+	return err
+}
+
+// Write appends a record and returns the number of records evicted to stay
+// under the maximum size of the spool.
+func (s *DiskSpool) Write(data []byte) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	recordSize := int64(8 + len(data))
+	if recordSize > int64(s.config.SegmentSize) {
+		return 0, fmt.Errorf("record too large for segment (%d bytes)", len(data))
+	}
+
+	// evict the oldest segments if the spool is full
+	evicted := 0
+	for s.size+recordSize > int64(s.config.MaxSize) && len(s.segments) > 0 {
+		if s.current != nil && len(s.segments) == 1 {
+			if err := s.sealSegment(); err != nil {
+				return evicted, err
+			}
+		}
+		evicted += s.removeHead()
+	}
+
+	// rotate the segment ?
+	if s.current != nil && s.segments[len(s.segments)-1].size+recordSize > int64(s.config.SegmentSize) {
+		if err := s.sealSegment(); err != nil {
+			return evicted, err
+		}
+	}
+	if s.current == nil {
+		if err := s.openSegment(); err != nil {
+			return evicted, err
+		}
+	}
+
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(data))
+	s.writer.Write(header[:])
+	if _, err := s.writer.Write(data); err != nil {
+		return evicted, err
+	}
+
+	seg := s.segments[len(s.segments)-1]
+	seg.size += recordSize
+	seg.records++
+	s.size += recordSize
+	s.records++
+
+	return evicted, s.sync()
+}
+
+func (s *DiskSpool) sync() error {
+	switch s.config.FsyncPolicy {
+	case pkgconfig.SpoolFsyncAlways:
+		if err := s.writer.Flush(); err != nil {
+			return err
+		}
+		return s.current.Sync()
+	case pkgconfig.SpoolFsyncInterval:
+		if time.Since(s.lastSync) < time.Duration(s.config.FsyncInterval)*time.Second {
+			return nil
+		}
+		s.lastSync = time.Now()
+		if err := s.writer.Flush(); err != nil {
+			return err
+		}
+		return s.current.Sync()
+	}
+	return nil
+}
+
+// removeHead deletes the oldest segment and returns its number of records
+func (s *DiskSpool) removeHead() int {
+	seg := s.segments[0]
+	os.Remove(seg.path)
+	s.segments = s.segments[1:]
+	s.size -= seg.size
+	s.records -= seg.records
+	return seg.records
+}
+
+// Replay calls send for each record, oldest first. Replayed records are removed
+// from the disk, it stops at the first error and keeps the remaining records.
+// The records lost in a corrupted segment are returned as evicted.
+func (s *DiskSpool) Replay(send func([]byte) error) (int, int, error) {
+	return s.ReplayBatch(1, func(records [][]byte) error { return send(records[0]) })
+}
+
+// ReplayBatch is like Replay but send receives up to size records of the same segment.
+// The lock is not held while sending, so the records written meanwhile go to a new segment.
+func (s *DiskSpool) ReplayBatch(size int, send func([][]byte) error) (int, int, error) {
+	replayed, evicted := 0, 0
+	for {
+		// the head segment is sealed before reading it
+		s.Lock()
+		if len(s.segments) == 0 {
+			s.Unlock()
+			return replayed, evicted, nil
+		}
+		if len(s.segments) == 1 {
+			if err := s.sealSegment(); err != nil {
+				s.Unlock()
+				return replayed, evicted, err
+			}
+		}
+		seg := s.segments[0]
+		records := seg.records
+		s.Unlock()
+
+		done, offset, corrupted, sendErr := seg.replay(size, records, send)
+		replayed += done
+
+		s.Lock()
+		if len(s.segments) == 0 || s.segments[0] != seg {
+			// evicted by a write during the replay
+			s.Unlock()
+			if sendErr != nil {
+				return replayed, evicted, sendErr
+			}
+			continue
+		}
+		if sendErr == nil {
+			// segment fully replayed, or unreadable records are lost
+			if corrupted {
+				evicted += records - done
+			}
+			s.removeHead()
+			s.Unlock()
+			continue
+		}
+
+		// keep only the records not yet replayed
+		var err error
+		if done > 0 {
+			if err = seg.dropPrefix(offset); err == nil {
+				seg.size -= offset
+				seg.records -= done
+				s.size -= offset
+				s.records -= done
+			}
+		}
+		s.Unlock()
+		if err != nil {
+			return replayed, evicted, err
+		}
+		return replayed, evicted, sendErr
+	}
+}
+
+// replay sends the records of a sealed segment by batch and returns the number
+// of records sent with their size in bytes, corrupted is true if a record can't be read
+func (seg *spoolSegment) replay(size, records int, send func([][]byte) error) (int, int64, bool, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	done := 0
+	var readErr error
+	batch := make([][]byte, 0, size)
+	for done < records && readErr == nil {
+		batch = batch[:0]
+		var batchBytes int64
+		for done+len(batch) < records && len(batch) < size {
+			payload, err := readSpoolRecord(r)
+			if err != nil {
+				readErr = err
+				break
+			}
+			batch = append(batch, payload)
+			batchBytes += int64(8 + len(payload))
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := send(batch); err != nil {
+			return done, offset, readErr != nil, err
+		}
+		offset += batchBytes
+		done += len(batch)
+	}
+	return done, offset, readErr != nil, nil
+}
+
+// dropPrefix rewrites the segment without its first bytes
+func (seg *spoolSegment) dropPrefix(offset int64) error {
+	data, err := os.ReadFile(seg.path)
+	if err != nil {
+		return err
+	}
+	tmp := seg.path + ".tmp"
+	if err := os.WriteFile(tmp, data[offset:], 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, seg.path)
+}
+
+// Records returns the number of records waiting in the spool
+func (s *DiskSpool) Records() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.records
+}
+
+// Size returns the number of bytes used on disk
+func (s *DiskSpool) Size() int64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.size
+}
+
+func (s *DiskSpool) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.sealSegment()
+}
@@ -0,0 +1,354 @@
+package workers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dmachard/go-dnscollector/dnsutils"
+	"github.com/dmachard/go-dnscollector/pkgconfig"
+	"github.com/dmachard/go-logger"
+	"github.com/dmachard/go-netutils"
+)
+
+func getSpoolConfigForTest(t *testing.T) pkgconfig.ConfigSpool {
+	cfg := pkgconfig.GetDefaultConfig().Loggers.TCPClient.Spool
+	cfg.Enable = true
+	cfg.Directory = t.TempDir()
+	cfg.FsyncPolicy = pkgconfig.SpoolFsyncAlways
+	return cfg
+}
+
+func TestDiskSpool_WriteReplay(t *testing.T) {
+	cfg := getSpoolConfigForTest(t)
+	cfg.SegmentSize = 64
+	cfg.MaxSize = 1024
+
+	spool, err := NewDiskSpool(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := spool.Write([]byte(fmt.Sprintf("record-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if spool.Records() != 10 {
+		t.Errorf("expected 10 records, got %d", spool.Records())
+	}
+
+	// several segments must be created
+	files, _ := filepath.Glob(filepath.Join(cfg.Directory, "test-*.spool"))
+	if len(files) < 2 {
+		t.Errorf("expected several segments, got %d", len(files))
+	}
+
+	// replay and check the order
+	var got []string
+	replayed, _, err := spool.Replay(func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != 10 {
+		t.Errorf("expected 10 replayed records, got %d", replayed)
+	}
+	for i, record := range got {
+		if record != fmt.Sprintf("record-%d", i) {
+			t.Errorf("invalid order, got %s at index %d", record, i)
+		}
+	}
+	if spool.Records() != 0 || spool.Size() != 0 {
+		t.Errorf("spool must be empty, records=%d size=%d", spool.Records(), spool.Size())
+	}
+	files, _ = filepath.Glob(filepath.Join(cfg.Directory, "test-*.spool"))
+	if len(files) != 0 {
+		t.Errorf("segments must be removed, got %d", len(files))
+	}
+}
+
+func TestDiskSpool_ReplayInterrupted(t *testing.T) {
+	cfg := getSpoolConfigForTest(t)
+	spool, err := NewDiskSpool(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		spool.Write([]byte(fmt.Sprintf("record-%d", i)))
+	}
+
+	// remote goes down after 2 records
+	sent := 0
+	replayed, _, err := spool.Replay(func(data []byte) error {
+		if sent == 2 {
+			return errors.New("remote down")
+		}
+		sent++
+		return nil
+	})
+	if err == nil || replayed != 2 {
+		t.Fatalf("expected error after 2 records, got replayed=%d err=%v", replayed, err)
+	}
+	if spool.Records() != 3 {
+		t.Errorf("expected 3 remaining records, got %d", spool.Records())
+	}
+
+	// the next replay starts from the first record not sent
+	var first string
+	spool.Replay(func(data []byte) error {
+		if first == "" {
+			first = string(data)
+		}
+		return nil
+	})
+	if first != "record-2" {
+		t.Errorf("expected record-2, got %s", first)
+	}
+}
+
+func TestDiskSpool_ReplayBatch(t *testing.T) {
+	cfg := getSpoolConfigForTest(t)
+	spool, err := NewDiskSpool(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		spool.Write([]byte(fmt.Sprintf("record-%d", i)))
+	}
+
+	// the second batch fails, the first one is removed
+	var sizes []int
+	replayed, _, err := spool.ReplayBatch(3, func(records [][]byte) error {
+		sizes = append(sizes, len(records))
+		if len(sizes) == 2 {
+			return errors.New("remote down")
+		}
+		return nil
+	})
+	if err == nil || replayed != 3 || spool.Records() != 4 {
+		t.Fatalf("expected 3 records replayed, got replayed=%d remaining=%d err=%v", replayed, spool.Records(), err)
+	}
+
+	sizes = nil
+	var first string
+	replayed, _, err = spool.ReplayBatch(3, func(records [][]byte) error {
+		if first == "" {
+			first = string(records[0])
+		}
+		sizes = append(sizes, len(records))
+		return nil
+	})
+	if err != nil || replayed != 4 || first != "record-3" {
+		t.Fatalf("expected 4 records from record-3, got replayed=%d first=%s err=%v", replayed, first, err)
+	}
+	if len(sizes) != 2 || sizes[0] != 3 || sizes[1] != 1 {
+		t.Errorf("invalid batches: %v", sizes)
+	}
+}
+
+func TestDiskSpool_EvictOldest(t *testing.T) {
+	cfg := getSpoolConfigForTest(t)
+	cfg.SegmentSize = 32
+	cfg.MaxSize = 64
+
+	spool, err := NewDiskSpool(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each record uses 16 bytes on disk, 2 records per segment
+	evicted := 0
+	for i := 0; i < 10; i++ {
+		n, err := spool.Write([]byte(fmt.Sprintf("rec%05d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		evicted += n
+	}
+	if spool.Size() > int64(cfg.MaxSize) {
+		t.Errorf("spool size %d exceeds max size", spool.Size())
+	}
+	if evicted+spool.Records() != 10 {
+		t.Errorf("evicted=%d and records=%d, expected 10 in total", evicted, spool.Records())
+	}
+
+	var first string
+	spool.Replay(func(data []byte) error {
+		if first == "" {
+			first = string(data)
+		}
+		return nil
+	})
+	if first != fmt.Sprintf("rec%05d", evicted) {
+		t.Errorf("oldest records must be evicted first, got %s", first)
+	}
+}
+
+func TestDiskSpool_Reopen(t *testing.T) {
+	cfg := getSpoolConfigForTest(t)
+
+	spool, err := NewDiskSpool(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spool.Write([]byte("record-0"))
+	spool.Write([]byte("record-1"))
+	spool.Close()
+
+	// simulate a crash during a write
+	files, _ := filepath.Glob(filepath.Join(cfg.Directory, "test-*.spool"))
+	if len(files) != 1 {
+		t.Fatalf("expected one segment, got %d", len(files))
+	}
+	f, _ := os.OpenFile(files[0], os.O_APPEND|os.O_WRONLY, 0)
+	f.Write([]byte{0x00, 0x00, 0x00, 0x10, 0x01})
+	f.Close()
+
+	spool, err = NewDiskSpool(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spool.Records() != 2 {
+		t.Errorf("expected 2 records after restart, got %d", spool.Records())
+	}
+
+	// new records are appended after the existing ones
+	spool.Write([]byte("record-2"))
+	var got []string
+	spool.Replay(func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	})
+	if len(got) != 3 || got[2] != "record-2" {
+		t.Errorf("unexpected records after restart: %v", got)
+	}
+}
+
+func TestDiskSpool_ReplayCorrupted(t *testing.T) {
+	cfg := getSpoolConfigForTest(t)
+
+	spool, err := NewDiskSpool(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		spool.Write([]byte(fmt.Sprintf("record-%d", i)))
+	}
+	spool.Close()
+
+	// corrupt the payload of the second record
+	files, _ := filepath.Glob(filepath.Join(cfg.Directory, "test-*.spool"))
+	f, _ := os.OpenFile(files[0], os.O_WRONLY, 0)
+	f.WriteAt([]byte("X"), 16+8)
+	f.Close()
+
+	replayed, evicted, err := spool.Replay(func(data []byte) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != 1 || evicted != 2 {
+		t.Errorf("expected 1 replayed and 2 evicted records, got %d and %d", replayed, evicted)
+	}
+	if spool.Records() != 0 {
+		t.Errorf("expected an empty spool, got %d records", spool.Records())
+	}
+}
+
+func TestDiskSpool_WriteDuringReplay(t *testing.T) {
+	cfg := getSpoolConfigForTest(t)
+
+	spool, err := NewDiskSpool(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spool.Write([]byte("record-0"))
+
+	// the spool is not locked while sending, the new record goes to a new segment
+	var got []string
+	_, _, err = spool.Replay(func(data []byte) error {
+		got = append(got, string(data))
+		if len(got) == 1 {
+			if _, err := spool.Write([]byte("record-1")); err != nil {
+				t.Errorf("write during replay: %s", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1] != "record-1" {
+		t.Errorf("unexpected records replayed: %v", got)
+	}
+}
+
+func TestDiskSpool_InvalidConfig(t *testing.T) {
+	cfg := getSpoolConfigForTest(t)
+	cfg.FsyncPolicy = "sometimes"
+	if _, err := NewDiskSpool(cfg, "test"); err == nil {
+		t.Errorf("invalid fsync policy must be rejected")
+	}
+
+	cfg = getSpoolConfigForTest(t)
+	cfg.MaxSize = cfg.SegmentSize - 1
+	if _, err := NewDiskSpool(cfg, "test"); err == nil {
+		t.Errorf("max size lower than segment size must be rejected")
+	}
+}
+
+func Test_TcpClient_SpoolReplay(t *testing.T) {
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.TCPClient.FlushInterval = 1
+	cfg.Loggers.TCPClient.Mode = pkgconfig.ModeText
+	cfg.Loggers.TCPClient.RemoteAddress = "127.0.0.1"
+	cfg.Loggers.TCPClient.RemotePort = 9998
+	cfg.Loggers.TCPClient.ConnectTimeout = 1
+	cfg.Loggers.TCPClient.RetryInterval = 1
+	cfg.Loggers.TCPClient.Spool = getSpoolConfigForTest(t)
+
+	g := NewTCPClient(cfg, logger.New(false), "test")
+	go g.StartCollect()
+
+	// remote is down, the message is spooled
+	dm := dnsutils.GetFakeDNSMessage()
+	g.GetInputChannel() <- dm
+	time.Sleep(time.Second)
+	if !g.SpoolPending() {
+		t.Fatalf("dns message not spooled")
+	}
+
+	// start receiver, the spool is replayed on connect
+	fakeRcvr, err := net.Listen(netutils.SocketTCP, ":9998")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fakeRcvr.Close()
+
+	conn, err := fakeRcvr.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	pattern := regexp.MustCompile("dns.collector")
+	if !pattern.MatchString(line) {
+		t.Errorf("tcp error want dns.collector, got: %s", line)
+	}
+
+	// stop all
+	fakeRcvr.Close()
+	g.Stop()
+}
@@ -2,13 +2,13 @@ package workers
 
 import (
 	"bytes"
-	"encoding/json"
+	"encoding/binary"
 	"io"
 	"log"
 	"os"
-	"strings"
 	"time"
 
+	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-dnscollector/transformers"
 	"github.com/dmachard/go-logger"
@@ -18,24 +18,14 @@ import (
 )
 
 func IsStdoutValidMode(mode string) bool {
-	switch mode {
-	case
-		pkgconfig.ModeJinja,
-		pkgconfig.ModeText,
-		pkgconfig.ModeJSON,
-		pkgconfig.ModeFlatJSON,
-		pkgconfig.ModePCAP:
-		return true
-	}
-	return false
+	return mode == pkgconfig.ModePCAP || pkgconfig.IsValidMode(mode)
 }
 
 type StdOut struct {
 	*GenericWorker
-	textFormat  []string
-	jinjaFormat string
-	writerText  *log.Logger
-	writerPcap  *pcapgo.Writer
+	encoder    dnsutils.Encoder
+	writerText *log.Logger
+	writerPcap *pcapgo.Writer
 }
 
 func NewStdOut(config *pkgconfig.Config, console *logger.Logger, name string) *StdOut {
@@ -54,16 +44,9 @@ func (w *StdOut) ReadConfig() {
 		w.LogFatal("invalid mode: ", w.GetConfig().Loggers.Stdout.Mode)
 	}
 
-	if len(w.GetConfig().Loggers.Stdout.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.Stdout.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
-	}
-
-	if len(w.GetConfig().Loggers.Stdout.JinjaFormat) > 0 {
-		w.jinjaFormat = w.GetConfig().Loggers.Stdout.JinjaFormat
-	} else {
-		w.jinjaFormat = w.GetConfig().Global.TextJinja
+	if w.GetConfig().Loggers.Stdout.Mode != pkgconfig.ModePCAP {
+		opts := w.GetEncoderOptions(w.GetConfig().Loggers.Stdout.TextFormat, w.GetConfig().Loggers.Stdout.JinjaFormat)
+		w.encoder = w.NewEncoder(w.GetConfig().Loggers.Stdout.Mode, opts)
 	}
 }
 
@@ -98,6 +81,10 @@ func (w *StdOut) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -142,9 +129,6 @@ func (w *StdOut) StartLogging() {
 	w.LogInfo("logging has started")
 	defer w.LoggingDone()
 
-	// standard output buffer
-	buffer := new(bytes.Buffer)
-
 	if w.GetConfig().Loggers.Stdout.Mode == pkgconfig.ModePCAP && w.writerPcap == nil {
 		w.SetPcapWriter(os.Stdout)
 	}
@@ -191,30 +175,20 @@ func (w *StdOut) StartLogging() {
 
 				w.writerPcap.WritePacket(ci, buf.Bytes())
 
-			case pkgconfig.ModeText:
-				w.writerText.Print(dm.String(w.textFormat, w.GetConfig().Global.TextFormatDelimiter, w.GetConfig().Global.TextFormatBoundary))
-
-			case pkgconfig.ModeJinja:
-				textLine, err := dm.ToTextTemplate(w.jinjaFormat)
+			default:
+				data, err := w.encoder.Encode(&dm)
 				if err != nil {
-					w.LogError("process: unable to update template: %s", err)
+					w.LogError("process: unable to encode: %s", err)
 					continue
 				}
-				w.writerText.Print(textLine)
-
-			case pkgconfig.ModeJSON:
-				json.NewEncoder(buffer).Encode(dm)
-				w.writerText.Print(buffer.String())
-				buffer.Reset()
 
-			case pkgconfig.ModeFlatJSON:
-				flat, err := dm.Flatten()
-				if err != nil {
-					w.LogError("process: flattening DNS message failed: %e", err)
+				// binary messages are prefixed with their length
+				if w.encoder.IsBinary() {
+					binary.Write(w.writerText.Writer(), binary.BigEndian, uint32(len(data)))
+					w.writerText.Writer().Write(data)
+					continue
 				}
-				json.NewEncoder(buffer).Encode(flat)
-				w.writerText.Print(buffer.String())
-				buffer.Reset()
+				w.writerText.Print(string(data))
 			}
 		}
 	}
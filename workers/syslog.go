@@ -3,7 +3,6 @@ package workers
 import (
 	"bytes"
 	"crypto/tls"
-	"encoding/json"
 	"time"
 
 	"strings"
@@ -23,7 +22,7 @@ type Syslog struct {
 	syslogWriter                       *syslog.Writer
 	syslogReady                        bool
 	transportReady, transportReconnect chan bool
-	textFormat                         []string
+	encoder                            dnsutils.Encoder
 }
 
 func NewSyslog(config *pkgconfig.Config, console *logger.Logger, name string) *Syslog {
@@ -43,8 +42,9 @@ func (w *Syslog) ReadConfig() {
 		w.LogFatal(pkgconfig.PrefixLogWorker + "invalid tls min version")
 	}
 
-	if !pkgconfig.IsValidMode(w.GetConfig().Loggers.Syslog.Mode) {
-		w.LogFatal(pkgconfig.PrefixLogWorker + "invalid mode text or json expected")
+	w.encoder = w.NewEncoder(w.GetConfig().Loggers.Syslog.Mode, w.GetEncoderOptions(w.GetConfig().Loggers.Syslog.TextFormat, ""))
+	if w.encoder.IsBinary() {
+		w.LogFatal(pkgconfig.PrefixLogWorker + "invalid mode, text based mode expected")
 	}
 	severity, err := syslog.GetPriority(w.GetConfig().Loggers.Syslog.Severity)
 	if err != nil {
@@ -57,12 +57,6 @@ func (w *Syslog) ReadConfig() {
 		w.LogFatal(pkgconfig.PrefixLogWorker + "invalid facility")
 	}
 	w.facility = facility
-
-	if len(w.GetConfig().Loggers.Syslog.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.Syslog.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
-	}
 }
 
 func (w *Syslog) ConnectToRemote() {
@@ -182,6 +176,10 @@ func (w *Syslog) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
@@ -226,49 +224,28 @@ func (w *Syslog) FlushBuffer(buf *[]dnsutils.DNSMessage) {
 	var err error
 
 	for _, dm := range *buf {
-		switch w.GetConfig().Loggers.Syslog.Mode {
-		case pkgconfig.ModeText:
-			// write the text line to the buffer
-			buffer.Write(dm.Bytes(w.textFormat, w.GetConfig().Global.TextFormatDelimiter, w.GetConfig().Global.TextFormatBoundary))
-
-			// replace NULL char from text line directly in the buffer
-			// because the NULL is at end of log in syslog
-			for i := 0; i < buffer.Len(); i++ {
-				if buffer.Bytes()[i] == 0 {
-					buffer.Bytes()[i] = w.GetConfig().Loggers.Syslog.ReplaceNullChar[0]
-				}
-			}
-
-			// ensure it ends in a \n
-			buffer.WriteString("\n")
-
-			// write the modified content of the buffer to s.syslogWriter
-			// and reset the buffer
-			_, err = buffer.WriteTo(w.syslogWriter)
-
-		case pkgconfig.ModeJSON:
-			// encode to json the dns message
-			json.NewEncoder(buffer).Encode(dm)
-
-			// write the content of the buffer to s.syslogWriter
-			// and reset the buffer
-			_, err = buffer.WriteTo(w.syslogWriter)
+		// write the encoded message to the buffer
+		line, errEncode := w.encoder.Encode(&dm)
+		if errEncode != nil {
+			w.LogError("encoding DNS message failed: %s", errEncode)
+			continue
+		}
+		buffer.Write(line)
 
-		case pkgconfig.ModeFlatJSON:
-			// get flatten object
-			flat, errflat := dm.Flatten()
-			if errflat != nil {
-				w.LogError("flattening DNS message failed: %e", err)
-				continue
+		// replace NULL char from text line directly in the buffer
+		// because the NULL is at end of log in syslog
+		for i := 0; i < buffer.Len(); i++ {
+			if buffer.Bytes()[i] == 0 {
+				buffer.Bytes()[i] = w.GetConfig().Loggers.Syslog.ReplaceNullChar[0]
 			}
+		}
 
-			// encode to json
-			json.NewEncoder(buffer).Encode(flat)
+		// ensure it ends in a \n
+		buffer.WriteString("\n")
 
-			// write the content of the buffer to s.syslogWriter
-			// and reset the buffer
-			_, err = buffer.WriteTo(w.syslogWriter)
-		}
+		// write the modified content of the buffer to s.syslogWriter
+		// and reset the buffer
+		_, err = buffer.WriteTo(w.syslogWriter)
 
 		if err != nil {
 			w.LogError("write error %s", err)
@@ -3,12 +3,12 @@ package workers
 import (
 	"bufio"
 	"crypto/tls"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"io"
 	"net"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
@@ -20,8 +20,8 @@ import (
 
 type TCPClient struct {
 	*GenericWorker
-	stopRead, doneRead                 chan bool
-	textFormat                         []string
+	stopConnect                        chan bool
+	encoder                            dnsutils.Encoder
 	transport                          string
 	transportWriter                    *bufio.Writer
 	transportConn                      net.Conn
@@ -37,9 +37,9 @@ func NewTCPClient(config *pkgconfig.Config, logger *logger.Logger, name string)
 	w := &TCPClient{GenericWorker: NewGenericWorker(config, logger, name, "tcpclient", bufSize, pkgconfig.DefaultMonitor)}
 	w.transportReady = make(chan bool)
 	w.transportReconnect = make(chan bool)
-	w.stopRead = make(chan bool)
-	w.doneRead = make(chan bool)
+	w.stopConnect = make(chan bool)
 	w.ReadConfig()
+	w.InitSpool(config.Loggers.TCPClient.Spool)
 	return w
 }
 
@@ -55,42 +55,30 @@ func (w *TCPClient) ReadConfig() {
 	}
 	// end
 
-	if len(w.GetConfig().Loggers.TCPClient.TextFormat) > 0 {
-		w.textFormat = strings.Fields(w.GetConfig().Loggers.TCPClient.TextFormat)
-	} else {
-		w.textFormat = strings.Fields(w.GetConfig().Global.TextFormat)
-	}
+	w.encoder = w.NewEncoder(w.GetConfig().Loggers.TCPClient.Mode, w.GetEncoderOptions(w.GetConfig().Loggers.TCPClient.TextFormat, ""))
 }
 
 func (w *TCPClient) Disconnect() {
+	close(w.stopConnect)
 	if w.transportConn != nil {
 		w.LogInfo("closing tcp connection")
 		w.transportConn.Close()
 	}
 }
 
-func (w *TCPClient) ReadFromConnection() {
+// ReadFromConnection discards the data sent by the remote, until the connection is closed
+func (w *TCPClient) ReadFromConnection(conn net.Conn) {
 	buffer := make([]byte, 4096)
-
-	go func() {
-		for {
-			_, err := w.transportConn.Read(buffer)
-			if err != nil {
-				if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
-					w.LogInfo("read from connection terminated")
-					break
-				}
-				w.LogError("Error on reading: %s", err.Error())
+	for {
+		_, err := conn.Read(buffer)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				w.LogInfo("read from connection terminated")
+				return
 			}
-			// We just discard the data
+			w.LogError("Error on reading: %s", err.Error())
 		}
-	}()
-
-	// block goroutine until receive true event in stopRead channel
-	<-w.stopRead
-	w.doneRead <- true
-
-	w.LogInfo("read goroutine terminated")
+	}
 }
 
 func (w *TCPClient) ConnectToRemote() {
@@ -146,49 +134,39 @@ func (w *TCPClient) ConnectToRemote() {
 		if err != nil {
 			w.LogError("%s", err)
 			w.LogInfo("retry to connect in %d seconds", w.GetConfig().Loggers.TCPClient.RetryInterval)
-			time.Sleep(time.Duration(w.GetConfig().Loggers.TCPClient.RetryInterval) * time.Second)
-			continue
+			select {
+			case <-w.stopConnect:
+				return
+			case <-time.After(time.Duration(w.GetConfig().Loggers.TCPClient.RetryInterval) * time.Second):
+				continue
+			}
 		}
 
 		w.transportConn = conn
 
 		// block until framestream is ready
-		w.transportReady <- true
+		select {
+		case w.transportReady <- true:
+		case <-w.stopConnect:
+			conn.Close()
+			return
+		}
 
 		// block until an error occurred, need to reconnect
-		w.transportReconnect <- true
+		select {
+		case w.transportReconnect <- true:
+		case <-w.stopConnect:
+			return
+		}
 	}
 }
 
 func (w *TCPClient) FlushBuffer(buf *[]dnsutils.DNSMessage) {
-	for _, dm := range *buf {
-		if w.GetConfig().Loggers.TCPClient.Mode == pkgconfig.ModeText {
-			w.transportWriter.Write(dm.Bytes(w.textFormat,
-				w.GetConfig().Global.TextFormatDelimiter,
-				w.GetConfig().Global.TextFormatBoundary))
-			w.transportWriter.WriteString(w.GetConfig().Loggers.TCPClient.PayloadDelimiter)
-		}
-
-		if w.GetConfig().Loggers.TCPClient.Mode == pkgconfig.ModeJSON {
-			json.NewEncoder(w.transportWriter).Encode(dm)
-			w.transportWriter.WriteString(w.GetConfig().Loggers.TCPClient.PayloadDelimiter)
-		}
-
-		if w.GetConfig().Loggers.TCPClient.Mode == pkgconfig.ModeFlatJSON {
-			flat, err := dm.Flatten()
-			if err != nil {
-				w.LogError("flattening DNS message failed: %e", err)
-				continue
-			}
-			json.NewEncoder(w.transportWriter).Encode(flat)
-			w.transportWriter.WriteString(w.GetConfig().Loggers.TCPClient.PayloadDelimiter)
-		}
-
-		// flush the transport buffer
-		err := w.transportWriter.Flush()
-		if err != nil {
+	for i, dm := range *buf {
+		if err := w.WriteMessage(dm); err != nil {
 			w.LogError("send frame error", err.Error())
 			w.writerReady = false
+			w.SpoolBuffer((*buf)[i:])
 			<-w.transportReconnect
 			break
 		}
@@ -198,6 +176,55 @@ func (w *TCPClient) FlushBuffer(buf *[]dnsutils.DNSMessage) {
 	*buf = nil
 }
 
+func (w *TCPClient) WriteMessage(dm dnsutils.DNSMessage) error {
+	data, err := w.encoder.Encode(&dm)
+	if err != nil {
+		w.LogError("encoding DNS message failed: %s", err)
+		return nil
+	}
+
+	// binary messages are prefixed with their length, text ones are followed by the delimiter
+	if w.encoder.IsBinary() {
+		binary.Write(w.transportWriter, binary.BigEndian, uint32(len(data)))
+		w.transportWriter.Write(data)
+	} else {
+		w.transportWriter.Write(data)
+		w.transportWriter.WriteString(w.GetConfig().Loggers.TCPClient.PayloadDelimiter)
+	}
+
+	// flush the transport buffer
+	return w.transportWriter.Flush()
+}
+
+// SpoolBuffer writes the dns messages to the disk spool, if enabled
+func (w *TCPClient) SpoolBuffer(buf []dnsutils.DNSMessage) {
+	if !w.SpoolEnabled() {
+		return
+	}
+	for _, dm := range buf {
+		data, err := json.Marshal(dm)
+		if err != nil {
+			continue
+		}
+		w.SpoolData(data)
+	}
+}
+
+// FlushSpool sends the spooled dns messages once the connection is back
+func (w *TCPClient) FlushSpool() {
+	err := w.ReplaySpool(func(data []byte) error {
+		var dm dnsutils.DNSMessage
+		if err := json.Unmarshal(data, &dm); err != nil {
+			w.LogError("spool - invalid record: %s", err)
+			return nil
+		}
+		return w.WriteMessage(dm)
+	})
+	if err != nil {
+		w.LogError("spool - replay interrupted: %s", err)
+	}
+}
+
 func (w *TCPClient) StartCollect() {
 	w.LogInfo("starting data collection")
 	defer w.CollectDone()
@@ -215,13 +242,13 @@ func (w *TCPClient) StartCollect() {
 	// loop to process incoming messages
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			w.StopLogger()
 			subprocessors.Reset()
-
-			w.stopRead <- true
-			<-w.doneRead
-
 			return
 
 		case cfg := <-w.NewConfig():
@@ -277,6 +304,8 @@ func (w *TCPClient) StartLogging() {
 		case <-w.OnLoggerStopped():
 			// closing remote connection if exist
 			w.Disconnect()
+			w.SpoolBuffer(bufferDm)
+			w.CloseSpool()
 			return
 
 		case <-w.transportReady:
@@ -285,7 +314,10 @@ func (w *TCPClient) StartLogging() {
 			w.writerReady = true
 
 			// read from the connection until we stop
-			go w.ReadFromConnection()
+			go w.ReadFromConnection(w.transportConn)
+
+			// send messages spooled while the remote was down
+			w.FlushSpool()
 
 		// incoming dns message to process
 		case dm, opened := <-w.GetOutputChannel():
@@ -294,9 +326,10 @@ func (w *TCPClient) StartLogging() {
 				return
 			}
 
-			// drop dns message if the connection is not ready to avoid memory leak or
-			// to block the channel
+			// spool or drop dns message if the connection is not ready to avoid
+			// memory leak or to block the channel
 			if !w.writerReady {
+				w.SpoolBuffer([]dnsutils.DNSMessage{dm})
 				continue
 			}
 
@@ -311,6 +344,7 @@ func (w *TCPClient) StartLogging() {
 		// flush the buffer
 		case <-flushTimer.C:
 			if !w.writerReady {
+				w.SpoolBuffer(bufferDm)
 				bufferDm = nil
 			}
 
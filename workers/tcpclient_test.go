@@ -2,6 +2,8 @@ package workers
 
 import (
 	"bufio"
+	"encoding/binary"
+	"io"
 	"net"
 	"regexp"
 	"testing"
@@ -87,6 +89,64 @@ func Test_TcpClientRun(t *testing.T) {
 	}
 }
 
+func Test_TcpClientRun_MsgpackMode(t *testing.T) {
+	// init logger
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.TCPClient.FlushInterval = 1
+	cfg.Loggers.TCPClient.BufferSize = 0
+	cfg.Loggers.TCPClient.Mode = pkgconfig.ModeMsgpack
+	cfg.Loggers.TCPClient.RemoteAddress = "127.0.0.1"
+	cfg.Loggers.TCPClient.RemotePort = 9999
+
+	g := NewTCPClient(cfg, logger.New(false), "test")
+
+	// fake msgpack receiver
+	fakeRcvr, err := net.Listen(netutils.SocketTCP, ":9999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fakeRcvr.Close()
+
+	// start the logger
+	go g.StartCollect()
+
+	// accept conn from logger
+	conn, err := fakeRcvr.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// wait connection on logger
+	time.Sleep(time.Second)
+
+	// send fake dns message to logger
+	dm := dnsutils.GetFakeDNSMessage()
+	g.GetInputChannel() <- dm
+
+	// read the length prefixed message and decode-it
+	var size uint32
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := dnsutils.DNSMessage{}
+	if _, err := decoded.UnmarshalMsg(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.DNS.Qname != dm.DNS.Qname {
+		t.Errorf("tcp error want %s, got: %s", dm.DNS.Qname, decoded.DNS.Qname)
+	}
+
+	// stop all
+	fakeRcvr.Close()
+	g.Stop()
+}
+
 func Test_TcpClient_ConnectionAttempt(t *testing.T) {
 	// init logger
 	cfg := pkgconfig.GetDefaultConfig()
@@ -144,3 +204,46 @@ func Test_TcpClient_ConnectionAttempt(t *testing.T) {
 	g.Stop()
 
 }
+
+func Test_TcpClient_StopWhileReconnecting(t *testing.T) {
+	// reserve a port without receiver
+	listener, err := net.Listen(netutils.SocketTCP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	listener.Close()
+
+	cfg := pkgconfig.GetDefaultConfig()
+	cfg.Loggers.TCPClient.RemoteAddress = "127.0.0.1"
+	cfg.Loggers.TCPClient.RemotePort = addr.Port
+	cfg.Loggers.TCPClient.ConnectTimeout = 1
+	cfg.Loggers.TCPClient.RetryInterval = 1
+
+	g := NewTCPClient(cfg, logger.New(false), "test")
+	go g.StartCollect()
+	time.Sleep(500 * time.Millisecond)
+
+	stopped := make(chan bool)
+	go func() {
+		g.Stop()
+		stopped <- true
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the logger is not stopped")
+	}
+
+	// the receiver is back, the stopped logger must not connect
+	fakeRcvr, err := net.Listen(netutils.SocketTCP, addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fakeRcvr.Close()
+	fakeRcvr.(*net.TCPListener).SetDeadline(time.Now().Add(3 * time.Second))
+	if conn, err := fakeRcvr.Accept(); err == nil {
+		conn.Close()
+		t.Error("the logger is connected after stop")
+	}
+}
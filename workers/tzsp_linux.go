@@ -86,8 +86,7 @@ func (w *TZSPSniffer) StartCollect() {
 
 	// init dns processor
 	dnsProcessor := NewDNSProcessor(w.GetConfig(), w.GetLogger(), w.GetName(), w.GetConfig().Collectors.Tzsp.ChannelBufferSize)
-	dnsProcessor.SetDefaultRoutes(w.GetDefaultRoutes())
-	dnsProcessor.SetDefaultDropped(w.GetDroppedRoutes())
+	dnsProcessor.FollowRoutes(w.GenericWorker)
 	go dnsProcessor.StartCollect()
 
 	ctx, cancel := context.WithCancel(context.Background())
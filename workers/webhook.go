@@ -72,6 +72,9 @@ func (w *Webhook) StartCollect() {
 	w.LogInfo("waiting dns message to process...")
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			droppedRoutes, droppedNames = GetRoutes(w.GetDroppedRoutes())
+
 		case <-w.OnStop():
 			subprocessors.Reset()
 			cancel()
@@ -116,6 +119,9 @@ func (w *Webhook) StartLogging(threadnum int, ctx context.Context) {
 
 	for {
 		select {
+		case <-w.OnRoutesChanged():
+			defaultRoutes, defaultNames = GetRoutes(w.GetDefaultRoutes())
+
 		case <-ctx.Done():
 			return
 
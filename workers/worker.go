@@ -1,6 +1,9 @@
 package workers
 
 import (
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dmachard/go-dnscollector/dnsutils"
@@ -13,6 +16,8 @@ type Worker interface {
 	SetMetrics(metrics *telemetry.PrometheusCollector)
 	AddDefaultRoute(wrk Worker)
 	AddDroppedRoute(wrk Worker)
+	ReplaceRoutes(defaults []Worker, dropped []Worker)
+	ReplaceConditionalRoutes(routes []ConditionalRoute, fallback []Worker)
 	SetLoggers(loggers []Worker)
 	GetName() string
 	Stop()
@@ -24,13 +29,67 @@ type Worker interface {
 	ReloadConfig(config *pkgconfig.Config)
 }
 
+// workerRoutes holds the next stanzas of a worker, it can be shared with
+// the processors started by a collector so they follow the runtime changes.
+type workerRoutes struct {
+	sync.RWMutex
+	defaults, dropped []Worker
+	conditional       []ConditionalRoute
+	fallback          []Worker
+	changed           chan struct{}
+}
+
+// ConditionalRoute forwards the messages matching the conditions to the workers,
+// the syntax of the include and exclude conditions is the one of the dnsmessage collector
+type ConditionalRoute struct {
+	Include map[string]interface{}
+	Exclude map[string]interface{}
+	Workers []Worker
+	// values loaded from the match-source of the conditions
+	Sources []MatchSource
+}
+
+// NewConditionalRoute loads the external sources referenced in the conditions
+func NewConditionalRoute(include, exclude map[string]interface{}, next []Worker) (ConditionalRoute, error) {
+	route := ConditionalRoute{Include: include, Exclude: exclude, Workers: next}
+	for _, conditions := range []map[string]interface{}{include, exclude} {
+		for _, value := range conditions {
+			source, err := ReadMatchingSource(value)
+			if err != nil {
+				return ConditionalRoute{}, err
+			}
+			if source.Len() > 0 {
+				route.Sources = append(route.Sources, source)
+			}
+		}
+	}
+	return route, nil
+}
+
+// Match returns true if the message matches the include conditions and none of the exclude ones
+func (r *ConditionalRoute) Match(dm *dnsutils.DNSMessage) (bool, error) {
+	if len(r.Include) > 0 {
+		err, matched := dm.Matching(r.Include)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	if len(r.Exclude) > 0 {
+		err, matched := dm.Matching(r.Exclude)
+		if err != nil || matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 type GenericWorker struct {
 	doneRun, stopRun, stopProcess, doneProcess, doneMonitor, stopMonitor chan bool
-	config                                                               *pkgconfig.Config
+	config                                                               atomic.Pointer[pkgconfig.Config]
 	configChan                                                           chan *pkgconfig.Config
 	logger                                                               *logger.Logger
 	name, descr                                                          string
-	droppedRoutes, defaultRoutes                                         []Worker
+	routes                                                               *workerRoutes
 	droppedWorker                                                        chan string
 	droppedWorkerCount                                                   map[string]int
 	dnsMessageIn, dnsMessageOut                                          chan dnsutils.DNSMessage
@@ -38,12 +97,15 @@ type GenericWorker struct {
 	metrics                                                                 *telemetry.PrometheusCollector
 	countIngress, countEgress, countForwarded, countDropped, countDiscarded chan int
 	totalIngress, totalEgress, totalForwarded, totalDropped, totalDiscarded int
+
+	spool                                     *DiskSpool
+	countSpooled, countReplayed, countEvicted chan int
+	totalSpooled, totalReplayed, totalEvicted int
 }
 
 func NewGenericWorker(config *pkgconfig.Config, logger *logger.Logger, name string, descr string, bufferSize int, monitor bool) *GenericWorker {
 	logger.Info(pkgconfig.PrefixLogWorker+"[%s] %s - enabled", name, descr)
 	w := &GenericWorker{
-		config:             config,
 		configChan:         make(chan *pkgconfig.Config),
 		logger:             logger,
 		name:               name,
@@ -56,6 +118,7 @@ func NewGenericWorker(config *pkgconfig.Config, logger *logger.Logger, name stri
 		stopProcess:        make(chan bool),
 		droppedWorker:      make(chan string),
 		droppedWorkerCount: map[string]int{},
+		routes:             &workerRoutes{changed: make(chan struct{})},
 		dnsMessageIn:       make(chan dnsutils.DNSMessage, bufferSize),
 		dnsMessageOut:      make(chan dnsutils.DNSMessage, bufferSize),
 		countIngress:       make(chan int),
@@ -63,7 +126,11 @@ func NewGenericWorker(config *pkgconfig.Config, logger *logger.Logger, name stri
 		countDiscarded:     make(chan int),
 		countForwarded:     make(chan int),
 		countDropped:       make(chan int),
+		countSpooled:       make(chan int),
+		countReplayed:      make(chan int),
+		countEvicted:       make(chan int),
 	}
+	w.config.Store(config)
 	if monitor {
 		go w.Monitor()
 	}
@@ -76,9 +143,11 @@ func (w *GenericWorker) SetMetrics(metrics *telemetry.PrometheusCollector) {
 
 func (w *GenericWorker) GetName() string { return w.name }
 
-func (w *GenericWorker) GetConfig() *pkgconfig.Config { return w.config }
+// GetConfig returns the current config, it's replaced on reload by the collect goroutine
+// while the logging and monitor goroutines are reading it
+func (w *GenericWorker) GetConfig() *pkgconfig.Config { return w.config.Load() }
 
-func (w *GenericWorker) SetConfig(config *pkgconfig.Config) { w.config = config }
+func (w *GenericWorker) SetConfig(config *pkgconfig.Config) { w.config.Store(config) }
 
 func (w *GenericWorker) ReadConfig() {}
 
@@ -86,9 +155,17 @@ func (w *GenericWorker) NewConfig() chan *pkgconfig.Config { return w.configChan
 
 func (w *GenericWorker) GetLogger() *logger.Logger { return w.logger }
 
-func (w *GenericWorker) GetDroppedRoutes() []Worker { return w.droppedRoutes }
+func (w *GenericWorker) GetDroppedRoutes() []Worker {
+	w.routes.RLock()
+	defer w.routes.RUnlock()
+	return w.routes.dropped
+}
 
-func (w *GenericWorker) GetDefaultRoutes() []Worker { return w.defaultRoutes }
+func (w *GenericWorker) GetDefaultRoutes() []Worker {
+	w.routes.RLock()
+	defer w.routes.RUnlock()
+	return w.routes.defaults
+}
 
 func (w *GenericWorker) GetInputChannel() chan dnsutils.DNSMessage { return w.dnsMessageIn }
 
@@ -107,25 +184,66 @@ func (w *GenericWorker) GetOutputChannelAsList() []chan dnsutils.DNSMessage {
 }
 
 func (w *GenericWorker) AddDroppedRoute(wrk Worker) {
-	w.droppedRoutes = append(w.droppedRoutes, wrk)
+	w.routes.Lock()
+	defer w.routes.Unlock()
+	w.routes.dropped = append(w.routes.dropped, wrk)
 }
 
 func (w *GenericWorker) AddDefaultRoute(wrk Worker) {
-	w.defaultRoutes = append(w.defaultRoutes, wrk)
+	w.routes.Lock()
+	defer w.routes.Unlock()
+	w.routes.defaults = append(w.routes.defaults, wrk)
 }
 
 func (w *GenericWorker) SetDefaultRoutes(workers []Worker) {
-	w.defaultRoutes = workers
+	w.routes.Lock()
+	defer w.routes.Unlock()
+	w.routes.defaults = workers
 }
 
 func (w *GenericWorker) SetDefaultDropped(workers []Worker) {
-	w.droppedRoutes = workers
+	w.routes.Lock()
+	defer w.routes.Unlock()
+	w.routes.dropped = workers
+}
+
+func (w *GenericWorker) SetLoggers(loggers []Worker) { w.SetDefaultRoutes(loggers) }
+
+// ReplaceRoutes swaps the default and dropped routes in one step and wakes up
+// every goroutine waiting on OnRoutesChanged so they reload their channels.
+func (w *GenericWorker) ReplaceRoutes(defaults []Worker, dropped []Worker) {
+	w.routes.Lock()
+	defer w.routes.Unlock()
+	w.routes.defaults = defaults
+	w.routes.dropped = dropped
+	close(w.routes.changed)
+	w.routes.changed = make(chan struct{})
 }
 
-func (w *GenericWorker) SetLoggers(loggers []Worker) { w.defaultRoutes = loggers }
+// ReplaceConditionalRoutes sets the routes selected according to the content of the
+// messages, the fallback workers receive the messages matching none of them.
+func (w *GenericWorker) ReplaceConditionalRoutes(routes []ConditionalRoute, fallback []Worker) {
+	w.routes.Lock()
+	defer w.routes.Unlock()
+	w.routes.conditional = routes
+	w.routes.fallback = fallback
+}
+
+// OnRoutesChanged returns a channel closed on the next call to ReplaceRoutes.
+func (w *GenericWorker) OnRoutesChanged() chan struct{} {
+	w.routes.RLock()
+	defer w.routes.RUnlock()
+	return w.routes.changed
+}
+
+// FollowRoutes makes the worker share the routes of the parent, used by
+// the processors of collectors to be rewired on reload.
+func (w *GenericWorker) FollowRoutes(parent *GenericWorker) {
+	w.routes = parent.routes
+}
 
 func (w *GenericWorker) Loggers() ([]chan dnsutils.DNSMessage, []string) {
-	return GetRoutes(w.defaultRoutes)
+	return GetRoutes(w.GetDefaultRoutes())
 }
 
 func (w *GenericWorker) ReloadConfig(config *pkgconfig.Config) {
@@ -192,12 +310,12 @@ func (w *GenericWorker) Monitor() {
 		w.doneMonitor <- true
 	}()
 
-	w.LogInfo("starting monitoring - refresh every %ds", w.config.Global.Worker.InternalMonitor)
-	timerMonitor := time.NewTimer(time.Duration(w.config.Global.Worker.InternalMonitor) * time.Second)
+	w.LogInfo("starting monitoring - refresh every %ds", w.GetConfig().Global.Worker.InternalMonitor)
+	timerMonitor := time.NewTimer(time.Duration(w.GetConfig().Global.Worker.InternalMonitor) * time.Second)
 	for {
 		select {
-		case <-w.countDiscarded:
-			w.totalDiscarded++
+		case n := <-w.countDiscarded:
+			w.totalDiscarded += n
 
 		case <-w.countIngress:
 			w.totalIngress++
@@ -211,6 +329,15 @@ func (w *GenericWorker) Monitor() {
 		case <-w.countDropped:
 			w.totalDropped++
 
+		case n := <-w.countSpooled:
+			w.totalSpooled += n
+
+		case n := <-w.countReplayed:
+			w.totalReplayed += n
+
+		case n := <-w.countEvicted:
+			w.totalEvicted += n
+
 		case loggerName := <-w.droppedWorker:
 			if _, ok := w.droppedWorkerCount[loggerName]; !ok {
 				w.droppedWorkerCount[loggerName] = 1
@@ -232,8 +359,9 @@ func (w *GenericWorker) Monitor() {
 			}
 
 			// // send to telemetry?
-			if w.config.Global.Telemetry.Enabled && w.metrics != nil {
-				if w.totalIngress > 0 || w.totalEgress > 0 || w.totalForwarded > 0 || w.totalDropped > 0 {
+			if w.GetConfig().Global.Telemetry.Enabled && w.metrics != nil {
+				if w.totalIngress > 0 || w.totalEgress > 0 || w.totalForwarded > 0 || w.totalDropped > 0 ||
+					w.totalSpooled > 0 || w.totalReplayed > 0 || w.totalEvicted > 0 {
 					w.metrics.Record <- telemetry.WorkerStats{
 						Name:                 w.GetName(),
 						TotalIngress:         w.totalIngress,
@@ -241,16 +369,22 @@ func (w *GenericWorker) Monitor() {
 						TotalForwardedPolicy: w.totalForwarded,
 						TotalDroppedPolicy:   w.totalDropped,
 						TotalDiscarded:       w.totalDiscarded,
+						TotalSpooled:         w.totalSpooled,
+						TotalReplayed:        w.totalReplayed,
+						TotalEvicted:         w.totalEvicted,
 					}
 					w.totalIngress = 0
 					w.totalEgress = 0
 					w.totalForwarded = 0
 					w.totalDropped = 0
 					w.totalDiscarded = 0
+					w.totalSpooled = 0
+					w.totalReplayed = 0
+					w.totalEvicted = 0
 				}
 			}
 
-			timerMonitor.Reset(time.Duration(w.config.Global.Worker.InternalMonitor) * time.Second)
+			timerMonitor.Reset(time.Duration(w.GetConfig().Global.Worker.InternalMonitor) * time.Second)
 		}
 	}
 }
@@ -270,26 +404,33 @@ func (w *GenericWorker) StartLogging() {
 }
 
 func (w *GenericWorker) CountIngressTraffic() {
-	if w.config.Global.Telemetry.Enabled {
+	if w.GetConfig().Global.Telemetry.Enabled {
 		w.countIngress <- 1
 	}
 }
 
 func (w *GenericWorker) CountEgressTraffic() {
-	if w.config.Global.Telemetry.Enabled {
+	if w.GetConfig().Global.Telemetry.Enabled {
 		w.countEgress <- 1
 	}
 }
 
+// CountDiscardedTraffic counts the messages lost by the worker itself, like a failed insert
+func (w *GenericWorker) CountDiscardedTraffic(n int) {
+	if w.GetConfig().Global.Telemetry.Enabled {
+		w.countDiscarded <- n
+	}
+}
+
 func (w *GenericWorker) SendDroppedTo(routes []chan dnsutils.DNSMessage, routesName []string, dm dnsutils.DNSMessage) {
 	for i := range routes {
 		select {
 		case routes[i] <- dm:
-			if w.config.Global.Telemetry.Enabled {
+			if w.GetConfig().Global.Telemetry.Enabled {
 				w.countDropped <- 1
 			}
 		default:
-			if w.config.Global.Telemetry.Enabled {
+			if w.GetConfig().Global.Telemetry.Enabled {
 				w.countDiscarded <- 1
 			}
 			w.WorkerIsBusy(routesName[i])
@@ -298,19 +439,183 @@ func (w *GenericWorker) SendDroppedTo(routes []chan dnsutils.DNSMessage, routesN
 }
 
 func (w *GenericWorker) SendForwardedTo(routes []chan dnsutils.DNSMessage, routesName []string, dm dnsutils.DNSMessage) {
+	for i := range routes {
+		w.forwardTo(routes[i], routesName[i], dm)
+	}
+	w.sendConditionalRoutes(dm)
+}
+
+func (w *GenericWorker) forwardTo(route chan dnsutils.DNSMessage, routeName string, dm dnsutils.DNSMessage) {
+	select {
+	case route <- dm:
+		if w.GetConfig().Global.Telemetry.Enabled {
+			w.countForwarded <- 1
+		}
+	default:
+		if w.GetConfig().Global.Telemetry.Enabled {
+			w.countDiscarded <- 1
+		}
+		w.WorkerIsBusy(routeName)
+	}
+}
+
+// DeliverTo is like SendForwardedTo but waits while the next workers are busy instead of
+// discarding the message, false is returned if the worker is stopped in the meantime.
+func (w *GenericWorker) DeliverTo(routes []chan dnsutils.DNSMessage, dm dnsutils.DNSMessage) bool {
+	for _, wrk := range w.conditionalWorkers(dm) {
+		routes = append(routes, wrk.GetInputChannel())
+	}
 	for i := range routes {
 		select {
 		case routes[i] <- dm:
-			if w.config.Global.Telemetry.Enabled {
+			if w.GetConfig().Global.Telemetry.Enabled {
 				w.countForwarded <- 1
 			}
-		default:
-			if w.config.Global.Telemetry.Enabled {
-				w.countDiscarded <- 1
-			}
-			w.WorkerIsBusy(routesName[i])
+		case <-w.stopRun:
+			return false
 		}
 	}
+	return true
+}
+
+// sendConditionalRoutes forwards the message to every conditional route matching,
+// or to the fallback workers when no route matches
+func (w *GenericWorker) sendConditionalRoutes(dm dnsutils.DNSMessage) {
+	for _, wrk := range w.conditionalWorkers(dm) {
+		w.forwardTo(wrk.GetInputChannel(), wrk.GetName(), dm)
+	}
+}
+
+// conditionalWorkers returns the workers of the conditional routes matching the message
+func (w *GenericWorker) conditionalWorkers(dm dnsutils.DNSMessage) []Worker {
+	w.routes.RLock()
+	conditional, fallback := w.routes.conditional, w.routes.fallback
+	w.routes.RUnlock()
+
+	if len(conditional) == 0 && len(fallback) == 0 {
+		return nil
+	}
+
+	matched := false
+	var workers []Worker
+	for i := range conditional {
+		match, err := conditional[i].Match(&dm)
+		if err != nil {
+			w.LogError("routing - %s", err)
+		}
+		if match {
+			matched = true
+			workers = append(workers, conditional[i].Workers...)
+		}
+	}
+	if !matched {
+		return fallback
+	}
+	return workers
+}
+
+// InitSpool enables the disk-backed queue of the worker, the loggers write
+// to it when the remote is unreachable and replay it on reconnect.
+func (w *GenericWorker) InitSpool(cfg pkgconfig.ConfigSpool) {
+	if !cfg.Enable {
+		return
+	}
+	spool, err := NewDiskSpool(cfg, w.GetName())
+	if err != nil {
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.GetName()+"] spool - ", err)
+	}
+	w.spool = spool
+	w.LogInfo("spool enabled in %s, %d record(s) pending", cfg.Directory, spool.Records())
+}
+
+func (w *GenericWorker) SpoolEnabled() bool { return w.spool != nil }
+
+// SpoolPending returns true if records are waiting to be replayed
+func (w *GenericWorker) SpoolPending() bool {
+	return w.spool != nil && w.spool.Records() > 0
+}
+
+// SpoolData writes the record to the spool, false is returned if the spool
+// is disabled or the write failed so the caller can drop the data.
+func (w *GenericWorker) SpoolData(data []byte) bool {
+	if w.spool == nil {
+		return false
+	}
+	evicted, err := w.spool.Write(data)
+	if evicted > 0 {
+		w.LogWarning("spool is full, %d record(s) evicted", evicted)
+		w.countSpool(w.countEvicted, evicted)
+	}
+	if err != nil {
+		w.LogError("spool write error: %s", err)
+		return false
+	}
+	w.countSpool(w.countSpooled, 1)
+	return true
+}
+
+// ReplaySpool sends the spooled records in order until the first error
+func (w *GenericWorker) ReplaySpool(send func([]byte) error) error {
+	return w.ReplaySpoolBatch(1, func(records [][]byte) error { return send(records[0]) })
+}
+
+// ReplaySpoolBatch is like ReplaySpool with up to size records sent at once
+func (w *GenericWorker) ReplaySpoolBatch(size int, send func([][]byte) error) error {
+	if !w.SpoolPending() {
+		return nil
+	}
+	replayed, evicted, err := w.spool.ReplayBatch(size, send)
+	if replayed > 0 {
+		w.LogInfo("spool - %d record(s) replayed", replayed)
+		w.countSpool(w.countReplayed, replayed)
+	}
+	if evicted > 0 {
+		w.LogWarning("spool - %d corrupted record(s) evicted", evicted)
+		w.countSpool(w.countEvicted, evicted)
+	}
+	return err
+}
+
+func (w *GenericWorker) CloseSpool() {
+	if w.spool == nil {
+		return
+	}
+	if err := w.spool.Close(); err != nil {
+		w.LogError("spool close error: %s", err)
+	}
+}
+
+func (w *GenericWorker) countSpool(counter chan int, n int) {
+	if w.GetConfig().Global.Telemetry.Enabled {
+		counter <- n
+	}
+}
+
+// GetEncoderOptions returns the options of the encoders,
+// the text and jinja formats of the logger take precedence over the global ones
+func (w *GenericWorker) GetEncoderOptions(textFormat, jinjaFormat string) dnsutils.EncoderOptions {
+	opts := dnsutils.EncoderOptions{
+		TextFormat:     strings.Fields(w.GetConfig().Global.TextFormat),
+		FieldDelimiter: w.GetConfig().Global.TextFormatDelimiter,
+		FieldBoundary:  w.GetConfig().Global.TextFormatBoundary,
+		JinjaFormat:    w.GetConfig().Global.TextJinja,
+	}
+	if len(textFormat) > 0 {
+		opts.TextFormat = strings.Fields(textFormat)
+	}
+	if len(jinjaFormat) > 0 {
+		opts.JinjaFormat = jinjaFormat
+	}
+	return opts
+}
+
+// NewEncoder returns the encoder registered for the output mode, exit on invalid mode
+func (w *GenericWorker) NewEncoder(mode string, opts dnsutils.EncoderOptions) dnsutils.Encoder {
+	encoder, err := dnsutils.NewEncoder(mode, opts)
+	if err != nil {
+		w.LogFatal(pkgconfig.PrefixLogWorker+"["+w.name+"] "+w.descr+" - invalid mode: ", err)
+	}
+	return encoder
 }
 
 func GetRoutes(routes []Worker) ([]chan dnsutils.DNSMessage, []string) {
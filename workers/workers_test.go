@@ -3,10 +3,149 @@ package workers
 import (
 	"testing"
 
+	"github.com/dmachard/go-dnscollector/dnsutils"
 	"github.com/dmachard/go-dnscollector/pkgconfig"
 	"github.com/dmachard/go-logger"
+	"gopkg.in/yaml.v3"
 )
 
 func TestGenericWorker(t *testing.T) {
 	NewGenericWorker(pkgconfig.GetDefaultConfig(), logger.New(false), "testonly", "", pkgconfig.DefaultBufferSize, pkgconfig.WorkerMonitorDisabled)
 }
+
+func TestGenericWorker_ReplaceRoutes(t *testing.T) {
+	wrk := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	next := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	dropped := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+
+	processor := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	processor.FollowRoutes(wrk)
+
+	changed := processor.OnRoutesChanged()
+	wrk.ReplaceRoutes([]Worker{next}, []Worker{dropped})
+
+	select {
+	case <-changed:
+	default:
+		t.Fatalf("routes changed not notified")
+	}
+
+	if len(processor.GetDefaultRoutes()) != 1 || processor.GetDefaultRoutes()[0] != next {
+		t.Errorf("invalid default routes: %v", processor.GetDefaultRoutes())
+	}
+	if len(processor.GetDroppedRoutes()) != 1 || processor.GetDroppedRoutes()[0] != dropped {
+		t.Errorf("invalid dropped routes: %v", processor.GetDroppedRoutes())
+	}
+}
+
+func TestGenericWorker_ConditionalRoutes(t *testing.T) {
+	wrk := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	next := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	nxdomain := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	suspicious := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+	fallback := GetWorkerForTest(pkgconfig.DefaultBufferSize)
+
+	routeNx, err := NewConditionalRoute(map[string]interface{}{"dns.rcode": "NXDOMAIN"}, nil, []Worker{nxdomain})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	routeSuspicious, err := NewConditionalRoute(
+		map[string]interface{}{"suspicious.score": map[string]interface{}{"greater-than": 2.0}},
+		map[string]interface{}{"dns.qname": "^safe\\."},
+		[]Worker{suspicious})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	wrk.ReplaceConditionalRoutes([]ConditionalRoute{routeNx, routeSuspicious}, []Worker{fallback})
+
+	defaultRoutes, defaultNames := GetRoutes([]Worker{next})
+
+	testcases := []struct {
+		name       string
+		rcode      string
+		qname      string
+		score      float64
+		nxdomain   int
+		suspicious int
+		fallback   int
+	}{
+		{name: "no match", rcode: "NOERROR", qname: "dns.collector", fallback: 1},
+		{name: "nxdomain", rcode: "NXDOMAIN", qname: "dns.collector", nxdomain: 1},
+		{name: "nxdomain and suspicious", rcode: "NXDOMAIN", qname: "dns.collector", score: 3, nxdomain: 1, suspicious: 1},
+		{name: "suspicious excluded", rcode: "NOERROR", qname: "safe.collector", score: 3, fallback: 1},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dm := dnsutils.GetFakeDNSMessage()
+			dm.DNS.Rcode = tc.rcode
+			dm.DNS.Qname = tc.qname
+			dm.Suspicious = &dnsutils.TransformSuspicious{Score: tc.score}
+
+			wrk.SendForwardedTo(defaultRoutes, defaultNames, dm)
+
+			for _, check := range []struct {
+				name     string
+				wrk      *GenericWorker
+				expected int
+			}{
+				{"default", next, 1},
+				{"nxdomain", nxdomain, tc.nxdomain},
+				{"suspicious", suspicious, tc.suspicious},
+				{"fallback", fallback, tc.fallback},
+			} {
+				if got := len(check.wrk.GetInputChannel()); got != check.expected {
+					t.Errorf("route %s: expected %d message(s), got %d", check.name, check.expected, got)
+				}
+				for len(check.wrk.GetInputChannel()) > 0 {
+					<-check.wrk.GetInputChannel()
+				}
+			}
+		})
+	}
+}
+
+func TestGenericWorker_ConditionalRoutesMatchSource(t *testing.T) {
+	// nested maps of the conditions as decoded by yaml.v3
+	var include map[string]interface{}
+	conditions := `
+dns.qname:
+  match-source: "file://../tests/testsdata/filtering_keep_domains_regex.txt"
+  source-kind: "regexp_list"
+`
+	if err := yaml.Unmarshal([]byte(conditions), &include); err != nil {
+		t.Fatal(err)
+	}
+
+	route, err := NewConditionalRoute(include, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(route.Sources) != 1 || route.Sources[0].Len() != 3 {
+		t.Fatalf("match source not loaded: %v", route.Sources)
+	}
+
+	dm := dnsutils.GetFakeDNSMessage()
+	for qname, expected := range map[string]bool{"mail.google.com": true, "test.github.com": true, "dns.collector": false} {
+		dm.DNS.Qname = qname
+		matched, err := route.Match(&dm)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if matched != expected {
+			t.Errorf("%s: expected match %v", qname, expected)
+		}
+	}
+
+	// string list with the yaml.v2 maps
+	route, err = NewConditionalRoute(map[string]interface{}{
+		"dns.qname": map[interface{}]interface{}{"match-source": "file://../tests/testsdata/filtering_keep_domains.txt"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	dm.DNS.Qname = "google.fr"
+	if matched, _ := route.Match(&dm); !matched {
+		t.Errorf("google.fr must match the string list")
+	}
+}